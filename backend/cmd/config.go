@@ -0,0 +1,26 @@
+package main
+
+import (
+	"brainrot-tamagotchi/internal/config"
+	"fmt"
+)
+
+// runConfig handles `config check [flags]`: it loads the config the server
+// would start with, prints it with secrets masked and validates it
+func runConfig(args []string) error {
+	if len(args) == 0 || args[0] != "check" {
+		return fmt.Errorf("usage: config check [-config file.yaml] [-section.key value ...]")
+	}
+
+	cfg, err := config.Load(args[1:])
+	if err != nil {
+		return err
+	}
+	fmt.Print(cfg)
+
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid config:\n%w", err)
+	}
+	fmt.Println("✅ Config is valid")
+	return nil
+}
@@ -3,12 +3,26 @@ package main
 import (
 	"brainrot-tamagotchi/internal/api"
 	"brainrot-tamagotchi/internal/blockchain"
+	"brainrot-tamagotchi/internal/config"
+	"brainrot-tamagotchi/internal/events"
+	"brainrot-tamagotchi/internal/health"
+	"brainrot-tamagotchi/internal/metrics"
+	"brainrot-tamagotchi/internal/notify"
+	"brainrot-tamagotchi/internal/pricefeed"
+	"brainrot-tamagotchi/internal/realtime"
 	"brainrot-tamagotchi/internal/repository"
+	"brainrot-tamagotchi/internal/scheduler"
 	"brainrot-tamagotchi/internal/services"
+	"brainrot-tamagotchi/pkg/buildinfo"
 	"brainrot-tamagotchi/pkg/cache"
 	"brainrot-tamagotchi/pkg/database"
+	"brainrot-tamagotchi/pkg/logging"
+	"brainrot-tamagotchi/pkg/ratelimit"
+	"brainrot-tamagotchi/pkg/replay"
+	"brainrot-tamagotchi/pkg/tracing"
 	"context"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -17,71 +31,280 @@ import (
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/extra/redisotel/v8"
 	"github.com/joho/godotenv"
 )
 
+var logger = logging.For("main")
+
+// fatal logs err and exits
+func fatal(msg string, err error) {
+	logger.Error(msg, "error", err)
+	os.Exit(1)
+}
+
+// subcommands run instead of the server when named by the first argument
+var subcommands = map[string]func(args []string) error{
+	"migrate": runMigrate,
+	"config":  runConfig,
+}
+
 func main() {
 	// Load environment variables
 	if err := godotenv.Load(); err != nil {
 		log.Println("Warning: .env file not found")
 	}
 
+	if len(os.Args) > 1 {
+		if run, ok := subcommands[os.Args[1]]; ok {
+			if err := run(os.Args[2:]); err != nil {
+				log.Fatal(err)
+			}
+			return
+		}
+	}
+
+	cfg, err := config.Load(os.Args[1:])
+	if err != nil {
+		log.Fatal("Failed to load config:", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Invalid config:\n%v", err)
+	}
+	gameCasePrices, _ := cfg.Game.CasePricesWei() // Checked by Validate
+
+	if err := logging.Setup(os.Stdout, logging.Options{
+		Level:  cfg.Log.Level,
+		Format: cfg.Log.Format,
+		Levels: cfg.Log.Levels,
+	}); err != nil {
+		log.Fatal("Failed to set up logging:", err)
+	}
+
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Options{
+		Exporter:    cfg.Tracing.Exporter,
+		Endpoint:    cfg.Tracing.Endpoint,
+		Insecure:    cfg.Tracing.Insecure,
+		SampleRatio: cfg.Tracing.SampleRatio,
+		ServiceName: cfg.Tracing.ServiceName,
+	})
+	if err != nil {
+		fatal("failed to set up tracing", err)
+	}
+
 	// Initialize database
-	log.Println("📦 Connecting to database...")
-	db, err := database.NewPostgresDB()
+	logger.Info("connecting to database")
+	db, err := database.NewPostgresDB(cfg.Database.DSN(), cfg.Database.SlowQuery)
 	if err != nil {
-		log.Fatal("Failed to connect to database:", err)
+		fatal("failed to connect to database", err)
 	}
 
-	// Auto-migrate models
-	if err := database.AutoMigrate(db); err != nil {
-		log.Fatal("Failed to migrate database:", err)
+	// Apply pending migrations; concurrent boots wait on an advisory lock
+	applied, err := database.MigrateUp(db)
+	if err != nil {
+		fatal("failed to migrate database", err)
+	}
+	for _, m := range applied {
+		logger.Info("applied migration", "version", m.Version, "name", m.Name)
 	}
-	log.Println("✅ Database connected and migrated")
+	logger.Info("database connected and migrated")
+	sqlDB, err := db.DB()
+	if err != nil {
+		fatal("failed to get database handle", err)
+	}
+	metrics.RegisterDB(sqlDB)
 
 	// Initialize Redis
-	log.Println("📦 Connecting to Redis...")
-	redisClient := cache.NewRedisClient()
+	logger.Info("connecting to Redis")
+	redisClient := cache.NewRedisClient(cfg.Redis.URL)
+	metrics.InstrumentRedis(redisClient)
+	redisClient.AddHook(redisotel.NewTracingHook())
 	if err := redisClient.Ping(context.Background()).Err(); err != nil {
-		log.Fatal("Failed to connect to Redis:", err)
+		fatal("failed to connect to Redis", err)
 	}
-	log.Println("✅ Redis connected")
+	logger.Info("Redis connected")
 
-	// Initialize blockchain client (optional for MVP)
-	log.Println("📦 Connecting to Base blockchain...")
-	blockchainClient, err := blockchain.NewClient()
+	// Rate limits fall back to in-process buckets if Redis drops out later
+	rateLimits, err := ratelimit.NewGuard(
+		ratelimit.NewFallback(ratelimit.NewRedis(redisClient), ratelimit.NewMemory()),
+		cfg.RateLimit.Policies,
+		cfg.RateLimit.BypassIPs,
+		cfg.RateLimit.BypassKeys,
+	)
 	if err != nil {
-		log.Printf("⚠️  Warning: Failed to connect to blockchain: %v", err)
-		log.Println("⚠️  Blockchain features will be disabled. Set PRIVATE_KEY and BASE_RPC_URL to enable.")
-		blockchainClient = nil
+		fatal("invalid rate limit config", err)
+	}
+
+	// Realtime events fan out across replicas over Redis pub/sub
+	realtimeHub := realtime.NewHub(redisClient)
+	realtimeTokens := realtime.NewTokenSigner(cfg.Realtime.TokenSecret, cfg.Realtime.TokenTTL)
+	go realtimeHub.Run(context.Background())
+
+	// Initialize blockchain client (optional for MVP)
+	var blockchainClient *blockchain.Client
+	if cfg.Chain.Enabled {
+		logger.Info("connecting to Base blockchain")
+		blockchainClient, err = blockchain.NewClient(cfg.Chain)
+		if err != nil {
+			logger.Warn("failed to connect to blockchain, blockchain features are disabled; check BASE_RPC_URL and PRIVATE_KEY", "error", err)
+			blockchainClient = nil
+		} else {
+			logger.Info("blockchain connected", "chain_id", blockchainClient.ChainID.String())
+		}
 	} else {
-		log.Println("✅ Blockchain connected")
+		logger.Warn("CHAIN_ENABLED=false, blockchain features are disabled")
 	}
 
+	// Initialize ETH/USD price feed
+	priceFeed, err := pricefeed.NewFeed(cfg.PriceFeed)
+	if err != nil {
+		fatal("failed to configure price feed", err)
+	}
+	quoter := pricefeed.NewQuoter(priceFeed)
+
 	// Initialize repositories
 	userRepo := repository.NewUserRepository(db)
 	nftRepo := repository.NewNFTRepository(db)
+	metrics.RegisterPetPopulation(nftRepo)
 	listingRepo := repository.NewMarketListingRepository(db)
+	caseRepo := repository.NewCaseOpeningRepository(db)
+	saleRepo := repository.NewSaleRepository(db)
+	catalogRepo := repository.NewCaseDefinitionRepository(db)
+	voucherRepo := repository.NewVoucherRepository(db)
+	cursorRepo := repository.NewChainEventRepository(db)
+	adminRepo := repository.NewAdminRepository(db)
+	notificationRepo := repository.NewNotificationRepository(db)
+	jobRunRepo := repository.NewJobRunRepository(db)
+	// Stores for writes that commit together with their outbox events
+	stores := repository.NewDBStores(db)
 
 	// Initialize services
-	tamagotchiService := services.NewTamagotchiService(nftRepo, redisClient, blockchainClient)
-	caseService := services.NewCaseService(blockchainClient, nftRepo)
-	marketplaceService := services.NewMarketplaceService(listingRepo, nftRepo, blockchainClient)
+	notifyChannels, err := notify.NewChannels(cfg.Notify)
+	if err != nil {
+		fatal("failed to configure notification channels", err)
+	}
+	notificationService := services.NewNotificationService(notificationRepo, notifyChannels)
+	tamagotchiService := services.NewTamagotchiService(stores, redisClient, blockchainClient, realtimeHub, notificationService, cfg.Game)
+	adminService := services.NewAdminService(adminRepo, nftRepo, listingRepo, blockchainClient)
+	if err := adminService.BootstrapAdmins(cfg.Admin.Wallets); err != nil {
+		fatal("failed to bootstrap admin roles", err)
+	}
+	catalogService := services.NewCaseCatalogService(catalogRepo, caseRepo, blockchainClient, adminService, gameCasePrices)
+	if err := catalogService.SeedDefaults(); err != nil {
+		fatal("failed to seed case catalog", err)
+	}
+	pityService := services.NewPityService(caseRepo, catalogRepo, voucherRepo, cursorRepo)
+	caseRevealFeed := services.NewCaseRevealFeed(db, caseRepo, cursorRepo, realtimeHub)
+	caseService := services.NewCaseService(blockchainClient, nftRepo, caseRepo, listingRepo, catalogService)
+	listingSync := services.NewListingSync(stores, blockchainClient, cfg.Jobs.MarketplaceStartBlock, cfg.Jobs.ListingSyncConfirmations, realtimeHub)
+	caseOpeningSync := services.NewCaseOpeningSync(stores, blockchainClient, cfg.Jobs.CaseStartBlock, cfg.Jobs.CaseSyncConfirmations)
+	marketplaceService := services.NewMarketplaceService(listingRepo, nftRepo, blockchainClient, listingSync)
+	inventoryService := services.NewInventoryService(nftRepo, listingRepo, blockchainClient, cfg.Game)
+	revenueService := services.NewRevenueService(saleRepo, caseRepo, blockchainClient)
+	ownershipReconciler := services.NewOwnershipReconciler(
+		nftRepo,
+		listingRepo,
+		blockchainClient,
+		jobRunRepo,
+		cfg.Jobs.ReconcileAutoCorrect,
+		cfg.Jobs.ReconcileChunkSize,
+	)
 
-	// Start background jobs
-	log.Println("🔄 Starting background jobs...")
-	go tamagotchiService.StartHungerDecayJob()
-	log.Println("✅ Background jobs started")
+	// Domain event subscribers; delivery is at least once
+	dispatcher := events.NewDispatcher(stores, redisClient)
+	dispatcher.Subscribe(events.TypeListingSold, "notify_seller", notificationService.HandleListingSold)
+
+	// Background jobs run on the scheduler leader only, except manual triggers
+	jobScheduler := scheduler.NewScheduler(db, jobRunRepo)
+	jobs := []scheduler.Job{
+		{Name: "stat_decay", Schedule: "@hourly", Timeout: 30 * time.Minute, Run: func(ctx context.Context) error {
+			_, err := tamagotchiService.DecayStats(ctx)
+			return err
+		}},
+		{Name: "case_pity", Schedule: "@every 30s", Quiet: true, Run: func(ctx context.Context) error {
+			_, err := pityService.ProcessNewOpenings(ctx)
+			return err
+		}},
+		{Name: "case_reveals", Schedule: "@every 5s", Timeout: time.Minute, Quiet: true, Run: func(ctx context.Context) error {
+			_, err := caseRevealFeed.PublishNewOpenings(ctx)
+			return err
+		}},
+		{Name: "notification_delivery", Schedule: "@every 15s", Quiet: true, Run: func(ctx context.Context) error {
+			_, err := notificationService.DeliverDue(ctx)
+			return err
+		}},
+		{Name: "event_dispatch", Schedule: "@every 2s", Quiet: true, Run: dispatcher.DispatchPending},
+	}
+	var casePriceMonitor *services.CasePriceMonitor
+	if blockchainClient != nil {
+		casePriceMonitor = services.NewCasePriceMonitor(blockchainClient, priceFeed, catalogService, jobRunRepo, cfg.Jobs.CasePriceDriftThreshold)
+
+		jobs = append(jobs,
+			scheduler.Job{Name: "ownership_reconcile", Schedule: "@every " + cfg.Jobs.ReconcileInterval.String(), Timeout: time.Hour, Run: func(ctx context.Context) error {
+				_, err := ownershipReconciler.Reconcile(ctx)
+				return err
+			}},
+			scheduler.Job{Name: "listing_sync", Schedule: "@every 15s", Quiet: true, Run: func(ctx context.Context) error {
+				_, err := listingSync.SyncOnce(ctx)
+				return err
+			}},
+			scheduler.Job{Name: "case_opening_sync", Schedule: "@every 15s", Quiet: true, Run: func(ctx context.Context) error {
+				_, err := caseOpeningSync.SyncOnce(ctx)
+				return err
+			}},
+			scheduler.Job{Name: "case_price_check", Schedule: "@hourly", Run: func(ctx context.Context) error {
+				_, err := casePriceMonitor.Check(ctx)
+				return err
+			}},
+		)
+		if blockchainClient.PrivateKey != nil {
+			jobs = append(jobs,
+				scheduler.Job{Name: "case_catalog_sync", Schedule: "@every 1m", Run: func(ctx context.Context) error {
+					_, err := catalogService.SyncContract(ctx)
+					return err
+				}},
+				scheduler.Job{Name: "contract_calls", Schedule: "@every 10s", Quiet: true, Run: func(ctx context.Context) error {
+					return adminService.ProcessContractCalls(ctx)
+				}},
+			)
+		}
+	}
+	for _, job := range jobs {
+		if err := jobScheduler.Register(job); err != nil {
+			fatal("failed to register background job", err)
+		}
+	}
+
+	// Readiness checks; the chain ones only when the chain is connected
+	healthChecker := health.NewChecker(cfg.Health.CheckTimeout,
+		health.Postgres(sqlDB),
+		health.Redis(redisClient),
+		health.JobRecency(db, "stat_decay", cfg.Health.MaxDecayAge),
+	)
+	if blockchainClient != nil {
+		healthChecker.Add(
+			health.ChainHead(blockchainClient, cfg.Health.MaxHeadAge),
+			health.IndexerLag("marketplace", listingSync.Lag, cfg.Health.MaxIndexerLag),
+			health.IndexerLag("cases", caseOpeningSync.Lag, cfg.Health.MaxIndexerLag),
+		)
+	}
+
+	// Cancelled on shutdown; stops the scheduler and the jobs it is running
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	go jobScheduler.Start(jobsCtx)
 
 	// Setup Gin router
-	router := gin.Default()
+	// The request span and ID come first so every later log line and span
+	// can carry them
+	router := gin.New()
+	router.Use(api.Tracing(cfg.Tracing.ServiceName), api.RequestID(), api.AccessLog(), api.Metrics(), api.Recovery(), api.Errors())
 
 	// CORS middleware
 	router.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{"http://localhost:3000", "https://brainrot-tamagotchi.vercel.app"},
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "X-Wallet-Address"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowOrigins:     cfg.Server.CORSOrigins,
+		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "X-Wallet-Address", "X-Wallet-Signature", "X-Wallet-Timestamp", "X-API-Key", "X-Request-ID", "Traceparent", "Tracestate"},
+		ExposeHeaders:    []string{"Content-Length", "Retry-After", "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-Request-ID", "X-Trace-ID"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
@@ -90,32 +313,50 @@ func main() {
 	handler := api.NewHandler(
 		tamagotchiService,
 		caseService,
+		catalogService,
+		pityService,
 		marketplaceService,
+		inventoryService,
+		revenueService,
+		adminService,
+		notificationService,
 		userRepo,
+		quoter,
+		rateLimits,
+		replay.NewGuard(redisClient),
+		realtimeHub,
+		realtimeTokens,
+		jobScheduler,
+		healthChecker,
+		ownershipReconciler,
+		casePriceMonitor,
 	)
 
 	// Setup routes
 	handler.SetupRoutes(router)
 
+	// Prometheus scrape endpoint; keep it off the public proxy
+	router.GET("/metrics", gin.WrapH(metrics.Handler()))
+
 	// Server configuration
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
-	}
+	port := cfg.Server.Port
 
-	log.Printf("🚀 Server starting on port %s...\n", port)
-	log.Println("🎮 Brainrot Tamagotchi API is ready!")
-	log.Println("📖 Documentation: http://localhost:" + port + "/api/v1/health")
+	build := buildinfo.Get()
+	logger.Info("server starting", "port", port, "version", build.Version, "commit", build.Commit, "build_time", build.BuildTime)
 
 	// Graceful shutdown
+	// Cancelling the base context ends open realtime streams, which would
+	// otherwise hold Shutdown until its timeout
+	baseCtx, cancelStreams := context.WithCancel(context.Background())
 	srv := &http.Server{
-		Addr:    ":" + port,
-		Handler: router,
+		Addr:        ":" + port,
+		Handler:     router,
+		BaseContext: func(net.Listener) context.Context { return baseCtx },
 	}
 
 	go func() {
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatal("Server failed to start:", err)
+			fatal("server failed to start", err)
 		}
 	}()
 
@@ -124,14 +365,22 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
-	log.Println("🛑 Shutting down server...")
+	logger.Info("shutting down server")
+	cancelStreams()
+	stopJobs()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	if err := srv.Shutdown(ctx); err != nil {
-		log.Fatal("Server forced to shutdown:", err)
+		fatal("server forced to shut down", err)
+	}
+	if !jobScheduler.Wait(10 * time.Second) {
+		logger.Warn("background jobs did not stop in time")
+	}
+	if err := shutdownTracing(ctx); err != nil {
+		logger.Warn("failed to flush traces", "error", err)
 	}
 
-	log.Println("✅ Server exited")
+	logger.Info("server exited")
 }
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"brainrot-tamagotchi/internal/config"
+	"brainrot-tamagotchi/pkg/database"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const migrateUsage = `usage: migrate <command>
+
+commands:
+  up                  apply all pending migrations
+  down [-steps N]     roll back the latest N migrations (default 1)
+  status              list migrations and when they were applied
+  create <name>       write an empty up/down pair to ` + database.MigrationsDir
+
+// runMigrate handles `migrate up|down|status|create`
+func runMigrate(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf(migrateUsage)
+	}
+
+	// create only touches files, so it works without a database
+	if args[0] == "create" {
+		if len(args) < 2 {
+			return fmt.Errorf("usage: migrate create <name>")
+		}
+		up, down, err := database.CreateMigration(database.MigrationsDir, strings.Join(args[1:], " "))
+		if err != nil {
+			return err
+		}
+		fmt.Println("Created", up)
+		fmt.Println("Created", down)
+		return nil
+	}
+
+	switch args[0] {
+	case "up", "down", "status":
+	default:
+		return fmt.Errorf("unknown migrate command %q\n\n%s", args[0], migrateUsage)
+	}
+
+	cfg, err := config.Load(nil)
+	if err != nil {
+		return err
+	}
+	db, err := database.NewPostgresDB(cfg.Database.DSN(), cfg.Database.SlowQuery)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := database.MigrateUp(db)
+		for _, m := range applied {
+			fmt.Printf("Applied %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Println("Already up to date")
+		}
+
+	case "down":
+		flags := flag.NewFlagSet("migrate down", flag.ContinueOnError)
+		steps := flags.Int("steps", 1, "number of migrations to roll back")
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+		if *steps < 1 {
+			return fmt.Errorf("-steps must be at least 1")
+		}
+		reverted, err := database.MigrateDown(db, *steps)
+		for _, m := range reverted {
+			fmt.Printf("Rolled back %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(reverted) == 0 {
+			fmt.Println("Nothing to roll back")
+		}
+
+	case "status":
+		statuses, err := database.MigrationStatuses(db)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED")
+		for _, s := range statuses {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = s.AppliedAt.Format(time.RFC3339)
+			}
+			if s.Missing {
+				applied += " (not in this build)"
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\n", s.Version, s.Name, applied)
+		}
+		return w.Flush()
+	}
+	return nil
+}
@@ -0,0 +1,448 @@
+package api
+
+import (
+	"brainrot-tamagotchi/internal/models"
+	"brainrot-tamagotchi/internal/repository"
+	"brainrot-tamagotchi/internal/scheduler"
+	"brainrot-tamagotchi/internal/services"
+	"brainrot-tamagotchi/pkg/apperr"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ==================== Admin Endpoints ====================
+
+// AdminMe returns the caller's admin role
+func (h *Handler) AdminMe(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"role": adminRole(c)})
+}
+
+// AdminInspectPet returns any pet's raw state, listing and admin history
+func (h *Handler) AdminInspectPet(c *gin.Context) {
+	tokenID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(errInvalidTokenID)
+		return
+	}
+	setAudit(c, "pet", c.Param("id"), "", nil)
+
+	pet, err := h.adminService.InspectPet(uint(tokenID))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, pet)
+}
+
+// AdminAdjustPetStats sets a pet's hunger, mood or energy
+func (h *Handler) AdminAdjustPetStats(c *gin.Context) {
+	tokenID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(errInvalidTokenID)
+		return
+	}
+
+	var body struct {
+		services.PetStatsAdjustment
+		Reason string `json:"reason" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.Error(invalidBody(err))
+		return
+	}
+	setAudit(c, "pet", c.Param("id"), body.Reason, nil)
+
+	nft, before, err := h.tamagotchiService.AdjustPetStats(c.Request.Context(), uint(tokenID), body.PetStatsAdjustment, adminRole(c).WalletAddress, body.Reason)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	setAudit(c, "pet", c.Param("id"), body.Reason, map[string]interface{}{
+		"before": before,
+		"after":  map[string]int{"hunger": nft.Hunger, "mood": nft.Mood, "energy": nft.Energy},
+	})
+
+	c.JSON(http.StatusOK, gin.H{"pet": nft})
+}
+
+// AdminGetBans lists the bans in force
+func (h *Handler) AdminGetBans(c *gin.Context) {
+	bans, err := h.adminService.GetActiveBans()
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"bans":  bans,
+		"count": len(bans),
+	})
+}
+
+// AdminBanWallet bans a wallet from write endpoints
+func (h *Handler) AdminBanWallet(c *gin.Context) {
+	var body struct {
+		WalletAddress string     `json:"wallet_address" binding:"required"`
+		Reason        string     `json:"reason" binding:"required"`
+		ExpiresAt     *time.Time `json:"expires_at"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.Error(invalidBody(err))
+		return
+	}
+	setAudit(c, "wallet", strings.ToLower(body.WalletAddress), body.Reason, map[string]interface{}{
+		"expires_at": body.ExpiresAt,
+	})
+
+	ban, err := h.adminService.BanWallet(body.WalletAddress, body.Reason, adminRole(c).WalletAddress, body.ExpiresAt)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"ban": ban})
+}
+
+// AdminLiftBan lifts the bans on a wallet
+func (h *Handler) AdminLiftBan(c *gin.Context) {
+	var body struct {
+		Reason string `json:"reason" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.Error(invalidBody(err))
+		return
+	}
+	address := strings.ToLower(c.Param("address"))
+	setAudit(c, "wallet", address, body.Reason, nil)
+
+	err := h.adminService.LiftBan(address)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Ban lifted"})
+}
+
+// AdminCancelListing queues Marketplace.emergencyCancelListing for a listing
+func (h *Handler) AdminCancelListing(c *gin.Context) {
+	tokenID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(errInvalidTokenID)
+		return
+	}
+
+	var body struct {
+		Reason string `json:"reason" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.Error(invalidBody(err))
+		return
+	}
+	setAudit(c, "listing", c.Param("id"), body.Reason, nil)
+
+	job, err := h.adminService.CancelListing(uint(tokenID), adminRole(c).WalletAddress, body.Reason)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	setAudit(c, "listing", c.Param("id"), body.Reason, map[string]interface{}{"contract_call_id": job.ID})
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":       "Cancellation queued",
+		"contract_call": job,
+	})
+}
+
+// AdminGetContractCalls lists queued and past owner contract calls
+func (h *Handler) AdminGetContractCalls(c *gin.Context) {
+	limit, offset := parsePagination(c)
+
+	jobs, err := h.adminService.GetContractCalls(c.Query("status"), limit, offset)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"contract_calls": jobs,
+		"count":          len(jobs),
+	})
+}
+
+// AdminQueueContractCall queues an owner-only contract call for the backend signer
+func (h *Handler) AdminQueueContractCall(c *gin.Context) {
+	var body struct {
+		Contract string   `json:"contract" binding:"required"`
+		Method   string   `json:"method" binding:"required"`
+		Args     []string `json:"args"`
+		Reason   string   `json:"reason" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.Error(invalidBody(err))
+		return
+	}
+	if body.Args == nil {
+		body.Args = []string{}
+	}
+	setAudit(c, "contract", body.Contract, body.Reason, map[string]interface{}{
+		"method": body.Method,
+		"args":   body.Args,
+	})
+
+	job, err := h.adminService.QueueContractCall(body.Contract, body.Method, body.Args, adminRole(c).WalletAddress, body.Reason)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"contract_call": job})
+}
+
+// AdminGetRoles lists every wallet with an admin role
+func (h *Handler) AdminGetRoles(c *gin.Context) {
+	roles, err := h.adminService.GetRoles()
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"roles": roles})
+}
+
+// AdminSetRole grants or changes a wallet's admin role
+func (h *Handler) AdminSetRole(c *gin.Context) {
+	var body struct {
+		Role   string `json:"role" binding:"required"`
+		Reason string `json:"reason" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.Error(invalidBody(err))
+		return
+	}
+	address := strings.ToLower(c.Param("address"))
+	setAudit(c, "wallet", address, body.Reason, map[string]interface{}{"role": body.Role})
+
+	role, err := h.adminService.SetRole(address, body.Role, adminRole(c).WalletAddress)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"role": role})
+}
+
+// AdminRevokeRole removes a wallet's admin role
+func (h *Handler) AdminRevokeRole(c *gin.Context) {
+	var body struct {
+		Reason string `json:"reason" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.Error(invalidBody(err))
+		return
+	}
+	address := strings.ToLower(c.Param("address"))
+	setAudit(c, "wallet", address, body.Reason, nil)
+
+	err := h.adminService.RevokeRole(address, adminRole(c).WalletAddress)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Role revoked"})
+}
+
+// AdminGetAuditLog lists admin actions, filtered by actor, action or target
+func (h *Handler) AdminGetAuditLog(c *gin.Context) {
+	limit, offset := parsePagination(c)
+
+	entries, total, err := h.adminService.GetAuditLogs(repository.AuditFilter{
+		ActorAddress: c.Query("actor"),
+		Action:       c.Query("action"),
+		TargetType:   c.Query("target_type"),
+		TargetID:     c.Query("target_id"),
+	}, limit, offset)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"entries": entries,
+		"count":   len(entries),
+		"total":   total,
+		"limit":   limit,
+		"offset":  offset,
+	})
+}
+
+// AdminGetCases lists the whole case catalog, including unavailable cases
+func (h *Handler) AdminGetCases(c *gin.Context) {
+	entries, err := h.catalogService.GetCatalog(true)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"cases": h.quoteCases(c, entries),
+		"count": len(entries),
+	})
+}
+
+// AdminCreateCase adds a case to the catalog
+func (h *Handler) AdminCreateCase(c *gin.Context) {
+	var def models.CaseDefinition
+	if err := c.ShouldBindJSON(&def); err != nil {
+		c.Error(invalidBody(err))
+		return
+	}
+	def.ID = 0
+	setAudit(c, "case", def.Slug, "", nil)
+
+	if err := h.catalogService.CreateCase(&def); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"case": def})
+}
+
+// AdminUpdateCase changes or schedules a catalog case
+func (h *Handler) AdminUpdateCase(c *gin.Context) {
+	var update services.CaseUpdate
+	if err := c.ShouldBindJSON(&update); err != nil {
+		c.Error(invalidBody(err))
+		return
+	}
+	setAudit(c, "case", c.Param("slug"), "", nil)
+
+	def, err := h.catalogService.UpdateCase(c.Param("slug"), update)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"case": def})
+}
+
+// AdminSyncCases pushes live catalog prices and availability to CaseOpening
+func (h *Handler) AdminSyncCases(c *gin.Context) {
+	results, err := h.catalogService.SyncContract(c.Request.Context())
+	setAudit(c, "contract", "cases", "", map[string]interface{}{"synced": results})
+	if err != nil {
+		// Cases synced before the failure stay synced; report them too
+		e, ok := apperr.As(err)
+		if !ok {
+			e = errInternal.Wrap(err)
+		}
+		c.Error(e.With("synced", results))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"synced": results})
+}
+
+// AdminGetJobs lists background jobs with their schedule and latest run
+func (h *Handler) AdminGetJobs(c *gin.Context) {
+	jobs, err := h.scheduler.Jobs()
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"jobs":   jobs,
+		"count":  len(jobs),
+		"leader": h.scheduler.IsLeader(),
+	})
+}
+
+// AdminGetJobRuns lists a job's run history, newest first
+func (h *Handler) AdminGetJobRuns(c *gin.Context) {
+	limit, offset := parsePagination(c)
+
+	runs, total, err := h.scheduler.Runs(c.Param("name"), limit, offset)
+	if errors.Is(err, scheduler.ErrUnknownJob) {
+		c.Error(errJobNotFound.Wrap(err))
+		return
+	}
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"runs":   runs,
+		"count":  len(runs),
+		"total":  total,
+		"limit":  limit,
+		"offset": offset,
+	})
+}
+
+// AdminTriggerJob starts a job now; the run shows up in its history
+func (h *Handler) AdminTriggerJob(c *gin.Context) {
+	name := c.Param("name")
+	setAudit(c, "job", name, "", nil)
+
+	err := h.scheduler.Trigger(name)
+	if errors.Is(err, scheduler.ErrUnknownJob) {
+		c.Error(errJobNotFound.Wrap(err))
+		return
+	}
+	if errors.Is(err, scheduler.ErrJobRunning) {
+		c.Error(errJobRunning.Wrap(err))
+		return
+	}
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"job": name, "status": "started"})
+}
+
+// AdminGetReconcileReport returns the last completed ownership reconciliation
+// pass
+func (h *Handler) AdminGetReconcileReport(c *gin.Context) {
+	report, err := h.reconciler.LastReport()
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if report == nil {
+		c.Error(errNoReport)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// AdminGetCasePriceDrift returns the last completed case price check
+func (h *Handler) AdminGetCasePriceDrift(c *gin.Context) {
+	if h.casePriceMonitor == nil {
+		c.Error(services.ErrChainDisabled)
+		return
+	}
+
+	report, err := h.casePriceMonitor.LastReport()
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if report == nil {
+		c.Error(errNoReport)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,29 @@
+package api
+
+import (
+	_ "embed"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// docsHTML is Swagger UI pointed at /openapi.json
+//
+//go:embed docs.html
+var docsHTML []byte
+
+// ==================== Docs Endpoints ====================
+
+// OpenAPIDocument serves the API specification as JSON
+func (h *Handler) OpenAPIDocument(c *gin.Context) {
+	if _, err := OpenAPISpec(); err != nil {
+		c.Error(err)
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", specJSON)
+}
+
+// Docs serves Swagger UI for the API specification
+func (h *Handler) Docs(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", docsHTML)
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"brainrot-tamagotchi/pkg/apperr"
+	"brainrot-tamagotchi/pkg/tracing"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Errors the API returns itself; services declare theirs next to the code
+// that returns them
+var (
+	errInternal       = apperr.New(apperr.KindInternal, "internal", "Internal server error")
+	errInvalidTokenID = apperr.Validation("invalid_token_id", "Invalid token ID")
+	errInvalidBody    = apperr.Validation("invalid_body", "Invalid request body")
+	errInvalidRequest = apperr.Validation("invalid_request", "Request does not match the API specification")
+	errInvalidQuery   = apperr.Validation("invalid_query", "Invalid query parameter")
+	errInvalidDates   = apperr.Validation("invalid_date_range", "Invalid date range")
+	errWalletRequired = apperr.Unauthorized("wallet_required", "Wallet address required")
+	errSignedHeaders  = apperr.Unauthorized("signed_headers_required", "Signed wallet headers required")
+	errNoAdminRole    = apperr.Forbidden("admin_role_required", "Wallet has no admin role")
+	errRoleTooLow     = apperr.Forbidden("insufficient_role", "Insufficient admin role")
+	errWalletBanned   = apperr.Forbidden("wallet_banned", "Wallet is banned")
+	errRateLimited    = apperr.RateLimited("rate_limited", "Too many requests")
+	errJobNotFound    = apperr.NotFound("job_not_found", "Job not found")
+	errJobRunning     = apperr.Conflict("job_running", "Job is already running")
+	errNoReport       = apperr.NotFound("report_not_found", "No completed run yet")
+	errInvalidTopics  = apperr.Validation("invalid_topics", "Invalid realtime topics")
+	errTopicForbidden = apperr.Forbidden("topic_forbidden", "Realtime topic not allowed")
+)
+
+// Signature errors from verifyWalletSignature and verifyOnce
+var (
+	errSignerAddress      = apperr.Unauthorized("invalid_signer_address", "invalid wallet address")
+	errSignatureTimestamp = apperr.Unauthorized("invalid_signature_timestamp", "invalid signature timestamp")
+	errSignatureExpired   = apperr.Unauthorized("signature_expired", "signature expired")
+	errInvalidSignature   = apperr.Unauthorized("invalid_signature", "invalid signature")
+	errSignatureMismatch  = apperr.Unauthorized("signature_mismatch", "signature does not match wallet")
+	errSignatureReused    = apperr.Unauthorized("signature_reused", "signature already used")
+)
+
+// statusByKind is the HTTP status each error kind answers with
+var statusByKind = map[apperr.Kind]int{
+	apperr.KindInternal:     http.StatusInternalServerError,
+	apperr.KindValidation:   http.StatusBadRequest,
+	apperr.KindUnauthorized: http.StatusUnauthorized,
+	apperr.KindForbidden:    http.StatusForbidden,
+	apperr.KindNotFound:     http.StatusNotFound,
+	apperr.KindConflict:     http.StatusConflict,
+	apperr.KindRateLimited:  http.StatusTooManyRequests,
+	apperr.KindUpstream:     http.StatusBadGateway,
+	apperr.KindUnavailable:  http.StatusServiceUnavailable,
+}
+
+// invalidBody describes a request body that failed to bind
+func invalidBody(err error) error {
+	return errInvalidBody.WithMessage(err.Error())
+}
+
+// abortWithError records err for Errors to answer with and stops the
+// handler chain
+func abortWithError(c *gin.Context, err error) {
+	_ = c.Error(err)
+	c.Abort()
+}
+
+// writeProblem answers with err as an RFC 7807 problem. Errors without a
+// kind are internal: clients get a generic detail and the cause is only
+// logged.
+func writeProblem(c *gin.Context, err error) {
+	e, ok := apperr.As(err)
+	if !ok {
+		e = errInternal
+	}
+	status, ok := statusByKind[e.Kind]
+	if !ok {
+		status = http.StatusInternalServerError
+	}
+
+	body := make(gin.H, len(e.Fields)+7)
+	for key, value := range e.Fields {
+		body[key] = value
+	}
+	body["type"] = "about:blank"
+	body["title"] = http.StatusText(status)
+	body["status"] = status
+	body["detail"] = e.Message
+	body["code"] = e.Code
+	body["instance"] = c.Request.URL.Path
+	if traceID := tracing.TraceID(c.Request.Context()); traceID != "" {
+		body["trace_id"] = traceID
+	}
+
+	c.Header("Content-Type", "application/problem+json")
+	c.AbortWithStatusJSON(status, body)
+}
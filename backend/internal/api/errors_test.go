@@ -0,0 +1,77 @@
+package api
+
+import (
+	"brainrot-tamagotchi/internal/services"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Recovery(), Errors())
+	router.GET("/missing", func(c *gin.Context) {
+		c.Error(fmt.Errorf("loading pet: %w", services.ErrPetNotFound.Wrap(errors.New("record not found"))))
+	})
+	router.GET("/outage", func(c *gin.Context) {
+		c.Error(errors.New("dial tcp: connection refused"))
+	})
+	router.GET("/limited", func(c *gin.Context) {
+		abortWithError(c, errRateLimited.With("retry_after", 3))
+	})
+	router.GET("/written", func(c *gin.Context) {
+		c.Error(errors.New("logged only"))
+		c.JSON(http.StatusAccepted, gin.H{"status": "pending"})
+	})
+	router.GET("/panic", func(c *gin.Context) {
+		panic("boom")
+	})
+
+	tests := []struct {
+		path       string
+		want       int
+		wantCode   string
+		wantDetail string
+	}{
+		{"/missing", http.StatusNotFound, "pet_not_found", "pet not found"},
+		{"/outage", http.StatusInternalServerError, "internal", "Internal server error"},
+		{"/limited", http.StatusTooManyRequests, "rate_limited", "Too many requests"},
+		{"/panic", http.StatusInternalServerError, "internal", "Internal server error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d", w.Code, tt.want)
+			}
+			if ct := w.Header().Get("Content-Type"); ct != "application/problem+json" {
+				t.Errorf("Content-Type = %q", ct)
+			}
+			var problem map[string]any
+			if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+				t.Fatal(err)
+			}
+			if problem["code"] != tt.wantCode || problem["detail"] != tt.wantDetail ||
+				problem["status"] != float64(tt.want) || problem["instance"] != tt.path {
+				t.Errorf("problem = %v", problem)
+			}
+			if tt.path == "/limited" && problem["retry_after"] != float64(3) {
+				t.Errorf("retry_after = %v, want 3", problem["retry_after"])
+			}
+		})
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/written", nil))
+	if w.Code != http.StatusAccepted || w.Body.String() != `{"status":"pending"}` {
+		t.Errorf("a handler that wrote its own response got %d %s", w.Code, w.Body)
+	}
+}
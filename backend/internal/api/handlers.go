@@ -1,32 +1,91 @@
 package api
 
 import (
+	"brainrot-tamagotchi/internal/blockchain"
+	"brainrot-tamagotchi/internal/health"
+	"brainrot-tamagotchi/internal/models"
+	"brainrot-tamagotchi/internal/pricefeed"
+	"brainrot-tamagotchi/internal/realtime"
 	"brainrot-tamagotchi/internal/repository"
+	"brainrot-tamagotchi/internal/scheduler"
 	"brainrot-tamagotchi/internal/services"
+	"brainrot-tamagotchi/pkg/buildinfo"
+	"brainrot-tamagotchi/pkg/money"
+	"brainrot-tamagotchi/pkg/ratelimit"
+	"brainrot-tamagotchi/pkg/replay"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 type Handler struct {
-	tamagotchiService  *services.TamagotchiService
-	caseService        *services.CaseService
-	marketplaceService *services.MarketplaceService
-	userRepo           *repository.UserRepository
+	tamagotchiService   *services.TamagotchiService
+	caseService         *services.CaseService
+	catalogService      *services.CaseCatalogService
+	pityService         *services.PityService
+	marketplaceService  *services.MarketplaceService
+	inventoryService    *services.InventoryService
+	revenueService      *services.RevenueService
+	adminService        *services.AdminService
+	notificationService *services.NotificationService
+	userRepo            repository.UserStore
+	quoter              *pricefeed.Quoter
+	rateLimits          *ratelimit.Guard
+	replay              *replay.Guard
+	realtimeHub         *realtime.Hub
+	realtimeTokens      *realtime.TokenSigner
+	scheduler           *scheduler.Scheduler
+	health              *health.Checker
+	reconciler          *services.OwnershipReconciler
+	casePriceMonitor    *services.CasePriceMonitor
 }
 
 func NewHandler(
 	tamagotchiService *services.TamagotchiService,
 	caseService *services.CaseService,
+	catalogService *services.CaseCatalogService,
+	pityService *services.PityService,
 	marketplaceService *services.MarketplaceService,
-	userRepo *repository.UserRepository,
+	inventoryService *services.InventoryService,
+	revenueService *services.RevenueService,
+	adminService *services.AdminService,
+	notificationService *services.NotificationService,
+	userRepo repository.UserStore,
+	quoter *pricefeed.Quoter,
+	rateLimits *ratelimit.Guard,
+	replay *replay.Guard,
+	realtimeHub *realtime.Hub,
+	realtimeTokens *realtime.TokenSigner,
+	scheduler *scheduler.Scheduler,
+	health *health.Checker,
+	reconciler *services.OwnershipReconciler,
+	casePriceMonitor *services.CasePriceMonitor,
 ) *Handler {
 	return &Handler{
-		tamagotchiService:  tamagotchiService,
-		caseService:        caseService,
-		marketplaceService: marketplaceService,
-		userRepo:           userRepo,
+		tamagotchiService:   tamagotchiService,
+		caseService:         caseService,
+		catalogService:      catalogService,
+		pityService:         pityService,
+		marketplaceService:  marketplaceService,
+		inventoryService:    inventoryService,
+		revenueService:      revenueService,
+		adminService:        adminService,
+		notificationService: notificationService,
+		userRepo:            userRepo,
+		quoter:              quoter,
+		rateLimits:          rateLimits,
+		replay:              replay,
+		realtimeHub:         realtimeHub,
+		realtimeTokens:      realtimeTokens,
+		scheduler:           scheduler,
+		health:              health,
+		reconciler:          reconciler,
+		casePriceMonitor:    casePriceMonitor,
 	}
 }
 
@@ -35,7 +94,8 @@ func (h *Handler) HealthCheck(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"status":  "ok",
 		"message": "Brainrot Tamagotchi API is running! 🎮",
-		"version": "1.0.0",
+		"version": buildinfo.Version,
+		"build":   buildinfo.Get(),
 	})
 }
 
@@ -45,13 +105,13 @@ func (h *Handler) HealthCheck(c *gin.Context) {
 func (h *Handler) GetPet(c *gin.Context) {
 	tokenID, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid token ID"})
+		c.Error(errInvalidTokenID)
 		return
 	}
 
-	nft, err := h.tamagotchiService.GetPetState(uint(tokenID))
+	nft, err := h.tamagotchiService.GetPetState(c.Request.Context(), uint(tokenID))
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Pet not found"})
+		c.Error(err)
 		return
 	}
 
@@ -62,7 +122,7 @@ func (h *Handler) GetPet(c *gin.Context) {
 func (h *Handler) FeedPet(c *gin.Context) {
 	tokenID, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid token ID"})
+		c.Error(errInvalidTokenID)
 		return
 	}
 
@@ -75,13 +135,13 @@ func (h *Handler) FeedPet(c *gin.Context) {
 
 	walletAddress := c.GetHeader("X-Wallet-Address")
 	if walletAddress == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Wallet address required"})
+		c.Error(errWalletRequired)
 		return
 	}
 
-	err = h.tamagotchiService.FeedPet(uint(tokenID), walletAddress, body.IsPaid)
+	err = h.tamagotchiService.FeedPet(c.Request.Context(), uint(tokenID), walletAddress, body.IsPaid)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -92,34 +152,88 @@ func (h *Handler) FeedPet(c *gin.Context) {
 func (h *Handler) PlayWithPet(c *gin.Context) {
 	tokenID, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid token ID"})
+		c.Error(errInvalidTokenID)
 		return
 	}
 
 	walletAddress := c.GetHeader("X-Wallet-Address")
 	if walletAddress == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Wallet address required"})
+		c.Error(errWalletRequired)
 		return
 	}
 
-	err = h.tamagotchiService.PlayWithPet(uint(tokenID), walletAddress)
+	err = h.tamagotchiService.PlayWithPet(c.Request.Context(), uint(tokenID), walletAddress)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Played with pet successfully"})
 }
 
+// GetUpgradeQuote prices a level upgrade in wei and USD
+func (h *Handler) GetUpgradeQuote(c *gin.Context) {
+	tokenID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(errInvalidTokenID)
+		return
+	}
+
+	level, err := strconv.Atoi(c.Query("level"))
+	if err != nil {
+		c.Error(errInvalidQuery.WithMessage("Target level required"))
+		return
+	}
+
+	nft, price, err := h.tamagotchiService.GetUpgradePrice(c.Request.Context(), uint(tokenID), level)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"token_id":   nft.TokenID,
+		"from_level": nft.Level,
+		"to_level":   level,
+		"price":      h.quoter.Quote(c.Request.Context(), price),
+	})
+}
+
 // ==================== Cases Endpoints ====================
 
-// GetCasePrices returns prices for all case types
+// GetCasePrices lists the cases on sale with prices in wei and USD
 func (h *Handler) GetCasePrices(c *gin.Context) {
+	entries, err := h.catalogService.GetCatalog(false)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"prices": services.CasePrices,
+		"cases":   h.quoteCases(c, entries),
+		"count":   len(entries),
+		"eth_usd": h.quoter.Rate(c.Request.Context()),
 	})
 }
 
+// quotedCase is a catalog entry with its wei price also quoted in USD
+type quotedCase struct {
+	services.CatalogEntry
+	PriceUSD *float64 `json:"price_usd"`
+}
+
+func (h *Handler) quoteCases(c *gin.Context, entries []services.CatalogEntry) []quotedCase {
+	rate := h.quoter.Rate(c.Request.Context())
+	quoted := make([]quotedCase, len(entries))
+	for i, entry := range entries {
+		quoted[i] = quotedCase{
+			CatalogEntry: entry,
+			PriceUSD:     pricefeed.QuoteAt(entry.Price, rate).USD,
+		}
+	}
+	return quoted
+}
+
 // BuyCase purchases a case
 func (h *Handler) BuyCase(c *gin.Context) {
 	var body struct {
@@ -127,13 +241,13 @@ func (h *Handler) BuyCase(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&body); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(invalidBody(err))
 		return
 	}
 
 	walletAddress := c.GetHeader("X-Wallet-Address")
 	if walletAddress == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Wallet address required"})
+		c.Error(errWalletRequired)
 		return
 	}
 
@@ -155,6 +269,148 @@ func (h *Handler) OpenCase(c *gin.Context) {
 	})
 }
 
+// GetCaseHistory returns a user's case openings, filtered by date range
+func (h *Handler) GetCaseHistory(c *gin.Context) {
+	address := c.Query("address")
+	if address == "" {
+		address = c.GetHeader("X-Wallet-Address")
+	}
+	if address == "" {
+		c.Error(errWalletRequired)
+		return
+	}
+
+	dateRange, err := parseDateRange(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	limit, offset := parsePagination(c)
+
+	openings, total, err := h.caseService.GetCaseHistory(address, dateRange, limit, offset)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"openings": openings,
+		"count":    len(openings),
+		"total":    total,
+		"limit":    limit,
+		"offset":   offset,
+	})
+}
+
+// GetCaseStats returns global case statistics, or a user's when ?address is set
+func (h *Handler) GetCaseStats(c *gin.Context) {
+	dateRange, err := parseDateRange(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var stats map[string]interface{}
+	if address := c.Query("address"); address != "" {
+		stats, err = h.caseService.GetUserCaseStats(address, dateRange)
+		if err == nil {
+			stats["pity"], err = h.pityService.GetProgress(address)
+		}
+	} else {
+		stats, err = h.caseService.GetCaseStats(dateRange)
+	}
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// GetCasePity returns a wallet's pity progress and outstanding vouchers
+func (h *Handler) GetCasePity(c *gin.Context) {
+	address := c.Query("address")
+	if address == "" {
+		address = c.GetHeader("X-Wallet-Address")
+	}
+	if address == "" {
+		c.Error(errWalletRequired)
+		return
+	}
+
+	progress, err := h.pityService.GetProgress(address)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	vouchers, err := h.pityService.GetVouchers(address, models.VoucherIssued)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	// Any wallet's pity is public, so leave out the codes that claim vouchers
+	pending := make([]pendingVoucher, len(vouchers))
+	for i, v := range vouchers {
+		pending[i] = pendingVoucher{CaseType: v.CaseType, Rarity: v.Rarity, IssuedAt: v.IssuedAt}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"address":  strings.ToLower(address),
+		"pity":     progress,
+		"vouchers": pending,
+	})
+}
+
+// pendingVoucher is an unclaimed voucher as GetCasePity shows it
+type pendingVoucher struct {
+	CaseType string    `json:"case_type"`
+	Rarity   string    `json:"rarity"`
+	IssuedAt time.Time `json:"issued_at"`
+}
+
+// GetVouchers lists the signing wallet's reward vouchers, optionally by ?status
+func (h *Handler) GetVouchers(c *gin.Context) {
+	walletAddress := c.GetHeader("X-Wallet-Address")
+	if walletAddress == "" {
+		c.Error(errWalletRequired)
+		return
+	}
+
+	vouchers, err := h.pityService.GetVouchers(walletAddress, c.Query("status"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"vouchers": vouchers,
+		"count":    len(vouchers),
+	})
+}
+
+// ClaimVoucher claims one of the signing wallet's issued vouchers
+func (h *Handler) ClaimVoucher(c *gin.Context) {
+	walletAddress := c.GetHeader("X-Wallet-Address")
+	if walletAddress == "" {
+		c.Error(errWalletRequired)
+		return
+	}
+
+	voucher, err := h.pityService.ClaimVoucher(c.Param("code"), walletAddress)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Voucher claimed",
+		"voucher": voucher,
+	})
+}
+
 // ==================== Marketplace Endpoints ====================
 
 // GetMarketplace retrieves active marketplace listings
@@ -171,90 +427,213 @@ func (h *Handler) GetMarketplace(c *gin.Context) {
 			filters["min_level"] = level
 		}
 	}
+	if maxPrice := c.Query("max_price"); maxPrice != "" {
+		price, err := money.ParseWei(maxPrice)
+		if err != nil || price.Sign() < 0 {
+			c.Error(errInvalidQuery.WithMessage("max_price must be an amount in wei"))
+			return
+		}
+		filters["max_price"] = price
+	}
 
 	listings, err := h.marketplaceService.GetActiveListings(limit, offset, filters)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch listings"})
+		c.Error(err)
 		return
 	}
 
+	rate := h.quoter.Rate(c.Request.Context())
+	quoted := make([]quotedListing, len(listings))
+	for i, listing := range listings {
+		quoted[i] = quotedListing{
+			MarketListing: listing,
+			PriceUSD:      pricefeed.QuoteAt(listing.Price, rate).USD,
+		}
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"listings": listings,
-		"count":    len(listings),
+		"listings": quoted,
+		"count":    len(quoted),
+		"eth_usd":  rate,
 	})
 }
 
-// ListNFT creates a new marketplace listing
+// quotedListing is a listing with its wei price also quoted in USD
+type quotedListing struct {
+	models.MarketListing
+	PriceUSD *float64 `json:"price_usd"`
+}
+
+// ListNFT confirms a Marketplace.listNFT transaction submitted by the seller
 func (h *Handler) ListNFT(c *gin.Context) {
 	var body struct {
-		TokenID uint    `json:"token_id" binding:"required"`
-		Price   float64 `json:"price" binding:"required"`
+		TokenID uint   `json:"token_id" binding:"required"`
+		TxHash  string `json:"tx_hash" binding:"required"`
 	}
 
 	if err := c.ShouldBindJSON(&body); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(invalidBody(err))
 		return
 	}
 
 	walletAddress := c.GetHeader("X-Wallet-Address")
 	if walletAddress == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Wallet address required"})
-		return
-	}
-
-	err := h.marketplaceService.ListNFT(body.TokenID, walletAddress, body.Price)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(errWalletRequired)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "NFT listed successfully"})
+	listing, err := h.marketplaceService.ListNFT(c.Request.Context(), body.TokenID, walletAddress, body.TxHash)
+	respondMarketplaceTx(c, body.TxHash, listing, err, "NFT listed successfully")
 }
 
-// BuyNFT purchases an NFT from marketplace
+// BuyNFT confirms a Marketplace.buyNFT transaction submitted by the buyer
 func (h *Handler) BuyNFT(c *gin.Context) {
 	tokenID, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid token ID"})
+		c.Error(errInvalidTokenID)
+		return
+	}
+
+	var body struct {
+		TxHash string `json:"tx_hash" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.Error(invalidBody(err))
 		return
 	}
 
 	walletAddress := c.GetHeader("X-Wallet-Address")
 	if walletAddress == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Wallet address required"})
+		c.Error(errWalletRequired)
 		return
 	}
 
-	err = h.marketplaceService.BuyNFT(uint(tokenID), walletAddress)
+	listing, err := h.marketplaceService.BuyNFT(c.Request.Context(), uint(tokenID), walletAddress, body.TxHash)
+	respondMarketplaceTx(c, body.TxHash, listing, err, "NFT purchased successfully")
+}
+
+// CancelListing confirms a Marketplace.cancelListing transaction submitted by the seller
+func (h *Handler) CancelListing(c *gin.Context) {
+	tokenID, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(errInvalidTokenID)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "NFT purchased successfully"})
+	var body struct {
+		TxHash string `json:"tx_hash" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.Error(invalidBody(err))
+		return
+	}
+
+	walletAddress := c.GetHeader("X-Wallet-Address")
+	if walletAddress == "" {
+		c.Error(errWalletRequired)
+		return
+	}
+
+	listing, err := h.marketplaceService.CancelListing(c.Request.Context(), uint(tokenID), walletAddress, body.TxHash)
+	respondMarketplaceTx(c, body.TxHash, listing, err, "Listing cancelled successfully")
 }
 
-// CancelListing cancels a marketplace listing
-func (h *Handler) CancelListing(c *gin.Context) {
+// UpdateListingPrice confirms a Marketplace.updatePrice transaction submitted by the seller
+func (h *Handler) UpdateListingPrice(c *gin.Context) {
 	tokenID, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid token ID"})
+		c.Error(errInvalidTokenID)
+		return
+	}
+
+	var body struct {
+		TxHash string `json:"tx_hash" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.Error(invalidBody(err))
 		return
 	}
 
 	walletAddress := c.GetHeader("X-Wallet-Address")
 	if walletAddress == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Wallet address required"})
+		c.Error(errWalletRequired)
 		return
 	}
 
-	err = h.marketplaceService.CancelListing(uint(tokenID), walletAddress)
+	listing, err := h.marketplaceService.UpdatePrice(c.Request.Context(), uint(tokenID), walletAddress, body.TxHash)
+	respondMarketplaceTx(c, body.TxHash, listing, err, "Price updated successfully")
+}
+
+// respondMarketplaceTx answers a submitted marketplace transaction: 202 while
+// it is still pending, 200 with the synced listing once confirmed
+func respondMarketplaceTx(c *gin.Context, txHash string, listing *models.MarketListing, err error, message string) {
+	if errors.Is(err, blockchain.ErrTxPending) {
+		c.JSON(http.StatusAccepted, gin.H{
+			"status":  "pending",
+			"tx_hash": txHash,
+			"message": "Transaction submitted, retry to confirm once mined",
+		})
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Listing cancelled successfully"})
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "confirmed",
+		"message": message,
+		"listing": listing,
+	})
+}
+
+// GetSellerEarnings returns a seller's sale ledger and proceeds
+func (h *Handler) GetSellerEarnings(c *gin.Context) {
+	address := c.Param("address")
+
+	dateRange, err := parseDateRange(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	limit, offset := parsePagination(c)
+
+	earnings, err := h.revenueService.GetSellerEarnings(address, dateRange, limit, offset)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, earnings)
+}
+
+// GetPlatformRevenue reports marketplace fees and case revenue
+func (h *Handler) GetPlatformRevenue(c *gin.Context) {
+	dateRange, err := parseDateRange(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	revenue, err := h.revenueService.GetPlatformRevenue(dateRange)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, revenue)
+}
+
+// ReconcileRevenue compares recorded revenue with contract balances
+func (h *Handler) ReconcileRevenue(c *gin.Context) {
+	report, err := h.revenueService.ReconcileBalances(c.Request.Context())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
 }
 
 // ==================== User Endpoints ====================
@@ -265,7 +644,7 @@ func (h *Handler) GetUser(c *gin.Context) {
 
 	user, err := h.userRepo.GetOrCreate(address)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user"})
+		c.Error(err)
 		return
 	}
 
@@ -276,10 +655,95 @@ func (h *Handler) GetUser(c *gin.Context) {
 func (h *Handler) GetInventory(c *gin.Context) {
 	address := c.Param("address")
 
-	// TODO: Implement inventory retrieval
-	c.JSON(http.StatusOK, gin.H{
-		"address": address,
-		"nfts":    []interface{}{},
-	})
+	filter := services.InventoryFilter{
+		Rarity:   c.Query("rarity"),
+		MemeType: c.Query("meme"),
+		SortBy:   c.DefaultQuery("sort", "token_id"),
+		SortDesc: c.Query("order") == "desc",
+	}
+	if !validInventorySorts[filter.SortBy] {
+		c.Error(errInvalidQuery.WithMessage("Invalid sort field"))
+		return
+	}
+	if alive := c.Query("alive"); alive != "" {
+		isAlive, err := strconv.ParseBool(alive)
+		if err != nil {
+			c.Error(errInvalidQuery.WithMessage("Invalid alive filter"))
+			return
+		}
+		filter.Alive = &isAlive
+	}
+
+	verify := c.Query("verify") == "true"
+	inventory, err := h.inventoryService.GetInventory(c.Request.Context(), address, filter, verify)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, inventory)
+}
+
+var validInventorySorts = map[string]bool{
+	"token_id":  true,
+	"level":     true,
+	"rarity":    true,
+	"hunger":    true,
+	"mood":      true,
+	"energy":    true,
+	"minted_at": true,
+}
+
+// ==================== Helpers ====================
+
+// parsePagination reads ?limit (1-100, default 20) and ?offset
+func parsePagination(c *gin.Context) (int, int) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return limit, offset
+}
+
+// parseDateRange reads the optional ?from and ?to query params.
+// Both accept RFC3339 timestamps or YYYY-MM-DD dates; a bare ?to date is inclusive.
+func parseDateRange(c *gin.Context) (repository.DateRange, error) {
+	var dateRange repository.DateRange
+
+	if from := c.Query("from"); from != "" {
+		t, _, err := parseDateParam(from)
+		if err != nil {
+			return dateRange, errInvalidDates.WithMessage(fmt.Sprintf("invalid from date: %s", from))
+		}
+		dateRange.From = &t
+	}
+
+	if to := c.Query("to"); to != "" {
+		t, dateOnly, err := parseDateParam(to)
+		if err != nil {
+			return dateRange, errInvalidDates.WithMessage(fmt.Sprintf("invalid to date: %s", to))
+		}
+		if dateOnly {
+			t = t.AddDate(0, 0, 1)
+		}
+		dateRange.To = &t
+	}
+
+	if dateRange.From != nil && dateRange.To != nil && !dateRange.From.Before(*dateRange.To) {
+		return dateRange, errInvalidDates.WithMessage("from must be before to")
+	}
+
+	return dateRange, nil
 }
 
+func parseDateParam(value string) (time.Time, bool, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, false, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	return t, true, err
+}
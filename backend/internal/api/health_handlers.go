@@ -0,0 +1,32 @@
+package api
+
+import (
+	"brainrot-tamagotchi/internal/health"
+	"brainrot-tamagotchi/pkg/buildinfo"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ==================== Health Endpoints ====================
+
+// Liveness reports that the process is up and serving. It checks no
+// dependencies, so an outage elsewhere doesn't get the process restarted.
+func (h *Handler) Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status": health.StatusOK,
+		"build":  buildinfo.Get(),
+	})
+}
+
+// Readiness runs the dependency checks. It answers 503 when a critical
+// check fails, so the load balancer stops routing here until it recovers.
+func (h *Handler) Readiness(c *gin.Context) {
+	report := h.health.Run(c.Request.Context())
+
+	status := http.StatusOK
+	if !report.Ready() {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, report)
+}
@@ -0,0 +1,482 @@
+package api
+
+import (
+	"brainrot-tamagotchi/internal/metrics"
+	"brainrot-tamagotchi/internal/models"
+	"brainrot-tamagotchi/pkg/logging"
+	"brainrot-tamagotchi/pkg/tracing"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"regexp"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"gorm.io/gorm"
+)
+
+var logger = logging.For("api")
+
+// requestIDPattern is what an incoming X-Request-ID must look like to be
+// reused; anything else is replaced so logs can't be forged through it
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// adminSignatureMaxAge bounds how old a signed admin request may be
+const adminSignatureMaxAge = 5 * time.Minute
+
+// maxSignedBodySize caps the body read to check a signature, before the
+// caller is known
+const maxSignedBodySize = 1 << 20
+
+// Context keys set by the auth middleware and handlers
+const (
+	ctxWallet      = "wallet"
+	ctxAdminRole   = "admin_role"
+	ctxAuditTarget = "audit_target"
+	ctxAuditReason = "audit_reason"
+	ctxAuditDetail = "audit_details"
+)
+
+// auditTarget identifies what an admin action touched
+type auditTarget struct {
+	Type string
+	ID   string
+}
+
+// AdminSignatureMessage is the text a wallet signs (EIP-191 personal_sign)
+// to authenticate one admin request. target is the path with its raw query,
+// if any, and bodyHash the hex SHA-256 of the raw body (see RequestBodyHash).
+func AdminSignatureMessage(method, target, bodyHash, timestamp string) string {
+	return fmt.Sprintf("Brainrot Tamagotchi admin request\n%s %s\n%s\n%s", method, target, bodyHash, timestamp)
+}
+
+// WalletSignatureMessage is the text a wallet signs (EIP-191 personal_sign)
+// to authenticate one player request that exposes or changes private data.
+// Its arguments are those of AdminSignatureMessage.
+func WalletSignatureMessage(method, target, bodyHash, timestamp string) string {
+	return fmt.Sprintf("Brainrot Tamagotchi request\n%s %s\n%s\n%s", method, target, bodyHash, timestamp)
+}
+
+// RequestBodyHash is the hex SHA-256 of a request body, as signed; an empty
+// body hashes to e3b0c442...b855
+func RequestBodyHash(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// probePaths are hit every few seconds by the orchestrator and scraper; they
+// are not traced and are logged at debug level unless they fail
+var probePaths = map[string]bool{"/metrics": true, "/healthz": true, "/readyz": true}
+
+// Tracing starts a span for every request except probes, joining the
+// caller's trace when it sends a traceparent header. Spans further down,
+// from services, GORM, Redis and the chain client, hang off the request
+// context it sets.
+func Tracing(service string) gin.HandlerFunc {
+	return otelgin.Middleware(service, otelgin.WithFilter(func(r *http.Request) bool {
+		return !probePaths[r.URL.Path]
+	}))
+}
+
+// RequestID gives every request an ID, reusing a well-formed X-Request-ID
+// from the caller. The ID is echoed in the response and attached to the
+// request context, so service, GORM and blockchain logs carry it. The
+// trace ID, once Tracing has run, is returned as X-Trace-ID.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader("X-Request-ID")
+		if !requestIDPattern.MatchString(id) {
+			id = logging.NewID()
+		}
+		c.Header("X-Request-ID", id)
+		if traceID := tracing.TraceID(c.Request.Context()); traceID != "" {
+			c.Header("X-Trace-ID", traceID)
+		}
+		c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), id))
+		c.Next()
+	}
+}
+
+// AccessLog logs one line per request once it is handled
+func AccessLog() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		if status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		} else if probePaths[c.Request.URL.Path] && status < http.StatusBadRequest {
+			level = slog.LevelDebug
+		}
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("route", route),
+			slog.String("path", c.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Int("bytes", c.Writer.Size()),
+			slog.Int64("duration_ms", time.Since(start).Milliseconds()),
+			slog.String("ip", c.ClientIP()),
+		}
+		if wallet := c.GetHeader("X-Wallet-Address"); wallet != "" {
+			attrs = append(attrs, slog.String("wallet", strings.ToLower(wallet)))
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.String("error", c.Errors.String()))
+		}
+		logger.LogAttrs(c.Request.Context(), level, "request", attrs...)
+	}
+}
+
+// Metrics records request counts and latency by route template, so
+// /api/nft/:tokenId is one series however many tokens are requested
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		metrics.ObserveHTTP(c.Request.Method, route, c.Writer.Status(), time.Since(start))
+	}
+}
+
+// Recovery turns a handler panic into a 500 and logs it with its stack
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, err any) {
+		logger.ErrorContext(c.Request.Context(), "handler panicked", "panic", fmt.Sprint(err), "stack", string(debug.Stack()))
+		writeProblem(c, errInternal)
+	})
+}
+
+// Errors answers requests whose handler or middleware recorded an error
+// with c.Error, and wrote nothing, with an application/problem+json body.
+// The error's apperr kind picks the status and its code tells clients what
+// went wrong; see writeProblem.
+func Errors() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		writeProblem(c, c.Errors.Last().Err)
+	}
+}
+
+// walletAuth requires X-Wallet-Address to be proven with X-Wallet-Signature
+// over WalletSignatureMessage and X-Wallet-Timestamp
+func (h *Handler) walletAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		address, err := h.verifySignedRequest(c, WalletSignatureMessage)
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+		c.Set(ctxWallet, strings.ToLower(address))
+		c.Next()
+	}
+}
+
+// adminAuth authenticates admin requests. The caller signs
+// AdminSignatureMessage for the request and sends X-Wallet-Address,
+// X-Wallet-Signature and X-Wallet-Timestamp (unix seconds). The wallet must
+// hold an admin role.
+func (h *Handler) adminAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		address, err := h.verifySignedRequest(c, AdminSignatureMessage)
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+
+		role, err := h.adminService.GetRole(address)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			abortWithError(c, errNoAdminRole)
+			return
+		}
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+
+		c.Set(ctxAdminRole, role)
+		c.Next()
+	}
+}
+
+// requireRole rejects admins ranked below minRole
+func requireRole(minRole string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := adminRole(c)
+		if role == nil || models.RoleRank[role.Role] < models.RoleRank[minRole] {
+			abortWithError(c, errRoleTooLow.WithMessage(fmt.Sprintf("Requires %s role", minRole)).With("required_role", minRole))
+			return
+		}
+		c.Next()
+	}
+}
+
+// auditAdmin records every authenticated admin request, including rejected
+// ones, once the handler has run
+func (h *Handler) auditAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		role := adminRole(c)
+		if role == nil {
+			return
+		}
+
+		entry := &models.AdminAuditLog{
+			ActorAddress: role.WalletAddress,
+			ActorRole:    role.Role,
+			Action:       c.Request.Method + " " + c.FullPath(),
+			Reason:       c.GetString(ctxAuditReason),
+			StatusCode:   c.Writer.Status(),
+			IPAddress:    c.ClientIP(),
+		}
+		if target, ok := c.Get(ctxAuditTarget); ok {
+			entry.TargetType = target.(auditTarget).Type
+			entry.TargetID = target.(auditTarget).ID
+		}
+		if details, ok := c.Get(ctxAuditDetail); ok {
+			entry.Details = details.(map[string]interface{})
+		}
+
+		if err := h.adminService.Audit(entry); err != nil {
+			logger.ErrorContext(c.Request.Context(), "failed to write admin audit log", "action", entry.Action, "actor", entry.ActorAddress, "error", err)
+		}
+	}
+}
+
+// banGuard rejects requests from banned wallets. It goes after walletAuth:
+// X-Wallet-Address alone proves nothing, so only the signer is checked.
+func (h *Handler) banGuard() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		address := c.GetString(ctxWallet)
+		if address == "" {
+			abortWithError(c, errSignedHeaders)
+			return
+		}
+		if err := h.checkBan(address); err != nil {
+			abortWithError(c, err)
+			return
+		}
+		c.Next()
+	}
+}
+
+// checkBan returns errWalletBanned, with its reason and expiry, if address
+// has an active ban
+func (h *Handler) checkBan(address string) error {
+	ban, err := h.adminService.GetActiveBan(address)
+	if err != nil {
+		return err
+	}
+	if ban != nil {
+		return errWalletBanned.With("reason", ban.Reason).With("expires_at", ban.ExpiresAt)
+	}
+	return nil
+}
+
+// rateLimit applies the IP and X-API-Key rules of the named rate limit
+// policy. It runs before authentication, so wallet rules are left to
+// walletRateLimit. Over-limit requests get 429 with Retry-After; callers on
+// the bypass list are never limited.
+func (h *Handler) rateLimit(group string) gin.HandlerFunc {
+	return h.limitBy(group, func(c *gin.Context) map[string]string {
+		return map[string]string{"ip": c.ClientIP(), "api_key": c.GetHeader("X-API-Key")}
+	})
+}
+
+// walletRateLimit applies the wallet rules of the named policy. It goes
+// after walletAuth and keys on the verified signer: X-Wallet-Address alone
+// would let anyone drain another wallet's bucket or rotate addresses.
+func (h *Handler) walletRateLimit(group string) gin.HandlerFunc {
+	return h.limitBy(group, func(c *gin.Context) map[string]string {
+		return map[string]string{"wallet": c.GetString(ctxWallet)}
+	})
+}
+
+// limitBy checks the named policy's rules for the identities of a request
+func (h *Handler) limitBy(group string, identities func(c *gin.Context) map[string]string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if h.rateLimits == nil || h.rateLimits.Bypassed(c.ClientIP(), c.GetHeader("X-API-Key")) {
+			c.Next()
+			return
+		}
+
+		result, limited, err := h.rateLimits.Check(c.Request.Context(), group, identities(c))
+		if err != nil {
+			// Both backends failed; don't block players on limiter errors
+			logger.WarnContext(c.Request.Context(), "rate limit check failed", "group", group, "error", err)
+			c.Next()
+			return
+		}
+		if limited {
+			c.Header("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+			c.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		}
+
+		if !result.Allowed {
+			retryAfter := int(math.Ceil(result.RetryAfter.Seconds()))
+			if retryAfter < 1 {
+				retryAfter = 1
+			}
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			abortWithError(c, errRateLimited.With("retry_after", retryAfter))
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// rateLimitQuery applies rateLimit(group) only to requests with ?param=true,
+// for query flags that turn a cheap read into RPC calls
+func (h *Handler) rateLimitQuery(param, group string) gin.HandlerFunc {
+	limit := h.rateLimit(group)
+	return func(c *gin.Context) {
+		if c.Query(param) != "true" {
+			c.Next()
+			return
+		}
+		limit(c)
+	}
+}
+
+// adminRole returns the role adminAuth attached to the request
+func adminRole(c *gin.Context) *models.AdminRole {
+	if role, ok := c.Get(ctxAdminRole); ok {
+		return role.(*models.AdminRole)
+	}
+	return nil
+}
+
+// setAudit attaches the target, reason and details of an admin action to
+// its audit entry
+func setAudit(c *gin.Context, targetType, targetID, reason string, details map[string]interface{}) {
+	c.Set(ctxAuditTarget, auditTarget{Type: targetType, ID: targetID})
+	if reason != "" {
+		c.Set(ctxAuditReason, reason)
+	}
+	if details != nil {
+		c.Set(ctxAuditDetail, details)
+	}
+}
+
+// verifySignedRequest checks the X-Wallet-* headers against message built
+// for this request, method, path with query, body and timestamp, and claims
+// the signature so it can't be replayed. It returns the verified wallet.
+func (h *Handler) verifySignedRequest(c *gin.Context, message func(method, target, bodyHash, timestamp string) string) (string, error) {
+	address := c.GetHeader("X-Wallet-Address")
+	signature := c.GetHeader("X-Wallet-Signature")
+	timestamp := c.GetHeader("X-Wallet-Timestamp")
+	if address == "" || signature == "" || timestamp == "" {
+		return "", errSignedHeaders
+	}
+
+	body, err := readBody(c)
+	if err != nil {
+		return "", invalidBody(err)
+	}
+	target := c.Request.URL.Path
+	if c.Request.URL.RawQuery != "" {
+		target += "?" + c.Request.URL.RawQuery
+	}
+
+	signed := message(c.Request.Method, target, RequestBodyHash(body), timestamp)
+	if err := h.verifyOnce(c, signed, address, signature, timestamp); err != nil {
+		return "", err
+	}
+	return address, nil
+}
+
+// verifyOnce is verifyWalletSignature that also rejects a message already
+// accepted. Messages are keyed rather than signatures, since one message has
+// several valid encodings of its signature.
+func (h *Handler) verifyOnce(c *gin.Context, message, address, signature, timestamp string) error {
+	if err := verifyWalletSignature(message, address, signature, timestamp); err != nil {
+		return err
+	}
+	key := RequestBodyHash([]byte(strings.ToLower(address) + "\n" + message))
+	// A timestamp up to adminSignatureMaxAge ahead stays valid for twice as long
+	if !h.replay.Claim(c.Request.Context(), key, 2*adminSignatureMaxAge) {
+		return errSignatureReused
+	}
+	return nil
+}
+
+// readBody reads the request body, up to maxSignedBodySize, and puts it
+// back for the handler
+func readBody(c *gin.Context) ([]byte, error) {
+	if c.Request.Body == nil {
+		return nil, nil
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxSignedBodySize))
+	if err != nil {
+		return nil, err
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// verifyWalletSignature checks that address signed message (EIP-191) within
+// adminSignatureMaxAge of timestamp
+func verifyWalletSignature(message, address, signature, timestamp string) error {
+	if !common.IsHexAddress(address) {
+		return errSignerAddress
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errSignatureTimestamp
+	}
+	age := time.Since(time.Unix(unix, 0))
+	if age > adminSignatureMaxAge || age < -adminSignatureMaxAge {
+		return errSignatureExpired
+	}
+
+	sig, err := hexutil.Decode(signature)
+	if err != nil || len(sig) != crypto.SignatureLength {
+		return errInvalidSignature
+	}
+	// Wallets return v as 27/28; recovery expects 0/1
+	if sig[crypto.RecoveryIDOffset] >= 27 {
+		sig[crypto.RecoveryIDOffset] -= 27
+	}
+
+	hash := accounts.TextHash([]byte(message))
+	pubKey, err := crypto.SigToPub(hash, sig)
+	if err != nil {
+		return errInvalidSignature
+	}
+
+	signer := crypto.PubkeyToAddress(*pubKey)
+	if !strings.EqualFold(signer.Hex(), address) {
+		return errSignatureMismatch
+	}
+	return nil
+}
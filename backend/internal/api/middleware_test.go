@@ -0,0 +1,162 @@
+package api
+
+import (
+	"brainrot-tamagotchi/pkg/ratelimit"
+	"brainrot-tamagotchi/pkg/replay"
+	"crypto/ecdsa"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/gin-gonic/gin"
+)
+
+// signRequest sets the X-Wallet-* headers for req signed by key
+func signRequest(t *testing.T, req *http.Request, key *ecdsa.PrivateKey, body, target string) {
+	t.Helper()
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	message := WalletSignatureMessage(req.Method, target, RequestBodyHash([]byte(body)), timestamp)
+	sig, err := crypto.Sign(accounts.TextHash([]byte(message)), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("X-Wallet-Address", crypto.PubkeyToAddress(key.PublicKey).Hex())
+	req.Header.Set("X-Wallet-Signature", hexutil.Encode(sig))
+	req.Header.Set("X-Wallet-Timestamp", timestamp)
+}
+
+func TestWalletAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := &Handler{replay: replay.NewGuard(nil)}
+	router := gin.New()
+	router.Use(Errors())
+	router.PUT("/prefs", h.walletAuth(), func(c *gin.Context) {
+		// The body is still there for the handler after hashing
+		body, _ := io.ReadAll(c.Request.Body)
+		c.String(http.StatusOK, string(body))
+	})
+
+	do := func(req *http.Request) (int, string) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var problem struct {
+			Code string `json:"code"`
+		}
+		_ = json.Unmarshal(w.Body.Bytes(), &problem)
+		return w.Code, problem.Code
+	}
+
+	const body = `{"quiet_start":"22:00"}`
+	req := httptest.NewRequest(http.MethodPut, "/prefs?lang=en", strings.NewReader(body))
+	signRequest(t, req, key, body, "/prefs?lang=en")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || w.Body.String() != body {
+		t.Fatalf("signed request: got %d %q", w.Code, w.Body.String())
+	}
+
+	// The same headers again, as captured
+	replayed := httptest.NewRequest(http.MethodPut, "/prefs?lang=en", strings.NewReader(body))
+	replayed.Header = req.Header.Clone()
+	if status, code := do(replayed); status != http.StatusUnauthorized || code != "signature_reused" {
+		t.Errorf("replay: got %d %q, want 401 signature_reused", status, code)
+	}
+
+	tampered := []struct {
+		name, target, body string
+	}{
+		{"other body", "/prefs?lang=en", `{"quiet_start":"00:00"}`},
+		{"other query", "/prefs?lang=uk", body},
+		{"query dropped", "/prefs", body},
+	}
+	for _, tt := range tampered {
+		t.Run(tt.name, func(t *testing.T) {
+			signed := httptest.NewRequest(http.MethodPut, "/prefs?lang=en", strings.NewReader(body))
+			signRequest(t, signed, key, body, "/prefs?lang=en")
+
+			req := httptest.NewRequest(http.MethodPut, tt.target, strings.NewReader(tt.body))
+			req.Header = signed.Header.Clone()
+			if status, code := do(req); status != http.StatusUnauthorized || code != "signature_mismatch" {
+				t.Errorf("got %d %q, want 401 signature_mismatch", status, code)
+			}
+		})
+	}
+
+	// Oversized bodies are refused before they are hashed
+	large := strings.Repeat("x", maxSignedBodySize+1)
+	oversized := httptest.NewRequest(http.MethodPut, "/prefs", strings.NewReader(large))
+	signRequest(t, oversized, key, large, "/prefs")
+	if status, code := do(oversized); status != http.StatusBadRequest || code != "invalid_body" {
+		t.Errorf("oversized body: got %d %q, want 400 invalid_body", status, code)
+	}
+
+	if status, code := do(httptest.NewRequest(http.MethodPut, "/prefs", nil)); status != http.StatusUnauthorized || code != "signed_headers_required" {
+		t.Errorf("unsigned: got %d %q, want 401 signed_headers_required", status, code)
+	}
+
+	// banGuard only trusts a wallet walletAuth verified, never the bare header
+	router.POST("/write", h.banGuard(), func(c *gin.Context) { c.Status(http.StatusOK) })
+	unsigned := httptest.NewRequest(http.MethodPost, "/write", nil)
+	unsigned.Header.Set("X-Wallet-Address", crypto.PubkeyToAddress(key.PublicKey).Hex())
+	if status, code := do(unsigned); status != http.StatusUnauthorized || code != "signed_headers_required" {
+		t.Errorf("banGuard without walletAuth: got %d %q, want 401 signed_headers_required", status, code)
+	}
+}
+
+func TestWalletRateLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	victim, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	guard, err := ratelimit.NewGuard(ratelimit.NewMemory(), "writes:wallet=1/1h", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := &Handler{replay: replay.NewGuard(nil), rateLimits: guard}
+	router := gin.New()
+	router.Use(Errors())
+	router.POST("/write", h.rateLimit("writes"), h.walletAuth(), h.walletRateLimit("writes"), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	do := func(req *http.Request) int {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// Claiming the victim's address without a signature spends nothing
+	for i := 0; i < 3; i++ {
+		forged := httptest.NewRequest(http.MethodPost, "/write", nil)
+		forged.Header.Set("X-Wallet-Address", crypto.PubkeyToAddress(victim.PublicKey).Hex())
+		if status := do(forged); status != http.StatusUnauthorized {
+			t.Fatalf("forged request: got %d, want 401", status)
+		}
+	}
+
+	signed := httptest.NewRequest(http.MethodPost, "/write", nil)
+	signRequest(t, signed, victim, "", "/write")
+	if status := do(signed); status != http.StatusOK {
+		t.Fatalf("first signed request: got %d, want 200", status)
+	}
+	// A different query, so it is not a replay of the first
+	again := httptest.NewRequest(http.MethodPost, "/write?n=2", nil)
+	signRequest(t, again, victim, "", "/write?n=2")
+	if status := do(again); status != http.StatusTooManyRequests {
+		t.Errorf("second signed request: got %d, want 429", status)
+	}
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"brainrot-tamagotchi/internal/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ==================== Notification Endpoints ====================
+
+// GetNotifications returns the signed caller's latest notifications. Pushes
+// carry no payload, so the app fetches these once one arrives.
+func (h *Handler) GetNotifications(c *gin.Context) {
+	walletAddress := c.GetString(ctxWallet)
+	if walletAddress == "" {
+		c.Error(errSignedHeaders)
+		return
+	}
+
+	limit, _ := parsePagination(c)
+
+	notifications, err := h.notificationService.GetNotifications(walletAddress, limit)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"notifications": notifications,
+		"count":         len(notifications),
+	})
+}
+
+// GetNotificationPreferences returns the caller's channels, muted kinds and
+// quiet hours
+func (h *Handler) GetNotificationPreferences(c *gin.Context) {
+	walletAddress := c.GetString(ctxWallet)
+	if walletAddress == "" {
+		c.Error(errSignedHeaders)
+		return
+	}
+
+	pref, err := h.notificationService.GetPreferences(walletAddress)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"preferences":        pref,
+		"webpush_public_key": h.notificationService.WebPushPublicKey(),
+	})
+}
+
+// UpdateNotificationPreferences changes the caller's preferences
+func (h *Handler) UpdateNotificationPreferences(c *gin.Context) {
+	walletAddress := c.GetString(ctxWallet)
+	if walletAddress == "" {
+		c.Error(errSignedHeaders)
+		return
+	}
+
+	var body services.PreferenceUpdate
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.Error(invalidBody(err))
+		return
+	}
+
+	pref, err := h.notificationService.UpdatePreferences(walletAddress, body)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"preferences": pref})
+}
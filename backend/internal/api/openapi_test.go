@@ -0,0 +1,110 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRoutesMatchSpec(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	doc, err := OpenAPISpec()
+	if err != nil {
+		t.Fatalf("openapi.yaml: %v", err)
+	}
+
+	router := gin.New()
+	(&Handler{}).SetupRoutes(router)
+
+	routes := make(map[string]bool)
+	for _, r := range router.Routes() {
+		routes[r.Method+" "+specPath(r.Path)] = true
+	}
+	documented := make(map[string]bool)
+	for path, item := range doc.Paths.Map() {
+		for method := range item.Operations() {
+			documented[method+" "+path] = true
+		}
+	}
+
+	var missing, stale []string
+	for route := range routes {
+		if !documented[route] {
+			missing = append(missing, route)
+		}
+	}
+	for route := range documented {
+		if !routes[route] {
+			stale = append(stale, route)
+		}
+	}
+	sort.Strings(missing)
+	sort.Strings(stale)
+	if len(missing) > 0 {
+		t.Errorf("routes missing from openapi.yaml:\n  %s", strings.Join(missing, "\n  "))
+	}
+	if len(stale) > 0 {
+		t.Errorf("openapi.yaml documents routes SetupRoutes doesn't register:\n  %s", strings.Join(stale, "\n  "))
+	}
+}
+
+func TestValidateRequest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Errors())
+	api := router.Group("/api/v1", validateRequest(mustOpenAPISpec()))
+	ok := func(c *gin.Context) { c.Status(http.StatusNoContent) }
+	api.GET("/pets/:id", ok)
+	api.POST("/pets/:id/feed", ok)
+	api.POST("/marketplace/list", ok)
+	api.GET("/users/:address/inventory", ok)
+	api.GET("/undocumented", ok)
+
+	const address = "0x1111111111111111111111111111111111111111"
+	txHash := "0x" + strings.Repeat("ab", 32)
+
+	tests := []struct {
+		name, method, path, body string
+		want                     int
+		wantError                string
+	}{
+		{"valid path param", "GET", "/api/v1/pets/7", "", http.StatusNoContent, ""},
+		{"non-numeric token ID", "GET", "/api/v1/pets/abc", "", http.StatusBadRequest, "Invalid path parameter id"},
+		{"token ID over uint32", "GET", "/api/v1/pets/4294967296", "", http.StatusBadRequest, "Invalid path parameter id"},
+		{"optional body omitted", "POST", "/api/v1/pets/7/feed", "", http.StatusNoContent, ""},
+		{"optional body", "POST", "/api/v1/pets/7/feed", `{"is_paid":true}`, http.StatusNoContent, ""},
+		{"wrong body type", "POST", "/api/v1/pets/7/feed", `{"is_paid":"yes"}`, http.StatusBadRequest, "Invalid request body: is_paid"},
+		{"valid body", "POST", "/api/v1/marketplace/list", `{"token_id":7,"tx_hash":"` + txHash + `"}`, http.StatusNoContent, ""},
+		{"missing required field", "POST", "/api/v1/marketplace/list", `{"token_id":7}`, http.StatusBadRequest, "tx_hash"},
+		{"malformed tx hash", "POST", "/api/v1/marketplace/list", `{"token_id":7,"tx_hash":"0x12"}`, http.StatusBadRequest, "Invalid request body: tx_hash"},
+		{"valid query", "GET", "/api/v1/users/" + address + "/inventory?sort=level&alive=true", "", http.StatusNoContent, ""},
+		{"empty query value is absent", "GET", "/api/v1/users/" + address + "/inventory?sort=&rarity=", "", http.StatusNoContent, ""},
+		{"unknown sort", "GET", "/api/v1/users/" + address + "/inventory?sort=price", "", http.StatusBadRequest, "Invalid query parameter sort"},
+		{"bad address", "GET", "/api/v1/users/bob/inventory", "", http.StatusBadRequest, "Invalid path parameter address"},
+		{"undocumented route passes", "GET", "/api/v1/undocumented?x=1", "", http.StatusNoContent, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d; body %s", w.Code, tt.want, w.Body)
+			}
+			if tt.wantError == "" {
+				return
+			}
+			if !strings.Contains(w.Body.String(), tt.wantError) || !strings.Contains(w.Body.String(), `"code":"invalid_request"`) {
+				t.Errorf("body = %s, want an invalid_request problem mentioning %q", w.Body, tt.wantError)
+			}
+		})
+	}
+}
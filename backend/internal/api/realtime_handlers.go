@@ -0,0 +1,123 @@
+package api
+
+import (
+	"brainrot-tamagotchi/internal/realtime"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// realtimeHeartbeat keeps idle streams open through proxies
+const realtimeHeartbeat = 25 * time.Second
+
+// maxRealtimeTopics caps the topics of one stream
+const maxRealtimeTopics = 20
+
+// ==================== Realtime Endpoints ====================
+
+// RealtimeSignatureMessage is the text a wallet signs (EIP-191 personal_sign)
+// to get a token for its private realtime topics
+func RealtimeSignatureMessage(address, timestamp string) string {
+	return fmt.Sprintf("Brainrot Tamagotchi realtime subscription\n%s\n%s", strings.ToLower(address), timestamp)
+}
+
+// IssueRealtimeToken exchanges a wallet signature for a short-lived token
+// granting the wallet's private topics. The caller signs
+// RealtimeSignatureMessage and sends X-Wallet-Address, X-Wallet-Signature and
+// X-Wallet-Timestamp.
+func (h *Handler) IssueRealtimeToken(c *gin.Context) {
+	address := c.GetHeader("X-Wallet-Address")
+	signature := c.GetHeader("X-Wallet-Signature")
+	timestamp := c.GetHeader("X-Wallet-Timestamp")
+	if address == "" || signature == "" || timestamp == "" {
+		c.Error(errSignedHeaders)
+		return
+	}
+
+	if err := h.verifyOnce(c, RealtimeSignatureMessage(address, timestamp), address, signature, timestamp); err != nil {
+		c.Error(err)
+		return
+	}
+	if err := h.checkBan(address); err != nil {
+		c.Error(err)
+		return
+	}
+
+	token, expiresAt := h.realtimeTokens.Issue(address)
+	c.JSON(http.StatusOK, gin.H{
+		"token":      token,
+		"topic":      realtime.WalletTopic(address).String(),
+		"expires_at": expiresAt,
+	})
+}
+
+// StreamEvents opens a Server-Sent Events stream for
+// ?topics=pet:<id>,wallet:<address>,marketplace,cases. Wallet topics need
+// ?token from IssueRealtimeToken; the token is only checked when the stream
+// opens.
+func (h *Handler) StreamEvents(c *gin.Context) {
+	var (
+		topics []realtime.Topic
+		names  []string
+	)
+	for _, raw := range strings.Split(c.Query("topics"), ",") {
+		if strings.TrimSpace(raw) == "" {
+			continue
+		}
+		topic, err := realtime.ParseTopic(raw)
+		if err != nil {
+			c.Error(errInvalidTopics.WithMessage(err.Error()))
+			return
+		}
+		topics = append(topics, topic)
+		names = append(names, topic.String())
+	}
+	if len(topics) == 0 {
+		c.Error(errInvalidTopics.WithMessage("At least one topic required"))
+		return
+	}
+	if len(topics) > maxRealtimeTopics {
+		c.Error(errInvalidTopics.WithMessage(fmt.Sprintf("At most %d topics per stream", maxRealtimeTopics)))
+		return
+	}
+
+	if err := h.realtimeTokens.Authorize(topics, c.Query("token")); err != nil {
+		c.Error(errTopicForbidden.WithMessage(err.Error()))
+		return
+	}
+
+	sub := h.realtimeHub.Subscribe(topics)
+	defer sub.Close()
+
+	heartbeat := time.NewTicker(realtimeHeartbeat)
+	defer heartbeat.Stop()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // Disable nginx response buffering
+
+	c.SSEvent("ready", gin.H{"topics": names})
+	c.Writer.Flush()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case event, ok := <-sub.Events():
+			if !ok {
+				// Fell too far behind; the client reconnects
+				return false
+			}
+			c.SSEvent(event.Type, event)
+			return true
+		case <-heartbeat.C:
+			c.SSEvent("ping", gin.H{"at": time.Now().UTC()})
+			return true
+		}
+	})
+}
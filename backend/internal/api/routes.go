@@ -1,13 +1,25 @@
 package api
 
 import (
+	"brainrot-tamagotchi/internal/models"
+
 	"github.com/gin-gonic/gin"
 )
 
 // SetupRoutes configures all API routes
 func (h *Handler) SetupRoutes(router *gin.Engine) {
+	// Probes sit outside /api/v1 so bans and rate limits never touch them
+	router.GET("/healthz", h.Liveness)
+	router.GET("/readyz", h.Readiness)
+
+	// API documentation
+	router.GET("/openapi.json", h.OpenAPIDocument)
+	router.GET("/docs", h.Docs)
+
 	// API v1 group
-	api := router.Group("/api/v1")
+	// Requests are validated against openapi.yaml before any handler runs
+	// Writes are signed, and banGuard checks the signing wallet
+	api := router.Group("/api/v1", validateRequest(mustOpenAPISpec()))
 	{
 		// Health check
 		api.GET("/health", h.HealthCheck)
@@ -15,43 +27,121 @@ func (h *Handler) SetupRoutes(router *gin.Engine) {
 		// Pet / Tamagotchi routes
 		pets := api.Group("/pets")
 		{
-			pets.GET("/:id", h.GetPet)           // Get pet state
-			pets.POST("/:id/feed", h.FeedPet)    // Feed pet
-			pets.POST("/:id/play", h.PlayWithPet) // Play with pet
+			pets.GET("/:id", h.GetPet)                        // Get pet state
+			pets.GET("/:id/upgrade-quote", h.GetUpgradeQuote) // Price a level upgrade
+
+			petActions := pets.Group("", h.rateLimit("pet_actions"), h.walletAuth(), h.walletRateLimit("pet_actions"), h.banGuard())
+			{
+				petActions.POST("/:id/feed", h.FeedPet)     // Feed pet
+				petActions.POST("/:id/play", h.PlayWithPet) // Play with pet
+			}
 		}
 
 		// Cases routes
 		cases := api.Group("/cases")
 		{
-			cases.GET("/prices", h.GetCasePrices) // Get case prices
-			cases.POST("/buy", h.BuyCase)          // Buy a case
-			cases.POST("/:id/open", h.OpenCase)    // Open a case
+			cases.GET("/prices", h.GetCasePrices)                 // Case catalog with prices
+			cases.GET("/history", h.GetCaseHistory)               // User's case opening history
+			cases.GET("/stats", h.GetCaseStats)                   // Global or per-user case stats
+			cases.GET("/pity", h.GetCasePity)                     // Pity progress and unclaimed vouchers
+			cases.GET("/vouchers", h.walletAuth(), h.GetVouchers) // Caller's reward vouchers
+
+			caseActions := cases.Group("", h.rateLimit("case_actions"), h.walletAuth(), h.walletRateLimit("case_actions"), h.banGuard())
+			{
+				caseActions.POST("/buy", h.BuyCase)                       // Buy a case
+				caseActions.POST("/:id/open", h.OpenCase)                 // Open a case
+				caseActions.POST("/vouchers/:code/claim", h.ClaimVoucher) // Claim a reward voucher
+			}
 		}
 
 		// Marketplace routes
 		marketplace := api.Group("/marketplace")
 		{
-			marketplace.GET("", h.GetMarketplace)           // Browse marketplace
-			marketplace.POST("/list", h.ListNFT)            // List NFT for sale
-			marketplace.POST("/:id/buy", h.BuyNFT)          // Buy NFT
-			marketplace.DELETE("/:id", h.CancelListing)     // Cancel listing
+			marketplace.GET("", h.GetMarketplace)                      // Browse marketplace
+			marketplace.GET("/earnings/:address", h.GetSellerEarnings) // Seller sale ledger
+
+			marketplaceWrites := marketplace.Group("", h.rateLimit("marketplace_write"), h.walletAuth(), h.walletRateLimit("marketplace_write"), h.banGuard())
+			{
+				marketplaceWrites.POST("/list", h.ListNFT)                // List NFT for sale
+				marketplaceWrites.POST("/:id/buy", h.BuyNFT)              // Buy NFT
+				marketplaceWrites.DELETE("/:id", h.CancelListing)         // Cancel listing
+				marketplaceWrites.PUT("/:id/price", h.UpdateListingPrice) // Change listing price
+			}
+		}
+
+		// Admin routes
+		// Signed by a wallet with a role in admin_roles; every request is audited
+		admin := api.Group("/admin", h.adminAuth(), h.auditAdmin())
+		{
+			admin.GET("/me", h.AdminMe) // Caller's role
+
+			support := admin.Group("", requireRole(models.RoleSupport))
+			{
+				support.GET("/pets/:id", h.AdminInspectPet) // Inspect any pet
+				support.GET("/bans", h.AdminGetBans)        // Active bans
+			}
+
+			moderator := admin.Group("", requireRole(models.RoleModerator))
+			{
+				moderator.PATCH("/pets/:id/stats", h.AdminAdjustPetStats)    // Adjust stats with a reason
+				moderator.POST("/bans", h.AdminBanWallet)                    // Ban a wallet
+				moderator.DELETE("/bans/:address", h.AdminLiftBan)           // Lift a ban
+				moderator.POST("/listings/:id/cancel", h.AdminCancelListing) // Queue emergencyCancelListing
+			}
+
+			owner := admin.Group("", requireRole(models.RoleAdmin))
+			{
+				owner.GET("/roles", h.AdminGetRoles)                      // Wallets with roles
+				owner.PUT("/roles/:address", h.AdminSetRole)              // Grant or change a role
+				owner.DELETE("/roles/:address", h.AdminRevokeRole)        // Revoke a role
+				owner.GET("/audit", h.AdminGetAuditLog)                   // Audit log
+				owner.GET("/contract-calls", h.AdminGetContractCalls)     // Queued owner calls
+				owner.POST("/contract-calls", h.AdminQueueContractCall)   // Queue an owner call
+				owner.GET("/cases", h.AdminGetCases)                      // Full case catalog
+				owner.POST("/cases", h.AdminCreateCase)                   // Create or schedule a case
+				owner.PUT("/cases/:slug", h.AdminUpdateCase)              // Update or reschedule a case
+				owner.POST("/cases/sync", h.AdminSyncCases)               // Mirror live cases on-chain
+				owner.GET("/cases/price-drift", h.AdminGetCasePriceDrift) // Last on-chain price check
+				owner.GET("/revenue", h.GetPlatformRevenue)               // Platform revenue report
+				owner.GET("/revenue/reconcile", h.ReconcileRevenue)       // Revenue vs contract balances
+				owner.GET("/jobs", h.AdminGetJobs)                        // Background jobs and their last run
+				owner.GET("/jobs/:name/runs", h.AdminGetJobRuns)          // Run history
+				owner.POST("/jobs/:name/run", h.AdminTriggerJob)          // Run a job now
+				owner.GET("/reconcile/last", h.AdminGetReconcileReport)   // Last ownership reconciliation
+			}
+		}
+
+		// Notification routes
+		notifications := api.Group("/notifications")
+		{
+			// Notifications are private and preferences hold emails and webhook
+			// URLs, so both need a signature
+			notifications.GET("", h.walletAuth(), h.GetNotifications)                                        // Caller's latest notifications
+			notifications.GET("/preferences", h.walletAuth(), h.GetNotificationPreferences)                  // Channels, muted kinds, quiet hours
+			notifications.PUT("/preferences", h.walletAuth(), h.banGuard(), h.UpdateNotificationPreferences) // Change preferences
+		}
+
+		// Realtime routes
+		// Server-Sent Events; wallet topics need a token from POST /realtime/token
+		rt := api.Group("/realtime")
+		{
+			rt.POST("/token", h.IssueRealtimeToken) // Token for the wallet's private topics
+			rt.GET("/stream", h.StreamEvents)       // Subscribe to topics
 		}
 
 		// User routes
 		users := api.Group("/users")
 		{
-			users.GET("/:address", h.GetUser)                // Get user info
-			users.GET("/:address/inventory", h.GetInventory) // Get user's NFTs
+			users.GET("/:address", h.GetUser)                                                           // Get user info
+			users.GET("/:address/inventory", h.rateLimitQuery("verify", "chain_reads"), h.GetInventory) // Get user's NFTs
 		}
 	}
 
-	// Serve OpenAPI/Swagger docs (optional)
 	router.GET("/", func(c *gin.Context) {
 		c.JSON(200, gin.H{
 			"name":    "Brainrot Tamagotchi API",
 			"version": "1.0.0",
-			"docs":    "/api/v1/health",
+			"docs":    "/docs",
 		})
 	})
 }
-
@@ -0,0 +1,221 @@
+package blockchain
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// caseOpeningABI is the subset of CaseOpening.sol the backend reads
+const caseOpeningABI = `[
+	{"type":"function","name":"caseConfigs","stateMutability":"view","inputs":[{"name":"","type":"uint8"}],"outputs":[{"name":"price","type":"uint256"},{"name":"active","type":"bool"}]},
+	{"type":"function","name":"buyAndOpenCase","stateMutability":"payable","inputs":[{"name":"caseType","type":"uint8"}],"outputs":[{"name":"","type":"uint256"}]},
+	{"type":"event","name":"CasePurchased","inputs":[{"name":"buyer","type":"address","indexed":true},{"name":"caseType","type":"uint8","indexed":false},{"name":"caseId","type":"uint256","indexed":false}]},
+	{"type":"event","name":"CaseOpened","inputs":[{"name":"opener","type":"address","indexed":true},{"name":"caseId","type":"uint256","indexed":false},{"name":"tokenId","type":"uint256","indexed":false},{"name":"rarity","type":"uint8","indexed":false},{"name":"memeType","type":"uint8","indexed":false}]}
+]`
+
+// CaseOpening.sol event names
+const (
+	EventCasePurchased = "CasePurchased"
+	EventCaseOpened    = "CaseOpened"
+)
+
+var parsedCaseOpeningABI = mustParseABI(caseOpeningABI)
+
+// CaseTypeIDs maps case type names to the CaseOpening.CaseType enum
+var CaseTypeIDs = map[string]uint8{
+	"bronze": 0,
+	"silver": 1,
+	"gold":   2,
+}
+
+// CaseConfig mirrors CaseOpening.CaseConfig
+type CaseConfig struct {
+	Price  *big.Int
+	Active bool
+}
+
+// CaseOpenedEvent is a decoded CaseOpening.sol CaseOpened log, with the case
+// type and price of the purchase that emitted it
+type CaseOpenedEvent struct {
+	Opener   string
+	TokenID  uint
+	Rarity   string
+	MemeType string
+	CaseType string
+	Price    *big.Int
+
+	TxHash      string
+	LogIndex    uint
+	BlockNumber uint64
+	BlockTime   time.Time
+}
+
+func (c *Client) caseContract() *bind.BoundContract {
+	return bind.NewBoundContract(c.CaseAddress, parsedCaseOpeningABI, c.Eth, c.Eth, c.Eth)
+}
+
+// GetCaseConfig reads the on-chain price and availability of a case type
+func (c *Client) GetCaseConfig(ctx context.Context, caseType string) (_ *CaseConfig, err error) {
+	ctx, span := startSpan(ctx, "GetCaseConfig", attribute.String("case_type", caseType))
+	defer func() { endSpan(span, err) }()
+
+	id, ok := CaseTypeIDs[caseType]
+	if !ok {
+		return nil, fmt.Errorf("unknown case type: %s", caseType)
+	}
+
+	var out []interface{}
+	err = c.caseContract().Call(&bind.CallOpts{Context: ctx}, &out, "caseConfigs", id)
+	if err != nil {
+		return nil, fmt.Errorf("caseConfigs call failed: %w", err)
+	}
+
+	return &CaseConfig{
+		Price:  out[0].(*big.Int),
+		Active: out[1].(bool),
+	}, nil
+}
+
+// FilterCaseOpenings decodes the CaseOpened events in a block range.
+//
+// CaseOpened carries neither the case type nor the price. The type comes from
+// the CasePurchased event of the same transaction when the contract emits
+// one, and from the buyAndOpenCase calldata otherwise. The price is the case
+// type's config as of the previous block, so the node must keep state that
+// far back.
+func (c *Client) FilterCaseOpenings(ctx context.Context, fromBlock, toBlock uint64) (_ []*CaseOpenedEvent, err error) {
+	ctx, span := startSpan(ctx, "FilterCaseOpenings",
+		attribute.Int64("from_block", int64(fromBlock)), attribute.Int64("to_block", int64(toBlock)))
+	defer func() { endSpan(span, err) }()
+
+	logs, err := c.Eth.FilterLogs(ctx, ethereum.FilterQuery{
+		FromBlock: new(big.Int).SetUint64(fromBlock),
+		ToBlock:   new(big.Int).SetUint64(toBlock),
+		Addresses: []common.Address{c.CaseAddress},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to filter case logs: %w", err)
+	}
+
+	purchased := make(map[common.Hash]string) // Case type by tx
+	for _, l := range logs {
+		if l.Removed || len(l.Topics) == 0 || l.Topics[0] != parsedCaseOpeningABI.Events[EventCasePurchased].ID {
+			continue
+		}
+		data, err := parsedCaseOpeningABI.Unpack(EventCasePurchased, l.Data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", EventCasePurchased, err)
+		}
+		if caseType, ok := caseTypeName(data[0].(uint8)); ok {
+			purchased[l.TxHash] = caseType
+		}
+	}
+
+	events := make([]*CaseOpenedEvent, 0, len(logs))
+	blockTimes := make(map[uint64]time.Time)
+	prices := make(map[string]*big.Int) // By case type and block
+	for _, l := range logs {
+		if l.Removed || len(l.Topics) < 2 || l.Topics[0] != parsedCaseOpeningABI.Events[EventCaseOpened].ID {
+			continue
+		}
+		data, err := parsedCaseOpeningABI.Unpack(EventCaseOpened, l.Data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", EventCaseOpened, err)
+		}
+		rarity, memeType := data[2].(uint8), data[3].(uint8)
+		if int(rarity) >= len(RarityNames) || int(memeType) >= len(MemeTypeNames) {
+			return nil, fmt.Errorf("%s in %s has unknown rarity %d or meme type %d", EventCaseOpened, l.TxHash.Hex(), rarity, memeType)
+		}
+
+		caseType, ok := purchased[l.TxHash]
+		if !ok {
+			if caseType, err = c.caseTypeFromTx(ctx, l.TxHash); err != nil {
+				return nil, err
+			}
+		}
+
+		priceKey := fmt.Sprintf("%s:%d", caseType, l.BlockNumber)
+		price, ok := prices[priceKey]
+		if !ok {
+			if price, err = c.casePriceBefore(ctx, caseType, l.BlockNumber); err != nil {
+				return nil, err
+			}
+			prices[priceKey] = price
+		}
+
+		blockTime, ok := blockTimes[l.BlockNumber]
+		if !ok {
+			header, err := c.Eth.HeaderByNumber(ctx, new(big.Int).SetUint64(l.BlockNumber))
+			if err != nil {
+				return nil, fmt.Errorf("failed to fetch block %d: %w", l.BlockNumber, err)
+			}
+			blockTime = time.Unix(int64(header.Time), 0)
+			blockTimes[l.BlockNumber] = blockTime
+		}
+
+		events = append(events, &CaseOpenedEvent{
+			Opener:      topicAddress(l.Topics[1]),
+			TokenID:     uint(data[1].(*big.Int).Uint64()),
+			Rarity:      RarityNames[rarity],
+			MemeType:    MemeTypeNames[memeType],
+			CaseType:    caseType,
+			Price:       price,
+			TxHash:      strings.ToLower(l.TxHash.Hex()),
+			LogIndex:    l.Index,
+			BlockNumber: l.BlockNumber,
+			BlockTime:   blockTime,
+		})
+	}
+	return events, nil
+}
+
+// caseTypeFromTx reads the case type from a buyAndOpenCase call
+func (c *Client) caseTypeFromTx(ctx context.Context, txHash common.Hash) (string, error) {
+	tx, _, err := c.Eth.TransactionByHash(ctx, txHash)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch transaction %s: %w", txHash.Hex(), err)
+	}
+
+	method := parsedCaseOpeningABI.Methods["buyAndOpenCase"]
+	if len(tx.Data()) < 4 || !bytes.Equal(tx.Data()[:4], method.ID) {
+		return "", fmt.Errorf("transaction %s opened a case without calling buyAndOpenCase", txHash.Hex())
+	}
+	args, err := method.Inputs.Unpack(tx.Data()[4:])
+	if err != nil {
+		return "", fmt.Errorf("failed to decode buyAndOpenCase in %s: %w", txHash.Hex(), err)
+	}
+	caseType, ok := caseTypeName(args[0].(uint8))
+	if !ok {
+		return "", fmt.Errorf("transaction %s opened unknown case type %d", txHash.Hex(), args[0])
+	}
+	return caseType, nil
+}
+
+// casePriceBefore reads a case type's price as of the block before blockNumber
+func (c *Client) casePriceBefore(ctx context.Context, caseType string, blockNumber uint64) (*big.Int, error) {
+	var out []interface{}
+	opts := &bind.CallOpts{Context: ctx, BlockNumber: new(big.Int).SetUint64(blockNumber - 1)}
+	if err := c.caseContract().Call(opts, &out, "caseConfigs", CaseTypeIDs[caseType]); err != nil {
+		return nil, fmt.Errorf("caseConfigs call at block %d failed: %w", blockNumber-1, err)
+	}
+	return out[0].(*big.Int), nil
+}
+
+// caseTypeName maps a CaseOpening.CaseType enum value to its name
+func caseTypeName(id uint8) (string, bool) {
+	for name, caseID := range CaseTypeIDs {
+		if caseID == id {
+			return name, true
+		}
+	}
+	return "", false
+}
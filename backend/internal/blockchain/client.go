@@ -1,19 +1,42 @@
 package blockchain
 
 import (
+	"brainrot-tamagotchi/internal/config"
+	"brainrot-tamagotchi/pkg/logging"
+	"brainrot-tamagotchi/pkg/tracing"
 	"context"
 	"crypto/ecdsa"
 	"fmt"
 	"math/big"
-	"os"
+	"net/http"
+	"sync"
+	"time"
 
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var logger = logging.For("blockchain")
+
+var tracer = otel.Tracer("brainrot-tamagotchi/internal/blockchain")
+
+// startSpan starts the span of a Client method that goes to the chain
+func startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer.Start(ctx, "blockchain."+name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
+
+// endSpan ends a span from startSpan; a pending transaction is not a failure
+func endSpan(span trace.Span, err error) {
+	tracing.End(span, err, ErrTxPending)
+}
+
 // Client represents a blockchain client
 type Client struct {
 	Eth        *ethclient.Client
@@ -25,20 +48,21 @@ type Client struct {
 	CaseAddress        common.Address
 	MarketplaceAddress common.Address
 	BurnAddress        common.Address
+
+	// txMu serializes sends so concurrent callers don't reuse a nonce
+	txMu sync.Mutex
 }
 
 // NewClient creates a new blockchain client
-func NewClient() (*Client, error) {
-	rpcURL := os.Getenv("BASE_RPC_URL")
-	if rpcURL == "" {
-		rpcURL = "https://sepolia.base.org" // Default to testnet
-	}
-
+func NewClient(cfg config.Chain) (*Client, error) {
 	// Connect to blockchain
-	client, err := ethclient.Dial(rpcURL)
+	// HTTP endpoints go through rpcTransport so RPC calls are measured
+	rpcClient, err := rpc.DialOptions(context.Background(), cfg.RPCURL,
+		rpc.WithHTTPClient(&http.Client{Transport: rpcTransport{next: http.DefaultTransport}}))
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to blockchain: %w", err)
 	}
+	client := ethclient.NewClient(rpcClient)
 
 	// Get chain ID
 	chainID, err := client.ChainID(context.Background())
@@ -48,19 +72,18 @@ func NewClient() (*Client, error) {
 
 	// Load private key (optional, for transactions)
 	var privateKey *ecdsa.PrivateKey
-	privateKeyHex := os.Getenv("PRIVATE_KEY")
-	if privateKeyHex != "" {
-		privateKey, err = crypto.HexToECDSA(privateKeyHex)
+	if cfg.PrivateKey != "" {
+		privateKey, err = crypto.HexToECDSA(cfg.PrivateKey)
 		if err != nil {
 			return nil, fmt.Errorf("failed to load private key: %w", err)
 		}
 	}
 
 	// Load contract addresses
-	nftAddr := common.HexToAddress(os.Getenv("CONTRACT_NFT_ADDRESS"))
-	caseAddr := common.HexToAddress(os.Getenv("CONTRACT_CASE_ADDRESS"))
-	marketAddr := common.HexToAddress(os.Getenv("CONTRACT_MARKETPLACE_ADDRESS"))
-	burnAddr := common.HexToAddress(os.Getenv("CONTRACT_BURN_ADDRESS"))
+	nftAddr := common.HexToAddress(cfg.NFTAddress)
+	caseAddr := common.HexToAddress(cfg.CaseAddress)
+	marketAddr := common.HexToAddress(cfg.MarketplaceAddress)
+	burnAddr := common.HexToAddress(cfg.BurnAddress)
 
 	return &Client{
 		Eth:                client,
@@ -102,8 +125,43 @@ func (c *Client) GetTransactor(ctx context.Context) (*bind.TransactOpts, error)
 	return auth, nil
 }
 
+// send signs and sends a contract call with the backend signer
+func (c *Client) send(ctx context.Context, contract *bind.BoundContract, method string, args ...interface{}) (_ *types.Transaction, err error) {
+	ctx, span := startSpan(ctx, "send", attribute.String("contract.method", method))
+	defer func() { endSpan(span, err) }()
+
+	c.txMu.Lock()
+	defer c.txMu.Unlock()
+
+	start := time.Now()
+	auth, err := c.GetTransactor(ctx)
+	if err != nil {
+		logger.WarnContext(ctx, "transaction not signed", "method", method, "error", err)
+		return nil, err
+	}
+	auth.Context = ctx
+
+	tx, err := contract.Transact(auth, method, args...)
+	if err != nil {
+		logger.WarnContext(ctx, "transaction failed", "method", method, "nonce", auth.Nonce.Uint64(), "error", err)
+		return nil, fmt.Errorf("%s failed: %w", method, err)
+	}
+	span.SetAttributes(attribute.String("tx", tx.Hash().Hex()), attribute.Int64("nonce", int64(tx.Nonce())))
+	logger.InfoContext(ctx, "transaction sent",
+		"method", method,
+		"tx", tx.Hash().Hex(),
+		"to", tx.To().Hex(),
+		"nonce", tx.Nonce(),
+		"elapsed_ms", time.Since(start).Milliseconds(),
+	)
+	return tx, nil
+}
+
 // WaitForTransaction waits for a transaction to be mined
-func (c *Client) WaitForTransaction(ctx context.Context, txHash common.Hash) error {
+func (c *Client) WaitForTransaction(ctx context.Context, txHash common.Hash) (err error) {
+	ctx, span := startSpan(ctx, "WaitForTransaction", attribute.String("tx", txHash.Hex()))
+	defer func() { endSpan(span, err) }()
+
 	receipt, err := bind.WaitMined(ctx, c.Eth, &types.Transaction{})
 	if err != nil {
 		return err
@@ -115,3 +173,11 @@ func (c *Client) WaitForTransaction(ctx context.Context, txHash common.Hash) err
 
 	return nil
 }
+
+// BalanceOf returns the current ETH balance of an address
+func (c *Client) BalanceOf(ctx context.Context, address common.Address) (_ *big.Int, err error) {
+	ctx, span := startSpan(ctx, "BalanceOf")
+	defer func() { endSpan(span, err) }()
+
+	return c.Eth.BalanceAt(ctx, address, nil)
+}
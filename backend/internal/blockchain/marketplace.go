@@ -0,0 +1,228 @@
+package blockchain
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// marketplaceABI is the subset of Marketplace.sol the backend consumes
+const marketplaceABI = `[
+	{"type":"event","name":"NFTListed","inputs":[{"name":"tokenId","type":"uint256","indexed":true},{"name":"seller","type":"address","indexed":true},{"name":"price","type":"uint256","indexed":false}]},
+	{"type":"event","name":"NFTSold","inputs":[{"name":"tokenId","type":"uint256","indexed":true},{"name":"seller","type":"address","indexed":true},{"name":"buyer","type":"address","indexed":true},{"name":"price","type":"uint256","indexed":false},{"name":"platformFee","type":"uint256","indexed":false}]},
+	{"type":"event","name":"ListingCancelled","inputs":[{"name":"tokenId","type":"uint256","indexed":true},{"name":"seller","type":"address","indexed":true}]},
+	{"type":"event","name":"PriceUpdated","inputs":[{"name":"tokenId","type":"uint256","indexed":true},{"name":"oldPrice","type":"uint256","indexed":false},{"name":"newPrice","type":"uint256","indexed":false}]},
+	{"type":"function","name":"emergencyCancelListing","stateMutability":"nonpayable","inputs":[{"name":"tokenId","type":"uint256"}],"outputs":[]}
+]`
+
+var parsedMarketplaceABI = mustParseABI(marketplaceABI)
+
+// Marketplace.sol event names
+const (
+	EventNFTListed        = "NFTListed"
+	EventNFTSold          = "NFTSold"
+	EventListingCancelled = "ListingCancelled"
+	EventPriceUpdated     = "PriceUpdated"
+)
+
+// ErrTxPending is returned when a transaction has not been mined yet
+var ErrTxPending = errors.New("transaction not mined yet")
+
+// ErrTxReverted is returned when a mined transaction failed
+var ErrTxReverted = errors.New("transaction reverted")
+
+// MarketplaceEvent is a decoded Marketplace.sol log
+type MarketplaceEvent struct {
+	Name        string
+	TokenID     uint
+	Seller      string
+	Buyer       string
+	Price       *big.Int // listing price, sale price or new price
+	OldPrice    *big.Int
+	PlatformFee *big.Int
+	// Set on ListingCancelled when the owner called emergencyCancelListing
+	Emergency bool
+
+	TxHash      string
+	LogIndex    uint
+	BlockNumber uint64
+	BlockTime   time.Time
+}
+
+// After reports whether the event comes later in the chain than a position
+func (e *MarketplaceEvent) After(blockNumber uint64, logIndex uint) bool {
+	if e.BlockNumber != blockNumber {
+		return e.BlockNumber > blockNumber
+	}
+	return e.LogIndex > logIndex
+}
+
+// BlockNumber returns the current chain head
+func (c *Client) BlockNumber(ctx context.Context) (_ uint64, err error) {
+	ctx, span := startSpan(ctx, "BlockNumber")
+	defer func() { endSpan(span, err) }()
+
+	return c.Eth.BlockNumber(ctx)
+}
+
+// Head returns the number and timestamp of the latest block
+func (c *Client) Head(ctx context.Context) (_ uint64, _ time.Time, err error) {
+	ctx, span := startSpan(ctx, "Head")
+	defer func() { endSpan(span, err) }()
+
+	header, err := c.Eth.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	return header.Number.Uint64(), time.Unix(int64(header.Time), 0), nil
+}
+
+// FilterMarketplaceEvents decodes all Marketplace.sol events in a block range
+func (c *Client) FilterMarketplaceEvents(ctx context.Context, fromBlock, toBlock uint64) (_ []*MarketplaceEvent, err error) {
+	ctx, span := startSpan(ctx, "FilterMarketplaceEvents",
+		attribute.Int64("from_block", int64(fromBlock)), attribute.Int64("to_block", int64(toBlock)))
+	defer func() { endSpan(span, err) }()
+
+	logs, err := c.Eth.FilterLogs(ctx, ethereum.FilterQuery{
+		FromBlock: new(big.Int).SetUint64(fromBlock),
+		ToBlock:   new(big.Int).SetUint64(toBlock),
+		Addresses: []common.Address{c.MarketplaceAddress},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to filter marketplace logs: %w", err)
+	}
+
+	return c.decodeMarketplaceLogs(ctx, logs)
+}
+
+// MarketplaceEventsFromTx decodes the Marketplace.sol events emitted by a
+// mined transaction. It returns ErrTxPending when the receipt is not available.
+func (c *Client) MarketplaceEventsFromTx(ctx context.Context, txHash string) (_ []*MarketplaceEvent, err error) {
+	ctx, span := startSpan(ctx, "MarketplaceEventsFromTx", attribute.String("tx", txHash))
+	defer func() { endSpan(span, err) }()
+
+	receipt, err := c.Eth.TransactionReceipt(ctx, common.HexToHash(txHash))
+	if errors.Is(err, ethereum.NotFound) {
+		return nil, ErrTxPending
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch receipt: %w", err)
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return nil, fmt.Errorf("%w: %s", ErrTxReverted, txHash)
+	}
+
+	logs := make([]types.Log, 0, len(receipt.Logs))
+	for _, l := range receipt.Logs {
+		if l.Address == c.MarketplaceAddress {
+			logs = append(logs, *l)
+		}
+	}
+
+	return c.decodeMarketplaceLogs(ctx, logs)
+}
+
+func (c *Client) decodeMarketplaceLogs(ctx context.Context, logs []types.Log) ([]*MarketplaceEvent, error) {
+	events := make([]*MarketplaceEvent, 0, len(logs))
+	blockTimes := make(map[uint64]time.Time)
+	for _, l := range logs {
+		if l.Removed {
+			continue
+		}
+
+		event, err := decodeMarketplaceLog(l)
+		if err != nil {
+			return nil, err
+		}
+		if event == nil {
+			continue
+		}
+
+		blockTime, ok := blockTimes[l.BlockNumber]
+		if !ok {
+			header, err := c.Eth.HeaderByNumber(ctx, new(big.Int).SetUint64(l.BlockNumber))
+			if err != nil {
+				return nil, fmt.Errorf("failed to fetch block %d: %w", l.BlockNumber, err)
+			}
+			blockTime = time.Unix(int64(header.Time), 0)
+			blockTimes[l.BlockNumber] = blockTime
+		}
+		event.BlockTime = blockTime
+
+		if event.Name == EventListingCancelled {
+			event.Emergency, err = c.isEmergencyCancel(ctx, l.TxHash)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+func decodeMarketplaceLog(l types.Log) (*MarketplaceEvent, error) {
+	if len(l.Topics) == 0 {
+		return nil, nil
+	}
+
+	abiEvent, err := parsedMarketplaceABI.EventByID(l.Topics[0])
+	if err != nil {
+		// Not an event we consume (e.g. OwnershipTransferred)
+		return nil, nil
+	}
+
+	event := &MarketplaceEvent{
+		Name:        abiEvent.Name,
+		TxHash:      strings.ToLower(l.TxHash.Hex()),
+		LogIndex:    l.Index,
+		BlockNumber: l.BlockNumber,
+	}
+
+	data := make(map[string]interface{})
+	if err := parsedMarketplaceABI.UnpackIntoMap(data, abiEvent.Name, l.Data); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", abiEvent.Name, err)
+	}
+
+	event.TokenID = uint(new(big.Int).SetBytes(l.Topics[1].Bytes()).Uint64())
+	switch abiEvent.Name {
+	case EventNFTListed:
+		event.Seller = topicAddress(l.Topics[2])
+		event.Price = data["price"].(*big.Int)
+	case EventNFTSold:
+		event.Seller = topicAddress(l.Topics[2])
+		event.Buyer = topicAddress(l.Topics[3])
+		event.Price = data["price"].(*big.Int)
+		event.PlatformFee = data["platformFee"].(*big.Int)
+	case EventListingCancelled:
+		event.Seller = topicAddress(l.Topics[2])
+	case EventPriceUpdated:
+		event.OldPrice = data["oldPrice"].(*big.Int)
+		event.Price = data["newPrice"].(*big.Int)
+	}
+
+	return event, nil
+}
+
+// isEmergencyCancel checks whether a transaction called emergencyCancelListing
+func (c *Client) isEmergencyCancel(ctx context.Context, txHash common.Hash) (bool, error) {
+	tx, _, err := c.Eth.TransactionByHash(ctx, txHash)
+	if err != nil {
+		return false, fmt.Errorf("failed to fetch transaction %s: %w", txHash.Hex(), err)
+	}
+	selector := parsedMarketplaceABI.Methods["emergencyCancelListing"].ID
+	return len(tx.Data()) >= 4 && bytes.Equal(tx.Data()[:4], selector), nil
+}
+
+func topicAddress(topic common.Hash) string {
+	return strings.ToLower(common.BytesToAddress(topic.Bytes()).Hex())
+}
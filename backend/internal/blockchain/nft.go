@@ -0,0 +1,120 @@
+package blockchain
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// brainrotNFTABI is the subset of BrainrotNFT.sol the backend reads
+const brainrotNFTABI = `[
+	{"type":"function","name":"ownerOf","stateMutability":"view","inputs":[{"name":"tokenId","type":"uint256"}],"outputs":[{"name":"","type":"address"}]},
+	{"type":"function","name":"tokensOfOwner","stateMutability":"view","inputs":[{"name":"owner","type":"address"}],"outputs":[{"name":"","type":"uint256[]"}]},
+	{"type":"function","name":"tokenMetadata","stateMutability":"view","inputs":[{"name":"","type":"uint256"}],"outputs":[{"name":"memeType","type":"uint8"},{"name":"rarity","type":"uint8"},{"name":"level","type":"uint8"},{"name":"mintedAt","type":"uint256"},{"name":"colorVariant","type":"uint8"}]}
+]`
+
+// RarityNames maps the BrainrotNFT.Rarity enum to the names stored in the DB
+var RarityNames = []string{"common", "rare", "epic", "legendary"}
+
+// MemeTypeNames maps the BrainrotNFT.MemeType enum to the names stored in the DB
+var MemeTypeNames = []string{"pepe", "doge", "gigachad", "wojak", "cheems", "drake", "vibing_cat", "pikachu"}
+
+// TokenMetadata mirrors BrainrotNFT.TokenMetadata with enums resolved to names
+type TokenMetadata struct {
+	MemeType     string
+	Rarity       string
+	Level        int
+	MintedAt     time.Time
+	ColorVariant int
+}
+
+var parsedNFTABI = mustParseABI(brainrotNFTABI)
+
+func mustParseABI(definition string) abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(definition))
+	if err != nil {
+		panic(fmt.Sprintf("invalid contract ABI: %v", err))
+	}
+	return parsed
+}
+
+func (c *Client) nftContract() *bind.BoundContract {
+	return bind.NewBoundContract(c.NFTAddress, parsedNFTABI, c.Eth, c.Eth, c.Eth)
+}
+
+// TokensOfOwner returns the token IDs an address owns according to BrainrotNFT
+func (c *Client) TokensOfOwner(ctx context.Context, owner string) (_ []uint, err error) {
+	ctx, span := startSpan(ctx, "TokensOfOwner", attribute.String("owner", owner))
+	defer func() { endSpan(span, err) }()
+
+	if !common.IsHexAddress(owner) {
+		return nil, fmt.Errorf("invalid address: %s", owner)
+	}
+
+	var out []interface{}
+	err = c.nftContract().Call(&bind.CallOpts{Context: ctx}, &out, "tokensOfOwner", common.HexToAddress(owner))
+	if err != nil {
+		return nil, fmt.Errorf("tokensOfOwner call failed: %w", err)
+	}
+
+	ids := *abi.ConvertType(out[0], new([]*big.Int)).(*[]*big.Int)
+	tokenIDs := make([]uint, len(ids))
+	for i, id := range ids {
+		tokenIDs[i] = uint(id.Uint64())
+	}
+	return tokenIDs, nil
+}
+
+// OwnerOf returns the lower-cased owner of a token according to BrainrotNFT
+func (c *Client) OwnerOf(ctx context.Context, tokenID uint) (_ string, err error) {
+	ctx, span := startSpan(ctx, "OwnerOf", attribute.Int64("token_id", int64(tokenID)))
+	defer func() { endSpan(span, err) }()
+
+	var out []interface{}
+	err = c.nftContract().Call(&bind.CallOpts{Context: ctx}, &out, "ownerOf", new(big.Int).SetUint64(uint64(tokenID)))
+	if err != nil {
+		return "", fmt.Errorf("ownerOf call failed: %w", err)
+	}
+
+	owner := *abi.ConvertType(out[0], new(common.Address)).(*common.Address)
+	return strings.ToLower(owner.Hex()), nil
+}
+
+// GetTokenMetadata reads the on-chain metadata of a token
+func (c *Client) GetTokenMetadata(ctx context.Context, tokenID uint) (_ *TokenMetadata, err error) {
+	ctx, span := startSpan(ctx, "GetTokenMetadata", attribute.Int64("token_id", int64(tokenID)))
+	defer func() { endSpan(span, err) }()
+
+	var out []interface{}
+	err = c.nftContract().Call(&bind.CallOpts{Context: ctx}, &out, "tokenMetadata", new(big.Int).SetUint64(uint64(tokenID)))
+	if err != nil {
+		return nil, fmt.Errorf("tokenMetadata call failed: %w", err)
+	}
+
+	memeType := out[0].(uint8)
+	rarity := out[1].(uint8)
+	if int(memeType) >= len(MemeTypeNames) || int(rarity) >= len(RarityNames) {
+		return nil, fmt.Errorf("unknown enum value for token %d", tokenID)
+	}
+
+	return &TokenMetadata{
+		MemeType:     MemeTypeNames[memeType],
+		Rarity:       RarityNames[rarity],
+		Level:        int(out[2].(uint8)),
+		MintedAt:     time.Unix(out[3].(*big.Int).Int64(), 0),
+		ColorVariant: int(out[4].(uint8)),
+	}, nil
+}
+
+// IsRevert reports whether an eth_call error came from the contract reverting,
+// as opposed to the RPC being unreachable
+func IsRevert(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "execution reverted")
+}
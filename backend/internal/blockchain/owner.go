@@ -0,0 +1,159 @@
+package blockchain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ownerCallABIs lists the owner-only functions the backend signer may call,
+// per contract
+var ownerCallABIs = map[string]abi.ABI{
+	"marketplace": mustParseABI(`[
+		{"type":"function","name":"setPlatformFee","stateMutability":"nonpayable","inputs":[{"name":"newFeeBps","type":"uint256"}],"outputs":[]},
+		{"type":"function","name":"withdrawFees","stateMutability":"nonpayable","inputs":[],"outputs":[]},
+		{"type":"function","name":"emergencyCancelListing","stateMutability":"nonpayable","inputs":[{"name":"tokenId","type":"uint256"}],"outputs":[]}
+	]`),
+	"cases": mustParseABI(`[
+		{"type":"function","name":"updateCasePrice","stateMutability":"nonpayable","inputs":[{"name":"caseType","type":"uint8"},{"name":"newPrice","type":"uint256"}],"outputs":[]},
+		{"type":"function","name":"toggleCaseActive","stateMutability":"nonpayable","inputs":[{"name":"caseType","type":"uint8"}],"outputs":[]},
+		{"type":"function","name":"withdraw","stateMutability":"nonpayable","inputs":[],"outputs":[]}
+	]`),
+	"burn": mustParseABI(`[
+		{"type":"function","name":"setUpgradeChance","stateMutability":"nonpayable","inputs":[{"name":"rarity","type":"uint8"},{"name":"chance","type":"uint8"}],"outputs":[]}
+	]`),
+	"nft": mustParseABI(`[
+		{"type":"function","name":"withdraw","stateMutability":"nonpayable","inputs":[],"outputs":[]}
+	]`),
+}
+
+// OwnerCallArgs validates an owner call and converts its string arguments to
+// ABI values. Enum arguments accept names, e.g. "gold" for caseType or
+// "epic" for rarity.
+func OwnerCallArgs(contract, method string, args []string) ([]interface{}, error) {
+	parsed, ok := ownerCallABIs[contract]
+	if !ok {
+		return nil, fmt.Errorf("unknown contract: %s", contract)
+	}
+	fn, ok := parsed.Methods[method]
+	if !ok {
+		return nil, fmt.Errorf("%s.%s is not an allowed owner call", contract, method)
+	}
+	if len(args) != len(fn.Inputs) {
+		return nil, fmt.Errorf("%s.%s takes %d arguments, got %d", contract, method, len(fn.Inputs), len(args))
+	}
+
+	values := make([]interface{}, len(args))
+	for i, input := range fn.Inputs {
+		value, err := convertOwnerArg(input, strings.TrimSpace(args[i]))
+		if err != nil {
+			return nil, fmt.Errorf("argument %s: %w", input.Name, err)
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+func convertOwnerArg(input abi.Argument, arg string) (interface{}, error) {
+	switch input.Type.T {
+	case abi.UintTy:
+		if input.Type.Size == 8 {
+			if id, ok := enumValue(input.Name, arg); ok {
+				return id, nil
+			}
+			v, err := strconv.ParseUint(arg, 10, 8)
+			if err != nil {
+				return nil, fmt.Errorf("invalid uint8 %q", arg)
+			}
+			return uint8(v), nil
+		}
+		v, ok := new(big.Int).SetString(arg, 10)
+		if !ok || v.Sign() < 0 {
+			return nil, fmt.Errorf("invalid uint256 %q", arg)
+		}
+		return v, nil
+	case abi.AddressTy:
+		if !common.IsHexAddress(arg) {
+			return nil, fmt.Errorf("invalid address %q", arg)
+		}
+		return common.HexToAddress(arg), nil
+	case abi.BoolTy:
+		return strconv.ParseBool(arg)
+	default:
+		return nil, fmt.Errorf("unsupported argument type %s", input.Type)
+	}
+}
+
+// enumValue resolves enum names for the uint8 arguments that are enums
+func enumValue(argName, arg string) (uint8, bool) {
+	switch argName {
+	case "caseType":
+		id, ok := CaseTypeIDs[strings.ToLower(arg)]
+		return id, ok
+	case "rarity":
+		for i, name := range RarityNames {
+			if strings.EqualFold(name, arg) {
+				return uint8(i), true
+			}
+		}
+	}
+	return 0, false
+}
+
+func (c *Client) ownerContractAddress(contract string) (common.Address, error) {
+	switch contract {
+	case "marketplace":
+		return c.MarketplaceAddress, nil
+	case "cases":
+		return c.CaseAddress, nil
+	case "burn":
+		return c.BurnAddress, nil
+	case "nft":
+		return c.NFTAddress, nil
+	}
+	return common.Address{}, fmt.Errorf("unknown contract: %s", contract)
+}
+
+// SendOwnerCall signs and sends an owner call without waiting for it to be
+// mined. args must come from OwnerCallArgs.
+func (c *Client) SendOwnerCall(ctx context.Context, contract, method string, args []interface{}) (_ common.Hash, err error) {
+	ctx, span := startSpan(ctx, "SendOwnerCall", attribute.String("contract", contract), attribute.String("contract.method", method))
+	defer func() { endSpan(span, err) }()
+
+	address, err := c.ownerContractAddress(contract)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	bound := bind.NewBoundContract(address, ownerCallABIs[contract], c.Eth, c.Eth, c.Eth)
+	tx, err := c.send(ctx, bound, method, args...)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return tx.Hash(), nil
+}
+
+// TxStatus reports whether a transaction was mined and, if so, whether it
+// succeeded. It returns ErrTxPending while the transaction is not mined.
+func (c *Client) TxStatus(ctx context.Context, txHash string) (_ bool, err error) {
+	ctx, span := startSpan(ctx, "TxStatus", attribute.String("tx", txHash))
+	defer func() { endSpan(span, err) }()
+
+	receipt, err := c.Eth.TransactionReceipt(ctx, common.HexToHash(txHash))
+	if errors.Is(err, ethereum.NotFound) {
+		return false, ErrTxPending
+	}
+	if err != nil {
+		return false, err
+	}
+	return receipt.Status == 1, nil
+}
@@ -0,0 +1,111 @@
+package blockchain
+
+import (
+	"brainrot-tamagotchi/internal/metrics"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// rpcTransport times JSON-RPC requests made over HTTP, counts failed ones and
+// gives each a span. The method comes from the request body; batches are
+// labelled "batch".
+type rpcTransport struct {
+	next http.RoundTripper
+}
+
+func (t rpcTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	method := "unknown"
+	if req.Body != nil && req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			raw, _ := io.ReadAll(body)
+			body.Close()
+			method = rpcMethod(raw)
+		}
+	}
+
+	ctx, span := tracer.Start(req.Context(), "rpc "+method, trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("rpc.system", "jsonrpc"), attribute.String("rpc.method", method)))
+	defer span.End()
+	req = req.WithContext(ctx)
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		metrics.RPCDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+		metrics.RPCErrors.WithLabelValues(method, "transport").Inc()
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	span.SetAttributes(attribute.Int("http.status_code", resp.StatusCode))
+	if resp.StatusCode >= 300 {
+		metrics.RPCDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+		metrics.RPCErrors.WithLabelValues(method, "http").Inc()
+		span.SetStatus(codes.Error, resp.Status)
+		return resp, nil
+	}
+
+	// Read the body here so the error check and the latency cover the whole
+	// response, then hand the buffered copy to the caller
+	raw, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	metrics.RPCDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+	if err != nil {
+		metrics.RPCErrors.WithLabelValues(method, "transport").Inc()
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	if rpcFailed(raw) {
+		metrics.RPCErrors.WithLabelValues(method, "rpc").Inc()
+		span.SetStatus(codes.Error, "rpc error")
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(raw))
+	return resp, nil
+}
+
+// rpcMethod returns the method of a JSON-RPC request body
+func rpcMethod(body []byte) string {
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '[' {
+		return "batch"
+	}
+	var msg struct {
+		Method string `json:"method"`
+	}
+	if json.Unmarshal(body, &msg) != nil || msg.Method == "" {
+		return "unknown"
+	}
+	return msg.Method
+}
+
+// rpcFailed reports whether a JSON-RPC response, or any response in a batch,
+// carries an error
+func rpcFailed(body []byte) bool {
+	type response struct {
+		Error json.RawMessage `json:"error"`
+	}
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '[' {
+		var batch []response
+		if json.Unmarshal(body, &batch) != nil {
+			return true
+		}
+		for _, r := range batch {
+			if len(r.Error) > 0 && string(r.Error) != "null" {
+				return true
+			}
+		}
+		return false
+	}
+	var r response
+	if json.Unmarshal(body, &r) != nil {
+		return true
+	}
+	return len(r.Error) > 0 && string(r.Error) != "null"
+}
@@ -0,0 +1,65 @@
+package blockchain
+
+import (
+	"brainrot-tamagotchi/internal/metrics"
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestRPCTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var buf bytes.Buffer
+		_, _ = buf.ReadFrom(r.Body)
+		if strings.Contains(buf.String(), "eth_call") {
+			w.Write([]byte(`{"jsonrpc":"2.0","id":1,"error":{"code":3,"message":"execution reverted"}}`))
+			return
+		}
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"0x10"}`))
+	}))
+	defer srv.Close()
+
+	client, err := rpc.DialOptions(context.Background(), srv.URL,
+		rpc.WithHTTPClient(&http.Client{Transport: rpcTransport{next: http.DefaultTransport}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	var head string
+	if err := client.Call(&head, "eth_blockNumber"); err != nil || head != "0x10" {
+		t.Fatalf("eth_blockNumber = %q, %v; the transport must pass the response through", head, err)
+	}
+	if err := client.Call(&head, "eth_call"); err == nil {
+		t.Fatal("eth_call succeeded; want the RPC error")
+	}
+
+	if n := testutil.ToFloat64(metrics.RPCErrors.WithLabelValues("eth_blockNumber", "rpc")); n != 0 {
+		t.Errorf("eth_blockNumber errors = %v, want 0", n)
+	}
+	if n := testutil.ToFloat64(metrics.RPCErrors.WithLabelValues("eth_call", "rpc")); n != 1 {
+		t.Errorf("eth_call rpc errors = %v, want 1", n)
+	}
+	if n := testutil.CollectAndCount(metrics.RPCDuration); n != 2 {
+		t.Errorf("got %d latency series, want eth_blockNumber and eth_call", n)
+	}
+}
+
+func TestRPCMethod(t *testing.T) {
+	for body, want := range map[string]string{
+		`{"jsonrpc":"2.0","id":1,"method":"eth_getLogs","params":[]}`: "eth_getLogs",
+		` [{"method":"eth_chainId"},{"method":"eth_blockNumber"}]`:    "batch",
+		`not json`: "unknown",
+	} {
+		if got := rpcMethod([]byte(body)); got != want {
+			t.Errorf("rpcMethod(%s) = %q, want %q", body, got, want)
+		}
+	}
+}
@@ -0,0 +1,324 @@
+// Package config loads the backend's settings into one typed struct.
+//
+// Every setting has a default, and each source overrides the one before it:
+// defaults, then the optional YAML file (-config or CONFIG_FILE), then
+// environment variables, then command line flags. A field's struct tags name
+// it in each source:
+//
+//	yaml:"port"      key in the YAML file; flags use the dotted path, -server.port
+//	env:"PORT"       environment variable
+//	default:"8080"   value when no source sets it
+//	secret:"true"    masked by Redacted; secret:"url" masks only the password
+//
+// Lists are comma separated in env and flags ("a,b"), maps are comma
+// separated key=value pairs ("bronze=0.0005,gold=0.01").
+package config
+
+import (
+	"brainrot-tamagotchi/pkg/logging"
+	"brainrot-tamagotchi/pkg/money"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/go-redis/redis/v8"
+)
+
+// Config is every setting the backend reads
+type Config struct {
+	Server    Server    `yaml:"server"`
+	Log       Log       `yaml:"log"`
+	Tracing   Tracing   `yaml:"tracing"`
+	Health    Health    `yaml:"health"`
+	Database  Database  `yaml:"database"`
+	Redis     Redis     `yaml:"redis"`
+	Chain     Chain     `yaml:"chain"`
+	PriceFeed PriceFeed `yaml:"price_feed"`
+	Notify    Notify    `yaml:"notify"`
+	Realtime  Realtime  `yaml:"realtime"`
+	RateLimit RateLimit `yaml:"rate_limit"`
+	Admin     Admin     `yaml:"admin"`
+	Jobs      Jobs      `yaml:"jobs"`
+	Game      Game      `yaml:"game"`
+}
+
+// Server configures the HTTP listener
+type Server struct {
+	Port        string   `yaml:"port" env:"PORT" default:"8080"`
+	CORSOrigins []string `yaml:"cors_origins" env:"CORS_ORIGINS" default:"http://localhost:3000,https://brainrot-tamagotchi.vercel.app"`
+}
+
+// Log configures structured logging. Levels sets the level of single
+// components, such as "gorm=debug,scheduler=warn".
+type Log struct {
+	Level  string            `yaml:"level" env:"LOG_LEVEL" default:"info"`
+	Format string            `yaml:"format" env:"LOG_FORMAT" default:"json"`
+	Levels map[string]string `yaml:"levels" env:"LOG_LEVELS"`
+}
+
+// Tracing configures OpenTelemetry tracing. Exporter is none, stdout (for
+// local development) or otlp, which sends OTLP over HTTP to Endpoint.
+type Tracing struct {
+	Exporter    string  `yaml:"exporter" env:"TRACING_EXPORTER" default:"none"`
+	Endpoint    string  `yaml:"endpoint" env:"TRACING_ENDPOINT" default:"localhost:4318"`
+	Insecure    bool    `yaml:"insecure" env:"TRACING_INSECURE"`
+	SampleRatio float64 `yaml:"sample_ratio" env:"TRACING_SAMPLE_RATIO" default:"1"`
+	ServiceName string  `yaml:"service_name" env:"TRACING_SERVICE_NAME" default:"brainrot-backend"`
+}
+
+// Health configures the /readyz checks. Each check gets CheckTimeout; the
+// others are the limits past which a check fails.
+type Health struct {
+	CheckTimeout  time.Duration `yaml:"check_timeout" env:"HEALTH_CHECK_TIMEOUT" default:"2s"`
+	MaxHeadAge    time.Duration `yaml:"max_head_age" env:"HEALTH_MAX_HEAD_AGE" default:"1m"`
+	MaxIndexerLag uint64        `yaml:"max_indexer_lag" env:"HEALTH_MAX_INDEXER_LAG" default:"100"`
+	MaxDecayAge   time.Duration `yaml:"max_decay_age" env:"HEALTH_MAX_DECAY_AGE" default:"3h"`
+}
+
+// Database configures Postgres. URL wins over the individual fields.
+// Queries slower than SlowQuery are logged as warnings.
+type Database struct {
+	URL      string `yaml:"url" env:"DATABASE_URL" secret:"url"`
+	Host     string `yaml:"host" env:"DB_HOST" default:"localhost"`
+	Port     string `yaml:"port" env:"DB_PORT" default:"5432"`
+	User     string `yaml:"user" env:"DB_USER" default:"postgres"`
+	Password string `yaml:"password" env:"DB_PASSWORD" secret:"true"`
+	Name     string `yaml:"name" env:"DB_NAME" default:"brainrot"`
+	SSLMode  string `yaml:"sslmode" env:"DB_SSLMODE" default:"disable"`
+
+	SlowQuery time.Duration `yaml:"slow_query" env:"DB_SLOW_QUERY" default:"200ms"`
+}
+
+// DSN returns the connection string
+func (d Database) DSN() string {
+	if d.URL != "" {
+		return d.URL
+	}
+	return fmt.Sprintf(
+		"host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
+		d.Host, d.Port, d.User, d.Password, d.Name, d.SSLMode,
+	)
+}
+
+// Redis configures the cache, pub/sub and rate limit store
+type Redis struct {
+	URL string `yaml:"url" env:"REDIS_URL" default:"redis://localhost:6379/0" secret:"url"`
+}
+
+// Chain configures the Base RPC connection and contracts. PrivateKey is
+// only needed for the jobs that send transactions.
+type Chain struct {
+	Enabled            bool   `yaml:"enabled" env:"CHAIN_ENABLED" default:"true"`
+	RPCURL             string `yaml:"rpc_url" env:"BASE_RPC_URL" default:"https://sepolia.base.org" secret:"url"`
+	PrivateKey         string `yaml:"private_key" env:"PRIVATE_KEY" secret:"true"`
+	NFTAddress         string `yaml:"nft_address" env:"CONTRACT_NFT_ADDRESS"`
+	CaseAddress        string `yaml:"case_address" env:"CONTRACT_CASE_ADDRESS"`
+	MarketplaceAddress string `yaml:"marketplace_address" env:"CONTRACT_MARKETPLACE_ADDRESS"`
+	BurnAddress        string `yaml:"burn_address" env:"CONTRACT_BURN_ADDRESS"`
+}
+
+// PriceFeed configures the ETH/USD source
+type PriceFeed struct {
+	Kind         string        `yaml:"kind" env:"PRICE_FEED" default:"file"` // "file" or "http"
+	File         string        `yaml:"file" env:"PRICE_FEED_FILE" default:"eth_usd.json"`
+	URL          string        `yaml:"url" env:"PRICE_FEED_URL"`
+	TTL          time.Duration `yaml:"ttl" env:"PRICE_FEED_TTL" default:"1m"`
+	MaxStaleness time.Duration `yaml:"max_staleness" env:"PRICE_FEED_MAX_STALENESS" default:"15m"`
+}
+
+// Notify configures the notification channels. A channel without its
+// settings only logs; Local forces that for every channel.
+type Notify struct {
+	Local           bool   `yaml:"local" env:"NOTIFY_LOCAL"`
+	WebhookSecret   string `yaml:"webhook_secret" env:"NOTIFY_WEBHOOK_SECRET" secret:"true"`
+	SMTPHost        string `yaml:"smtp_host" env:"SMTP_HOST"`
+	SMTPPort        string `yaml:"smtp_port" env:"SMTP_PORT" default:"587"`
+	SMTPUser        string `yaml:"smtp_user" env:"SMTP_USER"`
+	SMTPPassword    string `yaml:"smtp_password" env:"SMTP_PASSWORD" secret:"true"`
+	SMTPFrom        string `yaml:"smtp_from" env:"SMTP_FROM"`
+	TelegramToken   string `yaml:"telegram_bot_token" env:"TELEGRAM_BOT_TOKEN" secret:"true"`
+	VAPIDPublicKey  string `yaml:"vapid_public_key" env:"VAPID_PUBLIC_KEY"`
+	VAPIDPrivateKey string `yaml:"vapid_private_key" env:"VAPID_PRIVATE_KEY" secret:"true"`
+	VAPIDSubject    string `yaml:"vapid_subject" env:"VAPID_SUBJECT"`
+}
+
+// Realtime configures the SSE subscription tokens. Without a secret each
+// replica signs with its own random one.
+type Realtime struct {
+	TokenSecret string        `yaml:"token_secret" env:"REALTIME_TOKEN_SECRET" secret:"true"`
+	TokenTTL    time.Duration `yaml:"token_ttl" env:"REALTIME_TOKEN_TTL" default:"1h"`
+}
+
+// RateLimit overrides the default policies (see ratelimit.ParsePolicies)
+// and lists callers exempt from them
+type RateLimit struct {
+	Policies   string   `yaml:"policies" env:"RATE_LIMITS"`
+	BypassIPs  []string `yaml:"bypass_ips" env:"RATE_LIMIT_BYPASS_IPS"`
+	BypassKeys []string `yaml:"bypass_keys" env:"RATE_LIMIT_BYPASS_KEYS" secret:"true"`
+}
+
+// Admin lists the wallets granted the admin role on boot
+type Admin struct {
+	Wallets []string `yaml:"wallets" env:"ADMIN_WALLETS"`
+}
+
+// Jobs tunes the background jobs
+type Jobs struct {
+	MarketplaceStartBlock    uint64        `yaml:"marketplace_start_block" env:"MARKETPLACE_START_BLOCK"`
+	ListingSyncConfirmations uint64        `yaml:"listing_sync_confirmations" env:"LISTING_SYNC_CONFIRMATIONS" default:"2"`
+	CaseStartBlock           uint64        `yaml:"case_start_block" env:"CASE_START_BLOCK"`
+	CaseSyncConfirmations    uint64        `yaml:"case_sync_confirmations" env:"CASE_SYNC_CONFIRMATIONS" default:"2"`
+	ReconcileInterval        time.Duration `yaml:"reconcile_interval" env:"RECONCILE_INTERVAL" default:"6h"`
+	ReconcileChunkSize       int           `yaml:"reconcile_chunk_size" env:"RECONCILE_CHUNK_SIZE" default:"200"`
+	ReconcileAutoCorrect     bool          `yaml:"reconcile_auto_correct" env:"RECONCILE_AUTO_CORRECT"`
+	CasePriceDriftThreshold  float64       `yaml:"case_price_drift_threshold" env:"CASE_PRICE_DRIFT_THRESHOLD"`
+}
+
+// Game holds the tunable game rules. Stats run from 0 to 100.
+type Game struct {
+	HungerDecay       int           `yaml:"hunger_decay" env:"GAME_HUNGER_DECAY" default:"25"`
+	HungerDecayPeriod time.Duration `yaml:"hunger_decay_period" env:"GAME_HUNGER_DECAY_PERIOD" default:"6h"`
+	MoodDecay         int           `yaml:"mood_decay" env:"GAME_MOOD_DECAY" default:"20"`
+	MoodDecayPeriod   time.Duration `yaml:"mood_decay_period" env:"GAME_MOOD_DECAY_PERIOD" default:"12h"`
+	EnergyRegen       int           `yaml:"energy_regen" env:"GAME_ENERGY_REGEN" default:"10"` // Per hour
+	FeedHunger        int           `yaml:"feed_hunger" env:"GAME_FEED_HUNGER" default:"50"`
+	PlayMood          int           `yaml:"play_mood" env:"GAME_PLAY_MOOD" default:"30"`
+	PlayEnergyCost    int           `yaml:"play_energy_cost" env:"GAME_PLAY_ENERGY_COST" default:"10"`
+	RestoreStats      int           `yaml:"restore_stats" env:"GAME_RESTORE_STATS" default:"50"`
+	FreeFeedCooldown  time.Duration `yaml:"free_feed_cooldown" env:"GAME_FREE_FEED_COOLDOWN" default:"24h"`
+	// Prices in ETH the default cases are seeded with. Existing cases are
+	// repriced through the admin catalog instead.
+	CasePrices map[string]string `yaml:"case_prices" env:"GAME_CASE_PRICES" default:"bronze=0.0005,silver=0.002,gold=0.01"`
+}
+
+// CasePricesWei parses CasePrices
+func (g Game) CasePricesWei() (map[string]money.Wei, error) {
+	prices := make(map[string]money.Wei, len(g.CasePrices))
+	for slug, ether := range g.CasePrices {
+		price, err := money.ParseEther(ether)
+		if err != nil {
+			return nil, fmt.Errorf("case price %s: %w", slug, err)
+		}
+		prices[slug] = price
+	}
+	return prices, nil
+}
+
+// Validate reports every invalid setting at once
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.Server.Port != "", "server.port is required")
+	for _, origin := range c.Server.CORSOrigins {
+		u, err := url.Parse(origin)
+		check(err == nil && u.Scheme != "" && u.Host != "", "server.cors_origins: %q is not an origin", origin)
+	}
+
+	if _, err := logging.ParseLevel(c.Log.Level); err != nil {
+		errs = append(errs, fmt.Errorf("log.level: %w", err))
+	}
+	check(c.Log.Format == "json" || c.Log.Format == "text", "log.format must be json or text, not %q", c.Log.Format)
+	for _, name := range sortedKeys(c.Log.Levels) {
+		if _, err := logging.ParseLevel(c.Log.Levels[name]); err != nil {
+			errs = append(errs, fmt.Errorf("log.levels: %s: %w", name, err))
+		}
+	}
+	switch c.Tracing.Exporter {
+	case "none", "stdout":
+	case "otlp":
+		check(c.Tracing.Endpoint != "", "tracing.endpoint is required for the otlp exporter")
+	default:
+		check(false, "tracing.exporter must be none, stdout or otlp, not %q", c.Tracing.Exporter)
+	}
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "tracing.sample_ratio must be between 0 and 1")
+	check(c.Tracing.ServiceName != "", "tracing.service_name is required")
+	check(c.Health.CheckTimeout > 0, "health.check_timeout must be positive")
+	check(c.Health.MaxHeadAge > 0, "health.max_head_age must be positive")
+	check(c.Health.MaxDecayAge > 0, "health.max_decay_age must be positive")
+	check(c.Database.SlowQuery > 0, "database.slow_query must be positive")
+
+	if _, err := redis.ParseURL(c.Redis.URL); err != nil {
+		errs = append(errs, fmt.Errorf("redis.url: %w", err))
+	}
+
+	if c.Chain.Enabled {
+		for _, a := range []struct{ name, address string }{
+			{"chain.nft_address", c.Chain.NFTAddress},
+			{"chain.case_address", c.Chain.CaseAddress},
+			{"chain.marketplace_address", c.Chain.MarketplaceAddress},
+			{"chain.burn_address", c.Chain.BurnAddress},
+		} {
+			check(common.IsHexAddress(a.address) && common.HexToAddress(a.address) != (common.Address{}),
+				"%s must be a non-zero address when the chain is enabled", a.name)
+		}
+		if c.Chain.PrivateKey != "" {
+			_, err := crypto.HexToECDSA(c.Chain.PrivateKey)
+			check(err == nil, "chain.private_key is not a hex private key")
+		}
+	}
+
+	switch c.PriceFeed.Kind {
+	case "file":
+	case "http":
+		check(c.PriceFeed.URL != "", "price_feed.url is required for the http price feed")
+	default:
+		check(false, "price_feed.kind must be file or http, not %q", c.PriceFeed.Kind)
+	}
+	check(c.PriceFeed.TTL > 0, "price_feed.ttl must be positive")
+	check(c.PriceFeed.MaxStaleness >= c.PriceFeed.TTL, "price_feed.max_staleness must be at least price_feed.ttl")
+
+	for _, wallet := range c.Admin.Wallets {
+		check(common.IsHexAddress(wallet), "admin.wallets: %q is not an address", wallet)
+	}
+
+	check(c.Realtime.TokenTTL > 0, "realtime.token_ttl must be positive")
+	check(c.Jobs.ReconcileInterval > 0, "jobs.reconcile_interval must be positive")
+	check(c.Jobs.ReconcileChunkSize > 0, "jobs.reconcile_chunk_size must be positive")
+	check(c.Jobs.CasePriceDriftThreshold >= 0, "jobs.case_price_drift_threshold must not be negative")
+
+	g := c.Game
+	for _, stat := range []struct {
+		name  string
+		value int
+	}{
+		{"game.hunger_decay", g.HungerDecay},
+		{"game.mood_decay", g.MoodDecay},
+		{"game.energy_regen", g.EnergyRegen},
+		{"game.feed_hunger", g.FeedHunger},
+		{"game.play_mood", g.PlayMood},
+		{"game.play_energy_cost", g.PlayEnergyCost},
+	} {
+		check(stat.value >= 0 && stat.value <= 100, "%s must be between 0 and 100", stat.name)
+	}
+	check(g.RestoreStats > 0 && g.RestoreStats <= 100, "game.restore_stats must be between 1 and 100")
+	check(g.HungerDecayPeriod > 0, "game.hunger_decay_period must be positive")
+	check(g.MoodDecayPeriod > 0, "game.mood_decay_period must be positive")
+	check(g.FreeFeedCooldown >= 0, "game.free_feed_cooldown must not be negative")
+	if prices, err := g.CasePricesWei(); err != nil {
+		errs = append(errs, fmt.Errorf("game.case_prices: %w", err))
+	} else {
+		for _, slug := range sortedKeys(prices) {
+			check(prices[slug].Sign() > 0, "game.case_prices: %s must be positive", slug)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,149 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// validChain enables the chain with every contract address set
+func validChain(cfg *Config) {
+	cfg.Chain.Enabled = true
+	cfg.Chain.NFTAddress = "0x0000000000000000000000000000000000000001"
+	cfg.Chain.CaseAddress = "0x0000000000000000000000000000000000000002"
+	cfg.Chain.MarketplaceAddress = "0x0000000000000000000000000000000000000003"
+	cfg.Chain.BurnAddress = "0x0000000000000000000000000000000000000004"
+}
+
+func TestDefaultsAreValid(t *testing.T) {
+	cfg := Defaults()
+	validChain(cfg)
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("defaults do not validate: %v", err)
+	}
+	if cfg.Server.Port != "8080" || cfg.Game.HungerDecayPeriod != 6*time.Hour || cfg.Game.CasePrices["gold"] != "0.01" {
+		t.Fatalf("unexpected defaults: %+v", cfg)
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	yaml := `
+server:
+  port: "9000"
+game:
+  feed_hunger: 40
+  mood_decay_period: 6h
+  case_prices:
+    gold: "0.02"
+`
+	if err := os.WriteFile(file, []byte(yaml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CONFIG_FILE", file)
+	t.Setenv("GAME_FEED_HUNGER", "45")
+	t.Setenv("GAME_CASE_PRICES", "silver=0.003")
+	t.Setenv("ADMIN_WALLETS", " 0xa , ,0xb")
+
+	cfg, err := Load([]string{"-game.feed_hunger", "55"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.Server.Port != "9000" {
+		t.Errorf("port = %q, want the file's 9000", cfg.Server.Port)
+	}
+	if cfg.Game.MoodDecayPeriod != 6*time.Hour {
+		t.Errorf("mood decay period = %s, want the file's 6h", cfg.Game.MoodDecayPeriod)
+	}
+	if cfg.Game.FeedHunger != 55 {
+		t.Errorf("feed hunger = %d, want the flag's 55", cfg.Game.FeedHunger)
+	}
+	if cfg.Game.HungerDecay != 25 {
+		t.Errorf("hunger decay = %d, want the default 25", cfg.Game.HungerDecay)
+	}
+	// Each source merges into the map instead of replacing it
+	prices := cfg.Game.CasePrices
+	if prices["bronze"] != "0.0005" || prices["silver"] != "0.003" || prices["gold"] != "0.02" {
+		t.Errorf("case prices = %v", prices)
+	}
+	if strings.Join(cfg.Admin.Wallets, "|") != "0xa|0xb" {
+		t.Errorf("admin wallets = %q", cfg.Admin.Wallets)
+	}
+}
+
+func TestLoadRejects(t *testing.T) {
+	if _, err := Load([]string{"-game.feed_hunger", "lots"}); err == nil {
+		t.Error("accepted a non-numeric flag")
+	}
+
+	t.Setenv("GAME_MOOD_DECAY_PERIOD", "soon")
+	if _, err := Load(nil); err == nil {
+		t.Error("accepted a bad duration from the environment")
+	}
+	t.Setenv("GAME_MOOD_DECAY_PERIOD", "")
+
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(file, []byte("server:\n  prot: \"1\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load([]string{"-config", file}); err == nil {
+		t.Error("accepted a misspelled YAML key")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	cases := map[string]struct {
+		change func(*Config)
+		want   string
+	}{
+		"zero contract": {func(c *Config) { c.Chain.BurnAddress = "0x0000000000000000000000000000000000000000" }, "chain.burn_address"},
+		"bad key":       {func(c *Config) { c.Chain.PrivateKey = "nope" }, "chain.private_key"},
+		"http feed":     {func(c *Config) { c.PriceFeed.Kind = "http" }, "price_feed.url"},
+		"stat range":    {func(c *Config) { c.Game.PlayMood = 101 }, "game.play_mood"},
+		"decay period":  {func(c *Config) { c.Game.HungerDecayPeriod = 0 }, "game.hunger_decay_period"},
+		"case price":    {func(c *Config) { c.Game.CasePrices["gold"] = "free" }, "game.case_prices"},
+		"admin wallet":  {func(c *Config) { c.Admin.Wallets = []string{"bob"} }, "admin.wallets"},
+		"sample ratio":  {func(c *Config) { c.Tracing.SampleRatio = 1.5 }, "tracing.sample_ratio"},
+	}
+	for name, tc := range cases {
+		cfg := Defaults()
+		validChain(cfg)
+		tc.change(cfg)
+		err := cfg.Validate()
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: error = %v, want one about %s", name, err, tc.want)
+		}
+	}
+
+	// Contract addresses only matter with the chain on
+	cfg := Defaults()
+	cfg.Chain.Enabled = false
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("disabled chain without addresses: %v", err)
+	}
+}
+
+func TestRedacted(t *testing.T) {
+	cfg := Defaults()
+	cfg.Database.URL = "postgres://app:hunter2@db:5432/brainrot"
+	cfg.Database.Password = "hunter2"
+	cfg.Chain.PrivateKey = "deadbeef"
+	cfg.RateLimit.BypassKeys = []string{"internal-key"}
+
+	out := cfg.String()
+	for _, secret := range []string{"hunter2", "deadbeef", "internal-key"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("printed config leaks %q:\n%s", secret, out)
+		}
+	}
+	if !strings.Contains(out, "postgres://app:xxxxx@db:5432/brainrot") {
+		t.Errorf("database URL lost more than its password:\n%s", out)
+	}
+	// The original is untouched
+	if cfg.Chain.PrivateKey != "deadbeef" || cfg.RateLimit.BypassKeys[0] != "internal-key" {
+		t.Fatal("Redacted changed the config it copied")
+	}
+}
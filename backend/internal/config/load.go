@@ -0,0 +1,255 @@
+package config
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// field is one leaf setting of Config
+type field struct {
+	key    string // Dotted YAML path, also the flag name
+	env    string
+	def    string
+	secret string
+	value  reflect.Value
+}
+
+// Load reads the config from its defaults, the YAML file, the environment
+// and args, each overriding the last. args are flags such as -server.port;
+// -config names the YAML file. Load does not validate.
+func Load(args []string) (*Config, error) {
+	cfg := &Config{}
+	fields := fieldsOf(reflect.ValueOf(cfg).Elem(), "")
+
+	flags := flag.NewFlagSet("brainrot", flag.ContinueOnError)
+	configFile := flags.String("config", os.Getenv("CONFIG_FILE"), "YAML config file (env CONFIG_FILE)")
+	flagValues := make(map[string]string)
+	for _, f := range fields {
+		f := f
+		usage := "env " + f.env
+		if f.def != "" {
+			usage += ", default " + f.def
+		}
+		flags.Func(f.key, usage, func(s string) error {
+			// Parse into a scratch value so bad flags fail here
+			if err := setValue(reflect.New(f.value.Type()).Elem(), s); err != nil {
+				return err
+			}
+			flagValues[f.key] = s
+			return nil
+		})
+	}
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+	if flags.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected argument %q", flags.Arg(0))
+	}
+
+	applyDefaults(fields)
+
+	if *configFile != "" {
+		data, err := os.ReadFile(*configFile)
+		if err != nil {
+			return nil, err
+		}
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(cfg); err != nil {
+			return nil, fmt.Errorf("%s: %w", *configFile, err)
+		}
+	}
+
+	// An empty variable counts as unset, as it always has
+	for _, f := range fields {
+		if v := os.Getenv(f.env); v != "" {
+			if err := setValue(f.value, v); err != nil {
+				return nil, fmt.Errorf("%s: %w", f.env, err)
+			}
+		}
+	}
+
+	for _, f := range fields {
+		if v, ok := flagValues[f.key]; ok {
+			if err := setValue(f.value, v); err != nil {
+				return nil, fmt.Errorf("-%s: %w", f.key, err)
+			}
+		}
+	}
+
+	return cfg, nil
+}
+
+// Defaults returns the config with nothing but its defaults
+func Defaults() *Config {
+	cfg := &Config{}
+	applyDefaults(fieldsOf(reflect.ValueOf(cfg).Elem(), ""))
+	return cfg
+}
+
+// applyDefaults sets each field to its default tag. A default that does not
+// parse is a bug, so it panics.
+func applyDefaults(fields []field) {
+	for _, f := range fields {
+		if f.def == "" {
+			continue
+		}
+		if err := setValue(f.value, f.def); err != nil {
+			panic(fmt.Sprintf("config: default for %s: %v", f.key, err))
+		}
+	}
+}
+
+// fieldsOf lists the leaf settings under v, a struct
+func fieldsOf(v reflect.Value, prefix string) []field {
+	var fields []field
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		key := sf.Tag.Get("yaml")
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		if sf.Type.Kind() == reflect.Struct {
+			fields = append(fields, fieldsOf(v.Field(i), key)...)
+			continue
+		}
+		fields = append(fields, field{
+			key:    key,
+			env:    sf.Tag.Get("env"),
+			def:    sf.Tag.Get("default"),
+			secret: sf.Tag.Get("secret"),
+			value:  v.Field(i),
+		})
+	}
+	return fields
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// setValue parses s into v. Maps are merged into rather than replaced, so
+// one entry can be overridden without restating the rest.
+func setValue(v reflect.Value, s string) error {
+	switch {
+	case v.Type() == durationType:
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.String:
+		v.Set(reflect.ValueOf(splitList(s)))
+		return nil
+	case v.Kind() == reflect.Map:
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(v.Type()))
+		}
+		for _, pair := range splitList(s) {
+			key, value, ok := strings.Cut(pair, "=")
+			if !ok {
+				return fmt.Errorf("%q is not key=value", pair)
+			}
+			v.SetMapIndex(reflect.ValueOf(strings.TrimSpace(key)), reflect.ValueOf(strings.TrimSpace(value)))
+		}
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported setting type %s", v.Type())
+	}
+	return nil
+}
+
+// splitList splits a comma separated list, dropping blank entries
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Redacted returns a copy with secrets masked, safe to print or log
+func (c *Config) Redacted() *Config {
+	redacted := *c
+	for _, f := range fieldsOf(reflect.ValueOf(&redacted).Elem(), "") {
+		switch f.secret {
+		case "true":
+			redact(f.value)
+		case "url":
+			// Only a password embedded in the URL is secret
+			if u, err := url.Parse(f.value.String()); err == nil && u.User != nil {
+				f.value.SetString(u.Redacted())
+			}
+		}
+	}
+	return &redacted
+}
+
+// redact masks a non-empty string, or every entry of a string list
+func redact(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.String:
+		if v.String() != "" {
+			v.SetString("****")
+		}
+	case reflect.Slice:
+		if v.Len() == 0 {
+			return
+		}
+		// The copy shares the backing array, so build a new slice
+		masked := make([]string, v.Len())
+		for i := range masked {
+			masked[i] = "****"
+		}
+		v.Set(reflect.ValueOf(masked))
+	}
+}
+
+// String renders the redacted config as YAML
+func (c *Config) String() string {
+	out, err := yaml.Marshal(c.Redacted())
+	if err != nil {
+		return fmt.Sprintf("config: %v", err)
+	}
+	return string(out)
+}
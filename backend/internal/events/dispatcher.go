@@ -0,0 +1,199 @@
+package events
+
+import (
+	"brainrot-tamagotchi/internal/models"
+	"brainrot-tamagotchi/internal/repository"
+	"brainrot-tamagotchi/pkg/logging"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+var logger = logging.For("events")
+
+// StreamName is the Redis Stream every event is appended to
+const StreamName = "brainrot:events"
+
+// streamMaxLen caps the stream; older entries are trimmed approximately
+const streamMaxLen = 100000
+
+// dispatchBatchSize caps how many events one pass delivers
+const dispatchBatchSize = 100
+
+// maxDispatchAttempts is how many failed deliveries an event gets before it
+// is marked dead
+const maxDispatchAttempts = 10
+
+// Handler receives an event. Delivery is at least once, so handlers must be
+// idempotent; Envelope.ID is stable across redeliveries.
+type Handler func(ctx context.Context, env Envelope) error
+
+type subscriber struct {
+	name    string
+	handler Handler
+}
+
+// Dispatcher delivers outbox events to in-process subscribers and to the
+// Redis Stream. Several replicas can run it at once: each pass locks its
+// batch with SKIP LOCKED.
+type Dispatcher struct {
+	stores repository.Stores
+	redis  *redis.Client
+
+	mu   sync.RWMutex
+	subs map[string][]subscriber
+}
+
+func NewDispatcher(stores repository.Stores, redis *redis.Client) *Dispatcher {
+	return &Dispatcher{
+		stores: stores,
+		redis:  redis,
+		subs:   make(map[string][]subscriber),
+	}
+}
+
+// Subscribe registers a named handler for an event type. Register every
+// subscriber before starting the dispatch job.
+func (d *Dispatcher) Subscribe(eventType, name string, handler Handler) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.subs[eventType] = append(d.subs[eventType], subscriber{name: name, handler: handler})
+}
+
+// DispatchPending delivers batches until the due events run out
+func (d *Dispatcher) DispatchPending(ctx context.Context) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		delivered, err := d.DispatchOnce(ctx)
+		if err != nil {
+			return err
+		}
+		if delivered < dispatchBatchSize {
+			return nil
+		}
+	}
+}
+
+// DispatchOnce delivers one batch of due events and returns how many were
+// handled. A failed event is retried later with backoff; the rest of the
+// batch still goes out.
+func (d *Dispatcher) DispatchOnce(ctx context.Context) (int, error) {
+	handled := 0
+	err := d.stores.WithContext(ctx).Transaction(func(tx repository.Stores) error {
+		outbox := tx.Outbox()
+
+		pending, err := outbox.LockPending(time.Now(), dispatchBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for i := range pending {
+			event := &pending[i]
+			event.Attempts++
+
+			if err := d.deliver(ctx, event); err != nil {
+				event.LastError = err.Error()
+				if event.Attempts >= maxDispatchAttempts {
+					event.Status = models.OutboxDead
+					logger.ErrorContext(ctx, "event is dead", "event_id", event.ID, "type", event.Type, "attempts", event.Attempts, "error", err)
+				} else {
+					event.AvailableAt = time.Now().Add(retryBackoff(event.Attempts))
+				}
+			} else {
+				now := time.Now()
+				event.Status = models.OutboxDispatched
+				event.DispatchedAt = &now
+				event.LastError = ""
+			}
+
+			if err := outbox.Update(event); err != nil {
+				return err
+			}
+			handled++
+		}
+		return nil
+	})
+	return handled, err
+}
+
+// deliver appends an event to the stream and runs its subscribers. Every
+// step runs even if an earlier one fails, and any failure retries the event.
+func (d *Dispatcher) deliver(ctx context.Context, event *models.OutboxEvent) error {
+	env := Envelope{
+		ID:         event.ID,
+		Type:       event.Type,
+		OccurredAt: event.CreatedAt,
+		Payload:    event.Payload,
+	}
+
+	var failures []string
+	if err := d.appendToStream(ctx, env); err != nil {
+		failures = append(failures, fmt.Sprintf("stream: %v", err))
+	}
+
+	d.mu.RLock()
+	subs := d.subs[event.Type]
+	d.mu.RUnlock()
+
+	for _, sub := range subs {
+		if err := runHandler(ctx, sub.handler, env); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", sub.name, err))
+		}
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("%s", strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+// appendToStream adds an event to the Redis Stream for out-of-process
+// consumers, which read it with consumer groups and dedupe on id
+func (d *Dispatcher) appendToStream(ctx context.Context, env Envelope) error {
+	if d.redis == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	return d.redis.XAdd(ctx, &redis.XAddArgs{
+		Stream: StreamName,
+		MaxLen: streamMaxLen,
+		Approx: true,
+		Values: map[string]interface{}{
+			"id":          strconv.FormatUint(uint64(env.ID), 10),
+			"type":        env.Type,
+			"occurred_at": env.OccurredAt.UTC().Format(time.RFC3339Nano),
+			"payload":     string(env.Payload),
+		},
+	}).Err()
+}
+
+// runHandler turns a handler panic into an error so one bad subscriber
+// can't stop the dispatcher
+func runHandler(ctx context.Context, handler Handler, env Envelope) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return handler(ctx, env)
+}
+
+// retryBackoff doubles from 10 seconds up to an hour
+func retryBackoff(attempts int) time.Duration {
+	backoff := 10 * time.Second << (attempts - 1)
+	if backoff > time.Hour || backoff <= 0 {
+		return time.Hour
+	}
+	return backoff
+}
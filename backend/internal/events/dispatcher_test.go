@@ -0,0 +1,186 @@
+package events
+
+import (
+	"brainrot-tamagotchi/internal/models"
+	"brainrot-tamagotchi/internal/repository"
+	"brainrot-tamagotchi/internal/repository/memstore"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// record commits evts to the outbox in one transaction
+func record(t *testing.T, stores repository.Stores, evts ...Event) {
+	t.Helper()
+	err := stores.Transaction(func(tx repository.Stores) error {
+		return RecordTo(tx.Outbox(), evts...)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// makeDue moves every pending event's retry time into the past
+func makeDue(t *testing.T, stores *memstore.Stores) {
+	t.Helper()
+	for _, event := range stores.OutboxEvents() {
+		if event.Status != models.OutboxPending {
+			continue
+		}
+		event.AvailableAt = time.Now().Add(-time.Second)
+		if err := stores.Outbox().Update(&event); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestDispatchDeliversOnce(t *testing.T) {
+	stores := memstore.NewStores(memstore.NewNFTStore())
+	d := NewDispatcher(stores, nil)
+	ctx := context.Background()
+
+	var got []Envelope
+	d.Subscribe(TypePetFed, "count", func(ctx context.Context, env Envelope) error {
+		got = append(got, env)
+		return nil
+	})
+	d.Subscribe(TypePetPlayed, "other", func(ctx context.Context, env Envelope) error {
+		t.Errorf("%s handler got a %s", TypePetPlayed, env.Type)
+		return nil
+	})
+
+	record(t, stores, PetFed{TokenID: 1, Owner: "0xa", Hunger: 70})
+	for i := 0; i < 3; i++ {
+		if err := d.DispatchPending(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(got) != 1 {
+		t.Fatalf("handler ran %d times, want once", len(got))
+	}
+	var fed PetFed
+	if err := got[0].Decode(&fed); err != nil {
+		t.Fatal(err)
+	}
+	if got[0].Type != TypePetFed || fed.TokenID != 1 || fed.Hunger != 70 {
+		t.Fatalf("delivered %s %+v", got[0].Type, fed)
+	}
+
+	event := stores.OutboxEvents()[0]
+	if event.Status != models.OutboxDispatched || event.Attempts != 1 || event.DispatchedAt == nil {
+		t.Fatalf("outbox row = %+v, want dispatched on the first attempt", event)
+	}
+	if got[0].ID != event.ID {
+		t.Fatalf("envelope ID = %d, want the outbox ID %d", got[0].ID, event.ID)
+	}
+}
+
+func TestDispatchRetriesFailedHandler(t *testing.T) {
+	tests := []struct {
+		name    string
+		fail    func() error
+		wantErr string
+	}{
+		{"error", func() error { return errors.New("smtp down") }, "notify: smtp down"},
+		{"panic", func() error { panic("nil map") }, "notify: panic: nil map"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stores := memstore.NewStores(memstore.NewNFTStore())
+			d := NewDispatcher(stores, nil)
+			ctx := context.Background()
+
+			calls := 0
+			d.Subscribe(TypeListingSold, "notify", func(ctx context.Context, env Envelope) error {
+				calls++
+				if calls == 1 {
+					return tt.fail()
+				}
+				return nil
+			})
+			record(t, stores, ListingSold{TokenID: 1, Seller: "0xa", Buyer: "0xb"})
+
+			before := time.Now()
+			if handled, err := d.DispatchOnce(ctx); err != nil || handled != 1 {
+				t.Fatalf("first pass = %d, %v", handled, err)
+			}
+			event := stores.OutboxEvents()[0]
+			if event.Status != models.OutboxPending || event.Attempts != 1 || !strings.Contains(event.LastError, tt.wantErr) {
+				t.Fatalf("after a failure: %+v, want pending with error %q", event, tt.wantErr)
+			}
+			if event.AvailableAt.Before(before.Add(retryBackoff(1))) {
+				t.Fatalf("retry at %s, want at least %s after the attempt", event.AvailableAt, retryBackoff(1))
+			}
+
+			// Not due yet
+			if handled, err := d.DispatchOnce(ctx); err != nil || handled != 0 {
+				t.Fatalf("pass during backoff = %d, %v", handled, err)
+			}
+
+			makeDue(t, stores)
+			if handled, err := d.DispatchOnce(ctx); err != nil || handled != 1 {
+				t.Fatalf("retry = %d, %v", handled, err)
+			}
+			event = stores.OutboxEvents()[0]
+			if calls != 2 || event.Status != models.OutboxDispatched || event.Attempts != 2 || event.LastError != "" {
+				t.Fatalf("after the retry: %d calls, %+v", calls, event)
+			}
+		})
+	}
+}
+
+func TestDispatchMarksEventDead(t *testing.T) {
+	stores := memstore.NewStores(memstore.NewNFTStore())
+	d := NewDispatcher(stores, nil)
+	ctx := context.Background()
+
+	calls := 0
+	d.Subscribe(TypePetDied, "broken", func(ctx context.Context, env Envelope) error {
+		calls++
+		return errors.New("broken")
+	})
+	record(t, stores, PetDied{TokenID: 1, Owner: "0xa"})
+
+	for i := 0; i < maxDispatchAttempts+2; i++ {
+		makeDue(t, stores)
+		if err := d.DispatchPending(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	event := stores.OutboxEvents()[0]
+	if calls != maxDispatchAttempts || event.Status != models.OutboxDead || event.Attempts != maxDispatchAttempts {
+		t.Fatalf("%d calls, %+v; want dead after %d attempts", calls, event, maxDispatchAttempts)
+	}
+}
+
+func TestRolledBackEventIsNotDispatched(t *testing.T) {
+	stores := memstore.NewStores(memstore.NewNFTStore())
+	d := NewDispatcher(stores, nil)
+
+	d.Subscribe(TypePetFed, "count", func(ctx context.Context, env Envelope) error {
+		t.Errorf("a rolled back event was delivered: %+v", env)
+		return nil
+	})
+
+	failed := errors.New("update failed")
+	err := stores.Transaction(func(tx repository.Stores) error {
+		if err := RecordTo(tx.Outbox(), PetFed{TokenID: 1, Owner: "0xa"}); err != nil {
+			return err
+		}
+		return failed
+	})
+	if !errors.Is(err, failed) {
+		t.Fatalf("err = %v, want the transaction's error", err)
+	}
+
+	if err := d.DispatchPending(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(stores.OutboxEvents()); n != 0 {
+		t.Fatalf("outbox holds %d events after rollback", n)
+	}
+}
@@ -0,0 +1,175 @@
+// Package events defines the domain events services emit and delivers them
+// through a transactional outbox: an event is written in the same database
+// transaction as the state change it describes, then a dispatcher delivers
+// it at least once to in-process subscribers and a Redis Stream.
+package events
+
+import (
+	"brainrot-tamagotchi/internal/models"
+	"brainrot-tamagotchi/internal/repository"
+	"brainrot-tamagotchi/pkg/money"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Event types
+const (
+	TypePetFed           = "pet.fed"
+	TypePetPlayed        = "pet.played"
+	TypePetRestored      = "pet.restored"
+	TypePetDied          = "pet.died"
+	TypePetStatsAdjusted = "pet.stats_adjusted"
+	TypeCaseOpened       = "case.opened"
+	TypeListingCreated   = "listing.created"
+	TypeListingSold      = "listing.sold"
+	TypeListingCancelled = "listing.cancelled"
+	TypeListingRepriced  = "listing.repriced"
+)
+
+// Event is a typed domain event
+type Event interface {
+	EventType() string
+}
+
+// PetFed is emitted when an owner feeds a pet
+type PetFed struct {
+	TokenID uint   `json:"token_id"`
+	Owner   string `json:"owner"`
+	Hunger  int    `json:"hunger"`
+	Paid    bool   `json:"paid"`
+}
+
+// PetPlayed is emitted when an owner plays with a pet
+type PetPlayed struct {
+	TokenID uint   `json:"token_id"`
+	Owner   string `json:"owner"`
+	Mood    int    `json:"mood"`
+	Energy  int    `json:"energy"`
+}
+
+// PetRestored is emitted when a dead pet is revived
+type PetRestored struct {
+	TokenID uint   `json:"token_id"`
+	Owner   string `json:"owner"`
+}
+
+// PetDied is emitted when decay takes a pet's hunger, mood or energy to 0
+type PetDied struct {
+	TokenID uint   `json:"token_id"`
+	Owner   string `json:"owner"`
+	Hunger  int    `json:"hunger"`
+	Mood    int    `json:"mood"`
+	Energy  int    `json:"energy"`
+}
+
+// PetStatsAdjusted is emitted when an admin sets a pet's stats
+type PetStatsAdjusted struct {
+	TokenID    uint   `json:"token_id"`
+	Owner      string `json:"owner"`
+	Hunger     int    `json:"hunger"`
+	Mood       int    `json:"mood"`
+	Energy     int    `json:"energy"`
+	AdjustedBy string `json:"adjusted_by"`
+	Reason     string `json:"reason"`
+}
+
+// CaseOpened is emitted once per recorded case opening
+type CaseOpened struct {
+	OpeningID uint      `json:"opening_id"`
+	Wallet    string    `json:"wallet"`
+	CaseType  string    `json:"case_type"`
+	TokenID   uint      `json:"token_id"`
+	Rarity    string    `json:"rarity"`
+	MemeType  string    `json:"meme_type"`
+	Price     money.Wei `json:"price"`
+	TxHash    string    `json:"tx_hash"`
+	OpenedAt  time.Time `json:"opened_at"`
+}
+
+// ListingCreated is emitted when a pet is listed on the marketplace
+type ListingCreated struct {
+	TokenID uint      `json:"token_id"`
+	Seller  string    `json:"seller"`
+	Price   money.Wei `json:"price"`
+	TxHash  string    `json:"tx_hash"`
+}
+
+// ListingSold is emitted when a listed pet is bought
+type ListingSold struct {
+	TokenID     uint      `json:"token_id"`
+	Seller      string    `json:"seller"`
+	Buyer       string    `json:"buyer"`
+	Price       money.Wei `json:"price"`
+	PlatformFee money.Wei `json:"platform_fee"`
+	TxHash      string    `json:"tx_hash"`
+	LogIndex    uint      `json:"log_index"`
+	SoldAt      time.Time `json:"sold_at"`
+}
+
+// ListingCancelled is emitted when a listing is withdrawn by its seller or
+// the contract owner
+type ListingCancelled struct {
+	TokenID   uint   `json:"token_id"`
+	Seller    string `json:"seller"`
+	Emergency bool   `json:"emergency"`
+	TxHash    string `json:"tx_hash"`
+}
+
+// ListingRepriced is emitted when a seller changes a listing's price
+type ListingRepriced struct {
+	TokenID uint      `json:"token_id"`
+	Price   money.Wei `json:"price"`
+	TxHash  string    `json:"tx_hash"`
+}
+
+func (PetFed) EventType() string           { return TypePetFed }
+func (PetPlayed) EventType() string        { return TypePetPlayed }
+func (PetRestored) EventType() string      { return TypePetRestored }
+func (PetDied) EventType() string          { return TypePetDied }
+func (PetStatsAdjusted) EventType() string { return TypePetStatsAdjusted }
+func (CaseOpened) EventType() string       { return TypeCaseOpened }
+func (ListingCreated) EventType() string   { return TypeListingCreated }
+func (ListingSold) EventType() string      { return TypeListingSold }
+func (ListingCancelled) EventType() string { return TypeListingCancelled }
+func (ListingRepriced) EventType() string  { return TypeListingRepriced }
+
+// Record writes events to the outbox using tx, so they are committed or
+// rolled back together with the caller's state change
+func Record(tx *gorm.DB, evts ...Event) error {
+	return RecordTo(repository.NewOutboxRepository(tx), evts...)
+}
+
+// RecordTo is Record for an outbox from a repository.Stores transaction
+func RecordTo(outbox repository.OutboxStore, evts ...Event) error {
+	rows := make([]*models.OutboxEvent, 0, len(evts))
+	now := time.Now()
+	for _, evt := range evts {
+		payload, err := json.Marshal(evt)
+		if err != nil {
+			return fmt.Errorf("encode %s: %w", evt.EventType(), err)
+		}
+		rows = append(rows, &models.OutboxEvent{
+			Type:        evt.EventType(),
+			Payload:     payload,
+			Status:      models.OutboxPending,
+			AvailableAt: now,
+		})
+	}
+	return outbox.Create(rows...)
+}
+
+// Envelope is an event as delivered to subscribers
+type Envelope struct {
+	ID         uint            `json:"id"` // Outbox ID; the same across redeliveries
+	Type       string          `json:"type"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Payload    json.RawMessage `json:"payload"`
+}
+
+// Decode unmarshals the payload into the event struct for its type
+func (e Envelope) Decode(v Event) error {
+	return json.Unmarshal(e.Payload, v)
+}
@@ -0,0 +1,96 @@
+package health
+
+import (
+	"brainrot-tamagotchi/internal/blockchain"
+	"brainrot-tamagotchi/internal/repository"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"gorm.io/gorm"
+)
+
+// Postgres pings the database and reports the pool
+func Postgres(db *sql.DB) Check {
+	return Check{Name: "postgres", Critical: true, Run: func(ctx context.Context) (map[string]any, error) {
+		if err := db.PingContext(ctx); err != nil {
+			return nil, err
+		}
+		stats := db.Stats()
+		return map[string]any{
+			"open_connections": stats.OpenConnections,
+			"in_use":           stats.InUse,
+		}, nil
+	}}
+}
+
+// Redis pings Redis. It is not critical: rate limits and replay protection
+// fall back to memory while it is down, so the replica can keep serving.
+func Redis(client *redis.Client) Check {
+	return Check{Name: "redis", Run: func(ctx context.Context) (map[string]any, error) {
+		return nil, client.Ping(ctx).Err()
+	}}
+}
+
+// ChainHead fails when the RPC is unreachable or its latest block is older
+// than maxAge, which means the node stopped following the chain
+func ChainHead(client *blockchain.Client, maxAge time.Duration) Check {
+	return Check{Name: "chain_head", Run: func(ctx context.Context) (map[string]any, error) {
+		number, at, err := client.Head(ctx)
+		if err != nil {
+			return nil, err
+		}
+		age := time.Since(at).Truncate(time.Second)
+		details := map[string]any{
+			"block":       number,
+			"block_time":  at.UTC(),
+			"age_seconds": int64(age.Seconds()),
+		}
+		if age > maxAge {
+			return details, fmt.Errorf("head block is %s old, over %s", age, maxAge)
+		}
+		return details, nil
+	}}
+}
+
+// IndexerLag fails when an indexer trails the chain head by more than
+// maxLag blocks. lag is the indexer's own measure, such as ListingSync.Lag.
+func IndexerLag(name string, lag func(context.Context) (uint64, error), maxLag uint64) Check {
+	return Check{Name: name + "_indexer", Run: func(ctx context.Context) (map[string]any, error) {
+		blocks, err := lag(ctx)
+		if err != nil {
+			return nil, err
+		}
+		details := map[string]any{"lag_blocks": blocks}
+		if blocks > maxLag {
+			return details, fmt.Errorf("%d blocks behind the head, over %d", blocks, maxLag)
+		}
+		return details, nil
+	}}
+}
+
+// JobRecency fails when a scheduled job last succeeded more than maxAge
+// ago, or never has
+func JobRecency(db *gorm.DB, job string, maxAge time.Duration) Check {
+	return Check{Name: job + "_job", Run: func(ctx context.Context) (map[string]any, error) {
+		run, err := repository.NewJobRunRepository(db.WithContext(ctx)).GetLastSucceeded(job)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%s has not succeeded yet", job)
+		}
+		if err != nil {
+			return nil, err
+		}
+		age := time.Since(run.StartedAt).Truncate(time.Second)
+		details := map[string]any{
+			"last_success": run.StartedAt.UTC(),
+			"age_seconds":  int64(age.Seconds()),
+		}
+		if age > maxAge {
+			return details, fmt.Errorf("last succeeded %s ago, over %s", age, maxAge)
+		}
+		return details, nil
+	}}
+}
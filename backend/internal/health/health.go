@@ -0,0 +1,145 @@
+// Package health runs the dependency checks behind /readyz.
+//
+// Every check runs concurrently under its own timeout. A failed critical
+// check, such as Postgres, makes the service unready; a failed non-critical
+// one, such as a stale chain head, only degrades it, so a slow RPC provider
+// doesn't take every replica out of the load balancer.
+package health
+
+import (
+	"brainrot-tamagotchi/pkg/buildinfo"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Check and report statuses
+const (
+	StatusOK       = "ok"
+	StatusDegraded = "degraded" // Only non-critical checks failed
+	StatusFail     = "fail"
+)
+
+// Check probes one dependency. Run returns details to show in the report,
+// and an error when the dependency is unhealthy.
+type Check struct {
+	Name     string
+	Critical bool
+	Run      func(ctx context.Context) (map[string]any, error)
+}
+
+// Result is the outcome of one check
+type Result struct {
+	Status    string         `json:"status"`
+	Critical  bool           `json:"critical"`
+	LatencyMs int64          `json:"latency_ms"`
+	Error     string         `json:"error,omitempty"`
+	Details   map[string]any `json:"details,omitempty"`
+}
+
+// Report is the outcome of all checks
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+	Build  buildinfo.Info    `json:"build"`
+}
+
+// Ready reports whether every critical check passed
+func (r Report) Ready() bool {
+	return r.Status != StatusFail
+}
+
+// Checker runs a fixed set of checks
+type Checker struct {
+	timeout time.Duration
+	checks  []Check
+}
+
+// NewChecker returns a Checker that gives each check timeout to finish
+func NewChecker(timeout time.Duration, checks ...Check) *Checker {
+	return &Checker{timeout: timeout, checks: checks}
+}
+
+// Add registers more checks. It must not be called once Run is in use.
+func (c *Checker) Add(checks ...Check) {
+	c.checks = append(c.checks, checks...)
+}
+
+// Run runs every check and reports on all of them
+func (c *Checker) Run(ctx context.Context) Report {
+	report := Report{
+		Status: StatusOK,
+		Checks: make(map[string]Result, len(c.checks)),
+		Build:  buildinfo.Get(),
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, check := range c.checks {
+		wg.Add(1)
+		go func(check Check) {
+			defer wg.Done()
+			result := c.run(ctx, check)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[check.Name] = result
+			if result.Status == StatusFail {
+				if check.Critical {
+					report.Status = StatusFail
+				} else if report.Status == StatusOK {
+					report.Status = StatusDegraded
+				}
+			}
+		}(check)
+	}
+	wg.Wait()
+	return report
+}
+
+// run runs one check, giving up when its timeout passes even if the check
+// ignores its context
+func (c *Checker) run(ctx context.Context, check Check) Result {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	type outcome struct {
+		details map[string]any
+		err     error
+	}
+	done := make(chan outcome, 1)
+	start := time.Now()
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				done <- outcome{err: fmt.Errorf("check panicked: %v", p)}
+			}
+		}()
+		details, err := check.Run(ctx)
+		done <- outcome{details, err}
+	}()
+
+	var out outcome
+	select {
+	case out = <-done:
+	case <-ctx.Done():
+		out.err = ctx.Err()
+	}
+
+	result := Result{
+		Status:    StatusOK,
+		Critical:  check.Critical,
+		LatencyMs: time.Since(start).Milliseconds(),
+		Details:   out.details,
+	}
+	if out.err != nil {
+		result.Status = StatusFail
+		result.Error = out.err.Error()
+		if errors.Is(out.err, context.DeadlineExceeded) {
+			result.Error = fmt.Sprintf("timed out after %s", c.timeout)
+		}
+	}
+	return result
+}
@@ -0,0 +1,66 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+func TestCheckerRun(t *testing.T) {
+	ok := func(context.Context) (map[string]any, error) { return map[string]any{"block": 7}, nil }
+	broken := func(context.Context) (map[string]any, error) { return nil, errors.New("connection refused") }
+	hang := func(context.Context) (map[string]any, error) { select {} }
+
+	cases := map[string]struct {
+		checks []Check
+		want   string
+	}{
+		"all ok":             {[]Check{{Name: "postgres", Critical: true, Run: ok}, {Name: "chain_head", Run: ok}}, StatusOK},
+		"non-critical fails": {[]Check{{Name: "postgres", Critical: true, Run: ok}, {Name: "chain_head", Run: broken}}, StatusDegraded},
+		"critical fails":     {[]Check{{Name: "postgres", Critical: true, Run: broken}, {Name: "chain_head", Run: broken}}, StatusFail},
+		"critical hangs":     {[]Check{{Name: "postgres", Critical: true, Run: hang}}, StatusFail},
+	}
+	for name, tc := range cases {
+		start := time.Now()
+		report := NewChecker(50*time.Millisecond, tc.checks...).Run(context.Background())
+		if report.Status != tc.want {
+			t.Errorf("%s: status = %s, want %s (%+v)", name, report.Status, tc.want, report.Checks)
+		}
+		if report.Ready() != (tc.want != StatusFail) {
+			t.Errorf("%s: Ready = %v", name, report.Ready())
+		}
+		if len(report.Checks) != len(tc.checks) {
+			t.Errorf("%s: %d results, want %d", name, len(report.Checks), len(tc.checks))
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("%s: took %s; the timeout did not hold", name, elapsed)
+		}
+	}
+
+	report := NewChecker(50*time.Millisecond, Check{Name: "postgres", Critical: true, Run: hang}).Run(context.Background())
+	if r := report.Checks["postgres"]; r.Error != "timed out after 50ms" {
+		t.Errorf("hung check error = %q", r.Error)
+	}
+	report = NewChecker(time.Second, Check{Name: "chain_head", Run: ok}).Run(context.Background())
+	if r := report.Checks["chain_head"]; r.Details["block"] != 7 || r.Critical {
+		t.Errorf("chain_head result = %+v", r)
+	}
+}
+
+func TestRedisDownIsDegraded(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1})
+	defer client.Close()
+
+	report := NewChecker(time.Second, Check{Name: "postgres", Critical: true, Run: func(context.Context) (map[string]any, error) {
+		return nil, nil
+	}}, Redis(client)).Run(context.Background())
+	if report.Status != StatusDegraded || !report.Ready() {
+		t.Fatalf("status = %s, ready = %v, want degraded and ready (%+v)", report.Status, report.Ready(), report.Checks)
+	}
+	if r := report.Checks["redis"]; r.Error == "" || r.Critical {
+		t.Errorf("redis result = %+v", r)
+	}
+}
@@ -0,0 +1,146 @@
+package metrics
+
+import (
+	"brainrot-tamagotchi/internal/repository"
+	"brainrot-tamagotchi/pkg/logging"
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var logger = logging.For("metrics")
+
+// RegisterDB exports the connection pool stats of db
+func RegisterDB(db *sql.DB) {
+	Registry.MustRegister(&dbCollector{db: db})
+}
+
+var (
+	dbOpenDesc = prometheus.NewDesc(namespace+"_db_open_connections",
+		"Open DB connections, in use and idle.", []string{"state"}, nil)
+	dbMaxOpenDesc = prometheus.NewDesc(namespace+"_db_max_open_connections",
+		"Maximum open DB connections; 0 is unlimited.", nil, nil)
+	dbWaitsDesc = prometheus.NewDesc(namespace+"_db_wait_count_total",
+		"Connections waited for because the pool was exhausted.", nil, nil)
+	dbWaitDurationDesc = prometheus.NewDesc(namespace+"_db_wait_duration_seconds_total",
+		"Time spent waiting for a connection.", nil, nil)
+	dbClosedDesc = prometheus.NewDesc(namespace+"_db_closed_connections_total",
+		"Connections closed by the pool, by reason.", []string{"reason"}, nil)
+)
+
+type dbCollector struct {
+	db *sql.DB
+}
+
+func (c *dbCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- dbOpenDesc
+	ch <- dbMaxOpenDesc
+	ch <- dbWaitsDesc
+	ch <- dbWaitDurationDesc
+	ch <- dbClosedDesc
+}
+
+func (c *dbCollector) Collect(ch chan<- prometheus.Metric) {
+	s := c.db.Stats()
+	ch <- prometheus.MustNewConstMetric(dbOpenDesc, prometheus.GaugeValue, float64(s.InUse), "in_use")
+	ch <- prometheus.MustNewConstMetric(dbOpenDesc, prometheus.GaugeValue, float64(s.Idle), "idle")
+	ch <- prometheus.MustNewConstMetric(dbMaxOpenDesc, prometheus.GaugeValue, float64(s.MaxOpenConnections))
+	ch <- prometheus.MustNewConstMetric(dbWaitsDesc, prometheus.CounterValue, float64(s.WaitCount))
+	ch <- prometheus.MustNewConstMetric(dbWaitDurationDesc, prometheus.CounterValue, s.WaitDuration.Seconds())
+	ch <- prometheus.MustNewConstMetric(dbClosedDesc, prometheus.CounterValue, float64(s.MaxIdleClosed), "max_idle")
+	ch <- prometheus.MustNewConstMetric(dbClosedDesc, prometheus.CounterValue, float64(s.MaxIdleTimeClosed), "max_idle_time")
+	ch <- prometheus.MustNewConstMetric(dbClosedDesc, prometheus.CounterValue, float64(s.MaxLifetimeClosed), "max_lifetime")
+}
+
+// populationTimeout bounds the population query made on each scrape
+const populationTimeout = 5 * time.Second
+
+var petsDesc = prometheus.NewDesc(namespace+"_pets",
+	"Pets by rarity and state (alive or dead).", []string{"rarity", "state"}, nil)
+
+// RegisterPetPopulation exports pet counts by rarity and state, counted by
+// nfts on each scrape. A failed count is logged and leaves the gauge out of
+// that scrape.
+func RegisterPetPopulation(nfts *repository.NFTRepository) {
+	Registry.MustRegister(&petCollector{nfts: nfts})
+}
+
+type petCollector struct {
+	nfts *repository.NFTRepository
+}
+
+func (c *petCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- petsDesc
+}
+
+func (c *petCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), populationTimeout)
+	defer cancel()
+
+	rows, err := c.nfts.WithContext(ctx).(*repository.NFTRepository).CountPopulation()
+	if err != nil {
+		logger.Error("counting pet population failed", "error", err)
+		return
+	}
+	for _, row := range rows {
+		state := "dead"
+		if row.Alive {
+			state = "alive"
+		}
+		ch <- prometheus.MustNewConstMetric(petsDesc, prometheus.GaugeValue, float64(row.Count), row.Rarity, state)
+	}
+}
+
+// InstrumentRedis times every command client runs
+func InstrumentRedis(client *redis.Client) {
+	client.AddHook(redisHook{})
+}
+
+type redisStartKey struct{}
+
+type redisHook struct{}
+
+func (redisHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	return context.WithValue(ctx, redisStartKey{}, time.Now()), nil
+}
+
+func (redisHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	observeRedis(ctx, cmd.Name(), cmd.Err())
+	return nil
+}
+
+func (redisHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	return context.WithValue(ctx, redisStartKey{}, time.Now()), nil
+}
+
+func (redisHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	var err error
+	for _, cmd := range cmds {
+		if cmdErr := cmd.Err(); cmdErr != nil && !errors.Is(cmdErr, redis.Nil) {
+			err = cmdErr
+			break
+		}
+	}
+	observeRedis(ctx, "pipeline", err)
+	return nil
+}
+
+func observeRedis(ctx context.Context, command string, err error) {
+	if start, ok := ctx.Value(redisStartKey{}).(time.Time); ok {
+		RedisDuration.WithLabelValues(command).Observe(time.Since(start).Seconds())
+	}
+	if err != nil && !errors.Is(err, redis.Nil) {
+		RedisErrors.WithLabelValues(command).Inc()
+	}
+}
+
+// ObserveHTTP records one handled HTTP request
+func ObserveHTTP(method, route string, status int, elapsed time.Duration) {
+	HTTPRequests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	HTTPDuration.WithLabelValues(method, route).Observe(elapsed.Seconds())
+}
@@ -0,0 +1,125 @@
+// Package metrics holds the Prometheus metrics served on /metrics. Every
+// metric is named brainrot_<subsystem>_<name> and registered on Registry,
+// alongside the Go runtime and process collectors.
+//
+// Counters and histograms are package variables updated where the work
+// happens. Values that live elsewhere, such as the DB pool and the pet
+// population, are read by collectors at scrape time.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "brainrot"
+
+// Registry holds every metric this package defines
+var Registry = prometheus.NewRegistry()
+
+// HTTP
+var (
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace, Subsystem: "http", Name: "requests_total",
+		Help: "HTTP requests by method, route template and status code.",
+	}, []string{"method", "route", "status"})
+
+	HTTPDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace, Subsystem: "http", Name: "request_duration_seconds",
+		Help:    "HTTP request latency by method and route template.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route"})
+)
+
+// Redis
+var (
+	RedisDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace, Subsystem: "redis", Name: "command_duration_seconds",
+		Help:    "Redis command latency by command; pipelines count as one.",
+		Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"command"})
+
+	RedisErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace, Subsystem: "redis", Name: "errors_total",
+		Help: "Failed Redis commands by command. A missing key is not a failure.",
+	}, []string{"command"})
+)
+
+// Chain
+var (
+	RPCDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace, Subsystem: "rpc", Name: "request_duration_seconds",
+		Help:    "JSON-RPC request latency by method; batches are method=batch.",
+		Buckets: []float64{.01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+	}, []string{"method"})
+
+	RPCErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace, Subsystem: "rpc", Name: "errors_total",
+		Help: "Failed JSON-RPC requests by method and kind: transport, http or rpc.",
+	}, []string{"method", "kind"})
+
+	IndexerBlockLag = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace, Subsystem: "indexer", Name: "block_lag",
+		Help: "Blocks between the chain head and the last block an indexer synced.",
+	}, []string{"indexer"})
+)
+
+// Jobs and the game loop
+var (
+	JobRuns = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace, Subsystem: "job", Name: "runs_total",
+		Help: "Background job runs by job and status.",
+	}, []string{"job", "status"})
+
+	JobDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace, Subsystem: "job", Name: "duration_seconds",
+		Help:    "Background job run time by job.",
+		Buckets: []float64{.01, .05, .1, .5, 1, 5, 10, 30, 60, 300, 900, 1800, 3600},
+	}, []string{"job"})
+
+	DecayDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace, Subsystem: "stat_decay", Name: "duration_seconds",
+		Help:    "Time of complete stat decay passes.",
+		Buckets: []float64{1, 5, 10, 30, 60, 120, 300, 600, 1200, 1800},
+	})
+
+	DecayPets = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace, Subsystem: "stat_decay", Name: "pets_total",
+		Help: "Pets processed by stat decay: scanned, updated or died.",
+	}, []string{"result"})
+
+	CaseOpenings = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace, Subsystem: "cases", Name: "openings_total",
+		Help: "Case openings by case type and rarity pulled.",
+	}, []string{"case_type", "rarity"})
+
+	MarketplaceSales = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace, Subsystem: "marketplace", Name: "sales_total",
+		Help: "Marketplace sales indexed from the chain.",
+	})
+
+	MarketplaceVolume = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace, Subsystem: "marketplace", Name: "sales_volume_eth_total",
+		Help: "Marketplace sales volume in ETH.",
+	})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{Namespace: namespace}),
+		HTTPRequests, HTTPDuration,
+		RedisDuration, RedisErrors,
+		RPCDuration, RPCErrors, IndexerBlockLag,
+		JobRuns, JobDuration, DecayDuration, DecayPets,
+		CaseOpenings, MarketplaceSales, MarketplaceVolume,
+	)
+}
+
+// Handler serves Registry in the Prometheus text format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
@@ -0,0 +1,112 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Admin roles, from most to least privileged
+const (
+	RoleAdmin     = "admin"
+	RoleModerator = "moderator"
+	RoleSupport   = "support"
+)
+
+// RoleRank orders roles so that a higher rank includes the lower ones
+var RoleRank = map[string]int{
+	RoleSupport:   1,
+	RoleModerator: 2,
+	RoleAdmin:     3,
+}
+
+// AdminRole grants a wallet access to the admin API
+type AdminRole struct {
+	ID            uint           `gorm:"primarykey" json:"id"`
+	WalletAddress string         `gorm:"uniqueIndex;not null" json:"wallet_address"`
+	Role          string         `gorm:"not null" json:"role"`
+	GrantedBy     string         `json:"granted_by"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// TableName overrides the table name
+func (AdminRole) TableName() string {
+	return "admin_roles"
+}
+
+// AdminAuditLog records one request made through the admin API
+type AdminAuditLog struct {
+	ID           uint                   `gorm:"primarykey" json:"id"`
+	ActorAddress string                 `gorm:"index;not null" json:"actor_address"`
+	ActorRole    string                 `json:"actor_role"`
+	Action       string                 `gorm:"index;not null" json:"action"` // e.g. "PATCH /api/v1/admin/pets/:id/stats"
+	TargetType   string                 `gorm:"index:idx_audit_target" json:"target_type,omitempty"`
+	TargetID     string                 `gorm:"index:idx_audit_target" json:"target_id,omitempty"`
+	Reason       string                 `json:"reason,omitempty"`
+	Details      map[string]interface{} `gorm:"type:jsonb;serializer:json" json:"details,omitempty"`
+	StatusCode   int                    `json:"status_code"`
+	IPAddress    string                 `json:"ip_address"`
+	CreatedAt    time.Time              `gorm:"index" json:"created_at"`
+}
+
+// TableName overrides the table name
+func (AdminAuditLog) TableName() string {
+	return "admin_audit_logs"
+}
+
+// WalletBan blocks a wallet from write endpoints. Lifting a ban soft-deletes it.
+type WalletBan struct {
+	ID            uint           `gorm:"primarykey" json:"id"`
+	WalletAddress string         `gorm:"index;not null" json:"wallet_address"`
+	Reason        string         `gorm:"not null" json:"reason"`
+	BannedBy      string         `gorm:"not null" json:"banned_by"`
+	ExpiresAt     *time.Time     `json:"expires_at"` // nil for permanent
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// TableName overrides the table name
+func (WalletBan) TableName() string {
+	return "wallet_bans"
+}
+
+// IsActive reports whether the ban is in force at t
+func (b *WalletBan) IsActive(t time.Time) bool {
+	return b.ExpiresAt == nil || t.Before(*b.ExpiresAt)
+}
+
+// Contract call statuses
+const (
+	ContractCallQueued    = "queued"
+	ContractCallSent      = "sent"
+	ContractCallConfirmed = "confirmed"
+	ContractCallFailed    = "failed"
+)
+
+// ContractCallJob is an owner-only contract call queued for the backend signer.
+// At most one queued or sent job may hold a given idempotency key.
+type ContractCallJob struct {
+	ID             uint       `gorm:"primarykey" json:"id"`
+	Contract       string     `gorm:"not null" json:"contract"` // "marketplace", "cases", "burn", "nft"
+	Method         string     `gorm:"not null" json:"method"`
+	Args           []string   `gorm:"type:jsonb;serializer:json" json:"args"`
+	Status         string     `gorm:"index;not null" json:"status"`
+	IdempotencyKey *string    `json:"idempotency_key,omitempty"`
+	TxHash         *string    `json:"tx_hash,omitempty"`
+	Error          string     `json:"error,omitempty"`
+	Attempts       int        `json:"attempts"`
+	RequestedBy    string     `gorm:"not null" json:"requested_by"`
+	Reason         string     `gorm:"not null" json:"reason"`
+	SentAt         *time.Time `json:"sent_at,omitempty"`
+	FinishedAt     *time.Time `json:"finished_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// TableName overrides the table name
+func (ContractCallJob) TableName() string {
+	return "contract_call_jobs"
+}
@@ -1,12 +1,14 @@
 package models
 
 import (
+	"brainrot-tamagotchi/pkg/money"
 	"time"
 
 	"gorm.io/gorm"
 )
 
-// CaseOpening represents a case opening event
+// CaseOpening represents a case opening event. The (tx_hash, log_index) of
+// its CaseOpened log makes recording it idempotent.
 type CaseOpening struct {
 	ID          uint      `gorm:"primarykey" json:"id"`
 	UserAddress string    `gorm:"index;not null" json:"user_address"`
@@ -14,8 +16,9 @@ type CaseOpening struct {
 	TokenID     uint      `json:"token_id"`
 	Rarity      string    `json:"rarity"`
 	MemeType    string    `json:"meme_type"`
-	Price       float64   `json:"price"`
-	TxHash      string    `json:"tx_hash"`
+	Price       money.Wei `gorm:"not null;default:0" json:"price"` // Price in wei
+	TxHash      string    `gorm:"uniqueIndex:idx_case_opening_tx_log,where:tx_hash <> ''" json:"tx_hash"`
+	LogIndex    uint      `gorm:"uniqueIndex:idx_case_opening_tx_log;not null;default:0" json:"log_index"`
 	OpenedAt    time.Time `json:"opened_at"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
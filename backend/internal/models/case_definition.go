@@ -0,0 +1,70 @@
+package models
+
+import (
+	"brainrot-tamagotchi/pkg/money"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CaseDefinition is a case in the catalog. A case backed by a CaseOpening
+// contract type mirrors its price and availability on-chain while it is live.
+type CaseDefinition struct {
+	ID               uint           `gorm:"primarykey" json:"id"`
+	Slug             string         `gorm:"uniqueIndex;not null" json:"slug"`
+	Name             string         `gorm:"not null" json:"name"`
+	Description      string         `json:"description"`
+	ArtworkURL       string         `json:"artwork_url"`
+	Price            money.Wei      `gorm:"not null;default:0" json:"price"` // Price in wei
+	USDTarget        float64        `json:"usd_target"`                      // Advertised price in USD, 0 if none
+	ContractCaseType *string        `gorm:"index" json:"contract_case_type"` // "bronze", "silver", "gold"
+	IsActive         bool           `gorm:"not null" json:"is_active"`
+	StartsAt         *time.Time     `json:"starts_at"`
+	EndsAt           *time.Time     `json:"ends_at"`
+	SupplyCap        *int64         `json:"supply_cap"` // nil for unlimited
+	RarityWeights    map[string]int `gorm:"type:jsonb;serializer:json" json:"rarity_weights"`
+	MemeWeights      map[string]int `gorm:"type:jsonb;serializer:json" json:"meme_weights"`
+	// Pity: a voucher for PityRarity is guaranteed after PityHardLimit opens
+	// without it, with a growing chance of one from PitySoftStart misses on.
+	// A PityHardLimit of 0 disables pity.
+	PityRarity    string         `json:"pity_rarity"`
+	PityHardLimit int            `gorm:"not null;default:0" json:"pity_hard_limit"`
+	PitySoftStart int            `gorm:"not null;default:0" json:"pity_soft_start"`
+	SortOrder     int            `gorm:"not null;default:0" json:"sort_order"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// TableName overrides the table name
+func (CaseDefinition) TableName() string {
+	return "case_definitions"
+}
+
+// InWindow reports whether t falls within the case's sale window
+func (d *CaseDefinition) InWindow(t time.Time) bool {
+	if d.StartsAt != nil && t.Before(*d.StartsAt) {
+		return false
+	}
+	if d.EndsAt != nil && !t.Before(*d.EndsAt) {
+		return false
+	}
+	return true
+}
+
+// RarityOdds converts the rarity weights into probabilities
+func (d *CaseDefinition) RarityOdds() map[string]float64 {
+	total := 0
+	for _, w := range d.RarityWeights {
+		total += w
+	}
+
+	odds := make(map[string]float64, len(d.RarityWeights))
+	if total == 0 {
+		return odds
+	}
+	for rarity, w := range d.RarityWeights {
+		odds[rarity] = float64(w) / float64(total)
+	}
+	return odds
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+)
+
+// ProcessedChainEvent records a contract log that has been applied to the DB.
+// The (tx_hash, log_index) pair makes event processing idempotent.
+type ProcessedChainEvent struct {
+	ID          uint      `gorm:"primarykey" json:"id"`
+	TxHash      string    `gorm:"uniqueIndex:idx_chain_event_tx_log;not null" json:"tx_hash"`
+	LogIndex    uint      `gorm:"uniqueIndex:idx_chain_event_tx_log;not null" json:"log_index"`
+	BlockNumber uint64    `gorm:"index" json:"block_number"`
+	Contract    string    `json:"contract"`
+	EventName   string    `json:"event_name"`
+	TokenID     uint      `gorm:"index" json:"token_id"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// TableName overrides the table name
+func (ProcessedChainEvent) TableName() string {
+	return "processed_chain_events"
+}
+
+// SyncCursor stores how far a consumer has fully processed: the last block
+// for chain event consumers, or the last row ID for consumers of DB tables
+type SyncCursor struct {
+	Name        string    `gorm:"primarykey" json:"name"`
+	BlockNumber uint64    `json:"block_number"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// TableName overrides the table name
+func (SyncCursor) TableName() string {
+	return "sync_cursors"
+}
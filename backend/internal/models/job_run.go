@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// Job run statuses
+const (
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+)
+
+// Job run triggers
+const (
+	JobTriggerSchedule = "schedule"
+	JobTriggerManual   = "manual"
+)
+
+// JobRun records one run of a scheduled job
+type JobRun struct {
+	ID         uint       `gorm:"primarykey" json:"id"`
+	JobName    string     `gorm:"index;not null" json:"job_name"`
+	Trigger    string     `gorm:"not null" json:"trigger"`
+	Instance   string     `gorm:"not null" json:"instance"` // Host and PID that ran it
+	Status     string     `gorm:"not null" json:"status"`
+	Error      string     `json:"error,omitempty"`
+	StartedAt  time.Time  `gorm:"index;not null" json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	DurationMs int64      `json:"duration_ms"`
+}
+
+// TableName overrides the table name
+func (JobRun) TableName() string {
+	return "job_runs"
+}
+
+// JobReport holds the last report a job produced, one row per job
+type JobReport struct {
+	JobName    string    `gorm:"primarykey" json:"job_name"`
+	Report     string    `gorm:"type:jsonb;not null" json:"report"`
+	FinishedAt time.Time `gorm:"not null" json:"finished_at"`
+}
+
+// TableName overrides the table name
+func (JobReport) TableName() string {
+	return "job_reports"
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"brainrot-tamagotchi/pkg/money"
 	"time"
 
 	"gorm.io/gorm"
@@ -11,12 +12,18 @@ type MarketListing struct {
 	ID            uint           `gorm:"primarykey" json:"id"`
 	TokenID       uint           `gorm:"uniqueIndex;not null" json:"token_id"`
 	SellerAddress string         `gorm:"index;not null" json:"seller_address"`
-	Price         float64        `json:"price"` // Price in BASE (ETH)
+	Price         money.Wei      `gorm:"not null;default:0" json:"price"` // Price in wei
 	IsActive      bool           `gorm:"default:true;index" json:"is_active"`
 	ListedAt      time.Time      `json:"listed_at"`
 	SoldAt        *time.Time     `json:"sold_at,omitempty"`
 	BuyerAddress  *string        `json:"buyer_address,omitempty"`
 	TxHash        string         `json:"tx_hash"`
+	SaleTxHash    *string        `json:"sale_tx_hash,omitempty"`
+	CancelTxHash  *string        `json:"cancel_tx_hash,omitempty"`
+	CancelledAt   *time.Time     `json:"cancelled_at,omitempty"`
+	// Position of the last Marketplace.sol event applied to this row
+	LastEventBlock    uint64 `json:"last_event_block"`
+	LastEventLogIndex uint   `json:"last_event_log_index"`
 	CreatedAt     time.Time      `json:"created_at"`
 	UpdatedAt     time.Time      `json:"updated_at"`
 	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
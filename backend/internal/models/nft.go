@@ -51,9 +51,9 @@ func (n *NFT) NeedsFeeding() bool {
 	return n.Hunger < 50
 }
 
-// CanFeedFree checks if user can feed for free (once per day)
-func (n *NFT) CanFeedFree() bool {
-	return time.Since(n.LastFed) >= 24*time.Hour
+// CanFeedFree checks if user can feed for free (once per cooldown)
+func (n *NFT) CanFeedFree(cooldown time.Duration) bool {
+	return time.Since(n.LastFed) >= cooldown
 }
 
 // CanPlayFree checks if user can play for free
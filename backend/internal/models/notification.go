@@ -0,0 +1,76 @@
+package models
+
+import (
+	"time"
+)
+
+// Notification kinds
+const (
+	NotifyHungerLow     = "hunger_low"
+	NotifyMoodZero      = "mood_zero"
+	NotifyPetDied       = "pet_died"
+	NotifyListingSold   = "listing_sold"
+	NotifyOfferReceived = "offer_received"
+)
+
+// Notification delivery statuses
+const (
+	NotificationPending = "pending" // Waiting for quiet hours to end or a retry
+	NotificationSent    = "sent"
+	NotificationFailed  = "failed"  // Out of attempts on at least one channel
+	NotificationSkipped = "skipped" // No channel configured or kind muted
+)
+
+// NotificationPreference holds a wallet's delivery channels and settings
+type NotificationPreference struct {
+	ID            uint   `gorm:"primarykey" json:"id"`
+	WalletAddress string `gorm:"uniqueIndex;not null" json:"wallet_address"`
+	// Destination per channel: "webhook" URL, "email" address, "telegram"
+	// chat ID, "webpush" subscription JSON
+	Channels map[string]string `gorm:"type:jsonb;serializer:json" json:"channels"`
+	// Kinds set to false are muted; missing kinds are on
+	Kinds map[string]bool `gorm:"type:jsonb;serializer:json" json:"kinds"`
+	// Quiet hours as "HH:MM" in Timezone; delivery waits until they end.
+	// A window may wrap past midnight.
+	QuietStart string    `json:"quiet_start"`
+	QuietEnd   string    `json:"quiet_end"`
+	Timezone   string    `gorm:"not null;default:'UTC'" json:"timezone"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// TableName overrides the table name
+func (NotificationPreference) TableName() string {
+	return "notification_preferences"
+}
+
+// KindEnabled reports whether a kind is not muted
+func (p *NotificationPreference) KindEnabled(kind string) bool {
+	enabled, ok := p.Kinds[kind]
+	return !ok || enabled
+}
+
+// Notification is one message to a wallet and its delivery state. DedupKey
+// is unique, so repeated triggers for the same condition send once.
+type Notification struct {
+	ID            uint                   `gorm:"primarykey" json:"id"`
+	WalletAddress string                 `gorm:"index;not null" json:"wallet_address"`
+	Kind          string                 `gorm:"not null" json:"kind"`
+	DedupKey      string                 `gorm:"uniqueIndex;not null" json:"-"`
+	Title         string                 `gorm:"not null" json:"title"`
+	Body          string                 `gorm:"not null" json:"body"`
+	Data          map[string]interface{} `gorm:"type:jsonb;serializer:json" json:"data,omitempty"`
+	Status        string                 `gorm:"index;not null" json:"status"`
+	Delivered     []string               `gorm:"type:jsonb;serializer:json" json:"delivered"` // Channels already sent to
+	Attempts      int                    `json:"attempts"`
+	LastError     string                 `json:"-"`
+	DeliverAfter  time.Time              `gorm:"index" json:"deliver_after"`
+	SentAt        *time.Time             `json:"sent_at,omitempty"`
+	CreatedAt     time.Time              `json:"created_at"`
+	UpdatedAt     time.Time              `json:"updated_at"`
+}
+
+// TableName overrides the table name
+func (Notification) TableName() string {
+	return "notifications"
+}
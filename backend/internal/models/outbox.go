@@ -0,0 +1,32 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Outbox event statuses
+const (
+	OutboxPending    = "pending"
+	OutboxDispatched = "dispatched"
+	OutboxDead       = "dead" // Out of attempts; needs a look
+)
+
+// OutboxEvent is a domain event written in the same transaction as the state
+// change it describes, and delivered afterwards by the dispatcher
+type OutboxEvent struct {
+	ID           uint            `gorm:"primarykey" json:"id"`
+	Type         string          `gorm:"index;not null" json:"type"`
+	Payload      json.RawMessage `gorm:"type:jsonb;serializer:json;not null" json:"payload"`
+	Status       string          `gorm:"not null;index:idx_outbox_due,priority:1" json:"status"`
+	Attempts     int             `json:"attempts"`
+	LastError    string          `json:"last_error,omitempty"`
+	AvailableAt  time.Time       `gorm:"not null;index:idx_outbox_due,priority:2" json:"available_at"`
+	DispatchedAt *time.Time      `json:"dispatched_at,omitempty"`
+	CreatedAt    time.Time       `json:"created_at"`
+}
+
+// TableName overrides the table name
+func (OutboxEvent) TableName() string {
+	return "outbox_events"
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"brainrot-tamagotchi/pkg/money"
+	"time"
+)
+
+// MarketSale is a ledger entry for one Marketplace.sol sale.
+// FeeBps is the platform fee rate in effect when the sale happened.
+type MarketSale struct {
+	ID             uint      `gorm:"primarykey" json:"id"`
+	TokenID        uint      `gorm:"index;not null" json:"token_id"`
+	SellerAddress  string    `gorm:"index;not null" json:"seller_address"`
+	BuyerAddress   string    `gorm:"index;not null" json:"buyer_address"`
+	GrossPrice     money.Wei `gorm:"not null" json:"gross_price"` // Amounts in wei
+	FeeBps         int       `json:"fee_bps"`
+	PlatformFee    money.Wei `gorm:"not null" json:"platform_fee"`
+	SellerProceeds money.Wei `gorm:"not null" json:"seller_proceeds"`
+	TxHash         string    `gorm:"uniqueIndex:idx_market_sale_tx_log;not null" json:"tx_hash"`
+	LogIndex       uint      `gorm:"uniqueIndex:idx_market_sale_tx_log;not null" json:"log_index"`
+	SoldAt         time.Time `gorm:"index" json:"sold_at"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// TableName overrides the table name
+func (MarketSale) TableName() string {
+	return "market_sales"
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Voucher statuses
+const (
+	VoucherIssued    = "issued"    // Granted, waiting for the player to claim it
+	VoucherClaimed   = "claimed"   // Claimed by the player, waiting for fulfilment
+	VoucherFulfilled = "fulfilled" // Reward delivered
+)
+
+// RewardVoucher is an off-chain reward owed to a wallet, such as the
+// compensation for hitting pity on a case type
+type RewardVoucher struct {
+	ID              uint           `gorm:"primarykey" json:"id"`
+	Code            string         `gorm:"uniqueIndex;not null" json:"code"`
+	WalletAddress   string         `gorm:"index;not null" json:"wallet_address"`
+	Kind            string         `gorm:"not null" json:"kind"` // "pity"
+	CaseType        string         `gorm:"index" json:"case_type"`
+	Rarity          string         `json:"rarity"` // Guaranteed minimum rarity
+	SourceOpeningID *uint          `gorm:"uniqueIndex" json:"source_opening_id"`
+	Status          string         `gorm:"index;not null" json:"status"`
+	IssuedAt        time.Time      `json:"issued_at"`
+	ClaimedAt       *time.Time     `json:"claimed_at,omitempty"`
+	FulfilledAt     *time.Time     `json:"fulfilled_at,omitempty"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// TableName overrides the table name
+func (RewardVoucher) TableName() string {
+	return "reward_vouchers"
+}
@@ -0,0 +1,52 @@
+package notify
+
+import (
+	"context"
+	"sync"
+)
+
+// Delivery is a message recorded by a Local channel
+type Delivery struct {
+	Destination string
+	Message     Message
+}
+
+// Local stands in for a real channel: it logs and records every message and
+// accepts any destination
+type Local struct {
+	name string
+
+	mu   sync.Mutex
+	sent []Delivery
+}
+
+func NewLocal(name string) *Local {
+	return &Local{name: name}
+}
+
+// Send implements Channel
+func (l *Local) Send(ctx context.Context, destination string, msg Message) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sent = append(l.sent, Delivery{Destination: destination, Message: msg})
+	logger.InfoContext(ctx, "local notification", "channel", l.name, "destination", destination, "title", msg.Title, "body", msg.Body)
+	return nil
+}
+
+// Validate implements Channel, checking destinations the way the real
+// channel would
+func (l *Local) Validate(destination string) error {
+	if validate, ok := validators[l.name]; ok {
+		return validate(destination)
+	}
+	return nil
+}
+
+// Sent returns a copy of every recorded delivery
+func (l *Local) Sent() []Delivery {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return append([]Delivery(nil), l.sent...)
+}
@@ -0,0 +1,102 @@
+// Package notify delivers notifications to players over external channels.
+// Every channel has a local stand-in that records messages instead of
+// sending them, used whenever the real channel is not configured.
+package notify
+
+import (
+	"brainrot-tamagotchi/internal/config"
+	"brainrot-tamagotchi/pkg/logging"
+	"context"
+	"errors"
+)
+
+var logger = logging.For("notify")
+
+// Channel names, also the keys of NotificationPreference.Channels
+const (
+	ChannelWebhook  = "webhook"
+	ChannelEmail    = "email"
+	ChannelTelegram = "telegram"
+	ChannelWebPush  = "webpush"
+)
+
+// ErrGone is returned when a destination no longer exists (e.g. an expired
+// push subscription) and should be removed from the wallet's preferences
+var ErrGone = errors.New("destination no longer exists")
+
+// Message is one notification rendered for delivery
+type Message struct {
+	ID     uint                   `json:"id"`
+	Wallet string                 `json:"wallet"`
+	Kind   string                 `json:"kind"`
+	Title  string                 `json:"title"`
+	Body   string                 `json:"body"`
+	Data   map[string]interface{} `json:"data,omitempty"`
+}
+
+// Channel sends a message to a channel-specific destination
+type Channel interface {
+	Send(ctx context.Context, destination string, msg Message) error
+	// Validate checks a destination before it is saved to preferences
+	Validate(destination string) error
+}
+
+// validators check destinations per channel
+var validators = map[string]func(string) error{
+	ChannelWebhook:  validateWebhookURL,
+	ChannelEmail:    validateEmail,
+	ChannelTelegram: validateTelegramChat,
+	ChannelWebPush:  validatePushSubscription,
+}
+
+// NewChannels builds every channel, using the real adapter when its
+// settings are present and the local stand-in otherwise:
+//
+//	webhook:  WebhookSecret (optional; always real)
+//	email:    SMTPHost, SMTPPort, SMTPUser, SMTPPassword, SMTPFrom
+//	telegram: TelegramToken
+//	webpush:  VAPIDPublicKey, VAPIDPrivateKey, VAPIDSubject
+//
+// Local forces the stand-ins for every channel.
+func NewChannels(cfg config.Notify) (map[string]Channel, error) {
+	if cfg.Local {
+		logger.Warn("NOTIFY_LOCAL set, notifications are only logged")
+		return map[string]Channel{
+			ChannelWebhook:  NewLocal(ChannelWebhook),
+			ChannelEmail:    NewLocal(ChannelEmail),
+			ChannelTelegram: NewLocal(ChannelTelegram),
+			ChannelWebPush:  NewLocal(ChannelWebPush),
+		}, nil
+	}
+
+	channels := map[string]Channel{
+		ChannelWebhook: NewWebhook(cfg.WebhookSecret),
+	}
+
+	if cfg.SMTPHost != "" {
+		channels[ChannelEmail] = NewSMTP(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUser, cfg.SMTPPassword, cfg.SMTPFrom)
+	} else {
+		logger.Warn("SMTP_HOST not set, email notifications are only logged")
+		channels[ChannelEmail] = NewLocal(ChannelEmail)
+	}
+
+	if cfg.TelegramToken != "" {
+		channels[ChannelTelegram] = NewTelegram(cfg.TelegramToken)
+	} else {
+		logger.Warn("TELEGRAM_BOT_TOKEN not set, Telegram notifications are only logged")
+		channels[ChannelTelegram] = NewLocal(ChannelTelegram)
+	}
+
+	if cfg.VAPIDPrivateKey != "" {
+		push, err := NewWebPush(cfg.VAPIDPublicKey, cfg.VAPIDPrivateKey, cfg.VAPIDSubject)
+		if err != nil {
+			return nil, err
+		}
+		channels[ChannelWebPush] = push
+	} else {
+		logger.Warn("VAPID_PRIVATE_KEY not set, web push notifications are only logged")
+		channels[ChannelWebPush] = NewLocal(ChannelWebPush)
+	}
+
+	return channels, nil
+}
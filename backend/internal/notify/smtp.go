@@ -0,0 +1,70 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// SMTP sends plain-text email through a relay
+type SMTP struct {
+	addr string
+	host string
+	auth smtp.Auth
+	from string
+}
+
+func NewSMTP(host, port, username, password, from string) *SMTP {
+	s := &SMTP{
+		addr: net.JoinHostPort(host, port),
+		host: host,
+		from: from,
+	}
+	if username != "" {
+		s.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return s
+}
+
+// Send implements Channel
+func (s *SMTP) Send(ctx context.Context, destination string, msg Message) error {
+	headers := []string{
+		"From: " + s.from,
+		"To: " + destination,
+		"Subject: " + mime.QEncoding.Encode("utf-8", msg.Title),
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=utf-8",
+	}
+	body := strings.Join(headers, "\r\n") + "\r\n\r\n" + msg.Body + "\r\n"
+
+	// net/smtp has no context support; bound the send with the deadline
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(s.addr, s.auth, s.from, []string{destination}, []byte(body))
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Validate implements Channel
+func (s *SMTP) Validate(destination string) error {
+	return validateEmail(destination)
+}
+
+func validateEmail(destination string) error {
+	addr, err := mail.ParseAddress(destination)
+	if err != nil || addr.Address != destination {
+		return fmt.Errorf("invalid email address")
+	}
+	return nil
+}
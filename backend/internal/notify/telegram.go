@@ -0,0 +1,77 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Telegram sends messages through a bot to a chat ID. The player must have
+// started a chat with the bot first.
+type Telegram struct {
+	baseURL string
+	client  *http.Client
+}
+
+func NewTelegram(botToken string) *Telegram {
+	return &Telegram{
+		baseURL: "https://api.telegram.org/bot" + botToken,
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Send implements Channel
+func (t *Telegram) Send(ctx context.Context, destination string, msg Message) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"chat_id": destination,
+		"text":    msg.Title + "\n\n" + msg.Body,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.baseURL+"/sendMessage", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		OK          bool   `json:"ok"`
+		ErrorCode   int    `json:"error_code"`
+		Description string `json:"description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("telegram returned %d", resp.StatusCode)
+	}
+	if !result.OK {
+		// 403: the player blocked the bot; 400 "chat not found"
+		if result.ErrorCode == http.StatusForbidden || result.ErrorCode == http.StatusBadRequest {
+			return fmt.Errorf("%w: %s", ErrGone, result.Description)
+		}
+		return fmt.Errorf("telegram error %d: %s", result.ErrorCode, result.Description)
+	}
+	return nil
+}
+
+// Validate implements Channel
+func (t *Telegram) Validate(destination string) error {
+	return validateTelegramChat(destination)
+}
+
+func validateTelegramChat(destination string) error {
+	if _, err := strconv.ParseInt(destination, 10, 64); err != nil {
+		return fmt.Errorf("telegram chat ID must be numeric")
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// Webhook POSTs messages as JSON to a player's HTTPS endpoint. With a secret,
+// each request carries X-Brainrot-Signature: hex HMAC-SHA256 of
+// "<X-Brainrot-Timestamp>.<body>".
+type Webhook struct {
+	secret []byte
+	client *http.Client
+}
+
+func NewWebhook(secret string) *Webhook {
+	return &Webhook{
+		secret: []byte(secret),
+		client: publicHTTPClient(10 * time.Second),
+	}
+}
+
+// publicHTTPClient only connects to public IPs and doesn't follow redirects,
+// so player-supplied URLs can't reach internal services
+func publicHTTPClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !isPublicIP(ip) {
+				return fmt.Errorf("address %s is not public", host)
+			}
+			return nil
+		},
+	}
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: &http.Transport{DialContext: dialer.DialContext},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// Send implements Channel
+func (w *Webhook) Send(ctx context.Context, destination string, msg Message) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, destination, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	if len(w.secret) > 0 {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		mac := hmac.New(sha256.New, w.secret)
+		mac.Write([]byte(timestamp + "."))
+		mac.Write(body)
+		req.Header.Set("X-Brainrot-Timestamp", timestamp)
+		req.Header.Set("X-Brainrot-Signature", hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode == http.StatusGone {
+		return ErrGone
+	}
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Validate implements Channel
+func (w *Webhook) Validate(destination string) error {
+	return validateWebhookURL(destination)
+}
+
+func validateWebhookURL(destination string) error {
+	u, err := url.Parse(destination)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("webhook must be an https URL")
+	}
+	return nil
+}
+
+func isPublicIP(ip net.IP) bool {
+	return ip.IsGlobalUnicast() && !ip.IsPrivate() && !ip.IsLoopback() && !ip.IsLinkLocalUnicast()
+}
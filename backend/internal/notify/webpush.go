@@ -0,0 +1,149 @@
+package notify
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// WebPush sends payload-less Web Push messages authenticated with VAPID
+// (RFC 8292). The push only wakes the service worker, which then fetches
+// GET /notifications for the content, so no payload encryption is needed.
+type WebPush struct {
+	key       *ecdsa.PrivateKey
+	publicKey string // base64url uncompressed point, sent as the VAPID k param
+	subject   string
+	client    *http.Client
+}
+
+// PushSubscription is the JSON of a browser PushSubscription
+type PushSubscription struct {
+	Endpoint string `json:"endpoint"`
+}
+
+// NewWebPush takes the VAPID key pair as base64url strings: the public key
+// is the 65-byte uncompressed point, the private key the 32-byte scalar.
+// subject is a mailto: or https: contact URL.
+func NewWebPush(publicKey, privateKey, subject string) (*WebPush, error) {
+	d, err := base64.RawURLEncoding.DecodeString(privateKey)
+	if err != nil || len(d) != 32 {
+		return nil, fmt.Errorf("invalid VAPID_PRIVATE_KEY")
+	}
+	if subject == "" {
+		return nil, fmt.Errorf("VAPID_SUBJECT required")
+	}
+
+	curve := elliptic.P256()
+	key := &ecdsa.PrivateKey{D: new(big.Int).SetBytes(d)}
+	key.PublicKey.Curve = curve
+	key.PublicKey.X, key.PublicKey.Y = curve.ScalarBaseMult(d)
+
+	derived := base64.RawURLEncoding.EncodeToString(elliptic.Marshal(curve, key.X, key.Y))
+	if publicKey != "" && publicKey != derived {
+		return nil, fmt.Errorf("VAPID_PUBLIC_KEY does not match VAPID_PRIVATE_KEY")
+	}
+
+	return &WebPush{
+		key:       key,
+		publicKey: derived,
+		subject:   subject,
+		client:    publicHTTPClient(10 * time.Second),
+	}, nil
+}
+
+// PublicKey returns the applicationServerKey browsers subscribe with
+func (w *WebPush) PublicKey() string {
+	return w.publicKey
+}
+
+// Send implements Channel
+func (w *WebPush) Send(ctx context.Context, destination string, msg Message) error {
+	var sub PushSubscription
+	if err := json.Unmarshal([]byte(destination), &sub); err != nil {
+		return fmt.Errorf("invalid push subscription: %w", err)
+	}
+	endpoint, err := url.Parse(sub.Endpoint)
+	if err != nil {
+		return fmt.Errorf("invalid push endpoint: %w", err)
+	}
+
+	jwt, err := w.vapidToken(endpoint.Scheme + "://" + endpoint.Host)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.Endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("TTL", "86400")
+	req.Header.Set("Urgency", "normal")
+	req.Header.Set("Authorization", "vapid t="+jwt+", k="+w.publicKey)
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return ErrGone
+	case resp.StatusCode >= 300:
+		return fmt.Errorf("push service returned %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Validate implements Channel
+func (w *WebPush) Validate(destination string) error {
+	return validatePushSubscription(destination)
+}
+
+// vapidToken signs the ES256 JWT identifying this server to a push service
+func (w *WebPush) vapidToken(audience string) (string, error) {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"typ":"JWT","alg":"ES256"}`))
+	claims, err := json.Marshal(map[string]interface{}{
+		"aud": audience,
+		"exp": time.Now().Add(12 * time.Hour).Unix(),
+		"sub": w.subject,
+	})
+	if err != nil {
+		return "", err
+	}
+	signingInput := header + "." + base64.RawURLEncoding.EncodeToString(claims)
+
+	hash := sha256.Sum256([]byte(signingInput))
+	r, s, err := ecdsa.Sign(rand.Reader, w.key, hash[:])
+	if err != nil {
+		return "", err
+	}
+	sig := make([]byte, 64)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:])
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+func validatePushSubscription(destination string) error {
+	var sub PushSubscription
+	if err := json.Unmarshal([]byte(destination), &sub); err != nil {
+		return fmt.Errorf("webpush must be a PushSubscription JSON")
+	}
+	u, err := url.Parse(sub.Endpoint)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("push endpoint must be an https URL")
+	}
+	return nil
+}
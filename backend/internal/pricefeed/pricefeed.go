@@ -0,0 +1,162 @@
+// Package pricefeed quotes wei amounts in USD from a pluggable ETH/USD source.
+package pricefeed
+
+import (
+	"brainrot-tamagotchi/internal/config"
+	"brainrot-tamagotchi/pkg/logging"
+	"brainrot-tamagotchi/pkg/money"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"sync"
+	"time"
+)
+
+var logger = logging.For("pricefeed")
+
+// ErrStale is returned when no rate fresher than the staleness limit exists
+var ErrStale = errors.New("ETH/USD rate is stale")
+
+// Rate is an ETH/USD price observation
+type Rate struct {
+	USDPerETH float64   `json:"usd_per_eth"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Source    string    `json:"source"`
+}
+
+// PriceFeed provides the current ETH/USD rate
+type PriceFeed interface {
+	ETHUSD(ctx context.Context) (Rate, error)
+}
+
+// NewFeed builds the configured feed, "file" or "http", wrapped in a cache
+// that reuses a rate for TTL and serves it for up to MaxStaleness
+func NewFeed(cfg config.PriceFeed) (PriceFeed, error) {
+	var source PriceFeed
+	switch cfg.Kind {
+	case "file":
+		source = NewFileFeed(cfg.File)
+	case "http":
+		if cfg.URL == "" {
+			return nil, fmt.Errorf("a URL is required for the http price feed")
+		}
+		source = NewHTTPFeed(cfg.URL)
+	default:
+		return nil, fmt.Errorf("unknown price feed %q", cfg.Kind)
+	}
+
+	return NewCachedFeed(source, cfg.TTL, cfg.MaxStaleness), nil
+}
+
+// CachedFeed reuses a rate for ttl and keeps serving the last good rate while
+// the source fails, until the rate is older than maxStaleness
+type CachedFeed struct {
+	source       PriceFeed
+	ttl          time.Duration
+	maxStaleness time.Duration
+
+	mu        sync.Mutex
+	last      *Rate
+	fetchedAt time.Time
+}
+
+func NewCachedFeed(source PriceFeed, ttl, maxStaleness time.Duration) *CachedFeed {
+	return &CachedFeed{
+		source:       source,
+		ttl:          ttl,
+		maxStaleness: maxStaleness,
+	}
+}
+
+// ETHUSD implements PriceFeed
+func (f *CachedFeed) ETHUSD(ctx context.Context) (Rate, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.last != nil && time.Since(f.fetchedAt) < f.ttl {
+		return *f.last, nil
+	}
+
+	rate, err := f.source.ETHUSD(ctx)
+	if err == nil && rate.USDPerETH <= 0 {
+		err = fmt.Errorf("invalid ETH/USD rate %v", rate.USDPerETH)
+	}
+	if err == nil && time.Since(rate.UpdatedAt) > f.maxStaleness {
+		err = fmt.Errorf("%w: source rate from %s", ErrStale, rate.UpdatedAt.Format(time.RFC3339))
+	}
+	if err != nil {
+		if f.last != nil && time.Since(f.last.UpdatedAt) <= f.maxStaleness {
+			logger.WarnContext(ctx, "price feed refresh failed, serving cached rate", "error", err)
+			// Back off until the next ttl instead of retrying on every call
+			f.fetchedAt = time.Now()
+			return *f.last, nil
+		}
+		return Rate{}, err
+	}
+
+	f.last = &rate
+	f.fetchedAt = time.Now()
+	return rate, nil
+}
+
+// Quote is an amount in wei alongside its ETH and USD equivalents.
+// USD is omitted when no fresh rate is available.
+type Quote struct {
+	Wei money.Wei `json:"wei"`
+	ETH string    `json:"eth"`
+	USD *float64  `json:"usd"`
+}
+
+// Quoter converts amounts using a PriceFeed
+type Quoter struct {
+	feed PriceFeed
+}
+
+func NewQuoter(feed PriceFeed) *Quoter {
+	return &Quoter{feed: feed}
+}
+
+// Rate returns the current rate, or nil when the feed is unavailable
+func (q *Quoter) Rate(ctx context.Context) *Rate {
+	if q == nil || q.feed == nil {
+		return nil
+	}
+	rate, err := q.feed.ETHUSD(ctx)
+	if err != nil {
+		logger.WarnContext(ctx, "ETH/USD rate unavailable", "error", err)
+		return nil
+	}
+	return &rate
+}
+
+// Quote prices an amount at the current rate
+func (q *Quoter) Quote(ctx context.Context, amount money.Wei) Quote {
+	return QuoteAt(amount, q.Rate(ctx))
+}
+
+// QuoteAt prices an amount at a known rate; a nil rate leaves USD empty
+func QuoteAt(amount money.Wei, rate *Rate) Quote {
+	quote := Quote{Wei: amount, ETH: amount.Ether()}
+	if rate != nil {
+		usd := WeiToUSD(amount, rate.USDPerETH)
+		quote.USD = &usd
+	}
+	return quote
+}
+
+// WeiToUSD converts wei to USD, rounded to the cent
+func WeiToUSD(amount money.Wei, usdPerETH float64) float64 {
+	return math.Round(amount.EtherFloat()*usdPerETH*100) / 100
+}
+
+// USDToWei converts a USD amount to wei at a rate, rounding down
+func USDToWei(usd, usdPerETH float64) money.Wei {
+	if usdPerETH <= 0 {
+		return money.Wei{}
+	}
+	ether := new(big.Rat).Quo(new(big.Rat).SetFloat64(usd), new(big.Rat).SetFloat64(usdPerETH))
+	ether.Mul(ether, new(big.Rat).SetInt(money.WeiPerEther))
+	return money.NewWei(new(big.Int).Quo(ether.Num(), ether.Denom()))
+}
@@ -0,0 +1,212 @@
+package pricefeed
+
+import (
+	"brainrot-tamagotchi/pkg/money"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// fakeSource returns queued results in order, repeating the last one
+type fakeSource struct {
+	results []result
+	calls   int
+}
+
+type result struct {
+	rate Rate
+	err  error
+}
+
+func (s *fakeSource) ETHUSD(ctx context.Context) (Rate, error) {
+	r := s.results[min(s.calls, len(s.results)-1)]
+	s.calls++
+	return r.rate, r.err
+}
+
+func rateAt(usd float64, age time.Duration) result {
+	return result{rate: Rate{USDPerETH: usd, UpdatedAt: time.Now().Add(-age), Source: "test"}}
+}
+
+func TestCachedFeed(t *testing.T) {
+	down := result{err: errors.New("price feed returned 503 Service Unavailable")}
+
+	tests := []struct {
+		name     string
+		results  []result
+		ttl      time.Duration
+		calls    int // ETHUSD calls on the cached feed
+		wantUSD  float64
+		wantErr  error // nil for any error when wantFail is set
+		wantFail bool
+		sourced  int // Source calls
+	}{
+		{"fresh rate", []result{rateAt(3000, time.Minute)}, time.Minute, 1, 3000, nil, false, 1},
+		{"reused within ttl", []result{rateAt(3000, 0), rateAt(3100, 0)}, time.Minute, 3, 3000, nil, false, 1},
+		{"refreshed after ttl", []result{rateAt(3000, 0), rateAt(3100, 0)}, 0, 2, 3100, nil, false, 2},
+		{"source down, nothing cached", []result{down}, time.Minute, 1, 0, nil, true, 1},
+		{"stale source rate", []result{rateAt(3000, 2*time.Hour)}, time.Minute, 1, 0, ErrStale, true, 1},
+		{"zero rate", []result{rateAt(0, 0)}, time.Minute, 1, 0, nil, true, 1},
+		{"negative rate", []result{rateAt(-1, 0)}, time.Minute, 1, 0, nil, true, 1},
+		{"source down, cached rate served", []result{rateAt(3000, time.Minute), down}, 0, 2, 3000, nil, false, 2},
+		{"stale source, cached rate served", []result{rateAt(3000, time.Minute), rateAt(2900, 2*time.Hour)}, 0, 2, 3000, nil, false, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := &fakeSource{results: tt.results}
+			feed := NewCachedFeed(source, tt.ttl, time.Hour)
+
+			var rate Rate
+			var err error
+			for i := 0; i < tt.calls; i++ {
+				rate, err = feed.ETHUSD(context.Background())
+			}
+
+			if tt.wantFail {
+				if err == nil {
+					t.Fatalf("rate = %+v, want an error", rate)
+				}
+				if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+			} else if err != nil || rate.USDPerETH != tt.wantUSD {
+				t.Fatalf("rate = %v, %v; want %v", rate.USDPerETH, err, tt.wantUSD)
+			}
+			if source.calls != tt.sourced {
+				t.Fatalf("source called %d times, want %d", source.calls, tt.sourced)
+			}
+		})
+	}
+}
+
+func TestCachedFeedBacksOffAfterFailure(t *testing.T) {
+	source := &fakeSource{results: []result{rateAt(3000, 0), {err: errors.New("timeout")}}}
+	feed := NewCachedFeed(source, time.Minute, time.Hour)
+	ctx := context.Background()
+
+	if _, err := feed.ETHUSD(ctx); err != nil {
+		t.Fatal(err)
+	}
+	// Expire the cache: the next call refreshes, fails and serves the cached rate
+	feed.fetchedAt = time.Now().Add(-2 * time.Minute)
+	for i := 0; i < 3; i++ {
+		rate, err := feed.ETHUSD(ctx)
+		if err != nil || rate.USDPerETH != 3000 {
+			t.Fatalf("call %d = %v, %v; want the cached 3000", i, rate.USDPerETH, err)
+		}
+	}
+	if source.calls != 2 {
+		t.Fatalf("source called %d times, want one retry per ttl", source.calls)
+	}
+}
+
+func TestCachedFeedStopsServingStaleRate(t *testing.T) {
+	source := &fakeSource{results: []result{rateAt(3000, 0), {err: errors.New("timeout")}}}
+	feed := NewCachedFeed(source, time.Minute, time.Hour)
+	ctx := context.Background()
+
+	if _, err := feed.ETHUSD(ctx); err != nil {
+		t.Fatal(err)
+	}
+	// The source has been down for longer than the staleness limit
+	feed.last.UpdatedAt = time.Now().Add(-61 * time.Minute)
+	feed.fetchedAt = time.Now().Add(-2 * time.Minute)
+	if rate, err := feed.ETHUSD(ctx); err == nil {
+		t.Fatalf("served %+v past the staleness limit", rate)
+	}
+}
+
+func TestFileFeed(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, body string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	rate, err := NewFileFeed(write("dated.json", `{"usd_per_eth": 3150.25, "updated_at": "2024-01-01T00:00:00Z"}`)).ETHUSD(context.Background())
+	if err != nil || rate.USDPerETH != 3150.25 || !rate.UpdatedAt.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("dated file = %+v, %v", rate, err)
+	}
+
+	path := write("undated.json", `{"usd_per_eth": 3000}`)
+	info, _ := os.Stat(path)
+	rate, err = NewFileFeed(path).ETHUSD(context.Background())
+	if err != nil || !rate.UpdatedAt.Equal(info.ModTime()) {
+		t.Fatalf("undated file = %+v, %v; want the modification time", rate, err)
+	}
+
+	if _, err := NewFileFeed(filepath.Join(dir, "missing.json")).ETHUSD(context.Background()); err == nil {
+		t.Fatal("a missing file returned a rate")
+	}
+	if _, err := NewFileFeed(write("bad.json", `{`)).ETHUSD(context.Background()); err == nil {
+		t.Fatal("an invalid file returned a rate")
+	}
+}
+
+func TestHTTPFeed(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantUSD float64
+		wantErr bool
+	}{
+		{"own shape", http.StatusOK, `{"usd_per_eth": 3150.25, "updated_at": "2024-01-01T00:00:00Z"}`, 3150.25, false},
+		{"CoinGecko shape", http.StatusOK, `{"ethereum": {"usd": 2999.5}}`, 2999.5, false},
+		{"server error", http.StatusServiceUnavailable, `{}`, 0, true},
+		{"invalid body", http.StatusOK, `<html>`, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			rate, err := NewHTTPFeed(server.URL).ETHUSD(context.Background())
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("rate = %+v, want an error", rate)
+				}
+				return
+			}
+			if err != nil || rate.USDPerETH != tt.wantUSD || rate.Source != "http" {
+				t.Fatalf("rate = %+v, %v; want %v", rate, err, tt.wantUSD)
+			}
+		})
+	}
+}
+
+func TestConversions(t *testing.T) {
+	tests := []struct {
+		ether     string
+		usdPerETH float64
+		usd       float64
+	}{
+		{"1", 3000, 3000},
+		{"0.01", 2000, 20},
+		{"0.0115", 2000, 23},
+		{"0.333333333333333333", 3000, 1000},
+	}
+	for _, tt := range tests {
+		if got := WeiToUSD(money.MustParseEther(tt.ether), tt.usdPerETH); got != tt.usd {
+			t.Errorf("WeiToUSD(%s ETH at %v) = %v, want %v", tt.ether, tt.usdPerETH, got, tt.usd)
+		}
+	}
+
+	if got := USDToWei(20, 2000); got.Cmp(money.MustParseEther("0.01")) != 0 {
+		t.Errorf("USDToWei(20 at 2000) = %s, want 0.01 ETH", got.Ether())
+	}
+	if got := USDToWei(20, 0); !got.IsZero() {
+		t.Errorf("USDToWei at a zero rate = %s, want 0", got)
+	}
+}
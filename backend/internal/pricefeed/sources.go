@@ -0,0 +1,119 @@
+package pricefeed
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+)
+
+// rateDocument is the JSON shape read by FileFeed and HTTPFeed. HTTPFeed also
+// accepts the CoinGecko simple-price shape {"ethereum":{"usd":...}}.
+type rateDocument struct {
+	USDPerETH float64    `json:"usd_per_eth"`
+	UpdatedAt *time.Time `json:"updated_at"`
+	Ethereum  *struct {
+		USD float64 `json:"usd"`
+	} `json:"ethereum"`
+}
+
+func (d rateDocument) usdPerETH() float64 {
+	if d.USDPerETH > 0 {
+		return d.USDPerETH
+	}
+	if d.Ethereum != nil {
+		return d.Ethereum.USD
+	}
+	return 0
+}
+
+// FileFeed reads the rate from a local JSON file, e.g.
+//
+//	{"usd_per_eth": 3150.25, "updated_at": "2024-01-01T00:00:00Z"}
+//
+// Without updated_at the file's modification time is used. It is meant for
+// local development and tests.
+type FileFeed struct {
+	path string
+}
+
+func NewFileFeed(path string) *FileFeed {
+	return &FileFeed{path: path}
+}
+
+// ETHUSD implements PriceFeed
+func (f *FileFeed) ETHUSD(ctx context.Context) (Rate, error) {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return Rate{}, fmt.Errorf("price file unavailable: %w", err)
+	}
+
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return Rate{}, fmt.Errorf("price file unavailable: %w", err)
+	}
+
+	var doc rateDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return Rate{}, fmt.Errorf("invalid price file: %w", err)
+	}
+
+	rate := Rate{
+		USDPerETH: doc.usdPerETH(),
+		UpdatedAt: info.ModTime(),
+		Source:    "file",
+	}
+	if doc.UpdatedAt != nil {
+		rate.UpdatedAt = *doc.UpdatedAt
+	}
+	return rate, nil
+}
+
+// HTTPFeed fetches the rate from an HTTP endpoint
+type HTTPFeed struct {
+	url    string
+	client *http.Client
+}
+
+func NewHTTPFeed(url string) *HTTPFeed {
+	return &HTTPFeed{
+		url:    url,
+		client: &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// ETHUSD implements PriceFeed
+func (f *HTTPFeed) ETHUSD(ctx context.Context) (Rate, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, f.url, nil)
+	if err != nil {
+		return Rate{}, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return Rate{}, fmt.Errorf("price feed request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return Rate{}, fmt.Errorf("price feed returned %s", resp.Status)
+	}
+
+	var doc rateDocument
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return Rate{}, fmt.Errorf("invalid price feed response: %w", err)
+	}
+
+	rate := Rate{
+		USDPerETH: doc.usdPerETH(),
+		UpdatedAt: time.Now(),
+		Source:    "http",
+	}
+	if doc.UpdatedAt != nil {
+		rate.UpdatedAt = *doc.UpdatedAt
+	}
+	return rate, nil
+}
@@ -0,0 +1,182 @@
+// Package realtime pushes game events to subscribed clients. Events are
+// published on a Redis channel so every API replica can fan them out to its
+// own connections.
+package realtime
+
+import (
+	"brainrot-tamagotchi/pkg/logging"
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+var logger = logging.For("realtime")
+
+// redisChannel carries every event between replicas
+const redisChannel = "realtime:events"
+
+// subscriberBuffer is how many events a subscriber may fall behind by before
+// it is disconnected
+const subscriberBuffer = 64
+
+// Event types
+const (
+	EventPetUpdated       = "pet.updated"
+	EventListingCreated   = "listing.created"
+	EventListingSold      = "listing.sold"
+	EventListingUpdated   = "listing.updated"
+	EventListingCancelled = "listing.cancelled"
+	EventCaseRevealed     = "case.revealed"
+)
+
+// Event is one message pushed to subscribers of Topic
+type Event struct {
+	Topic string          `json:"topic"`
+	Type  string          `json:"type"`
+	Data  json.RawMessage `json:"data"`
+	At    time.Time       `json:"at"`
+}
+
+// Hub fans events out to local subscribers. Publish goes through Redis so
+// subscribers on every replica receive it; without Redis, events are only
+// delivered locally.
+type Hub struct {
+	redis *redis.Client
+
+	mu   sync.Mutex
+	subs map[*Subscription]struct{}
+}
+
+func NewHub(redis *redis.Client) *Hub {
+	return &Hub{
+		redis: redis,
+		subs:  make(map[*Subscription]struct{}),
+	}
+}
+
+// Run relays events from Redis to local subscribers until ctx is done. The
+// Redis client reconnects the subscription on its own after network errors.
+func (h *Hub) Run(ctx context.Context) {
+	if h.redis == nil {
+		return
+	}
+
+	pubsub := h.redis.Subscribe(ctx, redisChannel)
+	defer pubsub.Close()
+
+	logger.Info("realtime hub listening on Redis")
+
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+			var event Event
+			if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+				logger.Warn("dropping malformed realtime event", "error", err)
+				continue
+			}
+			h.dispatch(event)
+		}
+	}
+}
+
+// Publish sends an event to every subscriber of topic. It never fails the
+// caller: a nil hub is a no-op, and Redis errors fall back to local delivery.
+func (h *Hub) Publish(topic Topic, eventType string, data interface{}) {
+	if h == nil {
+		return
+	}
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		logger.Error("failed to encode realtime event", "type", eventType, "error", err)
+		return
+	}
+	event := Event{Topic: topic.String(), Type: eventType, Data: raw, At: time.Now().UTC()}
+
+	if h.redis == nil {
+		h.dispatch(event)
+		return
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		logger.Error("failed to encode realtime event", "type", eventType, "error", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := h.redis.Publish(ctx, redisChannel, payload).Err(); err != nil {
+		logger.Warn("realtime publish to Redis failed, delivering locally", "error", err)
+		h.dispatch(event)
+	}
+}
+
+// Subscribe registers a subscriber for the given topics. Callers must Close
+// the subscription when done.
+func (h *Hub) Subscribe(topics []Topic) *Subscription {
+	sub := &Subscription{
+		hub:    h,
+		topics: make(map[string]bool, len(topics)),
+		events: make(chan Event, subscriberBuffer),
+	}
+	for _, topic := range topics {
+		sub.topics[topic.String()] = true
+	}
+
+	h.mu.Lock()
+	h.subs[sub] = struct{}{}
+	h.mu.Unlock()
+	return sub
+}
+
+// dispatch delivers an event to matching local subscribers, disconnecting
+// any whose buffer is full rather than blocking the others
+func (h *Hub) dispatch(event Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.subs {
+		if !sub.topics[event.Topic] {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			delete(h.subs, sub)
+			close(sub.events)
+		}
+	}
+}
+
+// Subscription receives the events of a fixed set of topics
+type Subscription struct {
+	hub    *Hub
+	topics map[string]bool
+	events chan Event
+}
+
+// Events is closed when the subscription is closed or falls too far behind
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Close unregisters the subscription
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+
+	if _, ok := s.hub.subs[s]; ok {
+		delete(s.hub.subs, s)
+		close(s.events)
+	}
+}
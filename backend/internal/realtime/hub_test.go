@@ -0,0 +1,94 @@
+package realtime
+
+import "testing"
+
+// drain reads the events buffered for sub and reports whether it was closed
+func drain(sub *Subscription) (events int, closed bool) {
+	for {
+		select {
+		case _, ok := <-sub.Events():
+			if !ok {
+				return events, true
+			}
+			events++
+		default:
+			return events, false
+		}
+	}
+}
+
+func TestHubDeliversByTopic(t *testing.T) {
+	hub := NewHub(nil)
+	pet := hub.Subscribe([]Topic{PetTopic(1)})
+	defer pet.Close()
+	market := hub.Subscribe([]Topic{MarketplaceTopic(), PetTopic(2)})
+	defer market.Close()
+
+	hub.Publish(PetTopic(1), EventPetUpdated, map[string]int{"hunger": 50})
+	hub.Publish(MarketplaceTopic(), EventListingCreated, map[string]int{"token_id": 2})
+
+	select {
+	case event := <-pet.Events():
+		if event.Topic != "pet:1" || event.Type != EventPetUpdated || string(event.Data) != `{"hunger":50}` {
+			t.Errorf("pet event = %+v", event)
+		}
+	default:
+		t.Fatal("pet subscriber got nothing")
+	}
+	if n, _ := drain(pet); n != 0 {
+		t.Errorf("pet subscriber got %d events of other topics", n)
+	}
+	if n, closed := drain(market); n != 1 || closed {
+		t.Errorf("marketplace subscriber got %d events, closed %v; want 1", n, closed)
+	}
+
+	// A nil hub is a no-op
+	var none *Hub
+	none.Publish(PetTopic(1), EventPetUpdated, nil)
+}
+
+func TestHubDropsSlowSubscriber(t *testing.T) {
+	hub := NewHub(nil)
+	slow := hub.Subscribe([]Topic{PetTopic(1)})
+	fast := hub.Subscribe([]Topic{PetTopic(1)})
+	defer fast.Close()
+
+	for i := 0; i < subscriberBuffer+1; i++ {
+		hub.Publish(PetTopic(1), EventPetUpdated, i)
+		if n, closed := drain(fast); n != 1 || closed {
+			t.Fatalf("event %d: fast subscriber got %d events, closed %v", i, n, closed)
+		}
+	}
+
+	// The slow subscriber keeps what it buffered, then sees its channel close
+	if n, closed := drain(slow); n != subscriberBuffer || !closed {
+		t.Errorf("slow subscriber: %d events, closed %v; want %d then closed", n, closed, subscriberBuffer)
+	}
+
+	hub.mu.Lock()
+	_, registered := hub.subs[slow]
+	hub.mu.Unlock()
+	if registered {
+		t.Error("dropped subscriber is still registered")
+	}
+
+	// Closing a dropped subscription does not close its channel twice
+	slow.Close()
+
+	hub.Publish(PetTopic(1), EventPetUpdated, "after")
+	if n, closed := drain(fast); n != 1 || closed {
+		t.Errorf("fast subscriber after the drop: %d events, closed %v", n, closed)
+	}
+}
+
+func TestSubscriptionClose(t *testing.T) {
+	hub := NewHub(nil)
+	sub := hub.Subscribe([]Topic{CasesTopic()})
+	sub.Close()
+	sub.Close()
+
+	if _, ok := <-sub.Events(); ok {
+		t.Error("closed subscription still delivers")
+	}
+	hub.Publish(CasesTopic(), EventCaseRevealed, nil)
+}
@@ -0,0 +1,97 @@
+package realtime
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidToken is returned for malformed, forged or expired tokens
+var ErrInvalidToken = errors.New("invalid or expired subscription token")
+
+// TokenSigner issues short-lived tokens that let a wallet subscribe to its
+// private topics. EventSource cannot send headers, so the token travels in
+// the query string instead of a signature per request.
+type TokenSigner struct {
+	secret []byte
+	ttl    time.Duration
+}
+
+// NewTokenSigner signs with secret. Without one a random secret is used, so
+// tokens only work on the replica that issued them.
+func NewTokenSigner(secretKey string, ttl time.Duration) *TokenSigner {
+	secret := []byte(secretKey)
+	if len(secret) == 0 {
+		logger.Warn("REALTIME_TOKEN_SECRET not set, using a per-process secret")
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			panic(err)
+		}
+	}
+	return &TokenSigner{secret: secret, ttl: ttl}
+}
+
+// Issue returns a token for address and when it expires
+func (s *TokenSigner) Issue(address string) (string, time.Time) {
+	expiresAt := time.Now().Add(s.ttl).UTC()
+	payload := strings.ToLower(address) + "|" + strconv.FormatInt(expiresAt.Unix(), 10)
+	encoded := base64.RawURLEncoding.EncodeToString([]byte(payload))
+	return encoded + "." + s.sign(encoded), expiresAt
+}
+
+// Verify returns the wallet address a token was issued for
+func (s *TokenSigner) Verify(token string) (string, error) {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(s.sign(encoded))) {
+		return "", ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", ErrInvalidToken
+	}
+	address, expiry, ok := strings.Cut(string(payload), "|")
+	if !ok {
+		return "", ErrInvalidToken
+	}
+	unix, err := strconv.ParseInt(expiry, 10, 64)
+	if err != nil || time.Now().After(time.Unix(unix, 0)) {
+		return "", ErrInvalidToken
+	}
+	return address, nil
+}
+
+func (s *TokenSigner) sign(encoded string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(encoded))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Authorize checks that every private topic belongs to the token's wallet
+func (s *TokenSigner) Authorize(topics []Topic, token string) error {
+	var address string
+	for _, topic := range topics {
+		if !topic.Private() {
+			continue
+		}
+		if address == "" {
+			if token == "" {
+				return fmt.Errorf("topic %s requires a subscription token", topic)
+			}
+			var err error
+			if address, err = s.Verify(token); err != nil {
+				return err
+			}
+		}
+		if topic.Key != address {
+			return fmt.Errorf("token does not grant topic %s", topic)
+		}
+	}
+	return nil
+}
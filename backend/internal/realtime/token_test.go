@@ -0,0 +1,83 @@
+package realtime
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testWallet = "0x00000000000000000000000000000000000000Aa"
+
+func TestTokenVerify(t *testing.T) {
+	signer := NewTokenSigner("secret", time.Minute)
+	token, expiresAt := signer.Issue(testWallet)
+	if until := time.Until(expiresAt); until <= 0 || until > time.Minute {
+		t.Errorf("token expires in %s, want within a minute", until)
+	}
+
+	address, err := signer.Verify(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if address != strings.ToLower(testWallet) {
+		t.Errorf("Verify = %s, want the lowercase address", address)
+	}
+
+	// Another replica with the same secret accepts it
+	if _, err := NewTokenSigner("secret", time.Minute).Verify(token); err != nil {
+		t.Errorf("same secret: %v", err)
+	}
+
+	encoded, signature, _ := strings.Cut(token, ".")
+	otherWallet := base64.RawURLEncoding.EncodeToString([]byte("0xbb|9999999999"))
+	expired, _ := NewTokenSigner("secret", -time.Minute).Issue(testWallet)
+	noExpiry := base64.RawURLEncoding.EncodeToString([]byte(testWallet))
+
+	invalid := map[string]string{
+		"empty":             "",
+		"no signature":      encoded,
+		"other secret":      tokenFrom(NewTokenSigner("other", time.Minute), testWallet),
+		"payload swapped":   otherWallet + "." + signature,
+		"signature changed": encoded + "." + strings.Repeat("A", len(signature)),
+		"expired":           expired,
+		"no expiry":         noExpiry + "." + signer.sign(noExpiry),
+		"not base64":        "!!!." + signer.sign("!!!"),
+	}
+	for name, token := range invalid {
+		if _, err := signer.Verify(token); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("%s: Verify = %v, want ErrInvalidToken", name, err)
+		}
+	}
+}
+
+func tokenFrom(signer *TokenSigner, address string) string {
+	token, _ := signer.Issue(address)
+	return token
+}
+
+func TestTokenAuthorize(t *testing.T) {
+	signer := NewTokenSigner("secret", time.Minute)
+	token := tokenFrom(signer, testWallet)
+	other := WalletTopic("0x00000000000000000000000000000000000000bB")
+
+	tests := []struct {
+		name    string
+		topics  []Topic
+		token   string
+		wantErr bool
+	}{
+		{"public topics need no token", []Topic{PetTopic(1), MarketplaceTopic(), CasesTopic()}, "", false},
+		{"own wallet", []Topic{PetTopic(1), WalletTopic(testWallet)}, token, false},
+		{"wallet without a token", []Topic{WalletTopic(testWallet)}, "", true},
+		{"someone else's wallet", []Topic{WalletTopic(testWallet), other}, token, true},
+		{"invalid token", []Topic{WalletTopic(testWallet)}, token + "x", true},
+		{"expired token", []Topic{WalletTopic(testWallet)}, tokenFrom(NewTokenSigner("secret", -time.Minute), testWallet), true},
+	}
+	for _, tt := range tests {
+		if err := signer.Authorize(tt.topics, tt.token); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Authorize = %v, want error %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package realtime
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Topic kinds
+const (
+	TopicPet         = "pet"         // pet:<token id>, stat changes of one pet
+	TopicWallet      = "wallet"      // wallet:<address>, private to that wallet
+	TopicMarketplace = "marketplace" // listing created/sold/cancelled
+	TopicCases       = "cases"       // every case reveal
+)
+
+// Topic is a parsed subscription topic
+type Topic struct {
+	Kind string
+	Key  string // Token ID or lowercase wallet address; empty for global topics
+}
+
+func PetTopic(tokenID uint) Topic {
+	return Topic{Kind: TopicPet, Key: strconv.FormatUint(uint64(tokenID), 10)}
+}
+
+func WalletTopic(address string) Topic {
+	return Topic{Kind: TopicWallet, Key: strings.ToLower(address)}
+}
+
+func MarketplaceTopic() Topic {
+	return Topic{Kind: TopicMarketplace}
+}
+
+func CasesTopic() Topic {
+	return Topic{Kind: TopicCases}
+}
+
+// ParseTopic parses "pet:<id>", "wallet:<address>", "marketplace" or "cases"
+func ParseTopic(s string) (Topic, error) {
+	kind, key, _ := strings.Cut(strings.TrimSpace(s), ":")
+	switch kind {
+	case TopicPet:
+		id, err := strconv.ParseUint(key, 10, 32)
+		if err != nil {
+			return Topic{}, fmt.Errorf("invalid pet topic %q", s)
+		}
+		return PetTopic(uint(id)), nil
+
+	case TopicWallet:
+		if !common.IsHexAddress(key) {
+			return Topic{}, fmt.Errorf("invalid wallet topic %q", s)
+		}
+		return WalletTopic(key), nil
+
+	case TopicMarketplace, TopicCases:
+		if key != "" {
+			return Topic{}, fmt.Errorf("topic %q takes no key", kind)
+		}
+		return Topic{Kind: kind}, nil
+	}
+	return Topic{}, fmt.Errorf("unknown topic %q", s)
+}
+
+// Private reports whether subscribing needs a token for the topic's wallet
+func (t Topic) Private() bool {
+	return t.Kind == TopicWallet
+}
+
+func (t Topic) String() string {
+	if t.Key == "" {
+		return t.Kind
+	}
+	return t.Kind + ":" + t.Key
+}
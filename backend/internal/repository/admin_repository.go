@@ -0,0 +1,184 @@
+package repository
+
+import (
+	"brainrot-tamagotchi/internal/models"
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type AdminRepository struct {
+	db *gorm.DB
+}
+
+func NewAdminRepository(db *gorm.DB) *AdminRepository {
+	return &AdminRepository{db: db}
+}
+
+// AuditFilter narrows an audit log query. Empty fields match everything.
+type AuditFilter struct {
+	ActorAddress string
+	Action       string
+	TargetType   string
+	TargetID     string
+}
+
+// GetRole returns a wallet's admin role
+func (r *AdminRepository) GetRole(walletAddress string) (*models.AdminRole, error) {
+	var role models.AdminRole
+	err := r.db.Where("wallet_address = ?", strings.ToLower(walletAddress)).First(&role).Error
+	if err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+// GetRoles lists every wallet with an admin role
+func (r *AdminRepository) GetRoles() ([]models.AdminRole, error) {
+	var roles []models.AdminRole
+	err := r.db.Order("wallet_address").Find(&roles).Error
+	return roles, err
+}
+
+// SetRole grants or changes a wallet's role, restoring a revoked row
+func (r *AdminRepository) SetRole(role *models.AdminRole) error {
+	role.WalletAddress = strings.ToLower(role.WalletAddress)
+
+	var existing models.AdminRole
+	err := r.db.Unscoped().Where("wallet_address = ?", role.WalletAddress).First(&existing).Error
+	if err == nil {
+		role.ID = existing.ID
+		role.CreatedAt = existing.CreatedAt
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return r.db.Unscoped().Save(role).Error
+}
+
+// DeleteRole revokes a wallet's role
+func (r *AdminRepository) DeleteRole(walletAddress string) error {
+	return r.db.Where("wallet_address = ?", strings.ToLower(walletAddress)).Delete(&models.AdminRole{}).Error
+}
+
+// CreateAuditLog records an admin request
+func (r *AdminRepository) CreateAuditLog(entry *models.AdminAuditLog) error {
+	entry.ActorAddress = strings.ToLower(entry.ActorAddress)
+	return r.db.Create(entry).Error
+}
+
+// GetAuditLogs returns audit entries, newest first, with the total count
+func (r *AdminRepository) GetAuditLogs(filter AuditFilter, limit, offset int) ([]models.AdminAuditLog, int64, error) {
+	var entries []models.AdminAuditLog
+	var total int64
+
+	query := r.db.Model(&models.AdminAuditLog{})
+	if filter.ActorAddress != "" {
+		query = query.Where("actor_address = ?", strings.ToLower(filter.ActorAddress))
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.TargetType != "" {
+		query = query.Where("target_type = ?", filter.TargetType)
+	}
+	if filter.TargetID != "" {
+		query = query.Where("target_id = ?", filter.TargetID)
+	}
+	query = query.Session(&gorm.Session{})
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("created_at DESC, id DESC").Limit(limit).Offset(offset).Find(&entries).Error
+	return entries, total, err
+}
+
+// CreateBan bans a wallet
+func (r *AdminRepository) CreateBan(ban *models.WalletBan) error {
+	ban.WalletAddress = strings.ToLower(ban.WalletAddress)
+	return r.db.Create(ban).Error
+}
+
+// GetActiveBan returns the ban in force for a wallet, if any
+func (r *AdminRepository) GetActiveBan(walletAddress string, now time.Time) (*models.WalletBan, error) {
+	var ban models.WalletBan
+	err := r.db.Where("wallet_address = ? AND (expires_at IS NULL OR expires_at > ?)", strings.ToLower(walletAddress), now).
+		Order("created_at DESC").
+		First(&ban).Error
+	if err != nil {
+		return nil, err
+	}
+	return &ban, nil
+}
+
+// GetActiveBans lists the bans in force
+func (r *AdminRepository) GetActiveBans(now time.Time) ([]models.WalletBan, error) {
+	var bans []models.WalletBan
+	err := r.db.Where("expires_at IS NULL OR expires_at > ?", now).
+		Order("created_at DESC").
+		Find(&bans).Error
+	return bans, err
+}
+
+// LiftBans lifts every ban on a wallet and returns how many were lifted
+func (r *AdminRepository) LiftBans(walletAddress string) (int64, error) {
+	result := r.db.Where("wallet_address = ?", strings.ToLower(walletAddress)).Delete(&models.WalletBan{})
+	return result.RowsAffected, result.Error
+}
+
+// CreateContractCall queues an owner contract call
+func (r *AdminRepository) CreateContractCall(job *models.ContractCallJob) error {
+	return r.db.Create(job).Error
+}
+
+// CreateContractCallOnce queues an owner contract call and reports whether it
+// was new. A call whose idempotency key is held by a pending call is skipped.
+func (r *AdminRepository) CreateContractCallOnce(job *models.ContractCallJob) (bool, error) {
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(job)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// GetPendingContractCall returns the queued or sent job holding an
+// idempotency key
+func (r *AdminRepository) GetPendingContractCall(idempotencyKey string) (*models.ContractCallJob, error) {
+	var job models.ContractCallJob
+	err := r.db.Where("idempotency_key = ? AND status IN ?", idempotencyKey, []string{models.ContractCallQueued, models.ContractCallSent}).
+		First(&job).Error
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// UpdateContractCall saves all fields of a contract call job
+func (r *AdminRepository) UpdateContractCall(job *models.ContractCallJob) error {
+	return r.db.Save(job).Error
+}
+
+// GetContractCalls lists contract call jobs, newest first. An empty status
+// returns every status.
+func (r *AdminRepository) GetContractCalls(status string, limit, offset int) ([]models.ContractCallJob, error) {
+	var jobs []models.ContractCallJob
+	query := r.db
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("id DESC").Limit(limit).Offset(offset).Find(&jobs).Error
+	return jobs, err
+}
+
+// GetPendingContractCalls returns queued and sent jobs, oldest first
+func (r *AdminRepository) GetPendingContractCalls() ([]models.ContractCallJob, error) {
+	var jobs []models.ContractCallJob
+	err := r.db.Where("status IN ?", []string{models.ContractCallQueued, models.ContractCallSent}).
+		Order("id ASC").
+		Find(&jobs).Error
+	return jobs, err
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"brainrot-tamagotchi/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type CaseDefinitionRepository struct {
+	db *gorm.DB
+}
+
+func NewCaseDefinitionRepository(db *gorm.DB) *CaseDefinitionRepository {
+	return &CaseDefinitionRepository{db: db}
+}
+
+// Create creates a new case definition
+func (r *CaseDefinitionRepository) Create(def *models.CaseDefinition) error {
+	return r.db.Create(def).Error
+}
+
+// Update saves all fields of a case definition
+func (r *CaseDefinitionRepository) Update(def *models.CaseDefinition) error {
+	return r.db.Save(def).Error
+}
+
+// GetBySlug retrieves a case definition by slug
+func (r *CaseDefinitionRepository) GetBySlug(slug string) (*models.CaseDefinition, error) {
+	var def models.CaseDefinition
+	err := r.db.Where("slug = ?", slug).First(&def).Error
+	if err != nil {
+		return nil, err
+	}
+	return &def, nil
+}
+
+// GetAll returns every case definition in catalog order
+func (r *CaseDefinitionRepository) GetAll() ([]models.CaseDefinition, error) {
+	var defs []models.CaseDefinition
+	err := r.db.Order("sort_order ASC, id ASC").Find(&defs).Error
+	return defs, err
+}
+
+// GetByContractCaseType returns the definitions backed by a contract case type
+func (r *CaseDefinitionRepository) GetByContractCaseType(caseType string) ([]models.CaseDefinition, error) {
+	var defs []models.CaseDefinition
+	err := r.db.Where("contract_case_type = ?", caseType).
+		Order("sort_order ASC, id ASC").
+		Find(&defs).Error
+	return defs, err
+}
@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"brainrot-tamagotchi/internal/models"
+	"brainrot-tamagotchi/pkg/money"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CaseOpeningRepository struct {
+	db *gorm.DB
+}
+
+func NewCaseOpeningRepository(db *gorm.DB) *CaseOpeningRepository {
+	return &CaseOpeningRepository{db: db}
+}
+
+// WithContext returns a copy of the repository whose queries use ctx
+func (r *CaseOpeningRepository) WithContext(ctx context.Context) CaseOpeningStore {
+	return &CaseOpeningRepository{db: r.db.WithContext(ctx)}
+}
+
+// DateRange bounds a query on opened_at. Nil ends are open.
+type DateRange struct {
+	From *time.Time
+	To   *time.Time
+}
+
+// CaseTypeTotals holds the opening count and revenue for one case type
+type CaseTypeTotals struct {
+	CaseType string    `json:"case_type"`
+	Count    int64     `json:"count"`
+	Revenue  money.Wei `json:"revenue"`
+}
+
+// RarityCount holds how many openings of a case type rolled a rarity
+type RarityCount struct {
+	CaseType string `json:"case_type"`
+	Rarity   string `json:"rarity"`
+	Count    int64  `json:"count"`
+}
+
+// Create records a case opening; re-recording the same (tx hash, log index)
+// is a no-op
+func (r *CaseOpeningRepository) Create(opening *models.CaseOpening) error {
+	opening.UserAddress = strings.ToLower(opening.UserAddress)
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(opening).Error
+}
+
+// GetByUser retrieves a user's case openings, newest first, with the total count
+func (r *CaseOpeningRepository) GetByUser(userAddress string, dateRange DateRange, limit, offset int) ([]models.CaseOpening, int64, error) {
+	var openings []models.CaseOpening
+	var total int64
+
+	query := r.applyDateRange(r.db.Model(&models.CaseOpening{}), dateRange).
+		Where("user_address = ?", strings.ToLower(userAddress)).
+		Session(&gorm.Session{})
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("opened_at DESC").Limit(limit).Offset(offset).Find(&openings).Error
+	return openings, total, err
+}
+
+// GetByUserAndCaseType returns all of a user's openings of one case type,
+// oldest first
+func (r *CaseOpeningRepository) GetByUserAndCaseType(userAddress, caseType string) ([]models.CaseOpening, error) {
+	var openings []models.CaseOpening
+	err := r.db.Where("user_address = ? AND case_type = ?", strings.ToLower(userAddress), caseType).
+		Order("opened_at ASC, id ASC").
+		Find(&openings).Error
+	return openings, err
+}
+
+// GetAfterID returns up to limit openings with an ID above afterID, in ID order
+func (r *CaseOpeningRepository) GetAfterID(afterID uint, limit int) ([]models.CaseOpening, error) {
+	var openings []models.CaseOpening
+	err := r.db.Where("id > ?", afterID).
+		Order("id ASC").
+		Limit(limit).
+		Find(&openings).Error
+	return openings, err
+}
+
+// GetLatestID returns the highest opening ID, or 0 if there are none
+func (r *CaseOpeningRepository) GetLatestID() (uint, error) {
+	var id uint
+	err := r.db.Model(&models.CaseOpening{}).
+		Select("COALESCE(MAX(id), 0)").
+		Scan(&id).Error
+	return id, err
+}
+
+// GetTotalsByCaseType aggregates count and revenue per case type.
+// An empty userAddress aggregates across all users.
+func (r *CaseOpeningRepository) GetTotalsByCaseType(userAddress string, dateRange DateRange) ([]CaseTypeTotals, error) {
+	var totals []CaseTypeTotals
+	query := r.applyDateRange(r.db.Model(&models.CaseOpening{}), dateRange)
+	if userAddress != "" {
+		query = query.Where("user_address = ?", strings.ToLower(userAddress))
+	}
+
+	err := query.Select("case_type, COUNT(*) AS count, COALESCE(SUM(price), 0) AS revenue").
+		Group("case_type").
+		Order("case_type").
+		Scan(&totals).Error
+	return totals, err
+}
+
+// GetRarityDistribution counts rolled rarities per case type.
+// An empty userAddress aggregates across all users.
+func (r *CaseOpeningRepository) GetRarityDistribution(userAddress string, dateRange DateRange) ([]RarityCount, error) {
+	var counts []RarityCount
+	query := r.applyDateRange(r.db.Model(&models.CaseOpening{}), dateRange)
+	if userAddress != "" {
+		query = query.Where("user_address = ?", strings.ToLower(userAddress))
+	}
+
+	err := query.Select("case_type, rarity, COUNT(*) AS count").
+		Group("case_type, rarity").
+		Order("case_type, rarity").
+		Scan(&counts).Error
+	return counts, err
+}
+
+// GetLuckiestPulls returns the openings whose rolled rarity was least likely for
+// their case type. odds maps case type -> rarity -> probability (0-1).
+func (r *CaseOpeningRepository) GetLuckiestPulls(odds map[string]map[string]float64, dateRange DateRange, limit int) ([]models.CaseOpening, error) {
+	var openings []models.CaseOpening
+
+	var clauses []string
+	var args []interface{}
+	for caseType, rarities := range odds {
+		for rarity, chance := range rarities {
+			clauses = append(clauses, "WHEN case_type = ? AND rarity = ? THEN ?")
+			args = append(args, caseType, rarity, chance)
+		}
+	}
+	if len(clauses) == 0 {
+		return openings, nil
+	}
+	oddsExpr := fmt.Sprintf("CASE %s ELSE 1 END", strings.Join(clauses, " "))
+
+	err := r.applyDateRange(r.db.Model(&models.CaseOpening{}), dateRange).
+		Where(oddsExpr+" < 1", args...).
+		Clauses(clause.OrderBy{Expression: clause.Expr{
+			SQL:                oddsExpr + " ASC, opened_at DESC",
+			Vars:               args,
+			WithoutParentheses: true,
+		}}).
+		Limit(limit).
+		Find(&openings).Error
+	return openings, err
+}
+
+func (r *CaseOpeningRepository) applyDateRange(query *gorm.DB, dateRange DateRange) *gorm.DB {
+	if dateRange.From != nil {
+		query = query.Where("opened_at >= ?", *dateRange.From)
+	}
+	if dateRange.To != nil {
+		query = query.Where("opened_at < ?", *dateRange.To)
+	}
+	return query
+}
@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"brainrot-tamagotchi/internal/models"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ChainEventRepository struct {
+	db *gorm.DB
+}
+
+func NewChainEventRepository(db *gorm.DB) *ChainEventRepository {
+	return &ChainEventRepository{db: db}
+}
+
+// MarkProcessed records an event and reports whether it was new.
+// A false result means the (tx hash, log index) pair was already applied.
+func (r *ChainEventRepository) MarkProcessed(event *models.ProcessedChainEvent) (bool, error) {
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(event)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// GetCursor returns the last processed block for a consumer, or 0 if unset
+func (r *ChainEventRepository) GetCursor(name string) (uint64, error) {
+	var cursor models.SyncCursor
+	err := r.db.Where("name = ?", name).First(&cursor).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return cursor.BlockNumber, nil
+}
+
+// SetCursor stores the last processed block for a consumer
+func (r *ChainEventRepository) SetCursor(name string, blockNumber uint64) error {
+	return r.db.Save(&models.SyncCursor{Name: name, BlockNumber: blockNumber}).Error
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"brainrot-tamagotchi/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type JobRunRepository struct {
+	db *gorm.DB
+}
+
+func NewJobRunRepository(db *gorm.DB) *JobRunRepository {
+	return &JobRunRepository{db: db}
+}
+
+// Create records a run
+func (r *JobRunRepository) Create(run *models.JobRun) error {
+	return r.db.Create(run).Error
+}
+
+// Update saves all fields of a run
+func (r *JobRunRepository) Update(run *models.JobRun) error {
+	return r.db.Save(run).Error
+}
+
+// GetByJob returns a job's runs, newest first
+func (r *JobRunRepository) GetByJob(jobName string, limit, offset int) ([]models.JobRun, int64, error) {
+	var runs []models.JobRun
+	var total int64
+
+	query := r.db.Model(&models.JobRun{}).Where("job_name = ?", jobName)
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("started_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&runs).Error
+	return runs, total, err
+}
+
+// GetLastSucceeded returns the latest successful run of a job, or
+// gorm.ErrRecordNotFound if it never succeeded
+func (r *JobRunRepository) GetLastSucceeded(jobName string) (*models.JobRun, error) {
+	var run models.JobRun
+	err := r.db.Where("job_name = ? AND status = ?", jobName, models.JobSucceeded).
+		Order("started_at DESC").
+		First(&run).Error
+	if err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// GetLatest returns the most recent run of each job
+func (r *JobRunRepository) GetLatest() (map[string]models.JobRun, error) {
+	var runs []models.JobRun
+	err := r.db.Select("DISTINCT ON (job_name) *").
+		Order("job_name, started_at DESC").
+		Find(&runs).Error
+	if err != nil {
+		return nil, err
+	}
+
+	latest := make(map[string]models.JobRun, len(runs))
+	for _, run := range runs {
+		latest[run.JobName] = run
+	}
+	return latest, nil
+}
+
+// SaveReport replaces a job's last report
+func (r *JobRunRepository) SaveReport(report *models.JobReport) error {
+	return r.db.Clauses(clause.OnConflict{UpdateAll: true}).Create(report).Error
+}
+
+// GetReport returns a job's last report, or gorm.ErrRecordNotFound if it has
+// none
+func (r *JobRunRepository) GetReport(jobName string) (*models.JobReport, error) {
+	var report models.JobReport
+	if err := r.db.Where("job_name = ?", jobName).First(&report).Error; err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
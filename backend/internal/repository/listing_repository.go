@@ -2,6 +2,8 @@ package repository
 
 import (
 	"brainrot-tamagotchi/internal/models"
+	"brainrot-tamagotchi/pkg/money"
+	"context"
 	"strings"
 
 	"gorm.io/gorm"
@@ -15,6 +17,11 @@ func NewMarketListingRepository(db *gorm.DB) *MarketListingRepository {
 	return &MarketListingRepository{db: db}
 }
 
+// WithContext returns a copy of the repository whose queries use ctx
+func (r *MarketListingRepository) WithContext(ctx context.Context) ListingStore {
+	return &MarketListingRepository{db: r.db.WithContext(ctx)}
+}
+
 // Create creates a new listing
 func (r *MarketListingRepository) Create(listing *models.MarketListing) error {
 	listing.SellerAddress = strings.ToLower(listing.SellerAddress)
@@ -24,41 +31,72 @@ func (r *MarketListingRepository) Create(listing *models.MarketListing) error {
 // GetByTokenID retrieves a listing by token ID
 func (r *MarketListingRepository) GetByTokenID(tokenID uint) (*models.MarketListing, error) {
 	var listing models.MarketListing
-	err := r.db.Preload("NFT").Where("token_id = ? AND is_active = ?", tokenID, true).First(&listing).Error
+	err := r.db.Where("token_id = ? AND is_active = ?", tokenID, true).First(&listing).Error
+	if err != nil {
+		return nil, err
+	}
+	return &listing, nil
+}
+
+// GetLatestByTokenID retrieves the listing row for a token in any state
+func (r *MarketListingRepository) GetLatestByTokenID(tokenID uint) (*models.MarketListing, error) {
+	var listing models.MarketListing
+	err := r.db.Unscoped().Where("token_id = ?", tokenID).First(&listing).Error
 	if err != nil {
 		return nil, err
 	}
 	return &listing, nil
 }
 
+// Upsert saves a listing, reviving a soft-deleted row for the same token
+func (r *MarketListingRepository) Upsert(listing *models.MarketListing) error {
+	listing.SellerAddress = strings.ToLower(listing.SellerAddress)
+	listing.DeletedAt = gorm.DeletedAt{}
+	return r.db.Unscoped().Save(listing).Error
+}
+
+// GetActiveByTokenIDs retrieves the active listings for a set of tokens
+func (r *MarketListingRepository) GetActiveByTokenIDs(tokenIDs []uint) ([]models.MarketListing, error) {
+	var listings []models.MarketListing
+	if len(tokenIDs) == 0 {
+		return listings, nil
+	}
+	err := r.db.Where("token_id IN ? AND is_active = ?", tokenIDs, true).Find(&listings).Error
+	return listings, err
+}
+
 // GetActiveListings retrieves all active listings with pagination
 func (r *MarketListingRepository) GetActiveListings(limit, offset int, filters map[string]interface{}) ([]models.MarketListing, error) {
 	var listings []models.MarketListing
-	query := r.db.Preload("NFT").Where("is_active = ?", true)
+	query := r.db.Where("market_listings.is_active = ?", true)
 
 	// Apply filters
+	_, hasRarity := filters["rarity"]
+	_, hasMinLevel := filters["min_level"]
+	if hasRarity || hasMinLevel {
+		query = query.Joins("JOIN nfts ON nfts.token_id = market_listings.token_id")
+	}
+
 	if rarity, ok := filters["rarity"]; ok {
-		query = query.Joins("JOIN nfts ON nfts.token_id = market_listings.token_id").
-			Where("nfts.rarity = ?", rarity)
+		query = query.Where("nfts.rarity = ?", rarity)
 	}
 
 	if minLevel, ok := filters["min_level"]; ok {
-		query = query.Joins("JOIN nfts ON nfts.token_id = market_listings.token_id").
-			Where("nfts.level >= ?", minLevel)
+		query = query.Where("nfts.level >= ?", minLevel)
 	}
 
 	if maxPrice, ok := filters["max_price"]; ok {
-		query = query.Where("price <= ?", maxPrice)
+		query = query.Where("market_listings.price <= ?", maxPrice)
 	}
 
-	err := query.Limit(limit).Offset(offset).Order("listed_at DESC").Find(&listings).Error
+	err := query.Limit(limit).Offset(offset).Order("market_listings.listed_at DESC").Find(&listings).Error
 	return listings, err
 }
 
 // GetBySeller retrieves all listings by seller
 func (r *MarketListingRepository) GetBySeller(sellerAddress string, active bool) ([]models.MarketListing, error) {
 	var listings []models.MarketListing
-	query := r.db.Preload("NFT").Where("seller_address = ?", strings.ToLower(sellerAddress))
+	query := r.db.Where("seller_address = ?", strings.ToLower(sellerAddress))
 	if active {
 		query = query.Where("is_active = ?", true)
 	}
@@ -89,3 +127,26 @@ func (r *MarketListingRepository) MarkAsSold(tokenID uint, buyerAddress string)
 		}).Error
 }
 
+
+// GetAverageSalePriceByRarity returns the mean sold price per NFT rarity
+func (r *MarketListingRepository) GetAverageSalePriceByRarity() (map[string]money.Wei, error) {
+	var rows []struct {
+		Rarity       string
+		AveragePrice money.Wei
+	}
+	err := r.db.Model(&models.MarketListing{}).
+		Select("nfts.rarity AS rarity, TRUNC(AVG(market_listings.price)) AS average_price").
+		Joins("JOIN nfts ON nfts.token_id = market_listings.token_id").
+		Where("market_listings.sold_at IS NOT NULL").
+		Group("nfts.rarity").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	prices := make(map[string]money.Wei, len(rows))
+	for _, row := range rows {
+		prices[row.Rarity] = row.AveragePrice
+	}
+	return prices, nil
+}
@@ -0,0 +1,234 @@
+package memstore
+
+import (
+	"brainrot-tamagotchi/internal/models"
+	"brainrot-tamagotchi/internal/repository"
+	"context"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// CaseOpeningStore is an in-memory repository.CaseOpeningStore
+type CaseOpeningStore struct {
+	mu     sync.Mutex
+	rows   []models.CaseOpening // In ID order, including soft-deleted rows
+	nextID uint
+}
+
+var _ repository.CaseOpeningStore = (*CaseOpeningStore)(nil)
+
+func NewCaseOpeningStore() *CaseOpeningStore {
+	return &CaseOpeningStore{}
+}
+
+// WithContext returns the store itself; it has nothing to cancel or log
+func (s *CaseOpeningStore) WithContext(ctx context.Context) repository.CaseOpeningStore {
+	return s
+}
+
+// Create records a case opening; re-recording the same (tx hash, log index)
+// is a no-op. Openings without a tx hash are never deduplicated.
+func (s *CaseOpeningStore) Create(opening *models.CaseOpening) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	opening.UserAddress = strings.ToLower(opening.UserAddress)
+	if opening.TxHash != "" {
+		for _, row := range s.rows {
+			if row.TxHash == opening.TxHash && row.LogIndex == opening.LogIndex {
+				return nil
+			}
+		}
+	}
+	if opening.ID == 0 {
+		s.nextID++
+		opening.ID = s.nextID
+	} else {
+		for _, row := range s.rows {
+			if row.ID == opening.ID {
+				return errDuplicate("case_openings_pkey", opening.ID)
+			}
+		}
+		if opening.ID > s.nextID {
+			s.nextID = opening.ID
+		}
+	}
+	stamp(&opening.CreatedAt, &opening.UpdatedAt)
+
+	s.rows = append(s.rows, *opening)
+	sort.Slice(s.rows, func(i, j int) bool { return s.rows[i].ID < s.rows[j].ID })
+	return nil
+}
+
+// GetByUser returns a user's openings, newest first, with the total count
+func (s *CaseOpeningStore) GetByUser(userAddress string, dateRange repository.DateRange, limit, offset int) ([]models.CaseOpening, int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	userAddress = strings.ToLower(userAddress)
+	openings := []models.CaseOpening{}
+	for _, opening := range s.filter("", dateRange) {
+		if opening.UserAddress == userAddress {
+			openings = append(openings, opening)
+		}
+	}
+	sort.SliceStable(openings, func(i, j int) bool { return openings[i].OpenedAt.After(openings[j].OpenedAt) })
+	return paginate(openings, limit, offset), int64(len(openings)), nil
+}
+
+// GetByUserAndCaseType returns a user's openings of one case type, oldest
+// first
+func (s *CaseOpeningStore) GetByUserAndCaseType(userAddress, caseType string) ([]models.CaseOpening, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	userAddress = strings.ToLower(userAddress)
+	openings := []models.CaseOpening{}
+	for _, opening := range s.filter("", repository.DateRange{}) {
+		if opening.UserAddress == userAddress && opening.CaseType == caseType {
+			openings = append(openings, opening)
+		}
+	}
+	sort.SliceStable(openings, func(i, j int) bool { return openings[i].OpenedAt.Before(openings[j].OpenedAt) })
+	return openings, nil
+}
+
+// GetAfterID returns up to limit openings with an ID above afterID, in ID
+// order
+func (s *CaseOpeningStore) GetAfterID(afterID uint, limit int) ([]models.CaseOpening, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	openings := []models.CaseOpening{}
+	for _, opening := range s.filter("", repository.DateRange{}) {
+		if opening.ID > afterID {
+			openings = append(openings, opening)
+		}
+	}
+	return paginate(openings, limit, 0), nil
+}
+
+// GetLatestID returns the highest opening ID, or 0 if there are none
+func (s *CaseOpeningStore) GetLatestID() (uint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	openings := s.filter("", repository.DateRange{})
+	if len(openings) == 0 {
+		return 0, nil
+	}
+	return openings[len(openings)-1].ID, nil
+}
+
+// GetTotalsByCaseType aggregates count and revenue per case type. An empty
+// userAddress aggregates across all users.
+func (s *CaseOpeningStore) GetTotalsByCaseType(userAddress string, dateRange repository.DateRange) ([]repository.CaseTypeTotals, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	byType := make(map[string]*repository.CaseTypeTotals)
+	for _, opening := range s.filter(strings.ToLower(userAddress), dateRange) {
+		totals, ok := byType[opening.CaseType]
+		if !ok {
+			totals = &repository.CaseTypeTotals{CaseType: opening.CaseType}
+			byType[opening.CaseType] = totals
+		}
+		totals.Count++
+		totals.Revenue = totals.Revenue.Add(opening.Price)
+	}
+
+	totals := make([]repository.CaseTypeTotals, 0, len(byType))
+	for _, t := range byType {
+		totals = append(totals, *t)
+	}
+	sort.Slice(totals, func(i, j int) bool { return totals[i].CaseType < totals[j].CaseType })
+	return totals, nil
+}
+
+// GetRarityDistribution counts rolled rarities per case type. An empty
+// userAddress aggregates across all users.
+func (s *CaseOpeningStore) GetRarityDistribution(userAddress string, dateRange repository.DateRange) ([]repository.RarityCount, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	type key struct{ caseType, rarity string }
+	byKey := make(map[key]int64)
+	for _, opening := range s.filter(strings.ToLower(userAddress), dateRange) {
+		byKey[key{opening.CaseType, opening.Rarity}]++
+	}
+
+	counts := make([]repository.RarityCount, 0, len(byKey))
+	for k, n := range byKey {
+		counts = append(counts, repository.RarityCount{CaseType: k.caseType, Rarity: k.rarity, Count: n})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].CaseType != counts[j].CaseType {
+			return counts[i].CaseType < counts[j].CaseType
+		}
+		return counts[i].Rarity < counts[j].Rarity
+	})
+	return counts, nil
+}
+
+// GetLuckiestPulls returns the openings whose rolled rarity was least
+// likely for their case type. odds maps case type -> rarity -> probability.
+func (s *CaseOpeningStore) GetLuckiestPulls(odds map[string]map[string]float64, dateRange repository.DateRange, limit int) ([]models.CaseOpening, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	chance := func(opening *models.CaseOpening) float64 {
+		if c, ok := odds[opening.CaseType][opening.Rarity]; ok {
+			return c
+		}
+		return 1
+	}
+
+	openings := []models.CaseOpening{}
+	for _, opening := range s.filter("", dateRange) {
+		if chance(&opening) < 1 {
+			openings = append(openings, opening)
+		}
+	}
+	sort.SliceStable(openings, func(i, j int) bool {
+		ci, cj := chance(&openings[i]), chance(&openings[j])
+		if ci != cj {
+			return ci < cj
+		}
+		return openings[i].OpenedAt.After(openings[j].OpenedAt)
+	})
+	return paginate(openings, limit, 0), nil
+}
+
+// filter returns the live openings of a user (any user if empty) in a date
+// range, in ID order
+func (s *CaseOpeningStore) filter(userAddress string, dateRange repository.DateRange) []models.CaseOpening {
+	openings := []models.CaseOpening{}
+	for _, opening := range s.rows {
+		if deleted(opening.DeletedAt) || !inRange(opening.OpenedAt, dateRange) {
+			continue
+		}
+		if userAddress != "" && opening.UserAddress != userAddress {
+			continue
+		}
+		openings = append(openings, opening)
+	}
+	return openings
+}
+
+// snapshot copies the rows so a transaction can be rolled back
+func (s *CaseOpeningStore) snapshot() ([]models.CaseOpening, uint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]models.CaseOpening(nil), s.rows...), s.nextID
+}
+
+// restore puts back the rows from snapshot
+func (s *CaseOpeningStore) restore(rows []models.CaseOpening, nextID uint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.rows = rows
+	s.nextID = nextID
+}
@@ -0,0 +1,321 @@
+package memstore
+
+import (
+	"brainrot-tamagotchi/internal/models"
+	"brainrot-tamagotchi/internal/repository"
+	"brainrot-tamagotchi/pkg/money"
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ListingStore is an in-memory repository.ListingStore. Queries that join
+// nfts in SQL read from the NFTStore it was built with.
+type ListingStore struct {
+	nfts *NFTStore
+
+	mu     sync.Mutex
+	rows   map[uint]*models.MarketListing // By ID, including soft-deleted rows
+	nextID uint
+}
+
+var _ repository.ListingStore = (*ListingStore)(nil)
+
+func NewListingStore(nfts *NFTStore) *ListingStore {
+	return &ListingStore{
+		nfts: nfts,
+		rows: make(map[uint]*models.MarketListing),
+	}
+}
+
+// WithContext returns the store itself; it has nothing to cancel or log
+func (s *ListingStore) WithContext(ctx context.Context) repository.ListingStore {
+	return s
+}
+
+// Create stores a new listing
+func (s *ListingStore) Create(listing *models.MarketListing) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	listing.SellerAddress = strings.ToLower(listing.SellerAddress)
+	return s.insert(listing)
+}
+
+// GetByTokenID returns the active listing of a token
+func (s *ListingStore) GetByTokenID(tokenID uint) (*models.MarketListing, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, listing := range s.sorted(false) {
+		if listing.TokenID == tokenID && listing.IsActive {
+			return &listing, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+// GetLatestByTokenID returns the listing row of a token in any state
+func (s *ListingStore) GetLatestByTokenID(tokenID uint) (*models.MarketListing, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, listing := range s.sorted(true) {
+		if listing.TokenID == tokenID {
+			return &listing, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+// Upsert saves a listing, reviving a soft-deleted row for the same token
+func (s *ListingStore) Upsert(listing *models.MarketListing) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	listing.SellerAddress = strings.ToLower(listing.SellerAddress)
+	listing.DeletedAt = gorm.DeletedAt{}
+	return s.save(listing)
+}
+
+// GetActiveByTokenIDs returns the active listings for a set of tokens
+func (s *ListingStore) GetActiveByTokenIDs(tokenIDs []uint) ([]models.MarketListing, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wanted := make(map[uint]bool, len(tokenIDs))
+	for _, id := range tokenIDs {
+		wanted[id] = true
+	}
+	return s.filter(func(listing *models.MarketListing) bool {
+		return listing.IsActive && wanted[listing.TokenID]
+	}), nil
+}
+
+// GetActiveListings returns active listings, newest first. Filters are
+// "rarity" (string), "min_level" (int) and "max_price" (money.Wei).
+func (s *ListingStore) GetActiveListings(limit, offset int, filters map[string]interface{}) ([]models.MarketListing, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rarity, hasRarity := filters["rarity"]
+	minLevel, hasMinLevel := filters["min_level"]
+	maxPrice, hasMaxPrice := filters["max_price"]
+
+	listings := s.filter(func(listing *models.MarketListing) bool {
+		if !listing.IsActive {
+			return false
+		}
+		if hasRarity || hasMinLevel {
+			// Inner join: listings without a pet drop out
+			nft, ok := s.nfts.lookup(listing.TokenID)
+			if !ok {
+				return false
+			}
+			if hasRarity && nft.Rarity != fmt.Sprint(rarity) {
+				return false
+			}
+			if hasMinLevel && nft.Level < minLevel.(int) {
+				return false
+			}
+		}
+		if hasMaxPrice && listing.Price.Cmp(maxPrice.(money.Wei)) > 0 {
+			return false
+		}
+		return true
+	})
+	sortByListedAt(listings)
+	return paginate(listings, limit, offset), nil
+}
+
+// GetBySeller returns a seller's listings, newest first
+func (s *ListingStore) GetBySeller(sellerAddress string, active bool) ([]models.MarketListing, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sellerAddress = strings.ToLower(sellerAddress)
+	listings := s.filter(func(listing *models.MarketListing) bool {
+		return listing.SellerAddress == sellerAddress && (!active || listing.IsActive)
+	})
+	sortByListedAt(listings)
+	return listings, nil
+}
+
+// Update saves every field of a listing
+func (s *ListingStore) Update(listing *models.MarketListing) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.save(listing)
+}
+
+// Deactivate deactivates a token's listing
+func (s *ListingStore) Deactivate(tokenID uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.each(tokenID, func(row *models.MarketListing) {
+		row.IsActive = false
+	})
+	return nil
+}
+
+// MarkAsSold marks a token's listing as sold
+func (s *ListingStore) MarkAsSold(tokenID uint, buyerAddress string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	buyer := strings.ToLower(buyerAddress)
+	now := time.Now()
+	s.each(tokenID, func(row *models.MarketListing) {
+		row.IsActive = false
+		row.BuyerAddress = &buyer
+		row.SoldAt = &now
+	})
+	return nil
+}
+
+// GetAverageSalePriceByRarity returns the mean sold price per NFT rarity,
+// truncated to whole wei
+func (s *ListingStore) GetAverageSalePriceByRarity() (map[string]money.Wei, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sums := make(map[string]money.Wei)
+	counts := make(map[string]int64)
+	for _, listing := range s.sorted(false) {
+		if listing.SoldAt == nil {
+			continue
+		}
+		nft, ok := s.nfts.lookup(listing.TokenID)
+		if !ok {
+			continue
+		}
+		sums[nft.Rarity] = sums[nft.Rarity].Add(listing.Price)
+		counts[nft.Rarity]++
+	}
+
+	prices := make(map[string]money.Wei, len(sums))
+	for rarity, sum := range sums {
+		prices[rarity] = money.NewWei(new(big.Int).Quo(sum.BigInt(), big.NewInt(counts[rarity])))
+	}
+	return prices, nil
+}
+
+// save updates a listing by ID, or inserts it if its ID is zero or unknown
+func (s *ListingStore) save(listing *models.MarketListing) error {
+	if listing.ID == 0 {
+		return s.insert(listing)
+	}
+	if err := s.checkUnique(listing); err != nil {
+		return err
+	}
+	listing.UpdatedAt = time.Now()
+	row := *listing
+	s.rows[listing.ID] = &row
+	if listing.ID > s.nextID {
+		s.nextID = listing.ID
+	}
+	return nil
+}
+
+// insert applies column defaults, assigns an ID and stores a copy. Like
+// GORM, a false IsActive is a zero value and takes the column default.
+func (s *ListingStore) insert(listing *models.MarketListing) error {
+	if err := s.checkUnique(listing); err != nil {
+		return err
+	}
+	listing.IsActive = true
+	if listing.ID == 0 {
+		s.nextID++
+		listing.ID = s.nextID
+	} else if _, ok := s.rows[listing.ID]; ok {
+		return errDuplicate("market_listings_pkey", listing.ID)
+	} else if listing.ID > s.nextID {
+		s.nextID = listing.ID
+	}
+	stamp(&listing.CreatedAt, &listing.UpdatedAt)
+
+	row := *listing
+	s.rows[listing.ID] = &row
+	return nil
+}
+
+// checkUnique enforces the unique token_id index, which covers deleted rows
+func (s *ListingStore) checkUnique(listing *models.MarketListing) error {
+	for id, row := range s.rows {
+		if id != listing.ID && row.TokenID == listing.TokenID {
+			return errDuplicate("idx_market_listings_token_id", listing.TokenID)
+		}
+	}
+	return nil
+}
+
+// each runs fn on the live rows of a token
+func (s *ListingStore) each(tokenID uint, fn func(*models.MarketListing)) {
+	now := time.Now()
+	for _, row := range s.rows {
+		if row.TokenID == tokenID && !deleted(row.DeletedAt) {
+			fn(row)
+			row.UpdatedAt = now
+		}
+	}
+}
+
+// filter returns copies of the live rows that match, in ID order
+func (s *ListingStore) filter(match func(*models.MarketListing) bool) []models.MarketListing {
+	listings := []models.MarketListing{}
+	for _, listing := range s.sorted(false) {
+		if match(&listing) {
+			listings = append(listings, listing)
+		}
+	}
+	return listings
+}
+
+// sorted returns copies of the rows in ID order
+func (s *ListingStore) sorted(unscoped bool) []models.MarketListing {
+	listings := make([]models.MarketListing, 0, len(s.rows))
+	for _, row := range s.rows {
+		if unscoped || !deleted(row.DeletedAt) {
+			listings = append(listings, *row)
+		}
+	}
+	sort.Slice(listings, func(i, j int) bool { return listings[i].ID < listings[j].ID })
+	return listings
+}
+
+// snapshot copies every row, for rolling back a transaction
+func (s *ListingStore) snapshot() (map[uint]models.MarketListing, uint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rows := make(map[uint]models.MarketListing, len(s.rows))
+	for id, row := range s.rows {
+		rows[id] = *row
+	}
+	return rows, s.nextID
+}
+
+// restore puts back the rows from snapshot
+func (s *ListingStore) restore(rows map[uint]models.MarketListing, nextID uint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.rows = make(map[uint]*models.MarketListing, len(rows))
+	for id, row := range rows {
+		row := row
+		s.rows[id] = &row
+	}
+	s.nextID = nextID
+}
+
+func sortByListedAt(listings []models.MarketListing) {
+	sort.SliceStable(listings, func(i, j int) bool { return listings[i].ListedAt.After(listings[j].ListedAt) })
+}
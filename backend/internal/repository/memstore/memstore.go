@@ -0,0 +1,62 @@
+// Package memstore holds in-memory implementations of the repository store
+// interfaces for service tests. They follow the GORM repositories' behavior
+// closely enough to pass the same storetest suite: addresses are lower-cased
+// where the repository lower-cases them, deleted rows are hidden unless the
+// repository queries unscoped, column defaults fill zero fields on insert,
+// and missing records are gorm.ErrRecordNotFound.
+package memstore
+
+import (
+	"brainrot-tamagotchi/internal/repository"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// errDuplicate mimics a unique constraint violation
+func errDuplicate(constraint string, value interface{}) error {
+	return fmt.Errorf("duplicate key value violates unique constraint %q: %v", constraint, value)
+}
+
+// paginate applies LIMIT and OFFSET the way GORM builds them: a negative
+// limit is no limit and a non-positive offset is no offset
+func paginate[T any](rows []T, limit, offset int) []T {
+	if offset > 0 {
+		if offset >= len(rows) {
+			return []T{}
+		}
+		rows = rows[offset:]
+	}
+	if limit >= 0 && limit < len(rows) {
+		rows = rows[:limit]
+	}
+	return rows
+}
+
+// inRange reports whether t falls in a repository.DateRange
+func inRange(t time.Time, dateRange repository.DateRange) bool {
+	if dateRange.From != nil && t.Before(*dateRange.From) {
+		return false
+	}
+	if dateRange.To != nil && !t.Before(*dateRange.To) {
+		return false
+	}
+	return true
+}
+
+// deleted reports whether a soft-deleted row is gone
+func deleted(at gorm.DeletedAt) bool {
+	return at.Valid
+}
+
+// stamp sets CreatedAt and UpdatedAt as GORM does on insert
+func stamp(createdAt, updatedAt *time.Time) {
+	now := time.Now()
+	if createdAt.IsZero() {
+		*createdAt = now
+	}
+	if updatedAt.IsZero() {
+		*updatedAt = now
+	}
+}
@@ -0,0 +1,50 @@
+package memstore
+
+import (
+	"brainrot-tamagotchi/internal/repository"
+	"brainrot-tamagotchi/internal/repository/storetest"
+	"testing"
+)
+
+func TestNFTStore(t *testing.T) {
+	storetest.TestNFTStore(t, func(*testing.T) repository.NFTStore {
+		return NewNFTStore()
+	})
+}
+
+func TestListingStore(t *testing.T) {
+	storetest.TestListingStore(t, func(*testing.T) (repository.ListingStore, repository.NFTStore) {
+		nfts := NewNFTStore()
+		return NewListingStore(nfts), nfts
+	})
+}
+
+func TestUserStore(t *testing.T) {
+	storetest.TestUserStore(t, func(*testing.T) repository.UserStore {
+		return NewUserStore()
+	})
+}
+
+func TestCaseOpeningStore(t *testing.T) {
+	storetest.TestCaseOpeningStore(t, func(*testing.T) repository.CaseOpeningStore {
+		return NewCaseOpeningStore()
+	})
+}
+
+func TestNotificationStore(t *testing.T) {
+	storetest.TestNotificationStore(t, func(*testing.T) repository.NotificationStore {
+		return NewNotificationStore()
+	})
+}
+
+func TestSaleStore(t *testing.T) {
+	storetest.TestSaleStore(t, func(*testing.T) repository.SaleStore {
+		return NewSaleStore()
+	})
+}
+
+func TestStores(t *testing.T) {
+	storetest.TestStores(t, func(*testing.T) repository.Stores {
+		return NewStores(NewNFTStore())
+	})
+}
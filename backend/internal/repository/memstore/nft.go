@@ -0,0 +1,318 @@
+package memstore
+
+import (
+	"brainrot-tamagotchi/internal/models"
+	"brainrot-tamagotchi/internal/repository"
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// NFTStore is an in-memory repository.NFTStore
+type NFTStore struct {
+	mu     sync.Mutex
+	rows   map[uint]*models.NFT // By ID, including soft-deleted rows
+	nextID uint
+}
+
+var _ repository.NFTStore = (*NFTStore)(nil)
+
+func NewNFTStore() *NFTStore {
+	return &NFTStore{rows: make(map[uint]*models.NFT)}
+}
+
+// WithContext returns the store itself; it has nothing to cancel or log
+func (s *NFTStore) WithContext(ctx context.Context) repository.NFTStore {
+	return s
+}
+
+// Create stores a new NFT
+func (s *NFTStore) Create(nft *models.NFT) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	nft.OwnerAddress = strings.ToLower(nft.OwnerAddress)
+	return s.insert(nft)
+}
+
+// GetByTokenID returns an NFT by token ID
+func (s *NFTStore) GetByTokenID(tokenID uint) (*models.NFT, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, nft := range s.sorted(false) {
+		if nft.TokenID == tokenID {
+			return &nft, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+// GetByOwner returns all NFTs owned by an address
+func (s *NFTStore) GetByOwner(ownerAddress string) ([]models.NFT, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ownerAddress = strings.ToLower(ownerAddress)
+	return s.filter(func(nft *models.NFT) bool { return nft.OwnerAddress == ownerAddress }), nil
+}
+
+// Update saves every field of an NFT, inserting it if its ID is unknown
+func (s *NFTStore) Update(nft *models.NFT) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if nft.ID == 0 {
+		return s.insert(nft)
+	}
+	if err := s.checkUnique(nft); err != nil {
+		return err
+	}
+	nft.UpdatedAt = time.Now()
+	row := *nft
+	s.rows[nft.ID] = &row
+	if nft.ID >= s.nextID {
+		s.nextID = nft.ID
+	}
+	return nil
+}
+
+// UpdateStats saves the stats fields of an NFT
+func (s *NFTStore) UpdateStats(nft *models.NFT) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if nft.ID == 0 {
+		return gorm.ErrMissingWhereClause
+	}
+	if row, ok := s.rows[nft.ID]; ok && !deleted(row.DeletedAt) {
+		row.Hunger = nft.Hunger
+		row.Mood = nft.Mood
+		row.Energy = nft.Energy
+		row.LastFed = nft.LastFed
+		row.LastPlayed = nft.LastPlayed
+		row.LastInteract = nft.LastInteract
+		row.UpdatedAt = time.Now()
+	}
+	return nil
+}
+
+// UpdateOwner sets the owner of a token
+func (s *NFTStore) UpdateOwner(tokenID uint, ownerAddress string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.each(tokenID, func(row *models.NFT) {
+		row.OwnerAddress = strings.ToLower(ownerAddress)
+	})
+	return nil
+}
+
+// UpdateChainFields overwrites the fields mirrored from the NFT contract
+func (s *NFTStore) UpdateChainFields(nft *models.NFT) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.each(nft.TokenID, func(row *models.NFT) {
+		row.OwnerAddress = strings.ToLower(nft.OwnerAddress)
+		row.MemeType = nft.MemeType
+		row.Rarity = nft.Rarity
+		row.Level = nft.Level
+		row.ColorVariant = nft.ColorVariant
+	})
+	return nil
+}
+
+// GetAfterTokenID returns up to limit NFTs with token IDs above
+// afterTokenID, in token ID order
+func (s *NFTStore) GetAfterTokenID(afterTokenID uint, limit int) ([]models.NFT, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	nfts := s.filter(func(nft *models.NFT) bool { return nft.TokenID > afterTokenID })
+	sort.SliceStable(nfts, func(i, j int) bool { return nfts[i].TokenID < nfts[j].TokenID })
+	return paginate(nfts, limit, 0), nil
+}
+
+// GetAll returns NFTs newest first
+func (s *NFTStore) GetAll(limit, offset int) ([]models.NFT, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	nfts := s.filter(func(*models.NFT) bool { return true })
+	sort.SliceStable(nfts, func(i, j int) bool { return nfts[i].CreatedAt.After(nfts[j].CreatedAt) })
+	return paginate(nfts, limit, offset), nil
+}
+
+// GetAliveNFTs returns every NFT with hunger, mood and energy left
+func (s *NFTStore) GetAliveNFTs() ([]models.NFT, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.filter(func(nft *models.NFT) bool { return nft.IsAlive() }), nil
+}
+
+// DecayBatch applies rules to the next limit alive NFTs after afterID, in ID
+// order, as the decay UPDATE does
+func (s *NFTStore) DecayBatch(afterID uint, limit int, now time.Time, rules repository.DecayRules) (uint, int, []models.NFT, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	batch := paginate(s.filter(func(nft *models.NFT) bool { return nft.ID > afterID && nft.IsAlive() }), limit, 0)
+	if len(batch) == 0 {
+		return 0, 0, nil, nil
+	}
+
+	fedBefore := now.Add(-rules.HungerDecayPeriod)
+	playedBefore := now.Add(-rules.MoodDecayPeriod)
+	var decayed []models.NFT
+	for _, nft := range batch {
+		row := s.rows[nft.ID]
+		hungerDue := !row.LastFed.After(fedBefore)
+		moodDue := !row.LastPlayed.After(playedBefore)
+		if !hungerDue && !moodDue && row.Energy >= 100 {
+			continue
+		}
+		if hungerDue {
+			row.Hunger = max(0, row.Hunger-rules.HungerDecay*int(now.Sub(row.LastFed)/rules.HungerDecayPeriod))
+		}
+		if moodDue {
+			row.Mood = max(0, row.Mood-rules.MoodDecay*int(now.Sub(row.LastPlayed)/rules.MoodDecayPeriod))
+		}
+		row.Energy = min(100, row.Energy+rules.EnergyRegen)
+		row.UpdatedAt = now
+		decayed = append(decayed, *row)
+	}
+	return batch[len(batch)-1].ID, len(batch), decayed, nil
+}
+
+// Delete soft deletes an NFT
+func (s *NFTStore) Delete(tokenID uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.each(tokenID, func(row *models.NFT) {
+		row.DeletedAt = gorm.DeletedAt{Time: now, Valid: true}
+	})
+	return nil
+}
+
+// snapshot copies every row, for rolling back a transaction
+func (s *NFTStore) snapshot() (map[uint]models.NFT, uint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rows := make(map[uint]models.NFT, len(s.rows))
+	for id, row := range s.rows {
+		rows[id] = *row
+	}
+	return rows, s.nextID
+}
+
+// restore puts back the rows from snapshot
+func (s *NFTStore) restore(rows map[uint]models.NFT, nextID uint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.rows = make(map[uint]*models.NFT, len(rows))
+	for id, row := range rows {
+		row := row
+		s.rows[id] = &row
+	}
+	s.nextID = nextID
+}
+
+// lookup returns a token's row whether or not it is deleted, for stores
+// that join on nfts
+func (s *NFTStore) lookup(tokenID uint) (models.NFT, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, nft := range s.sorted(true) {
+		if nft.TokenID == tokenID {
+			return nft, true
+		}
+	}
+	return models.NFT{}, false
+}
+
+// insert applies column defaults, assigns an ID and stores a copy
+func (s *NFTStore) insert(nft *models.NFT) error {
+	if err := s.checkUnique(nft); err != nil {
+		return err
+	}
+	if nft.Level == 0 {
+		nft.Level = 1
+	}
+	if nft.Hunger == 0 {
+		nft.Hunger = 100
+	}
+	if nft.Mood == 0 {
+		nft.Mood = 100
+	}
+	if nft.Energy == 0 {
+		nft.Energy = 100
+	}
+	if nft.ID == 0 {
+		s.nextID++
+		nft.ID = s.nextID
+	} else if _, ok := s.rows[nft.ID]; ok {
+		return errDuplicate("nfts_pkey", nft.ID)
+	} else if nft.ID > s.nextID {
+		s.nextID = nft.ID
+	}
+	stamp(&nft.CreatedAt, &nft.UpdatedAt)
+
+	row := *nft
+	s.rows[nft.ID] = &row
+	return nil
+}
+
+// checkUnique enforces the unique token_id index, which covers deleted rows
+func (s *NFTStore) checkUnique(nft *models.NFT) error {
+	for id, row := range s.rows {
+		if id != nft.ID && row.TokenID == nft.TokenID {
+			return errDuplicate("idx_nfts_token_id", nft.TokenID)
+		}
+	}
+	return nil
+}
+
+// each runs fn on the live rows of a token
+func (s *NFTStore) each(tokenID uint, fn func(*models.NFT)) {
+	now := time.Now()
+	for _, row := range s.rows {
+		if row.TokenID == tokenID && !deleted(row.DeletedAt) {
+			fn(row)
+			row.UpdatedAt = now
+		}
+	}
+}
+
+// filter returns copies of the live rows that match, in ID order
+func (s *NFTStore) filter(match func(*models.NFT) bool) []models.NFT {
+	nfts := []models.NFT{}
+	for _, nft := range s.sorted(false) {
+		if match(&nft) {
+			nfts = append(nfts, nft)
+		}
+	}
+	return nfts
+}
+
+// sorted returns copies of the rows in ID order
+func (s *NFTStore) sorted(unscoped bool) []models.NFT {
+	nfts := make([]models.NFT, 0, len(s.rows))
+	for _, row := range s.rows {
+		if unscoped || !deleted(row.DeletedAt) {
+			nfts = append(nfts, *row)
+		}
+	}
+	sort.Slice(nfts, func(i, j int) bool { return nfts[i].ID < nfts[j].ID })
+	return nfts
+}
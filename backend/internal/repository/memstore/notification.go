@@ -0,0 +1,159 @@
+package memstore
+
+import (
+	"brainrot-tamagotchi/internal/models"
+	"brainrot-tamagotchi/internal/repository"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// NotificationStore is an in-memory repository.NotificationStore
+type NotificationStore struct {
+	mu          sync.Mutex
+	prefs       map[string]*models.NotificationPreference // By wallet address
+	prefNextID  uint
+	rows        []models.Notification // In ID order
+	dedupKeys   map[string]bool
+	notifNextID uint
+}
+
+var _ repository.NotificationStore = (*NotificationStore)(nil)
+
+func NewNotificationStore() *NotificationStore {
+	return &NotificationStore{
+		prefs:     make(map[string]*models.NotificationPreference),
+		dedupKeys: make(map[string]bool),
+	}
+}
+
+// GetPreference returns a copy of a wallet's notification preferences
+func (s *NotificationStore) GetPreference(walletAddress string) (*models.NotificationPreference, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pref, ok := s.prefs[strings.ToLower(walletAddress)]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return copyPreference(pref), nil
+}
+
+// SavePreference creates or replaces a wallet's notification preferences
+func (s *NotificationStore) SavePreference(pref *models.NotificationPreference) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pref.WalletAddress = strings.ToLower(pref.WalletAddress)
+	if existing, ok := s.prefs[pref.WalletAddress]; ok {
+		pref.ID = existing.ID
+		pref.CreatedAt = existing.CreatedAt
+		pref.UpdatedAt = time.Now()
+	} else {
+		s.prefNextID++
+		pref.ID = s.prefNextID
+		stamp(&pref.CreatedAt, &pref.UpdatedAt)
+	}
+	s.prefs[pref.WalletAddress] = copyPreference(pref)
+	return nil
+}
+
+// Create stores a notification and reports whether it was new. A
+// notification whose dedup key was already used is skipped.
+func (s *NotificationStore) Create(notification *models.Notification) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	notification.WalletAddress = strings.ToLower(notification.WalletAddress)
+	if s.dedupKeys[notification.DedupKey] {
+		return false, nil
+	}
+	s.dedupKeys[notification.DedupKey] = true
+
+	s.notifNextID++
+	notification.ID = s.notifNextID
+	stamp(&notification.CreatedAt, &notification.UpdatedAt)
+	s.rows = append(s.rows, copyNotification(notification))
+	return true, nil
+}
+
+// Update saves all fields of a notification
+func (s *NotificationStore) Update(notification *models.Notification) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.rows {
+		if s.rows[i].ID == notification.ID {
+			notification.UpdatedAt = time.Now()
+			s.rows[i] = copyNotification(notification)
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+// GetDue returns pending notifications whose delivery time has come, oldest
+// first
+func (s *NotificationStore) GetDue(now time.Time, limit int) ([]models.Notification, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	due := []models.Notification{}
+	for i := range s.rows {
+		row := &s.rows[i]
+		if row.Status == models.NotificationPending && !row.DeliverAfter.After(now) {
+			due = append(due, copyNotification(row))
+		}
+	}
+	sort.SliceStable(due, func(i, j int) bool {
+		return due[i].DeliverAfter.Before(due[j].DeliverAfter)
+	})
+	return paginate(due, limit, 0), nil
+}
+
+// GetByWallet returns a wallet's notifications, newest first
+func (s *NotificationStore) GetByWallet(walletAddress string, limit int) ([]models.Notification, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	walletAddress = strings.ToLower(walletAddress)
+	notifications := []models.Notification{}
+	for i := range s.rows {
+		if s.rows[i].WalletAddress == walletAddress {
+			notifications = append(notifications, copyNotification(&s.rows[i]))
+		}
+	}
+	sort.SliceStable(notifications, func(i, j int) bool {
+		return notifications[i].CreatedAt.After(notifications[j].CreatedAt)
+	})
+	return paginate(notifications, limit, 0), nil
+}
+
+// copyPreference copies a preference and its maps, which the database
+// would serialize
+func copyPreference(pref *models.NotificationPreference) *models.NotificationPreference {
+	c := *pref
+	if pref.Channels != nil {
+		c.Channels = make(map[string]string, len(pref.Channels))
+		for k, v := range pref.Channels {
+			c.Channels[k] = v
+		}
+	}
+	if pref.Kinds != nil {
+		c.Kinds = make(map[string]bool, len(pref.Kinds))
+		for k, v := range pref.Kinds {
+			c.Kinds[k] = v
+		}
+	}
+	return &c
+}
+
+// copyNotification copies a notification and its delivered channels
+func copyNotification(notification *models.Notification) models.Notification {
+	c := *notification
+	c.Delivered = append([]string(nil), notification.Delivered...)
+	return c
+}
@@ -0,0 +1,100 @@
+package memstore
+
+import (
+	"brainrot-tamagotchi/internal/models"
+	"brainrot-tamagotchi/internal/repository"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SaleStore is an in-memory repository.SaleStore
+type SaleStore struct {
+	mu     sync.Mutex
+	rows   []models.MarketSale // In ID order
+	nextID uint
+}
+
+var _ repository.SaleStore = (*SaleStore)(nil)
+
+func NewSaleStore() *SaleStore {
+	return &SaleStore{}
+}
+
+// Create records a sale; re-recording the same (tx hash, log index) is a no-op
+func (s *SaleStore) Create(sale *models.MarketSale) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sale.SellerAddress = strings.ToLower(sale.SellerAddress)
+	sale.BuyerAddress = strings.ToLower(sale.BuyerAddress)
+	for _, row := range s.rows {
+		if row.TxHash == sale.TxHash && row.LogIndex == sale.LogIndex {
+			return nil
+		}
+	}
+
+	s.nextID++
+	sale.ID = s.nextID
+	if sale.CreatedAt.IsZero() {
+		sale.CreatedAt = time.Now()
+	}
+	s.rows = append(s.rows, *sale)
+	return nil
+}
+
+// GetBySeller returns a seller's sales, newest first, with the total count
+func (s *SaleStore) GetBySeller(sellerAddress string, dateRange repository.DateRange, limit, offset int) ([]models.MarketSale, int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sales := s.filter(strings.ToLower(sellerAddress), dateRange)
+	sort.SliceStable(sales, func(i, j int) bool { return sales[i].SoldAt.After(sales[j].SoldAt) })
+	return paginate(sales, limit, offset), int64(len(sales)), nil
+}
+
+// GetTotals aggregates sales in a date range. An empty sellerAddress
+// aggregates across all sellers.
+func (s *SaleStore) GetTotals(sellerAddress string, dateRange repository.DateRange) (*repository.SaleTotals, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	totals := &repository.SaleTotals{}
+	for _, sale := range s.filter(strings.ToLower(sellerAddress), dateRange) {
+		totals.SalesCount++
+		totals.GrossVolume = totals.GrossVolume.Add(sale.GrossPrice)
+		totals.PlatformFees = totals.PlatformFees.Add(sale.PlatformFee)
+		totals.SellerProceeds = totals.SellerProceeds.Add(sale.SellerProceeds)
+	}
+	return totals, nil
+}
+
+// filter returns copies of a seller's sales in a date range, or of every
+// seller's for an empty address
+func (s *SaleStore) filter(sellerAddress string, dateRange repository.DateRange) []models.MarketSale {
+	sales := []models.MarketSale{}
+	for _, sale := range s.rows {
+		if (sellerAddress == "" || sale.SellerAddress == sellerAddress) && inRange(sale.SoldAt, dateRange) {
+			sales = append(sales, sale)
+		}
+	}
+	return sales
+}
+
+// snapshot copies every row, for rolling back a transaction
+func (s *SaleStore) snapshot() ([]models.MarketSale, uint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]models.MarketSale(nil), s.rows...), s.nextID
+}
+
+// restore puts back the rows from snapshot
+func (s *SaleStore) restore(rows []models.MarketSale, nextID uint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.rows = rows
+	s.nextID = nextID
+}
@@ -0,0 +1,253 @@
+package memstore
+
+import (
+	"brainrot-tamagotchi/internal/models"
+	"brainrot-tamagotchi/internal/repository"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// OutboxStore is an in-memory repository.OutboxStore
+type OutboxStore struct {
+	mu     sync.Mutex
+	events []models.OutboxEvent
+}
+
+var _ repository.OutboxStore = (*OutboxStore)(nil)
+
+func NewOutboxStore() *OutboxStore {
+	return &OutboxStore{}
+}
+
+// Create appends events, assigning IDs in order
+func (s *OutboxStore) Create(events ...*models.OutboxEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, event := range events {
+		event.ID = uint(len(s.events) + 1)
+		if event.CreatedAt.IsZero() {
+			event.CreatedAt = time.Now()
+		}
+		s.events = append(s.events, *event)
+	}
+	return nil
+}
+
+// Update saves all fields of an event
+func (s *OutboxStore) Update(event *models.OutboxEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.events {
+		if s.events[i].ID == event.ID {
+			s.events[i] = *event
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+// LockPending returns up to limit due events, oldest first. Transactions run
+// one at a time, so there is nothing else to lock them against.
+func (s *OutboxStore) LockPending(now time.Time, limit int) ([]models.OutboxEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	events := []models.OutboxEvent{}
+	for _, event := range s.events {
+		if event.Status == models.OutboxPending && !event.AvailableAt.After(now) {
+			events = append(events, event)
+		}
+	}
+	return paginate(events, limit, 0), nil
+}
+
+// Events returns the recorded events in order
+func (s *OutboxStore) Events() []models.OutboxEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]models.OutboxEvent(nil), s.events...)
+}
+
+// CursorStore is an in-memory repository.CursorStore
+type CursorStore struct {
+	mu      sync.Mutex
+	cursors map[string]uint64
+}
+
+var _ repository.CursorStore = (*CursorStore)(nil)
+
+func NewCursorStore() *CursorStore {
+	return &CursorStore{cursors: make(map[string]uint64)}
+}
+
+// GetCursor returns a cursor, or 0 if unset
+func (s *CursorStore) GetCursor(name string) (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.cursors[name], nil
+}
+
+// SetCursor stores a cursor
+func (s *CursorStore) SetCursor(name string, value uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.cursors[name] = value
+	return nil
+}
+
+// ChainEventStore is an in-memory repository.ChainEventStore
+type ChainEventStore struct {
+	mu        sync.Mutex
+	processed map[string]models.ProcessedChainEvent // By tx hash and log index
+}
+
+var _ repository.ChainEventStore = (*ChainEventStore)(nil)
+
+func NewChainEventStore() *ChainEventStore {
+	return &ChainEventStore{processed: make(map[string]models.ProcessedChainEvent)}
+}
+
+// MarkProcessed records an event and reports whether it was new
+func (s *ChainEventStore) MarkProcessed(event *models.ProcessedChainEvent) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := fmt.Sprintf("%s:%d", event.TxHash, event.LogIndex)
+	if _, ok := s.processed[key]; ok {
+		return false, nil
+	}
+	event.ID = uint(len(s.processed) + 1)
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+	s.processed[key] = *event
+	return true, nil
+}
+
+// Stores is an in-memory repository.Stores. Transactions run one at a time
+// and roll back by restoring a snapshot taken when they start; writes made
+// outside a transaction while one runs are lost on rollback.
+type Stores struct {
+	txMu        sync.Mutex
+	nfts        *NFTStore
+	listings    *ListingStore
+	sales       *SaleStore
+	openings    *CaseOpeningStore
+	chainEvents *ChainEventStore
+	outbox      *OutboxStore
+	cursors     *CursorStore
+}
+
+var _ repository.Stores = (*Stores)(nil)
+
+// NewStores bundles nfts with empty listing, sale, case opening, chain event,
+// outbox and cursor stores
+func NewStores(nfts *NFTStore) *Stores {
+	return &Stores{
+		nfts:        nfts,
+		listings:    NewListingStore(nfts),
+		sales:       NewSaleStore(),
+		openings:    NewCaseOpeningStore(),
+		chainEvents: NewChainEventStore(),
+		outbox:      NewOutboxStore(),
+		cursors:     NewCursorStore(),
+	}
+}
+
+// WithContext returns the stores themselves
+func (s *Stores) WithContext(ctx context.Context) repository.Stores {
+	return s
+}
+
+// NFTs returns the NFT store the stores were built with
+func (s *Stores) NFTs() repository.NFTStore {
+	return s.nfts
+}
+
+// Listings returns the listing store, which joins on the NFT store
+func (s *Stores) Listings() repository.ListingStore {
+	return s.listings
+}
+
+// Sales returns the sales ledger
+func (s *Stores) Sales() repository.SaleStore {
+	return s.sales
+}
+
+// CaseOpenings returns the case openings
+func (s *Stores) CaseOpenings() repository.CaseOpeningStore {
+	return s.openings
+}
+
+// ChainEvents returns the processed chain events
+func (s *Stores) ChainEvents() repository.ChainEventStore {
+	return s.chainEvents
+}
+
+// Outbox returns the outbox
+func (s *Stores) Outbox() repository.OutboxStore {
+	return s.outbox
+}
+
+// Cursors returns the sync cursors
+func (s *Stores) Cursors() repository.CursorStore {
+	return s.cursors
+}
+
+// OutboxEvents returns the events committed to the outbox
+func (s *Stores) OutboxEvents() []models.OutboxEvent {
+	return s.outbox.Events()
+}
+
+// Transaction runs fn and undoes its writes if it returns an error
+func (s *Stores) Transaction(fn func(tx repository.Stores) error) error {
+	s.txMu.Lock()
+	defer s.txMu.Unlock()
+
+	nftRows, nftNextID := s.nfts.snapshot()
+	listingRows, listingNextID := s.listings.snapshot()
+	saleRows, saleNextID := s.sales.snapshot()
+	openingRows, openingNextID := s.openings.snapshot()
+	s.chainEvents.mu.Lock()
+	processed := make(map[string]models.ProcessedChainEvent, len(s.chainEvents.processed))
+	for key, event := range s.chainEvents.processed {
+		processed[key] = event
+	}
+	s.chainEvents.mu.Unlock()
+	s.outbox.mu.Lock()
+	events := append([]models.OutboxEvent(nil), s.outbox.events...)
+	s.outbox.mu.Unlock()
+	s.cursors.mu.Lock()
+	cursors := make(map[string]uint64, len(s.cursors.cursors))
+	for name, value := range s.cursors.cursors {
+		cursors[name] = value
+	}
+	s.cursors.mu.Unlock()
+
+	if err := fn(s); err != nil {
+		s.nfts.restore(nftRows, nftNextID)
+		s.listings.restore(listingRows, listingNextID)
+		s.sales.restore(saleRows, saleNextID)
+		s.openings.restore(openingRows, openingNextID)
+		s.chainEvents.mu.Lock()
+		s.chainEvents.processed = processed
+		s.chainEvents.mu.Unlock()
+		s.outbox.mu.Lock()
+		s.outbox.events = events
+		s.outbox.mu.Unlock()
+		s.cursors.mu.Lock()
+		s.cursors.cursors = cursors
+		s.cursors.mu.Unlock()
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package memstore
+
+import (
+	"brainrot-tamagotchi/internal/models"
+	"brainrot-tamagotchi/internal/repository"
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// UserStore is an in-memory repository.UserStore
+type UserStore struct {
+	mu     sync.Mutex
+	rows   map[uint]*models.User // By ID, including soft-deleted rows
+	nextID uint
+}
+
+var _ repository.UserStore = (*UserStore)(nil)
+
+func NewUserStore() *UserStore {
+	return &UserStore{rows: make(map[uint]*models.User)}
+}
+
+// WithContext returns the store itself; it has nothing to cancel or log
+func (s *UserStore) WithContext(ctx context.Context) repository.UserStore {
+	return s
+}
+
+// Create stores a new user
+func (s *UserStore) Create(user *models.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user.WalletAddress = strings.ToLower(user.WalletAddress)
+	return s.insert(user)
+}
+
+// GetByWalletAddress returns a user by wallet address
+func (s *UserStore) GetByWalletAddress(address string) (*models.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.get(address)
+}
+
+// GetOrCreate returns a user, creating it on first sight
+func (s *UserStore) GetOrCreate(address string) (*models.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, err := s.get(address)
+	if err == gorm.ErrRecordNotFound {
+		user = &models.User{WalletAddress: strings.ToLower(address)}
+		if err := s.insert(user); err != nil {
+			return nil, err
+		}
+		return user, nil
+	}
+	return user, err
+}
+
+// Update saves every field of a user
+func (s *UserStore) Update(user *models.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if user.ID == 0 {
+		return s.insert(user)
+	}
+	if err := s.checkUnique(user); err != nil {
+		return err
+	}
+	user.UpdatedAt = time.Now()
+	row := *user
+	s.rows[user.ID] = &row
+	if user.ID > s.nextID {
+		s.nextID = user.ID
+	}
+	return nil
+}
+
+func (s *UserStore) get(address string) (*models.User, error) {
+	address = strings.ToLower(address)
+
+	var found *models.User
+	for _, row := range s.rows {
+		if row.WalletAddress == address && !deleted(row.DeletedAt) && (found == nil || row.ID < found.ID) {
+			found = row
+		}
+	}
+	if found == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	user := *found
+	return &user, nil
+}
+
+// insert assigns an ID and stores a copy
+func (s *UserStore) insert(user *models.User) error {
+	if err := s.checkUnique(user); err != nil {
+		return err
+	}
+	if user.ID == 0 {
+		s.nextID++
+		user.ID = s.nextID
+	} else if _, ok := s.rows[user.ID]; ok {
+		return errDuplicate("users_pkey", user.ID)
+	} else if user.ID > s.nextID {
+		s.nextID = user.ID
+	}
+	stamp(&user.CreatedAt, &user.UpdatedAt)
+
+	row := *user
+	s.rows[user.ID] = &row
+	return nil
+}
+
+// checkUnique enforces the unique wallet_address index, which covers
+// deleted rows
+func (s *UserStore) checkUnique(user *models.User) error {
+	for id, row := range s.rows {
+		if id != user.ID && row.WalletAddress == user.WalletAddress {
+			return errDuplicate("idx_users_wallet_address", user.WalletAddress)
+		}
+	}
+	return nil
+}
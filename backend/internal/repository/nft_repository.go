@@ -2,7 +2,9 @@ package repository
 
 import (
 	"brainrot-tamagotchi/internal/models"
+	"context"
 	"strings"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -15,6 +17,11 @@ func NewNFTRepository(db *gorm.DB) *NFTRepository {
 	return &NFTRepository{db: db}
 }
 
+// WithContext returns a copy of the repository whose queries use ctx
+func (r *NFTRepository) WithContext(ctx context.Context) NFTStore {
+	return &NFTRepository{db: r.db.WithContext(ctx)}
+}
+
 // Create creates a new NFT record
 func (r *NFTRepository) Create(nft *models.NFT) error {
 	nft.OwnerAddress = strings.ToLower(nft.OwnerAddress)
@@ -55,6 +62,34 @@ func (r *NFTRepository) UpdateStats(nft *models.NFT) error {
 	}).Error
 }
 
+// UpdateOwner sets the owner of a token
+func (r *NFTRepository) UpdateOwner(tokenID uint, ownerAddress string) error {
+	return r.db.Model(&models.NFT{}).
+		Where("token_id = ?", tokenID).
+		Update("owner_address", strings.ToLower(ownerAddress)).Error
+}
+
+// UpdateChainFields overwrites the fields mirrored from the NFT contract
+func (r *NFTRepository) UpdateChainFields(nft *models.NFT) error {
+	return r.db.Model(&models.NFT{}).
+		Where("token_id = ?", nft.TokenID).
+		Updates(map[string]interface{}{
+			"owner_address": strings.ToLower(nft.OwnerAddress),
+			"meme_type":     nft.MemeType,
+			"rarity":        nft.Rarity,
+			"level":         nft.Level,
+			"color_variant": nft.ColorVariant,
+		}).Error
+}
+
+// GetAfterTokenID retrieves up to limit NFTs with token IDs above afterTokenID,
+// in token ID order, for walking the whole collection in chunks
+func (r *NFTRepository) GetAfterTokenID(afterTokenID uint, limit int) ([]models.NFT, error) {
+	var nfts []models.NFT
+	err := r.db.Where("token_id > ?", afterTokenID).Order("token_id ASC").Limit(limit).Find(&nfts).Error
+	return nfts, err
+}
+
 // GetAll retrieves all NFTs with pagination
 func (r *NFTRepository) GetAll(limit, offset int) ([]models.NFT, error) {
 	var nfts []models.NFT
@@ -69,8 +104,87 @@ func (r *NFTRepository) GetAliveNFTs() ([]models.NFT, error) {
 	return nfts, err
 }
 
+// DecayRules are the stat changes one decay run applies: hunger drops
+// HungerDecay per HungerDecayPeriod since last fed, mood MoodDecay per
+// MoodDecayPeriod since last played, and energy regenerates EnergyRegen
+type DecayRules struct {
+	HungerDecay       int
+	HungerDecayPeriod time.Duration
+	MoodDecay         int
+	MoodDecayPeriod   time.Duration
+	EnergyRegen       int
+}
+
+// decayStatsSQL applies one run of DecayRules to the alive pets in an id
+// range. Rows with nothing to change are left alone.
+const decayStatsSQL = `
+UPDATE nfts SET
+	hunger = CASE WHEN last_fed <= @fed_before
+		THEN GREATEST(0, hunger - @hunger_decay * FLOOR(EXTRACT(EPOCH FROM (CAST(@now AS timestamptz) - last_fed)) / @hunger_period))::int
+		ELSE hunger END,
+	mood = CASE WHEN last_played <= @played_before
+		THEN GREATEST(0, mood - @mood_decay * FLOOR(EXTRACT(EPOCH FROM (CAST(@now AS timestamptz) - last_played)) / @mood_period))::int
+		ELSE mood END,
+	energy = LEAST(100, energy + @energy_regen),
+	updated_at = @now
+WHERE id > @after AND id <= @last
+	AND deleted_at IS NULL
+	AND hunger > 0 AND mood > 0 AND energy > 0
+	AND (last_fed <= @fed_before OR last_played <= @played_before OR energy < 100)
+RETURNING *`
+
+// DecayBatch decays the next limit alive NFTs after afterID, in id order,
+// with one UPDATE. It returns the last id covered (0 once none are left),
+// how many pets were covered and the rows that changed, with new stats.
+func (r *NFTRepository) DecayBatch(afterID uint, limit int, now time.Time, rules DecayRules) (uint, int, []models.NFT, error) {
+	var ids []uint
+	err := r.db.Model(&models.NFT{}).
+		Where("id > ? AND hunger > 0 AND mood > 0 AND energy > 0", afterID).
+		Order("id").
+		Limit(limit).
+		Pluck("id", &ids).Error
+	if err != nil || len(ids) == 0 {
+		return 0, 0, nil, err
+	}
+	lastID := ids[len(ids)-1]
+
+	var decayed []models.NFT
+	err = r.db.Raw(decayStatsSQL, map[string]interface{}{
+		"now":           now,
+		"fed_before":    now.Add(-rules.HungerDecayPeriod),
+		"played_before": now.Add(-rules.MoodDecayPeriod),
+		"hunger_decay":  rules.HungerDecay,
+		"hunger_period": rules.HungerDecayPeriod.Seconds(),
+		"mood_decay":    rules.MoodDecay,
+		"mood_period":   rules.MoodDecayPeriod.Seconds(),
+		"energy_regen":  rules.EnergyRegen,
+		"after":         afterID,
+		"last":          lastID,
+	}).Scan(&decayed).Error
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	return lastID, len(ids), decayed, nil
+}
+
 // Delete soft deletes an NFT (when burned)
 func (r *NFTRepository) Delete(tokenID uint) error {
 	return r.db.Where("token_id = ?", tokenID).Delete(&models.NFT{}).Error
 }
 
+// PetPopulation counts pets of one rarity that are alive or dead
+type PetPopulation struct {
+	Rarity string
+	Alive  bool
+	Count  int64
+}
+
+// CountPopulation counts pets by rarity and whether they are alive
+func (r *NFTRepository) CountPopulation() ([]PetPopulation, error) {
+	var rows []PetPopulation
+	err := r.db.Model(&models.NFT{}).
+		Select("rarity, hunger > 0 AND mood > 0 AND energy > 0 AS alive, COUNT(*) AS count").
+		Group("rarity, alive").
+		Scan(&rows).Error
+	return rows, err
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"brainrot-tamagotchi/internal/models"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type NotificationRepository struct {
+	db *gorm.DB
+}
+
+func NewNotificationRepository(db *gorm.DB) *NotificationRepository {
+	return &NotificationRepository{db: db}
+}
+
+// GetPreference retrieves a wallet's notification preferences
+func (r *NotificationRepository) GetPreference(walletAddress string) (*models.NotificationPreference, error) {
+	var pref models.NotificationPreference
+	err := r.db.Where("wallet_address = ?", strings.ToLower(walletAddress)).First(&pref).Error
+	if err != nil {
+		return nil, err
+	}
+	return &pref, nil
+}
+
+// SavePreference creates or replaces a wallet's notification preferences
+func (r *NotificationRepository) SavePreference(pref *models.NotificationPreference) error {
+	pref.WalletAddress = strings.ToLower(pref.WalletAddress)
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "wallet_address"}},
+		DoUpdates: clause.AssignmentColumns([]string{"channels", "kinds", "quiet_start", "quiet_end", "timezone", "updated_at"}),
+	}).Create(pref).Error
+}
+
+// Create stores a notification and reports whether it was new. A
+// notification whose dedup key was already used is skipped.
+func (r *NotificationRepository) Create(notification *models.Notification) (bool, error) {
+	notification.WalletAddress = strings.ToLower(notification.WalletAddress)
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(notification)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// Update saves all fields of a notification
+func (r *NotificationRepository) Update(notification *models.Notification) error {
+	return r.db.Save(notification).Error
+}
+
+// GetDue returns pending notifications whose delivery time has come, oldest
+// first
+func (r *NotificationRepository) GetDue(now time.Time, limit int) ([]models.Notification, error) {
+	var notifications []models.Notification
+	err := r.db.Where("status = ? AND deliver_after <= ?", models.NotificationPending, now).
+		Order("deliver_after ASC, id ASC").
+		Limit(limit).
+		Find(&notifications).Error
+	return notifications, err
+}
+
+// GetByWallet returns a wallet's notifications, newest first
+func (r *NotificationRepository) GetByWallet(walletAddress string, limit int) ([]models.Notification, error) {
+	var notifications []models.Notification
+	err := r.db.Where("wallet_address = ?", strings.ToLower(walletAddress)).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&notifications).Error
+	return notifications, err
+}
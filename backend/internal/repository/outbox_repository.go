@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"brainrot-tamagotchi/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type OutboxRepository struct {
+	db *gorm.DB
+}
+
+func NewOutboxRepository(db *gorm.DB) *OutboxRepository {
+	return &OutboxRepository{db: db}
+}
+
+// Create appends events to the outbox
+func (r *OutboxRepository) Create(events ...*models.OutboxEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	return r.db.Create(events).Error
+}
+
+// Update saves all fields of an event
+func (r *OutboxRepository) Update(event *models.OutboxEvent) error {
+	return r.db.Save(event).Error
+}
+
+// LockPending locks up to limit due events, oldest first, skipping rows
+// another dispatcher holds. Must run inside a transaction.
+func (r *OutboxRepository) LockPending(now time.Time, limit int) ([]models.OutboxEvent, error) {
+	var events []models.OutboxEvent
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("status = ? AND available_at <= ?", models.OutboxPending, now).
+		Order("id ASC").
+		Limit(limit).
+		Find(&events).Error
+	return events, err
+}
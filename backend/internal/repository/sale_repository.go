@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"brainrot-tamagotchi/internal/models"
+	"brainrot-tamagotchi/pkg/money"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SaleRepository struct {
+	db *gorm.DB
+}
+
+func NewSaleRepository(db *gorm.DB) *SaleRepository {
+	return &SaleRepository{db: db}
+}
+
+// SaleTotals aggregates a set of sales
+type SaleTotals struct {
+	SalesCount     int64     `json:"sales_count"`
+	GrossVolume    money.Wei `json:"gross_volume"`
+	PlatformFees   money.Wei `json:"platform_fees"`
+	SellerProceeds money.Wei `json:"seller_proceeds"`
+}
+
+// Create records a sale; re-recording the same (tx hash, log index) is a no-op
+func (r *SaleRepository) Create(sale *models.MarketSale) error {
+	sale.SellerAddress = strings.ToLower(sale.SellerAddress)
+	sale.BuyerAddress = strings.ToLower(sale.BuyerAddress)
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(sale).Error
+}
+
+// GetBySeller retrieves a seller's sales, newest first, with the total count
+func (r *SaleRepository) GetBySeller(sellerAddress string, dateRange DateRange, limit, offset int) ([]models.MarketSale, int64, error) {
+	var sales []models.MarketSale
+	var total int64
+
+	query := applySoldAtRange(r.db.Model(&models.MarketSale{}), dateRange).
+		Where("seller_address = ?", strings.ToLower(sellerAddress)).
+		Session(&gorm.Session{})
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("sold_at DESC").Limit(limit).Offset(offset).Find(&sales).Error
+	return sales, total, err
+}
+
+// GetTotals aggregates sales in a date range.
+// An empty sellerAddress aggregates across all sellers.
+func (r *SaleRepository) GetTotals(sellerAddress string, dateRange DateRange) (*SaleTotals, error) {
+	var totals SaleTotals
+	query := applySoldAtRange(r.db.Model(&models.MarketSale{}), dateRange)
+	if sellerAddress != "" {
+		query = query.Where("seller_address = ?", strings.ToLower(sellerAddress))
+	}
+
+	err := query.Select(`COUNT(*) AS sales_count,
+		COALESCE(SUM(gross_price), 0) AS gross_volume,
+		COALESCE(SUM(platform_fee), 0) AS platform_fees,
+		COALESCE(SUM(seller_proceeds), 0) AS seller_proceeds`).
+		Scan(&totals).Error
+	return &totals, err
+}
+
+func applySoldAtRange(query *gorm.DB, dateRange DateRange) *gorm.DB {
+	if dateRange.From != nil {
+		query = query.Where("sold_at >= ?", *dateRange.From)
+	}
+	if dateRange.To != nil {
+		query = query.Where("sold_at < ?", *dateRange.To)
+	}
+	return query
+}
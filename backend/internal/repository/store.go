@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"brainrot-tamagotchi/internal/models"
+	"brainrot-tamagotchi/pkg/money"
+	"context"
+	"time"
+)
+
+// The store interfaces are what services need from the core repositories.
+// The GORM repositories implement them, and so do the in-memory stores in
+// memstore; storetest holds the contract both must meet. A missing record
+// is gorm.ErrRecordNotFound in every implementation. WithContext scopes a
+// store to a request or job, so its queries carry the context's deadline
+// and log fields. Writes that must commit together go through Stores.
+
+// NFTStore stores pets
+type NFTStore interface {
+	WithContext(ctx context.Context) NFTStore
+	Create(nft *models.NFT) error
+	GetByTokenID(tokenID uint) (*models.NFT, error)
+	GetByOwner(ownerAddress string) ([]models.NFT, error)
+	Update(nft *models.NFT) error
+	UpdateStats(nft *models.NFT) error
+	UpdateOwner(tokenID uint, ownerAddress string) error
+	UpdateChainFields(nft *models.NFT) error
+	GetAfterTokenID(afterTokenID uint, limit int) ([]models.NFT, error)
+	GetAll(limit, offset int) ([]models.NFT, error)
+	GetAliveNFTs() ([]models.NFT, error)
+	DecayBatch(afterID uint, limit int, now time.Time, rules DecayRules) (uint, int, []models.NFT, error)
+	Delete(tokenID uint) error
+}
+
+// ListingStore stores marketplace listings, one row per token
+type ListingStore interface {
+	WithContext(ctx context.Context) ListingStore
+	Create(listing *models.MarketListing) error
+	GetByTokenID(tokenID uint) (*models.MarketListing, error)
+	GetLatestByTokenID(tokenID uint) (*models.MarketListing, error)
+	Upsert(listing *models.MarketListing) error
+	GetActiveByTokenIDs(tokenIDs []uint) ([]models.MarketListing, error)
+	GetActiveListings(limit, offset int, filters map[string]interface{}) ([]models.MarketListing, error)
+	GetBySeller(sellerAddress string, active bool) ([]models.MarketListing, error)
+	Update(listing *models.MarketListing) error
+	Deactivate(tokenID uint) error
+	MarkAsSold(tokenID uint, buyerAddress string) error
+	GetAverageSalePriceByRarity() (map[string]money.Wei, error)
+}
+
+// UserStore stores wallets that have used the app
+type UserStore interface {
+	WithContext(ctx context.Context) UserStore
+	Create(user *models.User) error
+	GetByWalletAddress(address string) (*models.User, error)
+	GetOrCreate(address string) (*models.User, error)
+	Update(user *models.User) error
+}
+
+// CaseOpeningStore stores case openings and aggregates them. Create skips an
+// opening whose (tx hash, log index) was already recorded.
+type CaseOpeningStore interface {
+	WithContext(ctx context.Context) CaseOpeningStore
+	Create(opening *models.CaseOpening) error
+	GetByUser(userAddress string, dateRange DateRange, limit, offset int) ([]models.CaseOpening, int64, error)
+	GetByUserAndCaseType(userAddress, caseType string) ([]models.CaseOpening, error)
+	GetAfterID(afterID uint, limit int) ([]models.CaseOpening, error)
+	GetLatestID() (uint, error)
+	GetTotalsByCaseType(userAddress string, dateRange DateRange) ([]CaseTypeTotals, error)
+	GetRarityDistribution(userAddress string, dateRange DateRange) ([]RarityCount, error)
+	GetLuckiestPulls(odds map[string]map[string]float64, dateRange DateRange, limit int) ([]models.CaseOpening, error)
+}
+
+// NotificationStore stores notification preferences and the notification
+// queue. Create skips a notification whose dedup key was already used.
+type NotificationStore interface {
+	GetPreference(walletAddress string) (*models.NotificationPreference, error)
+	SavePreference(pref *models.NotificationPreference) error
+	Create(notification *models.Notification) (bool, error)
+	Update(notification *models.Notification) error
+	GetDue(now time.Time, limit int) ([]models.Notification, error)
+	GetByWallet(walletAddress string, limit int) ([]models.Notification, error)
+}
+
+// SaleStore stores the marketplace sales ledger. Create skips a sale whose
+// (tx hash, log index) was already recorded.
+type SaleStore interface {
+	Create(sale *models.MarketSale) error
+	GetBySeller(sellerAddress string, dateRange DateRange, limit, offset int) ([]models.MarketSale, int64, error)
+	GetTotals(sellerAddress string, dateRange DateRange) (*SaleTotals, error)
+}
+
+// ChainEventStore records the contract logs that have been applied
+type ChainEventStore interface {
+	MarkProcessed(event *models.ProcessedChainEvent) (bool, error)
+}
+
+// OutboxStore is the transactional outbox. LockPending must run in a
+// transaction, and the events it returns stay locked until it ends.
+type OutboxStore interface {
+	Create(events ...*models.OutboxEvent) error
+	Update(event *models.OutboxEvent) error
+	LockPending(now time.Time, limit int) ([]models.OutboxEvent, error)
+}
+
+// CursorStore holds named sync cursors
+type CursorStore interface {
+	GetCursor(name string) (uint64, error)
+	SetCursor(name string, value uint64) error
+}
+
+// Stores bundles the stores whose writes must commit together, such as a
+// state change and the outbox events describing it
+type Stores interface {
+	WithContext(ctx context.Context) Stores
+	NFTs() NFTStore
+	Listings() ListingStore
+	Sales() SaleStore
+	CaseOpenings() CaseOpeningStore
+	ChainEvents() ChainEventStore
+	Outbox() OutboxStore
+	Cursors() CursorStore
+	// Transaction runs fn with stores bound to one transaction. Everything
+	// fn writes through them commits when it returns nil and is rolled back
+	// when it returns an error.
+	Transaction(fn func(tx Stores) error) error
+}
+
+var (
+	_ NFTStore          = (*NFTRepository)(nil)
+	_ ListingStore      = (*MarketListingRepository)(nil)
+	_ UserStore         = (*UserRepository)(nil)
+	_ CaseOpeningStore  = (*CaseOpeningRepository)(nil)
+	_ NotificationStore = (*NotificationRepository)(nil)
+	_ SaleStore         = (*SaleRepository)(nil)
+	_ ChainEventStore   = (*ChainEventRepository)(nil)
+	_ OutboxStore       = (*OutboxRepository)(nil)
+	_ CursorStore       = (*ChainEventRepository)(nil)
+	_ Stores            = (*DBStores)(nil)
+)
@@ -0,0 +1,90 @@
+package repository_test
+
+import (
+	"brainrot-tamagotchi/internal/repository"
+	"brainrot-tamagotchi/internal/repository/storetest"
+	"brainrot-tamagotchi/pkg/database"
+	"os"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// The GORM stores run the storetest contract against Postgres when
+// TEST_DATABASE_URL is set. It must point at a disposable database: the
+// store tables are truncated before every subtest.
+func testDB(t *testing.T) *gorm.DB {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := database.MigrateUp(db); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// emptied truncates the store tables
+func emptied(t *testing.T, db *gorm.DB) *gorm.DB {
+	if err := db.Exec("TRUNCATE nfts, market_listings, market_sales, processed_chain_events, users, case_openings, notifications, notification_preferences, outbox_events, sync_cursors RESTART IDENTITY").Error; err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestNFTRepositoryContract(t *testing.T) {
+	db := testDB(t)
+	storetest.TestNFTStore(t, func(t *testing.T) repository.NFTStore {
+		return repository.NewNFTRepository(emptied(t, db))
+	})
+}
+
+func TestMarketListingRepositoryContract(t *testing.T) {
+	db := testDB(t)
+	storetest.TestListingStore(t, func(t *testing.T) (repository.ListingStore, repository.NFTStore) {
+		emptied(t, db)
+		return repository.NewMarketListingRepository(db), repository.NewNFTRepository(db)
+	})
+}
+
+func TestUserRepositoryContract(t *testing.T) {
+	db := testDB(t)
+	storetest.TestUserStore(t, func(t *testing.T) repository.UserStore {
+		return repository.NewUserRepository(emptied(t, db))
+	})
+}
+
+func TestCaseOpeningRepositoryContract(t *testing.T) {
+	db := testDB(t)
+	storetest.TestCaseOpeningStore(t, func(t *testing.T) repository.CaseOpeningStore {
+		return repository.NewCaseOpeningRepository(emptied(t, db))
+	})
+}
+
+func TestNotificationRepositoryContract(t *testing.T) {
+	db := testDB(t)
+	storetest.TestNotificationStore(t, func(t *testing.T) repository.NotificationStore {
+		return repository.NewNotificationRepository(emptied(t, db))
+	})
+}
+
+func TestSaleRepositoryContract(t *testing.T) {
+	db := testDB(t)
+	storetest.TestSaleStore(t, func(t *testing.T) repository.SaleStore {
+		return repository.NewSaleRepository(emptied(t, db))
+	})
+}
+
+func TestDBStoresContract(t *testing.T) {
+	db := testDB(t)
+	storetest.TestStores(t, func(t *testing.T) repository.Stores {
+		return repository.NewDBStores(emptied(t, db))
+	})
+}
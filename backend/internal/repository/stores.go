@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// DBStores is Stores over the GORM repositories
+type DBStores struct {
+	db *gorm.DB
+}
+
+func NewDBStores(db *gorm.DB) *DBStores {
+	return &DBStores{db: db}
+}
+
+// WithContext returns a copy of the stores whose queries use ctx
+func (s *DBStores) WithContext(ctx context.Context) Stores {
+	return &DBStores{db: s.db.WithContext(ctx)}
+}
+
+// NFTs returns the NFT repository on the same connection or transaction
+func (s *DBStores) NFTs() NFTStore {
+	return NewNFTRepository(s.db)
+}
+
+// Listings returns the listing repository on the same connection or transaction
+func (s *DBStores) Listings() ListingStore {
+	return NewMarketListingRepository(s.db)
+}
+
+// Sales returns the sales ledger on the same connection or transaction
+func (s *DBStores) Sales() SaleStore {
+	return NewSaleRepository(s.db)
+}
+
+// CaseOpenings returns the case opening repository on the same connection or
+// transaction
+func (s *DBStores) CaseOpenings() CaseOpeningStore {
+	return NewCaseOpeningRepository(s.db)
+}
+
+// ChainEvents returns the processed chain events on the same connection or
+// transaction
+func (s *DBStores) ChainEvents() ChainEventStore {
+	return NewChainEventRepository(s.db)
+}
+
+// Outbox returns the outbox repository on the same connection or transaction
+func (s *DBStores) Outbox() OutboxStore {
+	return NewOutboxRepository(s.db)
+}
+
+// Cursors returns the sync cursors on the same connection or transaction
+func (s *DBStores) Cursors() CursorStore {
+	return NewChainEventRepository(s.db)
+}
+
+// Transaction runs fn in a database transaction
+func (s *DBStores) Transaction(fn func(tx Stores) error) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		return fn(&DBStores{db: tx})
+	})
+}
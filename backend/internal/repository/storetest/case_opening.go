@@ -0,0 +1,198 @@
+package storetest
+
+import (
+	"brainrot-tamagotchi/internal/models"
+	"brainrot-tamagotchi/internal/repository"
+	"brainrot-tamagotchi/pkg/money"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// TestCaseOpeningStore runs the CaseOpeningStore contract. newStore must
+// return an empty store on every call.
+func TestCaseOpeningStore(t *testing.T, newStore func(t *testing.T) repository.CaseOpeningStore) {
+	// seed records, in ID order:
+	//   0xaa: bronze/common @0, bronze/rare @1, gold/legendary @2, gold/common @3
+	//   0xbb: bronze/common @4
+	seed := func(t *testing.T) repository.CaseOpeningStore {
+		s := newStore(t)
+		openings := []models.CaseOpening{
+			{UserAddress: "0xAA", CaseType: "bronze", Rarity: "common", Price: money.WeiFromInt64(10), OpenedAt: at(0)},
+			{UserAddress: "0xaa", CaseType: "bronze", Rarity: "rare", Price: money.WeiFromInt64(10), OpenedAt: at(1)},
+			{UserAddress: "0xaa", CaseType: "gold", Rarity: "legendary", Price: money.WeiFromInt64(50), OpenedAt: at(2)},
+			{UserAddress: "0xaa", CaseType: "gold", Rarity: "common", Price: money.WeiFromInt64(50), OpenedAt: at(3)},
+			{UserAddress: "0xBB", CaseType: "bronze", Rarity: "common", Price: money.WeiFromInt64(10), OpenedAt: at(4)},
+		}
+		for i := range openings {
+			check(t, s.Create(&openings[i]))
+		}
+		return s
+	}
+	rangeOf := func(from, to int) repository.DateRange {
+		f, e := at(from), at(to)
+		return repository.DateRange{From: &f, To: &e}
+	}
+
+	t.Run("CreateLowerCases", func(t *testing.T) {
+		s := newStore(t)
+		opening := &models.CaseOpening{UserAddress: "0xAbC", CaseType: "bronze", OpenedAt: at(0)}
+		check(t, s.Create(opening))
+		if opening.ID == 0 || opening.UserAddress != "0xabc" {
+			t.Fatalf("created %+v, want an ID and a lower-cased user", opening)
+		}
+	})
+
+	t.Run("CreateDedups", func(t *testing.T) {
+		s := newStore(t)
+		opening := func(logIndex uint) *models.CaseOpening {
+			return &models.CaseOpening{UserAddress: "0xaa", CaseType: "bronze", TxHash: "0xtx", LogIndex: logIndex, OpenedAt: at(0)}
+		}
+		check(t, s.Create(opening(1)))
+		check(t, s.Create(opening(1)))
+		check(t, s.Create(opening(2)))
+		// Openings recorded without a tx hash are never merged
+		check(t, s.Create(&models.CaseOpening{UserAddress: "0xaa", CaseType: "gold", OpenedAt: at(1)}))
+		check(t, s.Create(&models.CaseOpening{UserAddress: "0xaa", CaseType: "gold", OpenedAt: at(2)}))
+
+		_, total, err := s.GetByUser("0xaa", repository.DateRange{}, 10, 0)
+		check(t, err)
+		if total != 4 {
+			t.Fatalf("stored %d openings, want 4", total)
+		}
+	})
+
+	t.Run("GetByUser", func(t *testing.T) {
+		s := seed(t)
+
+		openings, total, err := s.GetByUser("0xAA", repository.DateRange{}, 2, 1)
+		check(t, err)
+		if total != 4 || openedHours(openings) != "2,1" {
+			t.Fatalf("page = %s of %d, want 2,1 of 4", openedHours(openings), total)
+		}
+
+		// From is inclusive, To exclusive
+		openings, total, err = s.GetByUser("0xaa", rangeOf(1, 3), 10, 0)
+		check(t, err)
+		if total != 2 || openedHours(openings) != "2,1" {
+			t.Fatalf("range = %s of %d, want 2,1 of 2", openedHours(openings), total)
+		}
+	})
+
+	t.Run("GetByUserAndCaseType", func(t *testing.T) {
+		s := seed(t)
+		openings, err := s.GetByUserAndCaseType("0xAA", "gold")
+		check(t, err)
+		if openedHours(openings) != "2,3" {
+			t.Fatalf("gold = %s, want oldest first 2,3", openedHours(openings))
+		}
+	})
+
+	t.Run("GetAfterIDAndLatestID", func(t *testing.T) {
+		s := newStore(t)
+		latest, err := s.GetLatestID()
+		check(t, err)
+		if latest != 0 {
+			t.Fatalf("latest ID of an empty store = %d, want 0", latest)
+		}
+
+		s = seed(t)
+		all, err := s.GetAfterID(0, 10)
+		check(t, err)
+		if len(all) != 5 {
+			t.Fatalf("got %d openings, want 5", len(all))
+		}
+		next, err := s.GetAfterID(all[1].ID, 2)
+		check(t, err)
+		if len(next) != 2 || next[0].ID != all[2].ID || next[1].ID != all[3].ID {
+			t.Fatalf("after the second = %s, want the third and fourth", openedHours(next))
+		}
+
+		latest, err = s.GetLatestID()
+		check(t, err)
+		if latest != all[4].ID {
+			t.Fatalf("latest ID = %d, want %d", latest, all[4].ID)
+		}
+	})
+
+	t.Run("GetTotalsByCaseType", func(t *testing.T) {
+		s := seed(t)
+
+		totals, err := s.GetTotalsByCaseType("", repository.DateRange{})
+		check(t, err)
+		if got := totalsString(totals); got != "bronze:3:30 gold:2:100" {
+			t.Fatalf("totals = %s", got)
+		}
+
+		totals, err = s.GetTotalsByCaseType("0xAA", rangeOf(0, 3))
+		check(t, err)
+		if got := totalsString(totals); got != "bronze:2:20 gold:1:50" {
+			t.Fatalf("user totals = %s", got)
+		}
+	})
+
+	t.Run("GetRarityDistribution", func(t *testing.T) {
+		s := seed(t)
+		counts, err := s.GetRarityDistribution("", repository.DateRange{})
+		check(t, err)
+		var got string
+		for _, c := range counts {
+			got += fmt.Sprintf("%s/%s:%d ", c.CaseType, c.Rarity, c.Count)
+		}
+		if got != "bronze/common:2 bronze/rare:1 gold/common:1 gold/legendary:1 " {
+			t.Fatalf("distribution = %s", got)
+		}
+	})
+
+	t.Run("GetLuckiestPulls", func(t *testing.T) {
+		s := seed(t)
+		odds := map[string]map[string]float64{
+			"bronze": {"common": 0.8, "rare": 0.2},
+			"gold":   {"common": 0.5, "legendary": 0.05},
+		}
+
+		pulls, err := s.GetLuckiestPulls(odds, repository.DateRange{}, 3)
+		check(t, err)
+		// legendary 0.05, rare 0.2, gold common 0.5
+		if openedHours(pulls) != "2,1,3" {
+			t.Fatalf("luckiest = %s, want 2,1,3", openedHours(pulls))
+		}
+
+		// Ties on odds go to the newest pull; rarities missing from odds
+		// count as certain and drop out
+		pulls, err = s.GetLuckiestPulls(map[string]map[string]float64{"bronze": {"common": 0.8}}, repository.DateRange{}, 10)
+		check(t, err)
+		if openedHours(pulls) != "4,0" {
+			t.Fatalf("luckiest = %s, want 4,0", openedHours(pulls))
+		}
+
+		pulls, err = s.GetLuckiestPulls(nil, repository.DateRange{}, 10)
+		check(t, err)
+		if len(pulls) != 0 {
+			t.Fatalf("no odds returned %s", openedHours(pulls))
+		}
+	})
+}
+
+// openedHours lists openings by hours after base, in order
+func openedHours(openings []models.CaseOpening) string {
+	var s string
+	for i, opening := range openings {
+		if i > 0 {
+			s += ","
+		}
+		s += fmt.Sprint(int(opening.OpenedAt.Sub(base) / time.Hour))
+	}
+	return s
+}
+
+func totalsString(totals []repository.CaseTypeTotals) string {
+	var s string
+	for i, t := range totals {
+		if i > 0 {
+			s += " "
+		}
+		s += fmt.Sprintf("%s:%d:%s", t.CaseType, t.Count, t.Revenue)
+	}
+	return s
+}
@@ -0,0 +1,210 @@
+package storetest
+
+import (
+	"brainrot-tamagotchi/internal/models"
+	"brainrot-tamagotchi/internal/repository"
+	"brainrot-tamagotchi/pkg/money"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// TestListingStore runs the ListingStore contract. newStores must return an
+// empty listing store and the empty NFT store its joins read from.
+func TestListingStore(t *testing.T, newStores func(t *testing.T) (repository.ListingStore, repository.NFTStore)) {
+	t.Run("CreateAndGet", func(t *testing.T) {
+		s, _ := newStores(t)
+		listing := &models.MarketListing{TokenID: 1, SellerAddress: "0xSeLLer", Price: money.WeiFromInt64(500), ListedAt: at(0)}
+		check(t, s.Create(listing))
+		if listing.ID == 0 || listing.SellerAddress != "0xseller" {
+			t.Fatalf("created %+v, want an ID and a lower-cased seller", listing)
+		}
+		// A zero IsActive takes the column default
+		if !listing.IsActive {
+			t.Fatal("new listing is not active")
+		}
+
+		got, err := s.GetByTokenID(1)
+		check(t, err)
+		if got.ID != listing.ID || got.Price.Cmp(money.WeiFromInt64(500)) != 0 {
+			t.Fatalf("got %+v", got)
+		}
+
+		_, err = s.GetByTokenID(2)
+		wantNotFound(t, err)
+		_, err = s.GetLatestByTokenID(2)
+		wantNotFound(t, err)
+
+		if err := s.Create(&models.MarketListing{TokenID: 1, SellerAddress: "0xb"}); err == nil {
+			t.Fatal("second listing row for the same token was accepted")
+		}
+	})
+
+	t.Run("DeactivateAndMarkAsSold", func(t *testing.T) {
+		s, _ := newStores(t)
+		check(t, s.Create(&models.MarketListing{TokenID: 1, SellerAddress: "0xa", ListedAt: at(0)}))
+		check(t, s.Create(&models.MarketListing{TokenID: 2, SellerAddress: "0xa", ListedAt: at(0)}))
+
+		check(t, s.Deactivate(1))
+		_, err := s.GetByTokenID(1)
+		wantNotFound(t, err)
+		latest, err := s.GetLatestByTokenID(1)
+		check(t, err)
+		if latest.IsActive {
+			t.Fatal("deactivated listing is still active")
+		}
+
+		check(t, s.MarkAsSold(2, "0xBuYeR"))
+		sold, err := s.GetLatestByTokenID(2)
+		check(t, err)
+		if sold.IsActive || sold.SoldAt == nil || sold.BuyerAddress == nil || *sold.BuyerAddress != "0xbuyer" {
+			t.Fatalf("sold listing = %+v, want inactive with sold_at and a lower-cased buyer", sold)
+		}
+	})
+
+	t.Run("UpsertRevivesDeleted", func(t *testing.T) {
+		s, _ := newStores(t)
+		listing := &models.MarketListing{TokenID: 1, SellerAddress: "0xa", ListedAt: at(0)}
+		check(t, s.Create(listing))
+
+		listing.DeletedAt = gorm.DeletedAt{Time: at(1), Valid: true}
+		check(t, s.Update(listing))
+		_, err := s.GetByTokenID(1)
+		wantNotFound(t, err)
+		deleted, err := s.GetLatestByTokenID(1)
+		check(t, err)
+		if deleted.ID != listing.ID {
+			t.Fatalf("GetLatestByTokenID = %+v, want the deleted row", deleted)
+		}
+
+		deleted.SellerAddress = "0xNEW"
+		deleted.Price = money.WeiFromInt64(7)
+		check(t, s.Upsert(deleted))
+		got, err := s.GetByTokenID(1)
+		check(t, err)
+		if got.ID != listing.ID || got.SellerAddress != "0xnew" || got.Price.Cmp(money.WeiFromInt64(7)) != 0 {
+			t.Fatalf("revived listing = %+v", got)
+		}
+	})
+
+	t.Run("UpsertInserts", func(t *testing.T) {
+		s, _ := newStores(t)
+		listing := &models.MarketListing{TokenID: 3, SellerAddress: "0xA", IsActive: true, ListedAt: at(0)}
+		check(t, s.Upsert(listing))
+		if listing.ID == 0 {
+			t.Fatal("Upsert of a new listing did not assign an ID")
+		}
+		got, err := s.GetByTokenID(3)
+		check(t, err)
+		if got.SellerAddress != "0xa" {
+			t.Fatalf("seller = %q, want 0xa", got.SellerAddress)
+		}
+	})
+
+	t.Run("GetActiveByTokenIDs", func(t *testing.T) {
+		s, _ := newStores(t)
+		for _, id := range []uint{1, 2, 3} {
+			check(t, s.Create(&models.MarketListing{TokenID: id, SellerAddress: "0xa", ListedAt: at(0)}))
+		}
+		check(t, s.Deactivate(2))
+
+		listings, err := s.GetActiveByTokenIDs([]uint{1, 2, 4})
+		check(t, err)
+		if listedIDSet(listings) != "1" {
+			t.Fatalf("active = %s, want 1", listedIDSet(listings))
+		}
+		listings, err = s.GetActiveByTokenIDs(nil)
+		check(t, err)
+		if len(listings) != 0 {
+			t.Fatalf("no token IDs returned %s", listedIDSet(listings))
+		}
+	})
+
+	t.Run("GetActiveListings", func(t *testing.T) {
+		s, nfts := newStores(t)
+		pets := []models.NFT{
+			{TokenID: 1, OwnerAddress: "0xa", Rarity: "common", Level: 1},
+			{TokenID: 2, OwnerAddress: "0xa", Rarity: "rare", Level: 3},
+			{TokenID: 3, OwnerAddress: "0xa", Rarity: "rare", Level: 5},
+			{TokenID: 4, OwnerAddress: "0xa", Rarity: "rare", Level: 5},
+		}
+		for i := range pets {
+			check(t, nfts.Create(&pets[i]))
+		}
+		for i, id := range []uint{1, 2, 3, 4, 5} {
+			check(t, s.Create(&models.MarketListing{
+				TokenID:       id,
+				SellerAddress: "0xa",
+				Price:         money.WeiFromInt64(int64(id) * 100),
+				ListedAt:      at(i),
+			}))
+		}
+		check(t, s.Deactivate(4))
+
+		cases := []struct {
+			name    string
+			filters map[string]interface{}
+			limit   int
+			offset  int
+			want    string
+		}{
+			{"all newest first", nil, 10, 0, "5,3,2,1"},
+			{"paged", nil, 2, 1, "3,2"},
+			{"rarity", map[string]interface{}{"rarity": "rare"}, 10, 0, "3,2"},
+			{"min level", map[string]interface{}{"min_level": 3}, 10, 0, "3,2"},
+			{"max price", map[string]interface{}{"max_price": money.WeiFromInt64(200)}, 10, 0, "2,1"},
+			{"combined", map[string]interface{}{"rarity": "rare", "min_level": 4, "max_price": money.WeiFromInt64(300)}, 10, 0, "3"},
+		}
+		for _, tc := range cases {
+			listings, err := s.GetActiveListings(tc.limit, tc.offset, tc.filters)
+			check(t, err)
+			if listedIDs(listings) != tc.want {
+				t.Errorf("%s = %s, want %s", tc.name, listedIDs(listings), tc.want)
+			}
+		}
+	})
+
+	t.Run("GetBySeller", func(t *testing.T) {
+		s, _ := newStores(t)
+		check(t, s.Create(&models.MarketListing{TokenID: 1, SellerAddress: "0xAB", ListedAt: at(0)}))
+		check(t, s.Create(&models.MarketListing{TokenID: 2, SellerAddress: "0xab", ListedAt: at(1)}))
+		check(t, s.Create(&models.MarketListing{TokenID: 3, SellerAddress: "0xcd", ListedAt: at(2)}))
+		check(t, s.Deactivate(2))
+
+		listings, err := s.GetBySeller("0xAb", false)
+		check(t, err)
+		if listedIDs(listings) != "2,1" {
+			t.Fatalf("all = %s, want 2,1", listedIDs(listings))
+		}
+		listings, err = s.GetBySeller("0xAb", true)
+		check(t, err)
+		if listedIDs(listings) != "1" {
+			t.Fatalf("active = %s, want 1", listedIDs(listings))
+		}
+	})
+
+	t.Run("GetAverageSalePriceByRarity", func(t *testing.T) {
+		s, nfts := newStores(t)
+		for _, pet := range []models.NFT{
+			{TokenID: 1, OwnerAddress: "0xa", Rarity: "rare"},
+			{TokenID: 2, OwnerAddress: "0xa", Rarity: "rare"},
+			{TokenID: 3, OwnerAddress: "0xa", Rarity: "epic"},
+			{TokenID: 4, OwnerAddress: "0xa", Rarity: "epic"},
+		} {
+			pet := pet
+			check(t, nfts.Create(&pet))
+		}
+		for id, price := range map[uint]int64{1: 100, 2: 201, 3: 1000, 4: 5} {
+			check(t, s.Create(&models.MarketListing{TokenID: id, SellerAddress: "0xa", Price: money.WeiFromInt64(price), ListedAt: at(0)}))
+		}
+		check(t, s.MarkAsSold(1, "0xb"))
+		check(t, s.MarkAsSold(2, "0xb"))
+		check(t, s.MarkAsSold(3, "0xb"))
+
+		prices, err := s.GetAverageSalePriceByRarity()
+		check(t, err)
+		if len(prices) != 2 || prices["rare"].String() != "150" || prices["epic"].String() != "1000" {
+			t.Fatalf("averages = %v, want rare 150 (truncated) and epic 1000", prices)
+		}
+	})
+}
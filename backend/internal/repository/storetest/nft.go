@@ -0,0 +1,260 @@
+package storetest
+
+import (
+	"brainrot-tamagotchi/internal/models"
+	"brainrot-tamagotchi/internal/repository"
+	"testing"
+	"time"
+)
+
+// TestNFTStore runs the NFTStore contract. newStore must return an empty
+// store on every call.
+func TestNFTStore(t *testing.T, newStore func(t *testing.T) repository.NFTStore) {
+	t.Run("CreateAndGet", func(t *testing.T) {
+		s := newStore(t)
+		nft := &models.NFT{TokenID: 1, OwnerAddress: "0xAbCdEf", Rarity: "rare"}
+		check(t, s.Create(nft))
+		if nft.ID == 0 {
+			t.Fatal("Create did not assign an ID")
+		}
+		if nft.OwnerAddress != "0xabcdef" {
+			t.Fatalf("owner = %q, want it lower-cased", nft.OwnerAddress)
+		}
+
+		got, err := s.GetByTokenID(1)
+		check(t, err)
+		if got.ID != nft.ID || got.OwnerAddress != "0xabcdef" || got.Rarity != "rare" {
+			t.Fatalf("got %+v", got)
+		}
+		// Zero stats and level take the column defaults
+		if got.Hunger != 100 || got.Mood != 100 || got.Energy != 100 || got.Level != 1 {
+			t.Fatalf("stats = %d/%d/%d level %d, want defaults 100/100/100 level 1", got.Hunger, got.Mood, got.Energy, got.Level)
+		}
+
+		_, err = s.GetByTokenID(2)
+		wantNotFound(t, err)
+	})
+
+	t.Run("DuplicateTokenID", func(t *testing.T) {
+		s := newStore(t)
+		check(t, s.Create(&models.NFT{TokenID: 1, OwnerAddress: "0xa"}))
+		if err := s.Create(&models.NFT{TokenID: 1, OwnerAddress: "0xb"}); err == nil {
+			t.Fatal("second NFT with the same token ID was accepted")
+		}
+	})
+
+	t.Run("GetByOwner", func(t *testing.T) {
+		s := newStore(t)
+		check(t, s.Create(&models.NFT{TokenID: 1, OwnerAddress: "0xAA"}))
+		check(t, s.Create(&models.NFT{TokenID: 2, OwnerAddress: "0xaa"}))
+		check(t, s.Create(&models.NFT{TokenID: 3, OwnerAddress: "0xbb"}))
+
+		nfts, err := s.GetByOwner("0xAa")
+		check(t, err)
+		if tokenIDSet(nfts) != "1,2" {
+			t.Fatalf("owner's tokens = %s, want 1,2", tokenIDSet(nfts))
+		}
+	})
+
+	t.Run("UpdateStats", func(t *testing.T) {
+		s := newStore(t)
+		nft := &models.NFT{TokenID: 1, OwnerAddress: "0xa", MemeType: "pepe"}
+		check(t, s.Create(nft))
+
+		nft.Hunger, nft.Mood, nft.Energy = 10, 20, 30
+		nft.LastFed = at(1)
+		nft.MemeType = "doge" // Not a stats field
+		check(t, s.UpdateStats(nft))
+
+		got, err := s.GetByTokenID(1)
+		check(t, err)
+		if got.Hunger != 10 || got.Mood != 20 || got.Energy != 30 || !got.LastFed.Equal(at(1)) {
+			t.Fatalf("stats not saved: %+v", got)
+		}
+		if got.MemeType != "pepe" {
+			t.Fatalf("meme type = %q, UpdateStats must only touch stats", got.MemeType)
+		}
+	})
+
+	t.Run("UpdateOwnerAndChainFields", func(t *testing.T) {
+		s := newStore(t)
+		check(t, s.Create(&models.NFT{TokenID: 1, OwnerAddress: "0xa", Hunger: 40}))
+
+		check(t, s.UpdateOwner(1, "0xBEEF"))
+		got, err := s.GetByTokenID(1)
+		check(t, err)
+		if got.OwnerAddress != "0xbeef" {
+			t.Fatalf("owner = %q, want 0xbeef", got.OwnerAddress)
+		}
+
+		check(t, s.UpdateChainFields(&models.NFT{TokenID: 1, OwnerAddress: "0xCAFE", MemeType: "doge", Rarity: "epic", Level: 3, ColorVariant: 2}))
+		got, err = s.GetByTokenID(1)
+		check(t, err)
+		if got.OwnerAddress != "0xcafe" || got.MemeType != "doge" || got.Rarity != "epic" || got.Level != 3 || got.ColorVariant != 2 {
+			t.Fatalf("chain fields not saved: %+v", got)
+		}
+		if got.Hunger != 40 {
+			t.Fatalf("hunger = %d, UpdateChainFields must not touch stats", got.Hunger)
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		s := newStore(t)
+		nft := &models.NFT{TokenID: 1, OwnerAddress: "0xa"}
+		check(t, s.Create(nft))
+
+		nft.Level = 4
+		nft.TokenURI = "ipfs://x"
+		check(t, s.Update(nft))
+		got, err := s.GetByTokenID(1)
+		check(t, err)
+		if got.Level != 4 || got.TokenURI != "ipfs://x" {
+			t.Fatalf("update not saved: %+v", got)
+		}
+	})
+
+	t.Run("SoftDelete", func(t *testing.T) {
+		s := newStore(t)
+		check(t, s.Create(&models.NFT{TokenID: 1, OwnerAddress: "0xa"}))
+		check(t, s.Create(&models.NFT{TokenID: 2, OwnerAddress: "0xa"}))
+		check(t, s.Delete(1))
+
+		_, err := s.GetByTokenID(1)
+		wantNotFound(t, err)
+
+		owned, err := s.GetByOwner("0xa")
+		check(t, err)
+		alive, err := s.GetAliveNFTs()
+		check(t, err)
+		all, err := s.GetAll(10, 0)
+		check(t, err)
+		after, err := s.GetAfterTokenID(0, 10)
+		check(t, err)
+		for name, nfts := range map[string][]models.NFT{"GetByOwner": owned, "GetAliveNFTs": alive, "GetAll": all, "GetAfterTokenID": after} {
+			if tokenIDSet(nfts) != "2" {
+				t.Errorf("%s = %s after deleting token 1, want 2", name, tokenIDSet(nfts))
+			}
+		}
+
+		// Updates skip deleted rows
+		check(t, s.UpdateOwner(1, "0xb"))
+		owned, err = s.GetByOwner("0xb")
+		check(t, err)
+		if len(owned) != 0 {
+			t.Fatalf("UpdateOwner changed a deleted NFT")
+		}
+
+		// The token ID stays taken
+		if err := s.Create(&models.NFT{TokenID: 1, OwnerAddress: "0xa"}); err == nil {
+			t.Fatal("token ID of a deleted NFT was reused")
+		}
+	})
+
+	t.Run("GetAliveNFTs", func(t *testing.T) {
+		s := newStore(t)
+		check(t, s.Create(&models.NFT{TokenID: 1, OwnerAddress: "0xa"}))
+		dead := &models.NFT{TokenID: 2, OwnerAddress: "0xa"}
+		check(t, s.Create(dead))
+		dead.Mood = 0
+		check(t, s.UpdateStats(dead))
+
+		alive, err := s.GetAliveNFTs()
+		check(t, err)
+		if tokenIDSet(alive) != "1" {
+			t.Fatalf("alive = %s, want 1", tokenIDSet(alive))
+		}
+	})
+
+	t.Run("GetAfterTokenID", func(t *testing.T) {
+		s := newStore(t)
+		for _, id := range []uint{5, 2, 9, 7} {
+			check(t, s.Create(&models.NFT{TokenID: id, OwnerAddress: "0xa"}))
+		}
+
+		nfts, err := s.GetAfterTokenID(2, 2)
+		check(t, err)
+		if tokenIDs(nfts) != "5,7" {
+			t.Fatalf("after 2 = %s, want 5,7", tokenIDs(nfts))
+		}
+		nfts, err = s.GetAfterTokenID(9, 2)
+		check(t, err)
+		if len(nfts) != 0 {
+			t.Fatalf("after 9 = %s, want none", tokenIDs(nfts))
+		}
+	})
+
+	t.Run("GetAll", func(t *testing.T) {
+		s := newStore(t)
+		for i, id := range []uint{1, 2, 3} {
+			check(t, s.Create(&models.NFT{TokenID: id, OwnerAddress: "0xa", CreatedAt: at(i)}))
+		}
+
+		nfts, err := s.GetAll(2, 0)
+		check(t, err)
+		if tokenIDs(nfts) != "3,2" {
+			t.Fatalf("first page = %s, want newest first 3,2", tokenIDs(nfts))
+		}
+		nfts, err = s.GetAll(2, 2)
+		check(t, err)
+		if tokenIDs(nfts) != "1" {
+			t.Fatalf("second page = %s, want 1", tokenIDs(nfts))
+		}
+	})
+
+	t.Run("DecayBatch", func(t *testing.T) {
+		s := newStore(t)
+		rules := repository.DecayRules{
+			HungerDecay:       10,
+			HungerDecayPeriod: 4 * time.Hour,
+			MoodDecay:         20,
+			MoodDecayPeriod:   8 * time.Hour,
+			EnergyRegen:       5,
+		}
+		now := at(24)
+		pets := []*models.NFT{
+			// Two hunger periods and one mood period due
+			{TokenID: 1, Hunger: 80, Mood: 50, Energy: 100, LastFed: at(16), LastPlayed: at(15), LastInteract: now},
+			// Nothing due and full energy: covered but unchanged
+			{TokenID: 2, Hunger: 80, Mood: 50, Energy: 100, LastFed: at(23), LastPlayed: at(23), LastInteract: now},
+			// Dead: skipped
+			{TokenID: 3, Hunger: 80, Mood: 50, Energy: 100, LastFed: at(0), LastPlayed: at(23), LastInteract: now},
+			// Starves
+			{TokenID: 4, Hunger: 10, Mood: 50, Energy: 90, LastFed: at(20), LastPlayed: at(23), LastInteract: now},
+		}
+		for _, pet := range pets {
+			check(t, s.Create(pet))
+		}
+		pets[2].Hunger = 0
+		check(t, s.UpdateStats(pets[2]))
+
+		lastID, scanned, decayed, err := s.DecayBatch(0, 2, now, rules)
+		check(t, err)
+		if lastID != pets[1].ID || scanned != 2 || tokenIDSet(decayed) != "1" {
+			t.Fatalf("first batch = last %d, %d scanned, decayed %s; want last %d, 2 scanned, decayed 1", lastID, scanned, tokenIDSet(decayed), pets[1].ID)
+		}
+		if d := decayed[0]; d.Hunger != 60 || d.Mood != 30 || d.Energy != 100 {
+			t.Fatalf("pet 1 = %d/%d/%d, want 60/30/100", d.Hunger, d.Mood, d.Energy)
+		}
+
+		lastID, scanned, decayed, err = s.DecayBatch(lastID, 2, now, rules)
+		check(t, err)
+		if lastID != pets[3].ID || scanned != 1 || tokenIDSet(decayed) != "4" {
+			t.Fatalf("second batch = last %d, %d scanned, decayed %s; want last %d, 1 scanned, decayed 4", lastID, scanned, tokenIDSet(decayed), pets[3].ID)
+		}
+		if d := decayed[0]; d.Hunger != 0 || d.Mood != 50 || d.Energy != 95 {
+			t.Fatalf("pet 4 = %d/%d/%d, want 0/50/95", d.Hunger, d.Mood, d.Energy)
+		}
+
+		lastID, scanned, decayed, err = s.DecayBatch(lastID, 2, now, rules)
+		check(t, err)
+		if lastID != 0 || scanned != 0 || len(decayed) != 0 {
+			t.Fatalf("past the end = last %d, %d scanned, %d decayed; want nothing", lastID, scanned, len(decayed))
+		}
+
+		stored, err := s.GetByTokenID(1)
+		check(t, err)
+		if stored.Hunger != 60 || stored.Mood != 30 {
+			t.Fatalf("stored pet 1 = %d/%d, want 60/30", stored.Hunger, stored.Mood)
+		}
+	})
+}
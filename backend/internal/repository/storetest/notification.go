@@ -0,0 +1,188 @@
+package storetest
+
+import (
+	"brainrot-tamagotchi/internal/models"
+	"brainrot-tamagotchi/internal/repository"
+	"strconv"
+	"testing"
+)
+
+// notificationIDs lists notification IDs in order
+func notificationIDs(notifications []models.Notification) string {
+	ids := make([]uint, len(notifications))
+	for i, notification := range notifications {
+		ids[i] = notification.ID
+	}
+	return joinIDs(ids)
+}
+
+// TestNotificationStore runs the NotificationStore contract. newStore must
+// return an empty store on every call.
+func TestNotificationStore(t *testing.T, newStore func(t *testing.T) repository.NotificationStore) {
+	t.Run("Preferences", func(t *testing.T) {
+		s := newStore(t)
+		_, err := s.GetPreference("0xaaa")
+		wantNotFound(t, err)
+
+		pref := &models.NotificationPreference{
+			WalletAddress: "0xAAA",
+			Channels:      map[string]string{"email": "a@example.com"},
+			Kinds:         map[string]bool{models.NotifyHungerLow: false},
+			Timezone:      "UTC",
+		}
+		check(t, s.SavePreference(pref))
+		if pref.ID == 0 || pref.WalletAddress != "0xaaa" {
+			t.Fatalf("saved %+v, want an ID and a lower-cased address", pref)
+		}
+
+		// Saving again replaces the row instead of adding one
+		check(t, s.SavePreference(&models.NotificationPreference{
+			WalletAddress: "0xaaa",
+			Channels:      map[string]string{"webhook": "https://example.com/hook"},
+			Kinds:         map[string]bool{},
+			QuietStart:    "22:00",
+			QuietEnd:      "07:00",
+			Timezone:      "Europe/Kyiv",
+		}))
+		got, err := s.GetPreference("0xAaA")
+		check(t, err)
+		if got.ID != pref.ID || len(got.Channels) != 1 || got.Channels["webhook"] == "" ||
+			len(got.Kinds) != 0 || got.QuietStart != "22:00" || got.Timezone != "Europe/Kyiv" {
+			t.Fatalf("replaced preference = %+v", got)
+		}
+
+		// The returned maps are copies
+		got.Channels["email"] = "b@example.com"
+		again, err := s.GetPreference("0xaaa")
+		check(t, err)
+		if _, ok := again.Channels["email"]; ok {
+			t.Fatal("changing a returned preference changed the store")
+		}
+	})
+
+	t.Run("CreateDedups", func(t *testing.T) {
+		s := newStore(t)
+		first := &models.Notification{
+			WalletAddress: "0xAAA",
+			Kind:          models.NotifyHungerLow,
+			DedupKey:      "hunger_low:1:100",
+			Title:         "t",
+			Body:          "b",
+			Status:        models.NotificationPending,
+			DeliverAfter:  at(0),
+		}
+		isNew, err := s.Create(first)
+		check(t, err)
+		if !isNew || first.ID == 0 || first.WalletAddress != "0xaaa" {
+			t.Fatalf("created %+v new=%v, want an ID and a lower-cased address", first, isNew)
+		}
+
+		dup := &models.Notification{
+			WalletAddress: "0xaaa",
+			Kind:          models.NotifyHungerLow,
+			DedupKey:      "hunger_low:1:100",
+			Title:         "again",
+			Body:          "b",
+			Status:        models.NotificationPending,
+			DeliverAfter:  at(0),
+		}
+		isNew, err = s.Create(dup)
+		check(t, err)
+		if isNew {
+			t.Fatal("a second notification with the same dedup key was stored")
+		}
+
+		all, err := s.GetByWallet("0xaaa", 10)
+		check(t, err)
+		if notificationIDs(all) != joinIDs([]uint{first.ID}) || all[0].Title != "t" {
+			t.Fatalf("stored notifications = %+v", all)
+		}
+	})
+
+	t.Run("GetDueAndUpdate", func(t *testing.T) {
+		s := newStore(t)
+		rows := []struct {
+			key          string
+			status       string
+			deliverAfter int
+		}{
+			{"a", models.NotificationPending, 2},
+			{"b", models.NotificationPending, 1},
+			{"c", models.NotificationSent, 0},
+			{"d", models.NotificationPending, 5}, // Not due yet
+			{"e", models.NotificationPending, 1},
+		}
+		ids := map[string]uint{}
+		for _, row := range rows {
+			n := &models.Notification{
+				WalletAddress: "0xaaa",
+				Kind:          models.NotifyMoodZero,
+				DedupKey:      row.key,
+				Title:         "t",
+				Body:          "b",
+				Status:        row.status,
+				DeliverAfter:  at(row.deliverAfter),
+			}
+			_, err := s.Create(n)
+			check(t, err)
+			ids[row.key] = n.ID
+		}
+
+		due, err := s.GetDue(at(3), 10)
+		check(t, err)
+		if got, want := notificationIDs(due), joinIDs([]uint{ids["b"], ids["e"], ids["a"]}); got != want {
+			t.Fatalf("due = %s, want %s (by deliver_after, then ID)", got, want)
+		}
+		due, err = s.GetDue(at(3), 1)
+		check(t, err)
+		if len(due) != 1 || due[0].ID != ids["b"] {
+			t.Fatalf("due with limit 1 = %s", notificationIDs(due))
+		}
+
+		sent := due[0]
+		sent.Status = models.NotificationSent
+		sent.Delivered = []string{"email"}
+		sent.Attempts = 1
+		check(t, s.Update(&sent))
+
+		due, err = s.GetDue(at(3), 10)
+		check(t, err)
+		if got, want := notificationIDs(due), joinIDs([]uint{ids["e"], ids["a"]}); got != want {
+			t.Fatalf("due after sending = %s, want %s", got, want)
+		}
+
+		all, err := s.GetByWallet("0xaaa", 10)
+		check(t, err)
+		for _, n := range all {
+			if n.ID == sent.ID && (n.Attempts != 1 || len(n.Delivered) != 1 || n.Delivered[0] != "email") {
+				t.Fatalf("updated notification = %+v", n)
+			}
+		}
+	})
+
+	t.Run("GetByWallet", func(t *testing.T) {
+		s := newStore(t)
+		var ids []uint
+		for i, wallet := range []string{"0xaaa", "0xbbb", "0xaaa", "0xaaa"} {
+			n := &models.Notification{
+				WalletAddress: wallet,
+				Kind:          models.NotifyPetDied,
+				DedupKey:      "k" + strconv.Itoa(i),
+				Title:         "t",
+				Body:          "b",
+				Status:        models.NotificationSkipped,
+				DeliverAfter:  at(0),
+				CreatedAt:     at(i),
+			}
+			_, err := s.Create(n)
+			check(t, err)
+			ids = append(ids, n.ID)
+		}
+
+		got, err := s.GetByWallet("0xAAA", 2)
+		check(t, err)
+		if want := joinIDs([]uint{ids[3], ids[2]}); notificationIDs(got) != want {
+			t.Fatalf("GetByWallet = %s, want newest first %s", notificationIDs(got), want)
+		}
+	})
+}
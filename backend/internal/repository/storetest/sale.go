@@ -0,0 +1,128 @@
+package storetest
+
+import (
+	"brainrot-tamagotchi/internal/models"
+	"brainrot-tamagotchi/internal/repository"
+	"brainrot-tamagotchi/pkg/money"
+	"fmt"
+	"testing"
+)
+
+// soldIDs lists the token IDs of sales in order
+func soldIDs(sales []models.MarketSale) string {
+	ids := make([]uint, len(sales))
+	for i, sale := range sales {
+		ids[i] = sale.TokenID
+	}
+	return joinIDs(ids)
+}
+
+// TestSaleStore runs the SaleStore contract. newStore must return an empty
+// store on every call.
+func TestSaleStore(t *testing.T, newStore func(t *testing.T) repository.SaleStore) {
+	// seed records token n sold at hour n:
+	//   0xaa: 1 (100, fee 5), 2 (200, fee 10), 4 (40, fee 2)
+	//   0xbb: 3 (1000, fee 25)
+	seed := func(t *testing.T) repository.SaleStore {
+		s := newStore(t)
+		sales := []struct {
+			tokenID    uint
+			seller     string
+			gross, fee int64
+		}{
+			{1, "0xAA", 100, 5},
+			{2, "0xaa", 200, 10},
+			{3, "0xbb", 1000, 25},
+			{4, "0xaa", 40, 2},
+		}
+		for _, sale := range sales {
+			check(t, s.Create(&models.MarketSale{
+				TokenID:        sale.tokenID,
+				SellerAddress:  sale.seller,
+				BuyerAddress:   "0xcc",
+				GrossPrice:     money.WeiFromInt64(sale.gross),
+				PlatformFee:    money.WeiFromInt64(sale.fee),
+				SellerProceeds: money.WeiFromInt64(sale.gross - sale.fee),
+				TxHash:         fmt.Sprintf("0xtx%d", sale.tokenID),
+				LogIndex:       0,
+				SoldAt:         at(int(sale.tokenID)),
+			}))
+		}
+		return s
+	}
+
+	t.Run("CreateDedups", func(t *testing.T) {
+		s := newStore(t)
+		sale := &models.MarketSale{
+			TokenID:        1,
+			SellerAddress:  "0xAA",
+			BuyerAddress:   "0xBB",
+			GrossPrice:     money.WeiFromInt64(100),
+			PlatformFee:    money.WeiFromInt64(5),
+			SellerProceeds: money.WeiFromInt64(95),
+			TxHash:         "0xtx",
+			LogIndex:       3,
+			SoldAt:         at(0),
+		}
+		check(t, s.Create(sale))
+		if sale.SellerAddress != "0xaa" || sale.BuyerAddress != "0xbb" {
+			t.Fatalf("created %+v, want lower-cased addresses", sale)
+		}
+
+		again := *sale
+		again.ID = 0
+		check(t, s.Create(&again))
+		// The same transaction may hold another sale at another log index
+		other := *sale
+		other.ID = 0
+		other.LogIndex = 4
+		check(t, s.Create(&other))
+
+		_, total, err := s.GetBySeller("0xaa", repository.DateRange{}, 10, 0)
+		check(t, err)
+		if total != 2 {
+			t.Fatalf("stored %d sales, want 2", total)
+		}
+	})
+
+	t.Run("GetBySeller", func(t *testing.T) {
+		s := seed(t)
+
+		sales, total, err := s.GetBySeller("0xAA", repository.DateRange{}, 2, 0)
+		check(t, err)
+		if total != 3 || soldIDs(sales) != "4,2" {
+			t.Fatalf("page = %s of %d, want 4,2 of 3", soldIDs(sales), total)
+		}
+
+		from, to := at(2), at(4)
+		sales, total, err = s.GetBySeller("0xaa", repository.DateRange{From: &from, To: &to}, 10, 0)
+		check(t, err)
+		if total != 1 || soldIDs(sales) != "2" {
+			t.Fatalf("range = %s of %d, want 2 of 1", soldIDs(sales), total)
+		}
+	})
+
+	t.Run("GetTotals", func(t *testing.T) {
+		s := seed(t)
+
+		totals, err := s.GetTotals("0xAA", repository.DateRange{})
+		check(t, err)
+		if totals.SalesCount != 3 || totals.GrossVolume.String() != "340" ||
+			totals.PlatformFees.String() != "17" || totals.SellerProceeds.String() != "323" {
+			t.Fatalf("seller totals = %+v", totals)
+		}
+
+		all, err := s.GetTotals("", repository.DateRange{})
+		check(t, err)
+		if all.SalesCount != 4 || all.GrossVolume.String() != "1340" || all.PlatformFees.String() != "42" {
+			t.Fatalf("all totals = %+v", all)
+		}
+
+		from := at(10)
+		none, err := s.GetTotals("", repository.DateRange{From: &from})
+		check(t, err)
+		if none.SalesCount != 0 || none.GrossVolume.Sign() != 0 {
+			t.Fatalf("empty range totals = %+v", none)
+		}
+	})
+}
@@ -0,0 +1,192 @@
+package storetest
+
+import (
+	"brainrot-tamagotchi/internal/models"
+	"brainrot-tamagotchi/internal/repository"
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestStores runs the Stores transaction contract. newStores must return
+// empty stores on every call.
+func TestStores(t *testing.T, newStores func(t *testing.T) repository.Stores) {
+	t.Run("Commit", func(t *testing.T) {
+		s := newStores(t)
+		nft := &models.NFT{TokenID: 1, OwnerAddress: "0xa"}
+		check(t, s.NFTs().Create(nft))
+
+		err := s.Transaction(func(tx repository.Stores) error {
+			nft.Hunger = 42
+			if err := tx.NFTs().UpdateStats(nft); err != nil {
+				return err
+			}
+			if err := tx.Outbox().Create(&models.OutboxEvent{Type: "pet.fed", Payload: []byte(`{}`), Status: models.OutboxPending, AvailableAt: base}); err != nil {
+				return err
+			}
+			if err := tx.Listings().Upsert(&models.MarketListing{TokenID: 1, SellerAddress: "0xa", IsActive: true, ListedAt: base}); err != nil {
+				return err
+			}
+			if err := tx.Sales().Create(&models.MarketSale{TokenID: 1, SellerAddress: "0xa", BuyerAddress: "0xb", TxHash: "0xtx", SoldAt: base}); err != nil {
+				return err
+			}
+			if err := tx.CaseOpenings().Create(&models.CaseOpening{UserAddress: "0xa", CaseType: "bronze", TokenID: 1, TxHash: "0xopen", OpenedAt: base}); err != nil {
+				return err
+			}
+			if _, err := tx.ChainEvents().MarkProcessed(&models.ProcessedChainEvent{TxHash: "0xtx", LogIndex: 1, Contract: "marketplace"}); err != nil {
+				return err
+			}
+			return tx.Cursors().SetCursor("test", 7)
+		})
+		check(t, err)
+
+		if _, err := s.Listings().GetLatestByTokenID(1); err != nil {
+			t.Fatalf("committed listing: %v", err)
+		}
+		if totals, err := s.Sales().GetTotals("", repository.DateRange{}); err != nil || totals.SalesCount != 1 {
+			t.Fatalf("committed sales = %+v, %v", totals, err)
+		}
+		if latest, err := s.CaseOpenings().GetLatestID(); err != nil || latest == 0 {
+			t.Fatalf("committed case opening: latest ID %d, %v", latest, err)
+		}
+		isNew, err := s.ChainEvents().MarkProcessed(&models.ProcessedChainEvent{TxHash: "0xtx", LogIndex: 1, Contract: "marketplace"})
+		check(t, err)
+		if isNew {
+			t.Fatal("a committed chain event was processed again")
+		}
+
+		got, err := s.NFTs().GetByTokenID(1)
+		check(t, err)
+		if got.Hunger != 42 {
+			t.Fatalf("hunger = %d, want the committed 42", got.Hunger)
+		}
+		cursor, err := s.Cursors().GetCursor("test")
+		check(t, err)
+		if cursor != 7 {
+			t.Fatalf("cursor = %d, want 7", cursor)
+		}
+	})
+
+	t.Run("Rollback", func(t *testing.T) {
+		s := newStores(t)
+		nft := &models.NFT{TokenID: 1, OwnerAddress: "0xa", Hunger: 80}
+		check(t, s.NFTs().Create(nft))
+		check(t, s.Cursors().SetCursor("test", 3))
+
+		failed := errors.New("failed")
+		err := s.Transaction(func(tx repository.Stores) error {
+			nft.Hunger = 42
+			if err := tx.NFTs().UpdateStats(nft); err != nil {
+				return err
+			}
+			if err := tx.NFTs().Create(&models.NFT{TokenID: 2, OwnerAddress: "0xa"}); err != nil {
+				return err
+			}
+			if err := tx.Cursors().SetCursor("test", 9); err != nil {
+				return err
+			}
+			if err := tx.Listings().Upsert(&models.MarketListing{TokenID: 1, SellerAddress: "0xa", IsActive: true, ListedAt: base}); err != nil {
+				return err
+			}
+			if err := tx.Sales().Create(&models.MarketSale{TokenID: 1, SellerAddress: "0xa", BuyerAddress: "0xb", TxHash: "0xtx", SoldAt: base}); err != nil {
+				return err
+			}
+			if err := tx.CaseOpenings().Create(&models.CaseOpening{UserAddress: "0xa", CaseType: "bronze", TokenID: 1, TxHash: "0xopen", OpenedAt: base}); err != nil {
+				return err
+			}
+			if _, err := tx.ChainEvents().MarkProcessed(&models.ProcessedChainEvent{TxHash: "0xtx", LogIndex: 1, Contract: "marketplace"}); err != nil {
+				return err
+			}
+			return failed
+		})
+		if !errors.Is(err, failed) {
+			t.Fatalf("err = %v, want fn's error", err)
+		}
+
+		got, err := s.NFTs().GetByTokenID(1)
+		check(t, err)
+		if got.Hunger != 80 {
+			t.Fatalf("hunger = %d, want 80 after rollback", got.Hunger)
+		}
+		_, err = s.NFTs().GetByTokenID(2)
+		wantNotFound(t, err)
+		cursor, err := s.Cursors().GetCursor("test")
+		check(t, err)
+		if cursor != 3 {
+			t.Fatalf("cursor = %d, want 3 after rollback", cursor)
+		}
+		_, err = s.Listings().GetLatestByTokenID(1)
+		wantNotFound(t, err)
+		if totals, err := s.Sales().GetTotals("", repository.DateRange{}); err != nil || totals.SalesCount != 0 {
+			t.Fatalf("sales after rollback = %+v, %v", totals, err)
+		}
+		if latest, err := s.CaseOpenings().GetLatestID(); err != nil || latest != 0 {
+			t.Fatalf("case openings after rollback: latest ID %d, %v", latest, err)
+		}
+		isNew, err := s.ChainEvents().MarkProcessed(&models.ProcessedChainEvent{TxHash: "0xtx", LogIndex: 1, Contract: "marketplace"})
+		check(t, err)
+		if !isNew {
+			t.Fatal("a rolled back chain event stayed processed")
+		}
+	})
+	t.Run("Outbox", func(t *testing.T) {
+		s := newStores(t)
+		pending := func(availableAt time.Time) *models.OutboxEvent {
+			return &models.OutboxEvent{Type: "pet.fed", Payload: []byte(`{}`), Status: models.OutboxPending, AvailableAt: availableAt}
+		}
+		first, second, later, dispatched := pending(at(1)), pending(at(0)), pending(at(5)), pending(at(0))
+		dispatched.Status = models.OutboxDispatched
+		check(t, s.Outbox().Create(first, second, later, dispatched))
+
+		var due []models.OutboxEvent
+		check(t, s.Transaction(func(tx repository.Stores) error {
+			var err error
+			due, err = tx.Outbox().LockPending(at(2), 10)
+			return err
+		}))
+		if len(due) != 2 || due[0].ID != first.ID || due[1].ID != second.ID {
+			t.Fatalf("due = %+v, want the first two events in ID order", due)
+		}
+
+		check(t, s.Transaction(func(tx repository.Stores) error {
+			limited, err := tx.Outbox().LockPending(at(2), 1)
+			if err != nil {
+				return err
+			}
+			if len(limited) != 1 || limited[0].ID != due[0].ID {
+				t.Fatalf("limited = %+v, want only the oldest due event", limited)
+			}
+			return nil
+		}))
+
+		failed := errors.New("failed")
+		err := s.Transaction(func(tx repository.Stores) error {
+			event := due[0]
+			event.Status = models.OutboxDispatched
+			if err := tx.Outbox().Update(&event); err != nil {
+				return err
+			}
+			return failed
+		})
+		if !errors.Is(err, failed) {
+			t.Fatalf("err = %v, want fn's error", err)
+		}
+
+		check(t, s.Transaction(func(tx repository.Stores) error {
+			event := due[1]
+			event.Status = models.OutboxDispatched
+			return tx.Outbox().Update(&event)
+		}))
+
+		check(t, s.Transaction(func(tx repository.Stores) error {
+			still, err := tx.Outbox().LockPending(at(2), 10)
+			if err != nil {
+				return err
+			}
+			if len(still) != 1 || still[0].ID != due[0].ID {
+				t.Fatalf("pending = %+v, want only the event whose update rolled back", still)
+			}
+			return nil
+		}))
+	})
+}
@@ -0,0 +1,78 @@
+// Package storetest is the contract for the repository store interfaces.
+// Every implementation runs the same suite, so the in-memory stores used in
+// service tests can't drift from the GORM repositories.
+package storetest
+
+import (
+	"brainrot-tamagotchi/internal/models"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// base is a fixed, microsecond-precise time for ordering tests; Postgres
+// keeps microseconds
+var base = time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+func at(hours int) time.Time {
+	return base.Add(time.Duration(hours) * time.Hour)
+}
+
+func check(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func wantNotFound(t *testing.T, err error) {
+	t.Helper()
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("err = %v, want gorm.ErrRecordNotFound", err)
+	}
+}
+
+// tokenIDs lists the token IDs of NFTs in order, for compact comparisons
+func tokenIDs(nfts []models.NFT) string {
+	ids := make([]uint, len(nfts))
+	for i, nft := range nfts {
+		ids[i] = nft.TokenID
+	}
+	return joinIDs(ids)
+}
+
+// tokenIDSet is tokenIDs for queries without an order
+func tokenIDSet(nfts []models.NFT) string {
+	sorted := append([]models.NFT(nil), nfts...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].TokenID < sorted[j].TokenID })
+	return tokenIDs(sorted)
+}
+
+// listedIDs lists the token IDs of listings in order
+func listedIDs(listings []models.MarketListing) string {
+	ids := make([]uint, len(listings))
+	for i, listing := range listings {
+		ids[i] = listing.TokenID
+	}
+	return joinIDs(ids)
+}
+
+// listedIDSet is listedIDs for queries without an order
+func listedIDSet(listings []models.MarketListing) string {
+	sorted := append([]models.MarketListing(nil), listings...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].TokenID < sorted[j].TokenID })
+	return listedIDs(sorted)
+}
+
+func joinIDs(ids []uint) string {
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = strconv.FormatUint(uint64(id), 10)
+	}
+	return strings.Join(parts, ",")
+}
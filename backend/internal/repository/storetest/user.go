@@ -0,0 +1,65 @@
+package storetest
+
+import (
+	"brainrot-tamagotchi/internal/models"
+	"brainrot-tamagotchi/internal/repository"
+	"testing"
+)
+
+// TestUserStore runs the UserStore contract. newStore must return an empty
+// store on every call.
+func TestUserStore(t *testing.T, newStore func(t *testing.T) repository.UserStore) {
+	t.Run("CreateAndGet", func(t *testing.T) {
+		s := newStore(t)
+		user := &models.User{WalletAddress: "0xAbC"}
+		check(t, s.Create(user))
+		if user.ID == 0 || user.WalletAddress != "0xabc" {
+			t.Fatalf("created %+v, want an ID and a lower-cased address", user)
+		}
+
+		got, err := s.GetByWalletAddress("0XABC")
+		check(t, err)
+		if got.ID != user.ID {
+			t.Fatalf("got %+v, want user %d", got, user.ID)
+		}
+
+		_, err = s.GetByWalletAddress("0xdef")
+		wantNotFound(t, err)
+
+		if err := s.Create(&models.User{WalletAddress: "0xabc"}); err == nil {
+			t.Fatal("second user with the same wallet was accepted")
+		}
+	})
+
+	t.Run("GetOrCreate", func(t *testing.T) {
+		s := newStore(t)
+		first, err := s.GetOrCreate("0xAAA")
+		check(t, err)
+		if first.ID == 0 || first.WalletAddress != "0xaaa" {
+			t.Fatalf("created %+v, want an ID and a lower-cased address", first)
+		}
+
+		again, err := s.GetOrCreate("0xaaa")
+		check(t, err)
+		if again.ID != first.ID {
+			t.Fatalf("second GetOrCreate made user %d, want existing %d", again.ID, first.ID)
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		s := newStore(t)
+		user := &models.User{WalletAddress: "0xaaa"}
+		check(t, s.Create(user))
+		created := user.UpdatedAt
+
+		user.WalletAddress = "0xbbb"
+		check(t, s.Update(user))
+		got, err := s.GetByWalletAddress("0xbbb")
+		check(t, err)
+		if got.ID != user.ID || got.UpdatedAt.Before(created) {
+			t.Fatalf("updated user = %+v", got)
+		}
+		_, err = s.GetByWalletAddress("0xaaa")
+		wantNotFound(t, err)
+	})
+}
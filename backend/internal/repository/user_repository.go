@@ -2,6 +2,7 @@ package repository
 
 import (
 	"brainrot-tamagotchi/internal/models"
+	"context"
 	"strings"
 
 	"gorm.io/gorm"
@@ -15,6 +16,11 @@ func NewUserRepository(db *gorm.DB) *UserRepository {
 	return &UserRepository{db: db}
 }
 
+// WithContext returns a copy of the repository whose queries use ctx
+func (r *UserRepository) WithContext(ctx context.Context) UserStore {
+	return &UserRepository{db: r.db.WithContext(ctx)}
+}
+
 // Create creates a new user
 func (r *UserRepository) Create(user *models.User) error {
 	user.WalletAddress = strings.ToLower(user.WalletAddress)
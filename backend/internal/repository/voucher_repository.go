@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"brainrot-tamagotchi/internal/models"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type VoucherRepository struct {
+	db *gorm.DB
+}
+
+func NewVoucherRepository(db *gorm.DB) *VoucherRepository {
+	return &VoucherRepository{db: db}
+}
+
+// Create stores a voucher and reports whether it was new. A voucher for an
+// opening that already produced one is skipped.
+func (r *VoucherRepository) Create(voucher *models.RewardVoucher) (bool, error) {
+	voucher.WalletAddress = strings.ToLower(voucher.WalletAddress)
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(voucher)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// Update saves all fields of a voucher
+func (r *VoucherRepository) Update(voucher *models.RewardVoucher) error {
+	return r.db.Save(voucher).Error
+}
+
+// GetByCode retrieves a voucher by code
+func (r *VoucherRepository) GetByCode(code string) (*models.RewardVoucher, error) {
+	var voucher models.RewardVoucher
+	err := r.db.Where("code = ?", code).First(&voucher).Error
+	if err != nil {
+		return nil, err
+	}
+	return &voucher, nil
+}
+
+// GetByWallet returns a wallet's vouchers, newest first. An empty status
+// returns every status.
+func (r *VoucherRepository) GetByWallet(walletAddress, status string) ([]models.RewardVoucher, error) {
+	var vouchers []models.RewardVoucher
+	query := r.db.Where("wallet_address = ?", strings.ToLower(walletAddress))
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("issued_at DESC").Find(&vouchers).Error
+	return vouchers, err
+}
+
+// GetSourceOpeningIDs returns the openings of a case type that already
+// produced a voucher for a wallet
+func (r *VoucherRepository) GetSourceOpeningIDs(walletAddress, caseType string) (map[uint]bool, error) {
+	var ids []uint
+	err := r.db.Model(&models.RewardVoucher{}).
+		Where("wallet_address = ? AND case_type = ? AND source_opening_id IS NOT NULL", strings.ToLower(walletAddress), caseType).
+		Pluck("source_opening_id", &ids).Error
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		seen[id] = true
+	}
+	return seen, nil
+}
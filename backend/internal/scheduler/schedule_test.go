@@ -0,0 +1,121 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+// May 2024 starts on a Wednesday
+func may(day, hour, minute int) time.Time {
+	return time.Date(2024, 5, day, hour, minute, 0, 0, time.UTC)
+}
+
+func TestScheduleNext(t *testing.T) {
+	tests := []struct {
+		spec  string
+		after time.Time
+		want  time.Time
+	}{
+		// Steps
+		{"*/15 * * * *", may(1, 10, 7), may(1, 10, 15)},
+		{"*/15 * * * *", may(1, 10, 45), may(1, 11, 0)},
+		{"5/20 * * * *", may(1, 10, 30), may(1, 10, 45)},
+		{"10-20/5 * * * *", may(1, 10, 16), may(1, 10, 20)},
+		{"10-20/5 * * * *", may(1, 10, 20), may(1, 11, 10)},
+		{"0 */6 * * *", may(1, 13, 0), may(1, 18, 0)},
+
+		// Lists and ranges
+		{"0,30 9-17 * * *", may(1, 12, 10), may(1, 12, 30)},
+		{"0,30 9-17 * * *", may(1, 17, 30), may(2, 9, 0)},
+		{"0 9,21 * * *", may(1, 9, 0), may(1, 21, 0)},
+		{"0 9 * * 1-5", may(3, 10, 0), may(6, 9, 0)}, // Friday to Monday
+		{"0 0 1,15 * *", may(2, 0, 0), may(15, 0, 0)},
+		{"0 0 1 1,7 *", may(2, 0, 0), time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)},
+
+		// Day of week 0 and 7 are both Sunday
+		{"0 0 * * 0", may(1, 0, 0), may(5, 0, 0)},
+		{"0 0 * * 7", may(1, 0, 0), may(5, 0, 0)},
+		{"0 0 * * 5-7", may(4, 12, 0), may(5, 0, 0)},
+		{"0 0 * * 5-7", may(5, 12, 0), may(10, 0, 0)},
+
+		// With both day fields restricted either may match; with one, only it
+		{"0 0 13 * 5", may(1, 0, 0), may(3, 0, 0)},   // Friday the 3rd
+		{"0 0 13 * 5", may(10, 0, 0), may(13, 0, 0)}, // Monday the 13th
+		{"0 0 13 * *", may(1, 0, 0), may(13, 0, 0)},
+		{"0 0 * * 5", may(10, 0, 0), may(17, 0, 0)},
+
+		// Only minutes strictly after after
+		{"30 10 * * *", may(1, 10, 30), may(2, 10, 30)},
+		{"30 10 * * *", time.Date(2024, 5, 1, 10, 29, 59, 0, time.UTC), may(1, 10, 30)},
+
+		// Month and year rollover, leap day
+		{"0 0 1 * *", may(31, 23, 59), time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", may(1, 0, 0), time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 31 2 *", may(1, 0, 0), time.Time{}}, // Never
+
+		// Macros
+		{"@hourly", may(1, 10, 7), may(1, 11, 0)},
+		{"@daily", may(1, 10, 7), may(2, 0, 0)},
+		{"@midnight", may(1, 10, 7), may(2, 0, 0)},
+		{"@weekly", may(1, 10, 7), may(5, 0, 0)},
+		{"@monthly", may(1, 10, 7), time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)},
+
+		// @every counts from after, to the whole second
+		{"@every 90s", time.Date(2024, 5, 1, 10, 0, 0, 500_000_000, time.UTC), time.Date(2024, 5, 1, 10, 1, 30, 0, time.UTC)},
+		{"@every 1h30m", may(1, 10, 7), may(1, 11, 37)},
+		{" @every 10m ", may(1, 23, 55), may(2, 0, 5)},
+	}
+	for _, tt := range tests {
+		schedule, err := ParseSchedule(tt.spec)
+		if err != nil {
+			t.Errorf("ParseSchedule(%q): %v", tt.spec, err)
+			continue
+		}
+		if got := schedule.Next(tt.after); !got.Equal(tt.want) {
+			t.Errorf("%q after %s: got %s, want %s", tt.spec, tt.after.Format(time.RFC3339), got.Format(time.RFC3339), tt.want.Format(time.RFC3339))
+		}
+	}
+}
+
+func TestScheduleNextUsesUTC(t *testing.T) {
+	kyiv := time.FixedZone("EEST", 3*60*60)
+	schedule, err := ParseSchedule("0 9 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 10:00 in Kyiv is 07:00 UTC, so 09:00 UTC is the same day
+	got := schedule.Next(time.Date(2024, 5, 1, 10, 0, 0, 0, kyiv))
+	if !got.Equal(may(1, 9, 0)) || got.Location() != time.UTC {
+		t.Errorf("got %s, want 09:00 UTC the same day", got)
+	}
+}
+
+func TestParseScheduleInvalid(t *testing.T) {
+	invalid := []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * 32 * *",
+		"* * * 0 *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"*/-5 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+		"1-x * * * *",
+		"1,,2 * * * *",
+		"@yearly",
+		"@every",
+		"@every 500ms",
+		"@every soon",
+	}
+	for _, spec := range invalid {
+		if _, err := ParseSchedule(spec); err == nil {
+			t.Errorf("ParseSchedule(%q) succeeded, want an error", spec)
+		}
+	}
+}
@@ -0,0 +1,435 @@
+// Package scheduler runs background jobs on cron-like schedules. Only the
+// instance holding a Postgres advisory lock (the leader) runs scheduled
+// jobs, so replicas don't repeat each other's work; every run is recorded
+// in job_runs.
+package scheduler
+
+import (
+	"brainrot-tamagotchi/internal/metrics"
+	"brainrot-tamagotchi/internal/models"
+	"brainrot-tamagotchi/internal/repository"
+	"brainrot-tamagotchi/pkg/logging"
+	"brainrot-tamagotchi/pkg/tracing"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// leaderLockName is hashed into the advisory lock key held by the leader
+const leaderLockName = "brainrot:scheduler:leader"
+
+// electionInterval is how often followers try to become leader and the
+// leader checks its lock connection
+const electionInterval = 5 * time.Second
+
+var logger = logging.For("scheduler")
+
+var tracer = otel.Tracer("brainrot-tamagotchi/internal/scheduler")
+
+// defaultJobTimeout applies to jobs registered without a timeout
+const defaultJobTimeout = 5 * time.Minute
+
+var (
+	ErrUnknownJob = errors.New("unknown job")
+	ErrJobRunning = errors.New("job is already running")
+)
+
+// Job is a unit of background work
+type Job struct {
+	Name     string
+	Schedule string // See ParseSchedule
+	Timeout  time.Duration
+	// Quiet jobs only record failed scheduled runs, for frequent jobs whose
+	// successes would flood the history. Manual runs are always recorded.
+	Quiet bool
+	Run   func(ctx context.Context) error
+}
+
+// JobInfo describes a registered job
+type JobInfo struct {
+	Name     string         `json:"name"`
+	Schedule string         `json:"schedule"`
+	Timeout  string         `json:"timeout"`
+	Running  bool           `json:"running"` // On this instance
+	NextRun  *time.Time     `json:"next_run,omitempty"`
+	LastRun  *models.JobRun `json:"last_run,omitempty"`
+}
+
+type entry struct {
+	job      Job
+	schedule Schedule
+	next     time.Time
+	running  bool
+}
+
+// Scheduler runs registered jobs while it is leader, and runs manual
+// triggers on any instance. A per-job advisory lock keeps a job from
+// running on two instances at once.
+type Scheduler struct {
+	db       *gorm.DB
+	runRepo  *repository.JobRunRepository
+	instance string
+
+	mu      sync.Mutex
+	entries map[string]*entry
+	leader  *sql.Conn // Holds the leader lock; nil when following
+	ctx     context.Context
+	wg      sync.WaitGroup
+}
+
+func NewScheduler(db *gorm.DB, runRepo *repository.JobRunRepository) *Scheduler {
+	host, _ := os.Hostname()
+	return &Scheduler{
+		db:       db,
+		runRepo:  runRepo,
+		instance: fmt.Sprintf("%s/%d", host, os.Getpid()),
+		entries:  make(map[string]*entry),
+	}
+}
+
+// Register adds a job. Jobs must be registered before Start.
+func (s *Scheduler) Register(job Job) error {
+	schedule, err := ParseSchedule(job.Schedule)
+	if err != nil {
+		return fmt.Errorf("job %s: %w", job.Name, err)
+	}
+	if job.Timeout <= 0 {
+		job.Timeout = defaultJobTimeout
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.entries[job.Name]; ok {
+		return fmt.Errorf("job %s registered twice", job.Name)
+	}
+	s.entries[job.Name] = &entry{job: job, schedule: schedule}
+	return nil
+}
+
+// Start runs the election and scheduling loop until ctx is done. Cancelling
+// ctx also cancels running jobs; use Wait to let them finish.
+func (s *Scheduler) Start(ctx context.Context) {
+	s.mu.Lock()
+	s.ctx = ctx
+	s.mu.Unlock()
+
+	logger.Info("scheduler started", "jobs", len(s.entries), "instance", s.instance)
+
+	s.elect(ctx)
+	election := time.NewTicker(electionInterval)
+	defer election.Stop()
+	tick := time.NewTicker(time.Second)
+	defer tick.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.resign()
+			return
+		case <-election.C:
+			s.elect(ctx)
+		case now := <-tick.C:
+			s.runDue(now)
+		}
+	}
+}
+
+// Wait blocks until running jobs finish or timeout passes, and reports
+// whether they all finished
+func (s *Scheduler) Wait(timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// IsLeader reports whether this instance runs scheduled jobs
+func (s *Scheduler) IsLeader() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.leader != nil
+}
+
+// Trigger starts a job now on this instance, whether or not it is leader
+func (s *Scheduler) Trigger(name string) error {
+	s.mu.Lock()
+	e, ok := s.entries[name]
+	ctx := s.ctx
+	s.mu.Unlock()
+	if !ok {
+		return ErrUnknownJob
+	}
+	if ctx == nil {
+		return fmt.Errorf("scheduler not started")
+	}
+	return s.start(ctx, e, models.JobTriggerManual)
+}
+
+// Jobs describes every registered job with its latest recorded run
+func (s *Scheduler) Jobs() ([]JobInfo, error) {
+	latest, err := s.runRepo.GetLatest()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	jobs := make([]JobInfo, 0, len(s.entries))
+	for name, e := range s.entries {
+		info := JobInfo{
+			Name:     name,
+			Schedule: e.job.Schedule,
+			Timeout:  e.job.Timeout.String(),
+			Running:  e.running,
+		}
+		if !e.next.IsZero() {
+			next := e.next
+			info.NextRun = &next
+		}
+		if run, ok := latest[name]; ok {
+			info.LastRun = &run
+		}
+		jobs = append(jobs, info)
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].Name < jobs[j].Name })
+	return jobs, nil
+}
+
+// Runs returns a job's run history, newest first
+func (s *Scheduler) Runs(name string, limit, offset int) ([]models.JobRun, int64, error) {
+	s.mu.Lock()
+	_, ok := s.entries[name]
+	s.mu.Unlock()
+	if !ok {
+		return nil, 0, ErrUnknownJob
+	}
+	return s.runRepo.GetByJob(name, limit, offset)
+}
+
+// elect takes the leader lock if free, or checks that the held lock's
+// connection is still alive
+func (s *Scheduler) elect(ctx context.Context) {
+	s.mu.Lock()
+	conn := s.leader
+	s.mu.Unlock()
+
+	if conn != nil {
+		if err := conn.PingContext(ctx); err != nil {
+			logger.Warn("scheduler lost its leader connection", "error", err)
+			s.resign()
+		}
+		return
+	}
+
+	conn, acquired, err := s.tryLock(ctx, leaderLockName)
+	if err != nil {
+		logger.Error("scheduler leader election failed", "error", err)
+		return
+	}
+	if !acquired {
+		return
+	}
+
+	s.mu.Lock()
+	s.leader = conn
+	now := time.Now()
+	for _, e := range s.entries {
+		e.next = e.schedule.Next(now)
+	}
+	s.mu.Unlock()
+	logger.Info("scheduler is leader", "instance", s.instance)
+}
+
+// resign releases leadership; closing the connection drops the lock
+func (s *Scheduler) resign() {
+	s.mu.Lock()
+	conn := s.leader
+	s.leader = nil
+	for _, e := range s.entries {
+		e.next = time.Time{}
+	}
+	s.mu.Unlock()
+
+	if conn != nil {
+		conn.Close()
+	}
+}
+
+// runDue starts every job whose next run has come, if this is the leader
+func (s *Scheduler) runDue(now time.Time) {
+	s.mu.Lock()
+	if s.leader == nil {
+		s.mu.Unlock()
+		return
+	}
+	var due []*entry
+	for _, e := range s.entries {
+		if !e.next.IsZero() && !now.Before(e.next) {
+			e.next = e.schedule.Next(now)
+			due = append(due, e)
+		}
+	}
+	ctx := s.ctx
+	s.mu.Unlock()
+
+	for _, e := range due {
+		if err := s.start(ctx, e, models.JobTriggerSchedule); err != nil && !errors.Is(err, ErrJobRunning) {
+			logger.Error("job not started", "job", e.job.Name, "error", err)
+		}
+	}
+}
+
+// start takes the job's lock and runs it in the background. It returns
+// ErrJobRunning if the job is running here or on another instance.
+func (s *Scheduler) start(ctx context.Context, e *entry, trigger string) error {
+	s.mu.Lock()
+	if e.running {
+		s.mu.Unlock()
+		return ErrJobRunning
+	}
+	e.running = true
+	s.mu.Unlock()
+
+	conn, acquired, err := s.tryLock(ctx, "brainrot:job:"+e.job.Name)
+	if err != nil || !acquired {
+		s.mu.Lock()
+		e.running = false
+		s.mu.Unlock()
+		if err != nil {
+			return err
+		}
+		return ErrJobRunning
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer func() {
+			conn.Close()
+			s.mu.Lock()
+			e.running = false
+			s.mu.Unlock()
+		}()
+		s.run(ctx, e.job, trigger)
+	}()
+	return nil
+}
+
+// run executes a job under its timeout and records the run. The job's
+// context carries its name and a run ID, so everything it logs with that
+// context can be traced back to this run.
+func (s *Scheduler) run(ctx context.Context, job Job, trigger string) {
+	runID := logging.NewID()
+	ctx = logging.With(ctx, "job", job.Name, "run_id", runID)
+	// Each run is its own trace, whatever started it
+	ctx, span := tracer.Start(ctx, "job "+job.Name, trace.WithNewRoot(), trace.WithAttributes(
+		attribute.String("job", job.Name),
+		attribute.String("trigger", trigger),
+		attribute.String("run_id", runID),
+	))
+	quiet := job.Quiet && trigger == models.JobTriggerSchedule
+	run := &models.JobRun{
+		JobName:   job.Name,
+		Trigger:   trigger,
+		Instance:  s.instance,
+		Status:    models.JobRunning,
+		StartedAt: time.Now(),
+	}
+	if !quiet {
+		if err := s.runRepo.Create(run); err != nil {
+			logger.ErrorContext(ctx, "failed to record job start", "error", err)
+		}
+	}
+
+	if !quiet {
+		logger.InfoContext(ctx, "job started", "trigger", trigger)
+	}
+	jobCtx, cancel := context.WithTimeout(ctx, job.Timeout)
+	err := safeRun(jobCtx, job.Run)
+	cancel()
+	tracing.End(span, err)
+
+	finished := time.Now()
+	run.FinishedAt = &finished
+	run.DurationMs = finished.Sub(run.StartedAt).Milliseconds()
+	run.Status = models.JobSucceeded
+	if err != nil {
+		run.Status = models.JobFailed
+		run.Error = err.Error()
+		logger.ErrorContext(ctx, "job failed", "trigger", trigger, "duration_ms", run.DurationMs, "error", err)
+	} else if !quiet {
+		logger.InfoContext(ctx, "job finished", "trigger", trigger, "duration_ms", run.DurationMs)
+	}
+	metrics.JobRuns.WithLabelValues(job.Name, run.Status).Inc()
+	metrics.JobDuration.WithLabelValues(job.Name).Observe(finished.Sub(run.StartedAt).Seconds())
+
+	if quiet && err == nil {
+		return
+	}
+	// A quiet run has no row yet, so this inserts it
+	if err := s.runRepo.Update(run); err != nil {
+		logger.ErrorContext(ctx, "failed to record job run", "error", err)
+	}
+}
+
+// tryLock takes a session advisory lock on a dedicated connection. The
+// lock lasts until the connection is closed.
+func (s *Scheduler) tryLock(ctx context.Context, name string) (*sql.Conn, bool, error) {
+	sqlDB, err := s.db.DB()
+	if err != nil {
+		return nil, false, err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", lockKey(name)).Scan(&acquired); err != nil {
+		conn.Close()
+		return nil, false, err
+	}
+	if !acquired {
+		conn.Close()
+		return nil, false, nil
+	}
+	return conn, true, nil
+}
+
+// lockKey hashes a lock name into the bigint advisory lock space
+func lockKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	return int64(h.Sum64())
+}
+
+// safeRun turns a job panic into an error
+func safeRun(ctx context.Context, fn func(context.Context) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return fn(ctx)
+}
@@ -0,0 +1,419 @@
+package services
+
+import (
+	"brainrot-tamagotchi/internal/blockchain"
+	"brainrot-tamagotchi/internal/models"
+	"brainrot-tamagotchi/internal/repository"
+	"brainrot-tamagotchi/pkg/apperr"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"gorm.io/gorm"
+)
+
+// contractCallMaxAttempts is how many times a queued call is sent before it fails
+const contractCallMaxAttempts = 3
+
+// Errors returned by AdminService. ErrNotBanned means there was no ban to lift.
+var (
+	ErrInvalidWallet       = apperr.Validation("invalid_wallet_address", "invalid wallet address")
+	ErrUnknownRole         = apperr.Validation("unknown_role", "unknown role")
+	ErrOwnRole             = apperr.Forbidden("own_role", "cannot change your own role")
+	ErrRoleNotFound        = apperr.NotFound("role_not_found", "wallet has no admin role")
+	ErrInvalidStats        = apperr.Validation("invalid_stats", "invalid stats adjustment")
+	ErrInvalidBan          = apperr.Validation("invalid_ban", "invalid ban")
+	ErrBanAdmin            = apperr.Conflict("ban_admin", "revoke the wallet's admin role before banning it")
+	ErrNotBanned           = apperr.NotFound("not_banned", "wallet is not banned")
+	ErrListingNotActive    = apperr.Conflict("listing_not_active", "listing is not active")
+	ErrInvalidContractCall = apperr.Validation("invalid_contract_call", "invalid contract call")
+)
+
+// PetStatsAdjustment holds the stats an admin sets; nil fields are kept.
+// Level lives on-chain and cannot be adjusted here.
+type PetStatsAdjustment struct {
+	Hunger *int `json:"hunger"`
+	Mood   *int `json:"mood"`
+	Energy *int `json:"energy"`
+}
+
+type AdminService struct {
+	adminRepo   *repository.AdminRepository
+	nftRepo     *repository.NFTRepository
+	listingRepo *repository.MarketListingRepository
+	blockchain  *blockchain.Client
+}
+
+func NewAdminService(
+	adminRepo *repository.AdminRepository,
+	nftRepo *repository.NFTRepository,
+	listingRepo *repository.MarketListingRepository,
+	blockchain *blockchain.Client,
+) *AdminService {
+	return &AdminService{
+		adminRepo:   adminRepo,
+		nftRepo:     nftRepo,
+		listingRepo: listingRepo,
+		blockchain:  blockchain,
+	}
+}
+
+// BootstrapAdmins grants the admin role to the given wallets, typically from
+// ADMIN_WALLETS, so a fresh deployment has someone to grant further roles
+func (s *AdminService) BootstrapAdmins(wallets []string) error {
+	for _, wallet := range wallets {
+		wallet = strings.TrimSpace(wallet)
+		if wallet == "" {
+			continue
+		}
+		if !common.IsHexAddress(wallet) {
+			return fmt.Errorf("invalid admin wallet %q", wallet)
+		}
+
+		role, err := s.adminRepo.GetRole(wallet)
+		if err == nil && role.Role == models.RoleAdmin {
+			continue
+		}
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		if err := s.adminRepo.SetRole(&models.AdminRole{
+			WalletAddress: wallet,
+			Role:          models.RoleAdmin,
+			GrantedBy:     "bootstrap",
+		}); err != nil {
+			return err
+		}
+		logger.Info("granted bootstrap admin role", "wallet", strings.ToLower(wallet))
+	}
+	return nil
+}
+
+// GetRole returns a wallet's admin role
+func (s *AdminService) GetRole(walletAddress string) (*models.AdminRole, error) {
+	return s.adminRepo.GetRole(walletAddress)
+}
+
+// GetRoles lists every wallet with an admin role
+func (s *AdminService) GetRoles() ([]models.AdminRole, error) {
+	return s.adminRepo.GetRoles()
+}
+
+// SetRole grants or changes a wallet's role
+func (s *AdminService) SetRole(walletAddress, role, grantedBy string) (*models.AdminRole, error) {
+	if !common.IsHexAddress(walletAddress) {
+		return nil, ErrInvalidWallet
+	}
+	if _, ok := models.RoleRank[role]; !ok {
+		return nil, ErrUnknownRole.WithMessage(fmt.Sprintf("unknown role %q", role))
+	}
+	if strings.EqualFold(walletAddress, grantedBy) {
+		return nil, ErrOwnRole
+	}
+
+	adminRole := &models.AdminRole{
+		WalletAddress: walletAddress,
+		Role:          role,
+		GrantedBy:     strings.ToLower(grantedBy),
+	}
+	if err := s.adminRepo.SetRole(adminRole); err != nil {
+		return nil, err
+	}
+	return adminRole, nil
+}
+
+// RevokeRole removes a wallet's role
+func (s *AdminService) RevokeRole(walletAddress, revokedBy string) error {
+	if strings.EqualFold(walletAddress, revokedBy) {
+		return ErrOwnRole.WithMessage("cannot revoke your own role")
+	}
+	if _, err := s.adminRepo.GetRole(walletAddress); err != nil {
+		return notFound(err, ErrRoleNotFound)
+	}
+	return s.adminRepo.DeleteRole(walletAddress)
+}
+
+// Audit records an admin request
+func (s *AdminService) Audit(entry *models.AdminAuditLog) error {
+	return s.adminRepo.CreateAuditLog(entry)
+}
+
+// GetAuditLogs returns audit entries, newest first, with the total count
+func (s *AdminService) GetAuditLogs(filter repository.AuditFilter, limit, offset int) ([]models.AdminAuditLog, int64, error) {
+	return s.adminRepo.GetAuditLogs(filter, limit, offset)
+}
+
+// InspectPet returns a pet as stored, its latest listing and recent admin
+// actions on it. Stats are not decayed, so this shows the raw DB state.
+func (s *AdminService) InspectPet(tokenID uint) (map[string]interface{}, error) {
+	nft, err := s.nftRepo.GetByTokenID(tokenID)
+	if err != nil {
+		return nil, notFound(err, ErrPetNotFound)
+	}
+
+	var listing *models.MarketListing
+	listing, err = s.listingRepo.GetLatestByTokenID(tokenID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	history, _, err := s.adminRepo.GetAuditLogs(repository.AuditFilter{
+		TargetType: "pet",
+		TargetID:   strconv.FormatUint(uint64(tokenID), 10),
+	}, 20, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"pet":           nft,
+		"is_alive":      nft.IsAlive(),
+		"needs_feeding": nft.NeedsFeeding(),
+		"listing":       listing,
+		"admin_history": history,
+	}, nil
+}
+
+// BanWallet blocks a wallet from write endpoints until expiresAt, or forever
+func (s *AdminService) BanWallet(walletAddress, reason, bannedBy string, expiresAt *time.Time) (*models.WalletBan, error) {
+	if !common.IsHexAddress(walletAddress) {
+		return nil, ErrInvalidWallet
+	}
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return nil, ErrInvalidBan.WithMessage("expires_at must be in the future")
+	}
+	if _, err := s.adminRepo.GetRole(walletAddress); err == nil {
+		return nil, ErrBanAdmin
+	}
+
+	ban := &models.WalletBan{
+		WalletAddress: walletAddress,
+		Reason:        reason,
+		BannedBy:      strings.ToLower(bannedBy),
+		ExpiresAt:     expiresAt,
+	}
+	if err := s.adminRepo.CreateBan(ban); err != nil {
+		return nil, err
+	}
+	return ban, nil
+}
+
+// LiftBan lifts every ban on a wallet
+func (s *AdminService) LiftBan(walletAddress string) error {
+	lifted, err := s.adminRepo.LiftBans(walletAddress)
+	if err != nil {
+		return err
+	}
+	if lifted == 0 {
+		return ErrNotBanned
+	}
+	return nil
+}
+
+// GetActiveBan returns the ban in force for a wallet, or nil
+func (s *AdminService) GetActiveBan(walletAddress string) (*models.WalletBan, error) {
+	ban, err := s.adminRepo.GetActiveBan(walletAddress, time.Now())
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return ban, err
+}
+
+// GetActiveBans lists the bans in force
+func (s *AdminService) GetActiveBans() ([]models.WalletBan, error) {
+	return s.adminRepo.GetActiveBans(time.Now())
+}
+
+// CancelListing queues Marketplace.emergencyCancelListing for an active
+// listing. The listing is deactivated once the listing sync sees the event.
+func (s *AdminService) CancelListing(tokenID uint, requestedBy, reason string) (*models.ContractCallJob, error) {
+	listing, err := s.listingRepo.GetByTokenID(tokenID)
+	if err != nil {
+		return nil, notFound(err, ErrListingNotFound)
+	}
+	if !listing.IsActive {
+		return nil, ErrListingNotActive
+	}
+
+	return s.QueueContractCall(
+		"marketplace",
+		"emergencyCancelListing",
+		[]string{strconv.FormatUint(uint64(tokenID), 10)},
+		requestedBy,
+		reason,
+	)
+}
+
+// QueueContractCall validates an owner-only contract call and queues it for
+// the backend signer
+func (s *AdminService) QueueContractCall(contract, method string, args []string, requestedBy, reason string) (*models.ContractCallJob, error) {
+	job, err := s.newContractCall(contract, method, args, requestedBy, reason)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.adminRepo.CreateContractCall(job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// QueueContractCallOnce queues a call unless a queued or sent call already
+// holds its idempotency key, in which case that call is returned. It reports
+// whether a new call was queued.
+func (s *AdminService) QueueContractCallOnce(idempotencyKey, contract, method string, args []string, requestedBy, reason string) (*models.ContractCallJob, bool, error) {
+	job, err := s.newContractCall(contract, method, args, requestedBy, reason)
+	if err != nil {
+		return nil, false, err
+	}
+	job.IdempotencyKey = &idempotencyKey
+
+	created, err := s.adminRepo.CreateContractCallOnce(job)
+	if err != nil || created {
+		return job, created, err
+	}
+	pending, err := s.adminRepo.GetPendingContractCall(idempotencyKey)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// The pending call finished in between; the caller retries next run
+		return nil, false, nil
+	}
+	return pending, false, err
+}
+
+// newContractCall validates an owner-only contract call
+func (s *AdminService) newContractCall(contract, method string, args []string, requestedBy, reason string) (*models.ContractCallJob, error) {
+	if s.blockchain == nil {
+		return nil, ErrChainDisabled
+	}
+	if s.blockchain.PrivateKey == nil {
+		return nil, ErrSignerDisabled
+	}
+
+	values, err := blockchain.OwnerCallArgs(contract, method, args)
+	if err != nil {
+		return nil, ErrInvalidContractCall.WithMessage(err.Error())
+	}
+	if err := checkOwnerCallBounds(contract, method, values); err != nil {
+		return nil, err
+	}
+
+	return &models.ContractCallJob{
+		Contract:    contract,
+		Method:      method,
+		Args:        args,
+		Status:      models.ContractCallQueued,
+		RequestedBy: strings.ToLower(requestedBy),
+		Reason:      reason,
+	}, nil
+}
+
+// GetContractCalls lists queued and past contract calls
+func (s *AdminService) GetContractCalls(status string, limit, offset int) ([]models.ContractCallJob, error) {
+	return s.adminRepo.GetContractCalls(status, limit, offset)
+}
+
+// ProcessContractCalls advances every pending contract call by one step
+func (s *AdminService) ProcessContractCalls(ctx context.Context) error {
+	if s.blockchain == nil {
+		return ErrChainDisabled
+	}
+
+	jobs, err := s.adminRepo.GetPendingContractCalls()
+	if err != nil {
+		return err
+	}
+
+	for i := range jobs {
+		job := &jobs[i]
+		switch job.Status {
+		case models.ContractCallQueued:
+			s.sendContractCall(ctx, job)
+		case models.ContractCallSent:
+			s.checkContractCall(ctx, job)
+		}
+		if err := s.adminRepo.UpdateContractCall(job); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *AdminService) sendContractCall(ctx context.Context, job *models.ContractCallJob) {
+	job.Attempts++
+
+	values, err := blockchain.OwnerCallArgs(job.Contract, job.Method, job.Args)
+	if err == nil {
+		var txHash common.Hash
+		sendCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		txHash, err = s.blockchain.SendOwnerCall(sendCtx, job.Contract, job.Method, values)
+		cancel()
+		if err == nil {
+			hash := txHash.Hex()
+			now := time.Now()
+			job.Status = models.ContractCallSent
+			job.TxHash = &hash
+			job.SentAt = &now
+			job.Error = ""
+			logger.InfoContext(ctx, "sent contract call", "call_id", job.ID, "contract", job.Contract, "method", job.Method, "tx", hash)
+			return
+		}
+	}
+
+	job.Error = err.Error()
+	if job.Attempts >= contractCallMaxAttempts {
+		now := time.Now()
+		job.Status = models.ContractCallFailed
+		job.FinishedAt = &now
+		logger.ErrorContext(ctx, "contract call failed", "call_id", job.ID, "attempts", job.Attempts, "error", err)
+	}
+}
+
+func (s *AdminService) checkContractCall(ctx context.Context, job *models.ContractCallJob) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	success, err := s.blockchain.TxStatus(ctx, *job.TxHash)
+	if errors.Is(err, blockchain.ErrTxPending) {
+		return
+	}
+	if err != nil {
+		job.Error = err.Error()
+		return
+	}
+
+	now := time.Now()
+	job.FinishedAt = &now
+	if success {
+		job.Status = models.ContractCallConfirmed
+		job.Error = ""
+	} else {
+		job.Status = models.ContractCallFailed
+		job.Error = "transaction reverted"
+	}
+	logger.InfoContext(ctx, "contract call finished", "call_id", job.ID, "status", job.Status, "tx", *job.TxHash)
+}
+
+// checkOwnerCallBounds applies the contracts' own limits before a call is
+// queued, so obviously reverting calls are rejected up front
+func checkOwnerCallBounds(contract, method string, values []interface{}) error {
+	switch contract + "." + method {
+	case "marketplace.setPlatformFee":
+		if values[0].(*big.Int).Cmp(big.NewInt(1000)) > 0 {
+			return ErrInvalidContractCall.WithMessage("platform fee cannot exceed 1000 bps")
+		}
+	case "burn.setUpgradeChance":
+		if values[1].(uint8) > 100 {
+			return ErrInvalidContractCall.WithMessage("upgrade chance cannot exceed 100")
+		}
+	case "cases.updateCasePrice":
+		if values[1].(*big.Int).Sign() == 0 {
+			return ErrInvalidContractCall.WithMessage("case price must be positive")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,606 @@
+package services
+
+import (
+	"brainrot-tamagotchi/internal/blockchain"
+	"brainrot-tamagotchi/internal/models"
+	"brainrot-tamagotchi/internal/repository"
+	"brainrot-tamagotchi/pkg/apperr"
+	"brainrot-tamagotchi/pkg/money"
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ContractRarityWeights mirrors CaseOpening._determineRarity on-chain
+var ContractRarityWeights = map[string]map[string]int{
+	"bronze": {"common": 80, "rare": 20},
+	"silver": {"rare": 70, "epic": 25, "legendary": 5},
+	"gold":   {"epic": 60, "legendary": 40},
+}
+
+// defaultCases seeds the catalog with the cases CaseOpening.sol deploys
+// with. Their prices come from the game config.
+var defaultCases = []struct {
+	slug, name         string
+	usdTarget          float64
+	pityRarity         string
+	pityHard, pitySoft int
+}{
+	{"bronze", "Bronze Case", 0.50, "rare", 10, 6},
+	{"silver", "Silver Case", 2.00, "epic", 20, 12},
+	{"gold", "Gold Case", 10.00, "legendary", 8, 5},
+}
+
+var caseSlugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{1,62}$`)
+
+var (
+	ErrCaseNotFound      = apperr.NotFound("case_not_found", "case not found")
+	ErrCaseExists        = apperr.Conflict("case_exists", "case already exists")
+	ErrCaseWindowOverlap = apperr.Conflict("case_window_overlap", "sale window overlaps another active case")
+	ErrInvalidCase       = apperr.Validation("invalid_case", "invalid case definition")
+)
+
+// invalidCase returns ErrInvalidCase saying what is wrong
+func invalidCase(format string, args ...any) error {
+	return ErrInvalidCase.WithMessage(fmt.Sprintf(format, args...))
+}
+
+// CatalogEntry is a case definition with its current availability
+type CatalogEntry struct {
+	models.CaseDefinition
+	Opened    int64  `json:"opened"`
+	Remaining *int64 `json:"remaining"`
+	Available bool   `json:"available"`
+}
+
+// CaseUpdate holds the fields an admin may change; nil fields are kept
+type CaseUpdate struct {
+	Name          *string        `json:"name"`
+	Description   *string        `json:"description"`
+	ArtworkURL    *string        `json:"artwork_url"`
+	Price         *money.Wei     `json:"price"`
+	USDTarget     *float64       `json:"usd_target"`
+	IsActive      *bool          `json:"is_active"`
+	StartsAt      *time.Time     `json:"starts_at"`
+	EndsAt        *time.Time     `json:"ends_at"`
+	SupplyCap     *int64         `json:"supply_cap"`
+	RarityWeights map[string]int `json:"rarity_weights"`
+	MemeWeights   map[string]int `json:"meme_weights"`
+	PityRarity    *string        `json:"pity_rarity"`
+	PityHardLimit *int           `json:"pity_hard_limit"`
+	PitySoftStart *int           `json:"pity_soft_start"`
+	SortOrder     *int           `json:"sort_order"`
+}
+
+// ContractCaseSync describes how one contract case type was brought in line
+// with the catalog
+type ContractCaseSync struct {
+	CaseType      string    `json:"case_type"`
+	LiveCase      string    `json:"live_case,omitempty"`
+	OnChainPrice  money.Wei `json:"on_chain_price"`
+	OnChainActive bool      `json:"on_chain_active"`
+	PriceCallID   uint      `json:"price_call_id,omitempty"`
+	ToggleCallID  uint      `json:"toggle_call_id,omitempty"`
+}
+
+// CaseContract reads case configs from CaseOpening.sol
+type CaseContract interface {
+	GetCaseConfig(ctx context.Context, caseType string) (*blockchain.CaseConfig, error)
+}
+
+var _ CaseContract = (*blockchain.Client)(nil)
+
+// ContractCallQueue queues owner contract calls for the backend signer
+type ContractCallQueue interface {
+	QueueContractCallOnce(idempotencyKey, contract, method string, args []string, requestedBy, reason string) (*models.ContractCallJob, bool, error)
+}
+
+var _ ContractCallQueue = (*AdminService)(nil)
+
+// caseSyncRequester is recorded as the requester of calls SyncContract queues
+const caseSyncRequester = "case_catalog_sync"
+
+type CaseCatalogService struct {
+	catalogRepo *repository.CaseDefinitionRepository
+	caseRepo    *repository.CaseOpeningRepository
+	chain       CaseContract // nil without a blockchain client
+	calls       ContractCallQueue
+	seedPrices  map[string]money.Wei
+}
+
+func NewCaseCatalogService(
+	catalogRepo *repository.CaseDefinitionRepository,
+	caseRepo *repository.CaseOpeningRepository,
+	blockchain *blockchain.Client,
+	calls ContractCallQueue,
+	seedPrices map[string]money.Wei,
+) *CaseCatalogService {
+	s := &CaseCatalogService{
+		catalogRepo: catalogRepo,
+		caseRepo:    caseRepo,
+		calls:       calls,
+		seedPrices:  seedPrices,
+	}
+	if blockchain != nil {
+		s.chain = blockchain
+	}
+	return s
+}
+
+// SeedDefaults creates the default contract cases that are missing
+func (s *CaseCatalogService) SeedDefaults() error {
+	for i, d := range defaultCases {
+		_, err := s.catalogRepo.GetBySlug(d.slug)
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		price, ok := s.seedPrices[d.slug]
+		if !ok {
+			return fmt.Errorf("no seed price configured for the %s case", d.slug)
+		}
+		caseType := d.slug
+		def := &models.CaseDefinition{
+			Slug:             d.slug,
+			Name:             d.name,
+			Price:            price,
+			USDTarget:        d.usdTarget,
+			ContractCaseType: &caseType,
+			IsActive:         true,
+			PityRarity:       d.pityRarity,
+			PityHardLimit:    d.pityHard,
+			PitySoftStart:    d.pitySoft,
+			SortOrder:        i,
+		}
+		if err := s.CreateCase(def); err != nil {
+			return err
+		}
+		logger.Info("seeded case", "slug", d.slug)
+	}
+	return nil
+}
+
+// GetCatalog lists the catalog; unless includeUnavailable is set, inactive,
+// unscheduled, expired and sold out cases are left out
+func (s *CaseCatalogService) GetCatalog(includeUnavailable bool) ([]CatalogEntry, error) {
+	defs, err := s.catalogRepo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	opened, err := s.openedCounts()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	entries := make([]CatalogEntry, 0, len(defs))
+	for _, def := range defs {
+		entry := newCatalogEntry(def, opened[def.Slug], now)
+		if entry.Available || includeUnavailable {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+// GetCase returns one catalog entry, available or not
+func (s *CaseCatalogService) GetCase(slug string) (*CatalogEntry, error) {
+	def, err := s.catalogRepo.GetBySlug(slug)
+	if err != nil {
+		return nil, notFound(err, ErrCaseNotFound)
+	}
+
+	opened, err := s.openedCounts()
+	if err != nil {
+		return nil, err
+	}
+
+	entry := newCatalogEntry(*def, opened[def.Slug], time.Now())
+	return &entry, nil
+}
+
+// CreateCase validates and stores a new case definition
+func (s *CaseCatalogService) CreateCase(def *models.CaseDefinition) error {
+	if err := s.validate(def); err != nil {
+		return err
+	}
+	if _, err := s.catalogRepo.GetBySlug(def.Slug); err == nil {
+		return ErrCaseExists.WithMessage(fmt.Sprintf("case %q already exists", def.Slug))
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return s.catalogRepo.Create(def)
+}
+
+// UpdateCase applies an admin update to a case definition
+func (s *CaseCatalogService) UpdateCase(slug string, update CaseUpdate) (*models.CaseDefinition, error) {
+	def, err := s.catalogRepo.GetBySlug(slug)
+	if err != nil {
+		return nil, notFound(err, ErrCaseNotFound)
+	}
+
+	if update.Name != nil {
+		def.Name = *update.Name
+	}
+	if update.Description != nil {
+		def.Description = *update.Description
+	}
+	if update.ArtworkURL != nil {
+		def.ArtworkURL = *update.ArtworkURL
+	}
+	if update.Price != nil {
+		def.Price = *update.Price
+	}
+	if update.USDTarget != nil {
+		def.USDTarget = *update.USDTarget
+	}
+	if update.IsActive != nil {
+		def.IsActive = *update.IsActive
+	}
+	if update.StartsAt != nil {
+		def.StartsAt = update.StartsAt
+	}
+	if update.EndsAt != nil {
+		def.EndsAt = update.EndsAt
+	}
+	if update.SupplyCap != nil {
+		def.SupplyCap = update.SupplyCap
+	}
+	if update.RarityWeights != nil {
+		def.RarityWeights = update.RarityWeights
+	}
+	if update.MemeWeights != nil {
+		def.MemeWeights = update.MemeWeights
+	}
+	if update.PityRarity != nil {
+		def.PityRarity = *update.PityRarity
+	}
+	if update.PityHardLimit != nil {
+		def.PityHardLimit = *update.PityHardLimit
+	}
+	if update.PitySoftStart != nil {
+		def.PitySoftStart = *update.PitySoftStart
+	}
+	if update.SortOrder != nil {
+		def.SortOrder = *update.SortOrder
+	}
+
+	if err := s.validate(def); err != nil {
+		return nil, err
+	}
+	if err := s.catalogRepo.Update(def); err != nil {
+		return nil, err
+	}
+	return def, nil
+}
+
+// RarityOdds returns every case's rarity odds keyed by slug
+func (s *CaseCatalogService) RarityOdds() (map[string]map[string]float64, error) {
+	defs, err := s.catalogRepo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	odds := make(map[string]map[string]float64, len(defs))
+	for i := range defs {
+		odds[defs[i].Slug] = defs[i].RarityOdds()
+	}
+	return odds, nil
+}
+
+// LiveContractCase returns the available case backed by a contract case
+// type, or nil when none is live
+func (s *CaseCatalogService) LiveContractCase(caseType string) (*CatalogEntry, error) {
+	defs, err := s.catalogRepo.GetByContractCaseType(caseType)
+	if err != nil {
+		return nil, err
+	}
+
+	opened, err := s.openedCounts()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for _, def := range defs {
+		entry := newCatalogEntry(def, opened[def.Slug], now)
+		if entry.Available {
+			return &entry, nil
+		}
+	}
+	return nil, nil
+}
+
+// SyncContract queues calls that set each contract case type's price and
+// availability to those of its live catalog case. A type with no live case is
+// deactivated. Calls are keyed by case type and target state, so a run that
+// finds its previous calls still pending queues nothing new.
+func (s *CaseCatalogService) SyncContract(ctx context.Context) ([]ContractCaseSync, error) {
+	if s.chain == nil {
+		return nil, ErrChainDisabled
+	}
+
+	results := make([]ContractCaseSync, 0, len(blockchain.CaseTypeIDs))
+	for _, caseType := range contractCaseTypes() {
+		live, err := s.LiveContractCase(caseType)
+		if err != nil {
+			return results, err
+		}
+
+		config, err := s.chain.GetCaseConfig(ctx, caseType)
+		if err != nil {
+			return results, chainError(err)
+		}
+
+		result, err := s.syncCaseType(ctx, caseType, live, config)
+		if err != nil {
+			return results, err
+		}
+		results = append(results, *result)
+	}
+	return results, nil
+}
+
+// syncCaseType queues the calls that bring one case type in line with its
+// live case. live is nil when the type has none.
+func (s *CaseCatalogService) syncCaseType(ctx context.Context, caseType string, live *CatalogEntry, config *blockchain.CaseConfig) (*ContractCaseSync, error) {
+	result := &ContractCaseSync{
+		CaseType:      caseType,
+		OnChainPrice:  money.NewWei(config.Price),
+		OnChainActive: config.Active,
+	}
+
+	wantActive := live != nil
+	if live != nil {
+		result.LiveCase = live.Slug
+		if live.Price.Cmp(result.OnChainPrice) != 0 {
+			price := live.Price.String()
+			job, err := s.queueCaseCall(ctx, "updateCasePrice:"+caseType+":"+price, "updateCasePrice",
+				[]string{caseType, price}, fmt.Sprintf("%s price for live case %s", caseType, live.Slug))
+			if err != nil {
+				return result, err
+			}
+			if job != nil {
+				result.PriceCallID = job.ID
+			}
+		}
+	}
+
+	if config.Active != wantActive {
+		// toggleCaseActive flips the flag, so the key names the state it leads to
+		state := "inactive"
+		if wantActive {
+			state = "active"
+		}
+		job, err := s.queueCaseCall(ctx, "toggleCaseActive:"+caseType+":"+state, "toggleCaseActive",
+			[]string{caseType}, fmt.Sprintf("set %s %s", caseType, state))
+		if err != nil {
+			return result, err
+		}
+		if job != nil {
+			result.ToggleCallID = job.ID
+		}
+	}
+	return result, nil
+}
+
+// queueCaseCall queues a CaseOpening owner call unless one with the same key
+// is pending, and returns the queued or pending call
+func (s *CaseCatalogService) queueCaseCall(ctx context.Context, key, method string, args []string, reason string) (*models.ContractCallJob, error) {
+	job, queued, err := s.calls.QueueContractCallOnce("cases."+key, "cases", method, args, caseSyncRequester, reason)
+	if err != nil {
+		return nil, err
+	}
+	if queued {
+		logger.InfoContext(ctx, "queued case call", "call_id", job.ID, "method", method, "args", args)
+	}
+	return job, nil
+}
+
+// validate checks a definition and fills in the weights of contract cases
+func (s *CaseCatalogService) validate(def *models.CaseDefinition) error {
+	if !caseSlugPattern.MatchString(def.Slug) {
+		return invalidCase("slug must be 2-63 lowercase letters, digits or dashes")
+	}
+	if def.Name == "" {
+		return invalidCase("name is required")
+	}
+	if def.Price.Sign() <= 0 {
+		return invalidCase("price must be positive")
+	}
+	if def.USDTarget < 0 {
+		return invalidCase("usd_target cannot be negative")
+	}
+	if def.StartsAt != nil && def.EndsAt != nil && !def.EndsAt.After(*def.StartsAt) {
+		return invalidCase("ends_at must be after starts_at")
+	}
+	if def.SupplyCap != nil && *def.SupplyCap <= 0 {
+		return invalidCase("supply_cap must be positive")
+	}
+	if def.PityHardLimit < 0 {
+		return invalidCase("pity_hard_limit cannot be negative")
+	}
+	if def.PityHardLimit > 0 {
+		if _, ok := RarityRank[def.PityRarity]; !ok {
+			return invalidCase("unknown pity rarity %q", def.PityRarity)
+		}
+		if def.PitySoftStart < 0 || def.PitySoftStart >= def.PityHardLimit {
+			return invalidCase("pity_soft_start must be between 0 and pity_hard_limit")
+		}
+	}
+
+	if def.ContractCaseType != nil {
+		caseType := *def.ContractCaseType
+		if _, ok := blockchain.CaseTypeIDs[caseType]; !ok {
+			return invalidCase("unknown contract case type: %s", caseType)
+		}
+
+		// The contract's odds are fixed, so its cases cannot advertise others
+		if def.RarityWeights == nil {
+			def.RarityWeights = ContractRarityWeights[caseType]
+		} else if !sameWeights(def.RarityWeights, ContractRarityWeights[caseType]) {
+			return invalidCase("rarity weights of a %s contract case must match CaseOpening odds", caseType)
+		}
+		if def.MemeWeights == nil {
+			def.MemeWeights = uniformMemeWeights()
+		} else if !isUniform(def.MemeWeights, blockchain.MemeTypeNames) {
+			return invalidCase("meme weights of a contract case must be uniform")
+		}
+
+		if err := s.checkWindowOverlap(def); err != nil {
+			return err
+		}
+	}
+
+	if err := checkWeights("rarity", def.RarityWeights, blockchain.RarityNames); err != nil {
+		return err
+	}
+	if def.MemeWeights == nil {
+		def.MemeWeights = uniformMemeWeights()
+	}
+	return checkWeights("meme", def.MemeWeights, blockchain.MemeTypeNames)
+}
+
+// checkWindowOverlap rejects two active cases that would sell through the
+// same contract case type at the same time, since they share one on-chain price
+func (s *CaseCatalogService) checkWindowOverlap(def *models.CaseDefinition) error {
+	if !def.IsActive {
+		return nil
+	}
+
+	others, err := s.catalogRepo.GetByContractCaseType(*def.ContractCaseType)
+	if err != nil {
+		return err
+	}
+
+	for _, other := range others {
+		if other.ID == def.ID || !other.IsActive {
+			continue
+		}
+		if windowsOverlap(def, &other) {
+			return ErrCaseWindowOverlap.WithMessage(fmt.Sprintf("sale window overlaps active case %q on the %s contract case", other.Slug, *def.ContractCaseType))
+		}
+	}
+	return nil
+}
+
+func (s *CaseCatalogService) openedCounts() (map[string]int64, error) {
+	totals, err := s.caseRepo.GetTotalsByCaseType("", repository.DateRange{})
+	if err != nil {
+		return nil, err
+	}
+
+	opened := make(map[string]int64, len(totals))
+	for _, t := range totals {
+		opened[t.CaseType] = t.Count
+	}
+	return opened, nil
+}
+
+func newCatalogEntry(def models.CaseDefinition, opened int64, now time.Time) CatalogEntry {
+	entry := CatalogEntry{
+		CaseDefinition: def,
+		Opened:         opened,
+		Available:      def.IsActive && def.InWindow(now),
+	}
+	if def.SupplyCap != nil {
+		remaining := *def.SupplyCap - opened
+		if remaining < 0 {
+			remaining = 0
+		}
+		entry.Remaining = &remaining
+		entry.Available = entry.Available && remaining > 0
+	}
+	return entry
+}
+
+// windowsOverlap reports whether two sale windows share any instant;
+// a nil bound is open-ended
+func windowsOverlap(a, b *models.CaseDefinition) bool {
+	if a.EndsAt != nil && b.StartsAt != nil && !a.EndsAt.After(*b.StartsAt) {
+		return false
+	}
+	if b.EndsAt != nil && a.StartsAt != nil && !b.EndsAt.After(*a.StartsAt) {
+		return false
+	}
+	return true
+}
+
+func checkWeights(kind string, weights map[string]int, names []string) error {
+	known := make(map[string]bool, len(names))
+	for _, name := range names {
+		known[name] = true
+	}
+
+	total := 0
+	for name, w := range weights {
+		if !known[name] {
+			return invalidCase("unknown %s %q", kind, name)
+		}
+		if w < 0 {
+			return invalidCase("%s weight for %q cannot be negative", kind, name)
+		}
+		total += w
+	}
+	if total == 0 {
+		return invalidCase("%s weights must not all be zero", kind)
+	}
+	return nil
+}
+
+// sameWeights compares two weight sets, ignoring zero entries
+func sameWeights(a, b map[string]int) bool {
+	for k, v := range a {
+		if b[k] != v {
+			return false
+		}
+	}
+	for k, v := range b {
+		if a[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// isUniform reports whether every name has the same positive weight
+func isUniform(weights map[string]int, names []string) bool {
+	if len(weights) != len(names) {
+		return false
+	}
+	for _, name := range names {
+		if weights[name] <= 0 || weights[name] != weights[names[0]] {
+			return false
+		}
+	}
+	return true
+}
+
+// contractCaseTypes lists the CaseOpening case types in a stable order
+func contractCaseTypes() []string {
+	caseTypes := make([]string, 0, len(blockchain.CaseTypeIDs))
+	for caseType := range blockchain.CaseTypeIDs {
+		caseTypes = append(caseTypes, caseType)
+	}
+	sort.Slice(caseTypes, func(i, j int) bool {
+		return blockchain.CaseTypeIDs[caseTypes[i]] < blockchain.CaseTypeIDs[caseTypes[j]]
+	})
+	return caseTypes
+}
+
+func uniformMemeWeights() map[string]int {
+	weights := make(map[string]int, len(blockchain.MemeTypeNames))
+	for _, meme := range blockchain.MemeTypeNames {
+		weights[meme] = 1
+	}
+	return weights
+}
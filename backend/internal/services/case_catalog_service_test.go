@@ -0,0 +1,110 @@
+package services
+
+import (
+	"brainrot-tamagotchi/internal/blockchain"
+	"brainrot-tamagotchi/internal/models"
+	"brainrot-tamagotchi/pkg/money"
+	"context"
+	"math/big"
+	"testing"
+)
+
+// fakeCallQueue keeps contract calls in memory, one pending call per key
+type fakeCallQueue struct {
+	calls []*models.ContractCallJob
+}
+
+func (q *fakeCallQueue) QueueContractCallOnce(idempotencyKey, contract, method string, args []string, requestedBy, reason string) (*models.ContractCallJob, bool, error) {
+	for _, call := range q.calls {
+		if *call.IdempotencyKey == idempotencyKey && (call.Status == models.ContractCallQueued || call.Status == models.ContractCallSent) {
+			return call, false, nil
+		}
+	}
+	call := &models.ContractCallJob{
+		ID:             uint(len(q.calls) + 1),
+		Contract:       contract,
+		Method:         method,
+		Args:           args,
+		Status:         models.ContractCallQueued,
+		IdempotencyKey: &idempotencyKey,
+		RequestedBy:    requestedBy,
+		Reason:         reason,
+	}
+	q.calls = append(q.calls, call)
+	return call, true, nil
+}
+
+func TestSyncCaseTypeWaitsForPendingCalls(t *testing.T) {
+	queue := &fakeCallQueue{}
+	s := &CaseCatalogService{calls: queue}
+	ctx := context.Background()
+	live := &CatalogEntry{CaseDefinition: models.CaseDefinition{Slug: "bronze-summer", Price: money.MustParseEther("2")}}
+	// The chain still shows the old state while the calls are pending
+	config := &blockchain.CaseConfig{Price: money.MustParseEther("1").BigInt(), Active: false}
+
+	first, err := s.syncCaseType(ctx, "bronze", live, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.PriceCallID == 0 || first.ToggleCallID == 0 || len(queue.calls) != 2 {
+		t.Fatalf("first run = %+v with %d calls, want a price and a toggle call", first, len(queue.calls))
+	}
+	if got := queue.calls[1]; got.Method != "toggleCaseActive" || got.Args[0] != "bronze" {
+		t.Fatalf("toggle call = %+v", got)
+	}
+
+	// A run while the calls are sent but not mined queues nothing
+	for _, call := range queue.calls {
+		call.Status = models.ContractCallSent
+	}
+	second, err := s.syncCaseType(ctx, "bronze", live, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(queue.calls) != 2 || second.PriceCallID != first.PriceCallID || second.ToggleCallID != first.ToggleCallID {
+		t.Fatalf("second run = %+v with %d calls, want the pending calls", second, len(queue.calls))
+	}
+
+	// Once mined the chain matches and nothing is queued
+	for _, call := range queue.calls {
+		call.Status = models.ContractCallConfirmed
+	}
+	synced := &blockchain.CaseConfig{Price: live.Price.BigInt(), Active: true}
+	third, err := s.syncCaseType(ctx, "bronze", live, synced)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(queue.calls) != 2 || third.PriceCallID != 0 || third.ToggleCallID != 0 {
+		t.Fatalf("third run = %+v with %d calls, want none", third, len(queue.calls))
+	}
+
+	// The live case ends, so the type must be deactivated by a new call
+	ended, err := s.syncCaseType(ctx, "bronze", nil, synced)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(queue.calls) != 3 || ended.ToggleCallID != 3 || *queue.calls[2].IdempotencyKey == *queue.calls[1].IdempotencyKey {
+		t.Fatalf("ended run = %+v with %d calls, want a deactivating toggle", ended, len(queue.calls))
+	}
+}
+
+func TestSyncCaseTypeRequeuesFailedCalls(t *testing.T) {
+	queue := &fakeCallQueue{}
+	s := &CaseCatalogService{calls: queue}
+	ctx := context.Background()
+	live := &CatalogEntry{CaseDefinition: models.CaseDefinition{Slug: "gold", Price: money.NewWei(big.NewInt(500))}}
+	config := &blockchain.CaseConfig{Price: big.NewInt(400), Active: true}
+
+	if _, err := s.syncCaseType(ctx, "gold", live, config); err != nil {
+		t.Fatal(err)
+	}
+	queue.calls[0].Status = models.ContractCallFailed
+
+	retry, err := s.syncCaseType(ctx, "gold", live, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(queue.calls) != 2 || retry.PriceCallID != 2 || queue.calls[1].Args[1] != "500" {
+		t.Fatalf("retry = %+v with calls %+v, want a second price call", retry, queue.calls)
+	}
+}
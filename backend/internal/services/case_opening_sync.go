@@ -0,0 +1,173 @@
+package services
+
+import (
+	"brainrot-tamagotchi/internal/blockchain"
+	"brainrot-tamagotchi/internal/metrics"
+	"brainrot-tamagotchi/internal/models"
+	"brainrot-tamagotchi/internal/repository"
+	"brainrot-tamagotchi/pkg/money"
+	"brainrot-tamagotchi/pkg/tracing"
+	"context"
+)
+
+// caseOpeningSyncCursor names the sync_cursors row for CaseOpening.sol events
+const caseOpeningSyncCursor = "case_openings"
+
+// CaseOpeningChain reads CaseOpening.sol events; *blockchain.Client
+// implements it
+type CaseOpeningChain interface {
+	BlockNumber(ctx context.Context) (uint64, error)
+	FilterCaseOpenings(ctx context.Context, fromBlock, toBlock uint64) ([]*blockchain.CaseOpenedEvent, error)
+}
+
+var _ CaseOpeningChain = (*blockchain.Client)(nil)
+
+// CaseOpeningSync records CaseOpened events into case_openings, which feed
+// case history, stats, pity and the reveal feed. Every event is recorded at
+// most once, keyed by (tx hash, log index), and only once it is
+// confirmations blocks deep.
+type CaseOpeningSync struct {
+	stores        repository.Stores
+	chain         CaseOpeningChain // nil without a blockchain client
+	startBlock    uint64
+	confirmations uint64
+}
+
+func NewCaseOpeningSync(
+	stores repository.Stores,
+	blockchain *blockchain.Client,
+	startBlock uint64,
+	confirmations uint64,
+) *CaseOpeningSync {
+	s := &CaseOpeningSync{
+		stores:        stores,
+		startBlock:    startBlock,
+		confirmations: confirmations,
+	}
+	if blockchain != nil {
+		s.chain = blockchain
+	}
+	return s
+}
+
+// SyncOnce records all openings between the stored cursor and the confirmed
+// chain head, and returns how many new openings were recorded
+func (s *CaseOpeningSync) SyncOnce(ctx context.Context) (_ int, err error) {
+	ctx, span := tracer.Start(ctx, "CaseOpeningSync.SyncOnce")
+	defer func() { tracing.End(span, err) }()
+
+	if s.chain == nil {
+		return 0, ErrChainDisabled
+	}
+
+	cursors := s.stores.WithContext(ctx).Cursors()
+	fromBlock, err := cursors.GetCursor(caseOpeningSyncCursor)
+	if err != nil {
+		return 0, err
+	}
+	if fromBlock < s.startBlock {
+		fromBlock = s.startBlock
+	} else if fromBlock > 0 {
+		fromBlock++
+	}
+
+	head, err := s.chain.BlockNumber(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if head < s.confirmations {
+		return 0, nil
+	}
+	head -= s.confirmations
+	chainHead := head + s.confirmations
+	defer func() {
+		if fromBlock > 0 {
+			metrics.IndexerBlockLag.WithLabelValues("cases").Set(float64(chainHead - (fromBlock - 1)))
+		}
+	}()
+
+	recorded := 0
+	for fromBlock <= head {
+		toBlock := min64(fromBlock+listingSyncBatchBlocks-1, head)
+
+		events, err := s.chain.FilterCaseOpenings(ctx, fromBlock, toBlock)
+		if err != nil {
+			return recorded, err
+		}
+
+		for _, event := range events {
+			isNew, err := s.record(ctx, event)
+			if err != nil {
+				return recorded, err
+			}
+			if isNew {
+				recorded++
+			}
+		}
+
+		if err := cursors.SetCursor(caseOpeningSyncCursor, toBlock); err != nil {
+			return recorded, err
+		}
+		fromBlock = toBlock + 1
+	}
+
+	if recorded > 0 {
+		logger.InfoContext(ctx, "recorded case openings", "count", recorded)
+	}
+	return recorded, nil
+}
+
+// Lag returns how many blocks the last synced block trails the chain head
+func (s *CaseOpeningSync) Lag(ctx context.Context) (uint64, error) {
+	if s.chain == nil {
+		return 0, ErrChainDisabled
+	}
+
+	synced, err := s.stores.WithContext(ctx).Cursors().GetCursor(caseOpeningSyncCursor)
+	if err != nil {
+		return 0, err
+	}
+	if synced < s.startBlock {
+		synced = s.startBlock
+	}
+	head, err := s.chain.BlockNumber(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if head < synced {
+		return 0, nil
+	}
+	return head - synced, nil
+}
+
+// record stores one opening and reports whether it was new
+func (s *CaseOpeningSync) record(ctx context.Context, event *blockchain.CaseOpenedEvent) (bool, error) {
+	isNew := false
+	err := s.stores.WithContext(ctx).Transaction(func(tx repository.Stores) error {
+		var err error
+		isNew, err = tx.ChainEvents().MarkProcessed(&models.ProcessedChainEvent{
+			TxHash:      event.TxHash,
+			LogIndex:    event.LogIndex,
+			BlockNumber: event.BlockNumber,
+			Contract:    "cases",
+			EventName:   blockchain.EventCaseOpened,
+			TokenID:     event.TokenID,
+		})
+		if err != nil || !isNew {
+			return err
+		}
+
+		return tx.CaseOpenings().Create(&models.CaseOpening{
+			UserAddress: event.Opener,
+			CaseType:    event.CaseType,
+			TokenID:     event.TokenID,
+			Rarity:      event.Rarity,
+			MemeType:    event.MemeType,
+			Price:       money.NewWei(event.Price),
+			TxHash:      event.TxHash,
+			LogIndex:    event.LogIndex,
+			OpenedAt:    event.BlockTime,
+		})
+	})
+	return isNew, err
+}
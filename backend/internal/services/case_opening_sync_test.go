@@ -0,0 +1,93 @@
+package services
+
+import (
+	"brainrot-tamagotchi/internal/blockchain"
+	"brainrot-tamagotchi/internal/repository"
+	"brainrot-tamagotchi/internal/repository/memstore"
+	"context"
+	"math/big"
+	"testing"
+	"time"
+)
+
+// fakeCaseChain serves CaseOpened events from memory
+type fakeCaseChain struct {
+	head uint64
+	logs []*blockchain.CaseOpenedEvent
+}
+
+func (c *fakeCaseChain) BlockNumber(ctx context.Context) (uint64, error) {
+	return c.head, nil
+}
+
+func (c *fakeCaseChain) FilterCaseOpenings(ctx context.Context, fromBlock, toBlock uint64) ([]*blockchain.CaseOpenedEvent, error) {
+	var found []*blockchain.CaseOpenedEvent
+	for _, event := range c.logs {
+		if event.BlockNumber >= fromBlock && event.BlockNumber <= toBlock {
+			found = append(found, event)
+		}
+	}
+	return found, nil
+}
+
+func caseOpenedEvent(block uint64, logIndex uint, tx string, tokenID uint) *blockchain.CaseOpenedEvent {
+	return &blockchain.CaseOpenedEvent{
+		Opener:      "0xAA",
+		TokenID:     tokenID,
+		Rarity:      "rare",
+		MemeType:    "doge",
+		CaseType:    "bronze",
+		Price:       big.NewInt(500),
+		TxHash:      tx,
+		LogIndex:    logIndex,
+		BlockNumber: block,
+		BlockTime:   time.Unix(int64(block), 0),
+	}
+}
+
+func TestCaseOpeningSync(t *testing.T) {
+	chain := &fakeCaseChain{head: 11, logs: []*blockchain.CaseOpenedEvent{
+		caseOpenedEvent(5, 0, "0xone", 1),
+		caseOpenedEvent(5, 3, "0xtwo", 2),
+		caseOpenedEvent(10, 1, "0xthree", 3),
+	}}
+	stores := memstore.NewStores(memstore.NewNFTStore())
+	s := NewCaseOpeningSync(stores, nil, 0, 2)
+	s.chain = chain
+	ctx := context.Background()
+
+	// Block 10 is one confirmation deep, so only block 5 is recorded
+	recorded, err := s.SyncOnce(ctx)
+	if err != nil || recorded != 2 {
+		t.Fatalf("first pass recorded %d, %v; want 2", recorded, err)
+	}
+	if cursor, _ := stores.Cursors().GetCursor(caseOpeningSyncCursor); cursor != 9 {
+		t.Fatalf("cursor = %d, want head minus confirmations", cursor)
+	}
+
+	chain.head = 12
+	if recorded, err := s.SyncOnce(ctx); err != nil || recorded != 1 {
+		t.Fatalf("second pass recorded %d, %v; want 1", recorded, err)
+	}
+
+	// Replaying from an older cursor records nothing twice
+	if err := stores.Cursors().SetCursor(caseOpeningSyncCursor, 0); err != nil {
+		t.Fatal(err)
+	}
+	if recorded, err := s.SyncOnce(ctx); err != nil || recorded != 0 {
+		t.Fatalf("replayed pass recorded %d, %v; want 0", recorded, err)
+	}
+
+	openings, total, err := stores.CaseOpenings().GetByUser("0xaa", repository.DateRange{}, 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 3 {
+		t.Fatalf("stored %d openings, want 3", total)
+	}
+	newest := openings[0]
+	if newest.TokenID != 3 || newest.CaseType != "bronze" || newest.Rarity != "rare" || newest.Price.String() != "500" ||
+		newest.TxHash != "0xthree" || newest.LogIndex != 1 || !newest.OpenedAt.Equal(time.Unix(10, 0)) {
+		t.Fatalf("newest opening = %+v", newest)
+	}
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"brainrot-tamagotchi/internal/blockchain"
+	"brainrot-tamagotchi/internal/pricefeed"
+	"brainrot-tamagotchi/pkg/money"
+	"context"
+	"math"
+	"time"
+)
+
+// casePriceReportJob names the job_reports row of the last price check
+const casePriceReportJob = "case_price_check"
+
+// CasePriceDrift compares one on-chain case price with its USD target
+type CasePriceDrift struct {
+	CaseType       string    `json:"case_type"`
+	LiveCase       string    `json:"live_case"`
+	OnChainPrice   money.Wei `json:"on_chain_price"`
+	OnChainUSD     float64   `json:"on_chain_usd"`
+	TargetUSD      float64   `json:"target_usd"`
+	SuggestedPrice money.Wei `json:"suggested_price"`
+	DriftPercent   float64   `json:"drift_percent"`
+	Active         bool      `json:"active"`
+	Flagged        bool      `json:"flagged"`
+}
+
+// CasePriceReport is the result of one price check
+type CasePriceReport struct {
+	CheckedAt time.Time        `json:"checked_at"`
+	Rate      pricefeed.Rate   `json:"rate"`
+	Threshold float64          `json:"threshold"`
+	Cases     []CasePriceDrift `json:"cases"`
+}
+
+// LiveCaseCatalog finds the catalog case a contract case type currently
+// sells
+type LiveCaseCatalog interface {
+	LiveContractCase(caseType string) (*CatalogEntry, error)
+}
+
+var _ LiveCaseCatalog = (*CaseCatalogService)(nil)
+
+// CasePriceMonitor flags on-chain case prices that drifted from their USD
+// targets as ETH moved
+type CasePriceMonitor struct {
+	chain     CaseContract // nil without a blockchain client
+	feed      pricefeed.PriceFeed
+	catalog   LiveCaseCatalog
+	reports   ReportStore
+	threshold float64 // e.g. 0.15 flags prices more than 15% off target
+}
+
+func NewCasePriceMonitor(
+	blockchain *blockchain.Client,
+	feed pricefeed.PriceFeed,
+	catalog *CaseCatalogService,
+	reports ReportStore,
+	threshold float64,
+) *CasePriceMonitor {
+	if threshold <= 0 {
+		threshold = 0.15
+	}
+	m := &CasePriceMonitor{
+		feed:      feed,
+		catalog:   catalog,
+		reports:   reports,
+		threshold: threshold,
+	}
+	if blockchain != nil {
+		m.chain = blockchain
+	}
+	return m
+}
+
+// LastReport returns the most recent check on any instance, or nil if there
+// was none
+func (m *CasePriceMonitor) LastReport() (*CasePriceReport, error) {
+	var report CasePriceReport
+	found, err := loadReport(m.reports, casePriceReportJob, &report)
+	if err != nil || !found {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// Check compares the on-chain price of every contract case type with the USD
+// target of its live catalog case
+func (m *CasePriceMonitor) Check(ctx context.Context) (*CasePriceReport, error) {
+	if m.chain == nil {
+		return nil, ErrChainDisabled
+	}
+
+	rate, err := m.feed.ETHUSD(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &CasePriceReport{
+		CheckedAt: time.Now(),
+		Rate:      rate,
+		Threshold: m.threshold,
+	}
+
+	for _, caseType := range contractCaseTypes() {
+		live, err := m.catalog.LiveContractCase(caseType)
+		if err != nil {
+			return nil, err
+		}
+		if live == nil || live.USDTarget <= 0 {
+			continue
+		}
+
+		config, err := m.chain.GetCaseConfig(ctx, caseType)
+		if err != nil {
+			return nil, err
+		}
+
+		target := live.USDTarget
+		price := money.NewWei(config.Price)
+		usd := pricefeed.WeiToUSD(price, rate.USDPerETH)
+		drift := (usd - target) / target
+
+		entry := CasePriceDrift{
+			CaseType:       caseType,
+			LiveCase:       live.Slug,
+			OnChainPrice:   price,
+			OnChainUSD:     usd,
+			TargetUSD:      target,
+			SuggestedPrice: pricefeed.USDToWei(target, rate.USDPerETH),
+			DriftPercent:   math.Round(drift*10000) / 100,
+			Active:         config.Active,
+			Flagged:        math.Abs(drift) > m.threshold,
+		}
+		if entry.Flagged {
+			logger.WarnContext(ctx, "case price drifted from its USD target",
+				"case_type", caseType,
+				"usd", usd,
+				"target_usd", target,
+				"drift_percent", entry.DriftPercent,
+				"suggested_price_wei", entry.SuggestedPrice.String(),
+			)
+		}
+		report.Cases = append(report.Cases, entry)
+	}
+
+	if err := saveReport(m.reports, casePriceReportJob, report.CheckedAt, report); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
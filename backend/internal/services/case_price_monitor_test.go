@@ -0,0 +1,131 @@
+package services
+
+import (
+	"brainrot-tamagotchi/internal/blockchain"
+	"brainrot-tamagotchi/internal/models"
+	"brainrot-tamagotchi/internal/pricefeed"
+	"brainrot-tamagotchi/pkg/money"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// fakeCaseContract serves case configs from memory
+type fakeCaseContract map[string]*blockchain.CaseConfig
+
+func (c fakeCaseContract) GetCaseConfig(ctx context.Context, caseType string) (*blockchain.CaseConfig, error) {
+	config, ok := c[caseType]
+	if !ok {
+		return nil, fmt.Errorf("no config for %s", caseType)
+	}
+	return config, nil
+}
+
+// fakeLiveCatalog maps contract case types to their live catalog case
+type fakeLiveCatalog map[string]*CatalogEntry
+
+func (c fakeLiveCatalog) LiveContractCase(caseType string) (*CatalogEntry, error) {
+	return c[caseType], nil
+}
+
+// fakeReports keeps job reports in memory
+type fakeReports map[string]models.JobReport
+
+func (r fakeReports) SaveReport(report *models.JobReport) error {
+	r[report.JobName] = *report
+	return nil
+}
+
+func (r fakeReports) GetReport(jobName string) (*models.JobReport, error) {
+	report, ok := r[jobName]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &report, nil
+}
+
+// fakeFeed returns a fixed rate or error
+type fakeFeed struct {
+	rate pricefeed.Rate
+	err  error
+}
+
+func (f fakeFeed) ETHUSD(ctx context.Context) (pricefeed.Rate, error) {
+	return f.rate, f.err
+}
+
+func TestCasePriceMonitorThreshold(t *testing.T) {
+	// At $2000/ETH a $20 target is 0.01 ETH
+	tests := []struct {
+		name        string
+		price       string
+		wantUSD     float64
+		wantDrift   float64
+		wantFlagged bool
+	}{
+		{"on target", "0.01", 20, 0, false},
+		{"at the threshold", "0.0115", 23, 15, false},
+		{"above the threshold", "0.0116", 23.2, 16, true},
+		{"below the threshold", "0.0084", 16.8, -16, true},
+		{"just inside below", "0.0085", 17, -15, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reports := fakeReports{}
+			m := NewCasePriceMonitor(nil, fakeFeed{rate: pricefeed.Rate{USDPerETH: 2000, UpdatedAt: time.Now(), Source: "test"}}, nil, reports, 0.15)
+			m.chain = fakeCaseContract{
+				"bronze": {Price: money.MustParseEther(tt.price).BigInt(), Active: true},
+			}
+			m.catalog = fakeLiveCatalog{
+				"bronze": {CaseDefinition: models.CaseDefinition{Slug: "bronze-summer", USDTarget: 20}},
+				// No USD target: not monitored
+				"silver": {CaseDefinition: models.CaseDefinition{Slug: "silver-summer"}},
+			}
+
+			report, err := m.Check(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(report.Cases) != 1 {
+				t.Fatalf("checked %d cases, want only bronze", len(report.Cases))
+			}
+			got := report.Cases[0]
+			if got.OnChainUSD != tt.wantUSD || got.DriftPercent != tt.wantDrift || got.Flagged != tt.wantFlagged {
+				t.Fatalf("usd = %v, drift = %v%%, flagged = %v; want %v, %v%%, %v",
+					got.OnChainUSD, got.DriftPercent, got.Flagged, tt.wantUSD, tt.wantDrift, tt.wantFlagged)
+			}
+			if got.SuggestedPrice.Cmp(money.MustParseEther("0.01")) != 0 {
+				t.Fatalf("suggested price = %s, want 0.01 ETH", got.SuggestedPrice.Ether())
+			}
+
+			saved, err := m.LastReport()
+			if err != nil || saved == nil {
+				t.Fatalf("last report = %v, %v", saved, err)
+			}
+			if len(saved.Cases) != 1 || saved.Cases[0].Flagged != tt.wantFlagged || saved.Rate.USDPerETH != 2000 {
+				t.Fatalf("saved report = %+v", saved)
+			}
+		})
+	}
+}
+
+func TestCasePriceMonitorFeedUnavailable(t *testing.T) {
+	stale := fmt.Errorf("%w: source rate from yesterday", pricefeed.ErrStale)
+	for _, feedErr := range []error{stale, errors.New("price feed returned 503")} {
+		reports := fakeReports{}
+		m := NewCasePriceMonitor(nil, fakeFeed{err: feedErr}, nil, reports, 0.15)
+		m.chain = fakeCaseContract{}
+		m.catalog = fakeLiveCatalog{}
+
+		if _, err := m.Check(context.Background()); !errors.Is(err, feedErr) {
+			t.Fatalf("Check = %v, want %v", err, feedErr)
+		}
+		if report, err := m.LastReport(); err != nil || report != nil {
+			t.Fatalf("a failed check saved %+v, %v", report, err)
+		}
+	}
+}
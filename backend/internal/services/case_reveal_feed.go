@@ -0,0 +1,147 @@
+package services
+
+import (
+	"brainrot-tamagotchi/internal/events"
+	"brainrot-tamagotchi/internal/metrics"
+	"brainrot-tamagotchi/internal/models"
+	"brainrot-tamagotchi/internal/realtime"
+	"brainrot-tamagotchi/internal/repository"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// caseRevealSyncCursor names the sync_cursors row holding the last case
+// opening ID pushed to realtime subscribers
+const caseRevealSyncCursor = "case_reveals"
+
+// caseRevealBatchSize caps how many openings one pass publishes
+const caseRevealBatchSize = 200
+
+// caseRevealGapGrace is how long the feed waits on a gap in opening IDs
+// before passing it as a rolled-back insert
+const caseRevealGapGrace = time.Minute
+
+// CaseRevealFeed turns newly recorded case openings into CaseOpened events
+// and pushes them to the public cases topic and the opener's wallet topic
+type CaseRevealFeed struct {
+	db         *gorm.DB
+	caseRepo   *repository.CaseOpeningRepository
+	cursorRepo *repository.ChainEventRepository
+	hub        *realtime.Hub
+
+	// The first ID missing after the cursor and when the feed first saw it
+	gapID    uint64
+	gapSince time.Time
+}
+
+func NewCaseRevealFeed(
+	db *gorm.DB,
+	caseRepo *repository.CaseOpeningRepository,
+	cursorRepo *repository.ChainEventRepository,
+	hub *realtime.Hub,
+) *CaseRevealFeed {
+	return &CaseRevealFeed{
+		db:         db,
+		caseRepo:   caseRepo,
+		cursorRepo: cursorRepo,
+		hub:        hub,
+	}
+}
+
+// PublishNewOpenings publishes openings recorded since the last pass and
+// returns how many were published. The first pass only sets the cursor, so
+// history is not replayed to live clients. The cursor never passes an
+// opening that may still commit; see committed.
+func (f *CaseRevealFeed) PublishNewOpenings(ctx context.Context) (int, error) {
+	lastID, err := f.cursorRepo.GetCursor(caseRevealSyncCursor)
+	if err != nil {
+		return 0, err
+	}
+	if lastID == 0 {
+		latest, err := f.caseRepo.GetLatestID()
+		if err != nil || latest == 0 {
+			return 0, err
+		}
+		return 0, f.cursorRepo.SetCursor(caseRevealSyncCursor, uint64(latest))
+	}
+
+	published := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return published, err
+		}
+
+		fetched, err := f.caseRepo.GetAfterID(uint(lastID), caseRevealBatchSize)
+		if err != nil {
+			return published, err
+		}
+		openings := f.committed(lastID, fetched, time.Now())
+		if len(openings) == 0 {
+			break
+		}
+
+		// The events and the cursor move together, so each opening yields
+		// exactly one CaseOpened
+		lastID = uint64(openings[len(openings)-1].ID)
+		err = f.db.Transaction(func(tx *gorm.DB) error {
+			opened := make([]events.Event, 0, len(openings))
+			for _, o := range openings {
+				opened = append(opened, events.CaseOpened{
+					OpeningID: o.ID,
+					Wallet:    o.UserAddress,
+					CaseType:  o.CaseType,
+					TokenID:   o.TokenID,
+					Rarity:    o.Rarity,
+					MemeType:  o.MemeType,
+					Price:     o.Price,
+					TxHash:    o.TxHash,
+					OpenedAt:  o.OpenedAt,
+				})
+			}
+			if err := events.Record(tx, opened...); err != nil {
+				return err
+			}
+			return repository.NewChainEventRepository(tx).SetCursor(caseRevealSyncCursor, lastID)
+		})
+		if err != nil {
+			return published, err
+		}
+
+		for i := range openings {
+			opening := &openings[i]
+			f.hub.Publish(realtime.CasesTopic(), realtime.EventCaseRevealed, opening)
+			f.hub.Publish(realtime.WalletTopic(opening.UserAddress), realtime.EventCaseRevealed, opening)
+			metrics.CaseOpenings.WithLabelValues(opening.CaseType, opening.Rarity).Inc()
+		}
+		published += len(openings)
+		if len(openings) < caseRevealBatchSize {
+			break
+		}
+	}
+	return published, nil
+}
+
+// committed trims openings, ordered by ID, to the run the cursor can safely
+// pass. Bigserial IDs are taken at insert but show up at commit, so a gap
+// after the cursor is usually a transaction still in flight whose row would
+// land behind the cursor and never be published. The run stops at a gap
+// until it fills or has stayed open for caseRevealGapGrace, by which time
+// the insert rolled back.
+func (f *CaseRevealFeed) committed(afterID uint64, openings []models.CaseOpening, now time.Time) []models.CaseOpening {
+	next := afterID + 1
+	for i := range openings {
+		id := uint64(openings[i].ID)
+		if id != next {
+			if f.gapID != next {
+				f.gapID, f.gapSince = next, now
+			}
+			if now.Sub(f.gapSince) < caseRevealGapGrace {
+				return openings[:i]
+			}
+		}
+		next = id + 1
+	}
+	return openings
+}
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"brainrot-tamagotchi/internal/models"
+	"testing"
+	"time"
+)
+
+func openingsWithIDs(ids ...uint) []models.CaseOpening {
+	openings := make([]models.CaseOpening, len(ids))
+	for i, id := range ids {
+		openings[i].ID = id
+	}
+	return openings
+}
+
+func openingIDs(openings []models.CaseOpening) []uint {
+	ids := make([]uint, len(openings))
+	for i := range openings {
+		ids[i] = openings[i].ID
+	}
+	return ids
+}
+
+func TestCaseRevealFeedCommitted(t *testing.T) {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	f := &CaseRevealFeed{}
+
+	steps := []struct {
+		name    string
+		afterID uint64
+		ids     []uint
+		at      time.Duration
+		want    []uint
+	}{
+		{"contiguous", 10, []uint{11, 12, 13}, 0, []uint{11, 12, 13}},
+		{"stops at in-flight ID", 13, []uint{14, 16, 17}, 0, []uint{14}},
+		{"still waiting", 14, []uint{16, 17}, 30 * time.Second, []uint{}},
+		{"gap filled", 14, []uint{15, 16, 17}, 40 * time.Second, []uint{15, 16, 17}},
+		{"new gap", 17, []uint{19}, time.Minute, []uint{}},
+		{"rolled back after grace", 17, []uint{19, 20}, 2 * time.Minute, []uint{19, 20}},
+		{"later gap in the batch waits", 20, []uint{21, 23}, 2 * time.Minute, []uint{21}},
+	}
+	for _, step := range steps {
+		got := openingIDs(f.committed(step.afterID, openingsWithIDs(step.ids...), start.Add(step.at)))
+		if len(got) != len(step.want) {
+			t.Fatalf("%s: got %v, want %v", step.name, got, step.want)
+		}
+		for i := range got {
+			if got[i] != step.want[i] {
+				t.Fatalf("%s: got %v, want %v", step.name, got, step.want)
+			}
+		}
+	}
+}
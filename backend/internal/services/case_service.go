@@ -4,38 +4,71 @@ import (
 	"brainrot-tamagotchi/internal/blockchain"
 	"brainrot-tamagotchi/internal/models"
 	"brainrot-tamagotchi/internal/repository"
+	"brainrot-tamagotchi/pkg/apperr"
+	"brainrot-tamagotchi/pkg/money"
 	"fmt"
+	"strings"
 )
 
+var (
+	ErrCaseUnavailable       = apperr.Conflict("case_unavailable", "case is not available")
+	ErrCaseOpeningNotEnabled = apperr.Unavailable("case_opening_not_enabled", "case opening not yet implemented - needs smart contract integration")
+)
+
+// CaseCatalog looks up catalog cases and their roll odds
+type CaseCatalog interface {
+	GetCase(slug string) (*CatalogEntry, error)
+	RarityOdds() (map[string]map[string]float64, error)
+}
+
+var _ CaseCatalog = (*CaseCatalogService)(nil)
+
 type CaseService struct {
-	blockchain *blockchain.Client
-	nftRepo    *repository.NFTRepository
+	blockchain  *blockchain.Client
+	nftRepo     repository.NFTStore
+	caseRepo    repository.CaseOpeningStore
+	listingRepo repository.ListingStore
+	catalog     CaseCatalog
 }
 
 func NewCaseService(
 	blockchain *blockchain.Client,
-	nftRepo *repository.NFTRepository,
+	nftRepo repository.NFTStore,
+	caseRepo repository.CaseOpeningStore,
+	listingRepo repository.ListingStore,
+	catalog CaseCatalog,
 ) *CaseService {
 	return &CaseService{
-		blockchain: blockchain,
-		nftRepo:    nftRepo,
+		blockchain:  blockchain,
+		nftRepo:     nftRepo,
+		caseRepo:    caseRepo,
+		listingRepo: listingRepo,
+		catalog:     catalog,
 	}
 }
 
-// CasePrice represents case prices
-var CasePrices = map[string]float64{
-	"bronze": 0.0005, // ~$0.50
-	"silver": 0.002,  // ~$2
-	"gold":   0.01,   // ~$10
+// luckiestPullsLimit caps the luckiest pulls returned with global stats
+const luckiestPullsLimit = 10
+
+// GetCasePrice returns the price of a case that is currently on sale
+func (s *CaseService) GetCasePrice(caseType string) (money.Wei, error) {
+	entry, err := s.availableCase(caseType)
+	if err != nil {
+		return money.Wei{}, err
+	}
+	return entry.Price, nil
 }
 
-// GetCasePrice returns the price for a case type
-func (s *CaseService) GetCasePrice(caseType string) (float64, error) {
-	price, exists := CasePrices[caseType]
-	if !exists {
-		return 0, fmt.Errorf("invalid case type")
+// availableCase looks up a catalog case and checks that it is on sale
+func (s *CaseService) availableCase(caseType string) (*CatalogEntry, error) {
+	entry, err := s.catalog.GetCase(caseType)
+	if err != nil {
+		return nil, err
+	}
+	if !entry.Available {
+		return nil, ErrCaseUnavailable.WithMessage(fmt.Sprintf("case %s is not available", caseType))
 	}
-	return price, nil
+	return entry, nil
 }
 
 // OpenCase processes a case opening
@@ -43,8 +76,8 @@ func (s *CaseService) GetCasePrice(caseType string) (float64, error) {
 // For now, we'll handle the logic here and sync with blockchain
 func (s *CaseService) OpenCase(userAddress string, caseType string) (*models.NFT, error) {
 	// Validate case type
-	if _, exists := CasePrices[caseType]; !exists {
-		return nil, fmt.Errorf("invalid case type")
+	if _, err := s.availableCase(caseType); err != nil {
+		return nil, err
 	}
 
 	// In production: Call smart contract's buyAndOpenCase function
@@ -52,28 +85,131 @@ func (s *CaseService) OpenCase(userAddress string, caseType string) (*models.NFT
 	// TODO: Implement actual blockchain call
 
 	// For now, return placeholder
-	return nil, fmt.Errorf("case opening not yet implemented - needs smart contract integration")
+	return nil, ErrCaseOpeningNotEnabled
 }
 
 // GetCaseHistory returns the case opening history for a user
-func (s *CaseService) GetCaseHistory(userAddress string, limit int) ([]models.CaseOpening, error) {
-	// TODO: Implement case history query
-	return []models.CaseOpening{}, nil
+func (s *CaseService) GetCaseHistory(
+	userAddress string,
+	dateRange repository.DateRange,
+	limit, offset int,
+) ([]models.CaseOpening, int64, error) {
+	return s.caseRepo.GetByUser(userAddress, dateRange, limit, offset)
 }
 
-// GetCaseStats returns statistics about case openings
-func (s *CaseService) GetCaseStats() (map[string]interface{}, error) {
-	// TODO: Implement stats aggregation
+// GetCaseStats returns global statistics about case openings
+func (s *CaseService) GetCaseStats(dateRange repository.DateRange) (map[string]interface{}, error) {
+	totals, err := s.caseRepo.GetTotalsByCaseType("", dateRange)
+	if err != nil {
+		return nil, err
+	}
+
+	distribution, err := s.caseRepo.GetRarityDistribution("", dateRange)
+	if err != nil {
+		return nil, err
+	}
+
+	odds, err := s.catalog.RarityOdds()
+	if err != nil {
+		return nil, err
+	}
+
+	luckiest, err := s.caseRepo.GetLuckiestPulls(odds, dateRange, luckiestPullsLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	var totalOpened int64
+	var totalRevenue money.Wei
+	byType := make(map[string]repository.CaseTypeTotals, len(odds))
+	for caseType := range odds {
+		byType[caseType] = repository.CaseTypeTotals{CaseType: caseType}
+	}
+	for _, t := range totals {
+		byType[t.CaseType] = t
+		totalOpened += t.Count
+		totalRevenue = totalRevenue.Add(t.Revenue)
+	}
+
 	stats := map[string]interface{}{
-		"total_cases_opened": 0,
-		"by_type": map[string]int{
-			"bronze": 0,
-			"silver": 0,
-			"gold":   0,
-		},
-		"total_revenue": 0.0,
+		"total_cases_opened":  totalOpened,
+		"total_revenue":       totalRevenue,
+		"by_type":             byType,
+		"rarity_distribution": groupRarities(distribution),
+		"luckiest_pulls":      luckiest,
 	}
 
 	return stats, nil
 }
 
+// GetUserCaseStats returns spending and pull value statistics for a user.
+// Rarity value is estimated from the average marketplace sale price, so the
+// expected value is what the user's openings were worth on average and the
+// actual value is what their rolled rarities are worth.
+func (s *CaseService) GetUserCaseStats(userAddress string, dateRange repository.DateRange) (map[string]interface{}, error) {
+	totals, err := s.caseRepo.GetTotalsByCaseType(userAddress, dateRange)
+	if err != nil {
+		return nil, err
+	}
+
+	distribution, err := s.caseRepo.GetRarityDistribution(userAddress, dateRange)
+	if err != nil {
+		return nil, err
+	}
+
+	rarityValues, err := s.listingRepo.GetAverageSalePriceByRarity()
+	if err != nil {
+		return nil, err
+	}
+
+	odds, err := s.catalog.RarityOdds()
+	if err != nil {
+		return nil, err
+	}
+
+	var totalOpened int64
+	var totalSpent, expectedValue, actualValue money.Wei
+	for _, t := range totals {
+		totalOpened += t.Count
+		totalSpent = totalSpent.Add(t.Revenue)
+		expectedValue = expectedValue.Add(expectedCaseValue(odds[t.CaseType], rarityValues).MulInt64(t.Count))
+	}
+	for _, d := range distribution {
+		actualValue = actualValue.Add(rarityValues[d.Rarity].MulInt64(d.Count))
+	}
+
+	stats := map[string]interface{}{
+		"user_address":        strings.ToLower(userAddress),
+		"total_cases_opened":  totalOpened,
+		"total_spent":         totalSpent,
+		"expected_value":      expectedValue,
+		"actual_value":        actualValue,
+		"luck_delta":          actualValue.Sub(expectedValue),
+		"by_type":             totals,
+		"rarity_distribution": groupRarities(distribution),
+		"rarity_values":       rarityValues,
+	}
+
+	return stats, nil
+}
+
+// expectedCaseValue weighs rarity values by a case's roll odds
+func expectedCaseValue(odds map[string]float64, rarityValues map[string]money.Wei) money.Wei {
+	var value money.Wei
+	for rarity, chance := range odds {
+		value = value.Add(rarityValues[rarity].MulFloat(chance))
+	}
+	return value
+}
+
+// groupRarities nests rarity counts under their case type
+func groupRarities(counts []repository.RarityCount) map[string]map[string]int64 {
+	grouped := make(map[string]map[string]int64)
+	for _, c := range counts {
+		if grouped[c.CaseType] == nil {
+			grouped[c.CaseType] = make(map[string]int64)
+		}
+		grouped[c.CaseType][c.Rarity] = c.Count
+	}
+	return grouped
+}
@@ -0,0 +1,185 @@
+package services
+
+import (
+	"brainrot-tamagotchi/internal/models"
+	"brainrot-tamagotchi/internal/repository"
+	"brainrot-tamagotchi/internal/repository/memstore"
+	"brainrot-tamagotchi/pkg/money"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeCatalog is a fixed CaseCatalog
+type fakeCatalog struct {
+	cases map[string]CatalogEntry
+	odds  map[string]map[string]float64
+}
+
+func (c *fakeCatalog) GetCase(slug string) (*CatalogEntry, error) {
+	entry, ok := c.cases[slug]
+	if !ok {
+		return nil, ErrCaseNotFound
+	}
+	return &entry, nil
+}
+
+func (c *fakeCatalog) RarityOdds() (map[string]map[string]float64, error) {
+	return c.odds, nil
+}
+
+// newMemCaseService returns a case service over in-memory stores: bronze is
+// on sale, retired is not, and three bronze cases have been opened
+func newMemCaseService(t *testing.T) (*CaseService, *memstore.NFTStore, *memstore.ListingStore) {
+	t.Helper()
+	catalog := &fakeCatalog{
+		cases: map[string]CatalogEntry{
+			"bronze":  {CaseDefinition: models.CaseDefinition{Slug: "bronze", Price: money.MustParseEther("1")}, Available: true},
+			"retired": {CaseDefinition: models.CaseDefinition{Slug: "retired", Price: money.MustParseEther("5")}},
+		},
+		odds: map[string]map[string]float64{
+			"bronze": {"common": 0.75, "rare": 0.25},
+			"silver": {"common": 1},
+		},
+	}
+
+	openedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	openings := memstore.NewCaseOpeningStore()
+	for i, o := range []struct{ user, rarity string }{
+		{"0xA", "common"},
+		{"0xa", "rare"},
+		{"0xb", "common"},
+	} {
+		err := openings.Create(&models.CaseOpening{
+			UserAddress: o.user,
+			CaseType:    "bronze",
+			TokenID:     uint(i + 1),
+			Rarity:      o.rarity,
+			Price:       money.MustParseEther("1"),
+			OpenedAt:    openedAt.Add(time.Duration(i) * time.Minute),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	nfts := memstore.NewNFTStore()
+	listings := memstore.NewListingStore(nfts)
+	s := NewCaseService(nil, nfts, openings, listings, catalog)
+	return s, nfts, listings
+}
+
+// sell records a sold listing for a new NFT of the given rarity
+func sell(t *testing.T, nfts *memstore.NFTStore, listings *memstore.ListingStore, tokenID uint, rarity, price string) {
+	t.Helper()
+	if err := nfts.Create(&models.NFT{TokenID: tokenID, OwnerAddress: "0xc", Rarity: rarity}); err != nil {
+		t.Fatal(err)
+	}
+	listing := &models.MarketListing{TokenID: tokenID, SellerAddress: "0xc", Price: money.MustParseEther(price), IsActive: true}
+	if err := listings.Create(listing); err != nil {
+		t.Fatal(err)
+	}
+	if err := listings.MarkAsSold(tokenID, "0xd"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCaseAvailability(t *testing.T) {
+	s, _, _ := newMemCaseService(t)
+
+	price, err := s.GetCasePrice("bronze")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if price.Cmp(money.MustParseEther("1")) != 0 {
+		t.Errorf("bronze price = %s, want 1 ETH", price.Ether())
+	}
+
+	tests := []struct {
+		caseType string
+		want     error
+	}{
+		{"retired", ErrCaseUnavailable},
+		{"missing", ErrCaseNotFound},
+	}
+	for _, tt := range tests {
+		if _, err := s.GetCasePrice(tt.caseType); !errors.Is(err, tt.want) {
+			t.Errorf("GetCasePrice(%s) = %v, want %v", tt.caseType, err, tt.want)
+		}
+		if _, err := s.OpenCase("0xa", tt.caseType); !errors.Is(err, tt.want) {
+			t.Errorf("OpenCase(%s) = %v, want %v", tt.caseType, err, tt.want)
+		}
+	}
+
+	if _, err := s.OpenCase("0xa", "bronze"); !errors.Is(err, ErrCaseOpeningNotEnabled) {
+		t.Errorf("OpenCase(bronze) = %v, want %v", err, ErrCaseOpeningNotEnabled)
+	}
+}
+
+func TestGetCaseStats(t *testing.T) {
+	s, _, _ := newMemCaseService(t)
+
+	stats, err := s.GetCaseStats(repository.DateRange{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := stats["total_cases_opened"]; got != int64(3) {
+		t.Errorf("total_cases_opened = %v, want 3", got)
+	}
+	if got := stats["total_revenue"].(money.Wei); got.Cmp(money.MustParseEther("3")) != 0 {
+		t.Errorf("total_revenue = %s, want 3 ETH", got.Ether())
+	}
+
+	byType := stats["by_type"].(map[string]repository.CaseTypeTotals)
+	if byType["bronze"].Count != 3 {
+		t.Errorf("bronze count = %d, want 3", byType["bronze"].Count)
+	}
+	// Case types without openings are listed with zero totals
+	if silver, ok := byType["silver"]; !ok || silver.Count != 0 {
+		t.Errorf("silver totals = %+v, %v", silver, ok)
+	}
+
+	distribution := stats["rarity_distribution"].(map[string]map[string]int64)
+	if distribution["bronze"]["common"] != 2 || distribution["bronze"]["rare"] != 1 {
+		t.Errorf("rarity_distribution = %v", distribution)
+	}
+
+	luckiest := stats["luckiest_pulls"].([]models.CaseOpening)
+	if len(luckiest) == 0 || luckiest[0].Rarity != "rare" {
+		t.Errorf("luckiest_pulls = %+v, want the rare pull first", luckiest)
+	}
+}
+
+func TestGetUserCaseStats(t *testing.T) {
+	s, nfts, listings := newMemCaseService(t)
+	sell(t, nfts, listings, 100, "common", "1")
+	sell(t, nfts, listings, 101, "common", "3")
+	sell(t, nfts, listings, 102, "rare", "8")
+
+	stats, err := s.GetUserCaseStats("0xA", repository.DateRange{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := stats["user_address"]; got != "0xa" {
+		t.Errorf("user_address = %v, want 0xa", got)
+	}
+	if got := stats["total_cases_opened"]; got != int64(2) {
+		t.Errorf("total_cases_opened = %v, want 2", got)
+	}
+
+	// common sells for 2 ETH on average and rare for 8, so a bronze case
+	// is worth 0.75*2 + 0.25*8 = 3.5 ETH
+	wantWei := map[string]string{
+		"total_spent":    "2",
+		"expected_value": "7",
+		"actual_value":   "10",
+		"luck_delta":     "3",
+	}
+	for key, want := range wantWei {
+		if got := stats[key].(money.Wei); got.Cmp(money.MustParseEther(want)) != 0 {
+			t.Errorf("%s = %s, want %s ETH", key, got.Ether(), want)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package services
+
+import (
+	"brainrot-tamagotchi/internal/blockchain"
+	"brainrot-tamagotchi/pkg/apperr"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// Errors shared by several services. Each service declares its own next to
+// the code that returns them.
+var (
+	ErrPetNotFound      = apperr.NotFound("pet_not_found", "pet not found")
+	ErrListingNotFound  = apperr.NotFound("listing_not_found", "listing not found")
+	ErrNotOwner         = apperr.Forbidden("not_owner", "not the owner of this NFT")
+	ErrTxReverted       = apperr.Conflict("tx_reverted", "transaction reverted")
+	ErrChainUnavailable = apperr.Upstream("chain_unavailable", "blockchain RPC request failed")
+	ErrChainDisabled    = apperr.Unavailable("chain_disabled", "blockchain client not available")
+	ErrSignerDisabled   = apperr.Unavailable("signer_disabled", "backend signer not configured")
+)
+
+// notFound turns a missing record into missing, leaving other errors as
+// they are
+func notFound(err error, missing *apperr.Error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return missing.Wrap(err)
+	}
+	return err
+}
+
+// chainError classifies an error from the chain client. Pending and
+// reverted transactions keep their meaning; anything else is the RPC
+// provider failing.
+func chainError(err error) error {
+	switch {
+	case errors.Is(err, blockchain.ErrTxPending):
+		return err
+	case errors.Is(err, blockchain.ErrTxReverted):
+		return ErrTxReverted.Wrap(err)
+	}
+	if _, ok := apperr.As(err); ok {
+		return err
+	}
+	return ErrChainUnavailable.Wrap(err)
+}
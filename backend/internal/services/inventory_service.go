@@ -0,0 +1,266 @@
+package services
+
+import (
+	"brainrot-tamagotchi/internal/blockchain"
+	"brainrot-tamagotchi/internal/config"
+	"brainrot-tamagotchi/internal/models"
+	"brainrot-tamagotchi/internal/repository"
+	"context"
+	"sort"
+	"strings"
+	"time"
+)
+
+type InventoryService struct {
+	nftRepo     *repository.NFTRepository
+	listingRepo *repository.MarketListingRepository
+	blockchain  *blockchain.Client
+	game        config.Game
+}
+
+func NewInventoryService(
+	nftRepo *repository.NFTRepository,
+	listingRepo *repository.MarketListingRepository,
+	blockchain *blockchain.Client,
+	game config.Game,
+) *InventoryService {
+	return &InventoryService{
+		nftRepo:     nftRepo,
+		listingRepo: listingRepo,
+		blockchain:  blockchain,
+		game:        game,
+	}
+}
+
+// RarityRank orders rarities from lowest to highest
+var RarityRank = map[string]int{
+	"common":    0,
+	"rare":      1,
+	"epic":      2,
+	"legendary": 3,
+}
+
+// InventoryFilter narrows and orders an inventory listing
+type InventoryFilter struct {
+	Rarity   string
+	MemeType string
+	Alive    *bool
+	SortBy   string // "token_id", "level", "rarity", "hunger", "mood", "energy", "minted_at"
+	SortDesc bool
+}
+
+// InventoryItem is an owned NFT with live stats and listing status
+type InventoryItem struct {
+	models.NFT
+	IsAlive      bool                  `json:"is_alive"`
+	NeedsFeeding bool                  `json:"needs_feeding"`
+	IsListed     bool                  `json:"is_listed"`
+	Listing      *models.MarketListing `json:"listing,omitempty"`
+}
+
+// InventorySummary counts a user's whole inventory, regardless of filters
+type InventorySummary struct {
+	Total      int            `json:"total"`
+	Alive      int            `json:"alive"`
+	Dead       int            `json:"dead"`
+	Listed     int            `json:"listed"`
+	ByRarity   map[string]int `json:"by_rarity"`
+	ByMemeType map[string]int `json:"by_meme_type"`
+}
+
+// InventoryDrift reports where the DB disagrees with BrainrotNFT.tokensOfOwner
+type InventoryDrift struct {
+	OnChainCount int `json:"on_chain_count"`
+	// Owned on-chain but recorded under another owner in the DB
+	MissingFromDB []uint `json:"missing_from_db"`
+	// Recorded under this owner in the DB but not owned on-chain
+	StaleInDB []uint `json:"stale_in_db"`
+	// Owned on-chain but not indexed at all
+	UnknownTokens []uint `json:"unknown_tokens"`
+	InSync        bool   `json:"in_sync"`
+}
+
+// Inventory is the full inventory response for an address
+type Inventory struct {
+	Address string           `json:"address"`
+	NFTs    []InventoryItem  `json:"nfts"`
+	Count   int              `json:"count"`
+	Summary InventorySummary `json:"summary"`
+	Drift   *InventoryDrift  `json:"drift,omitempty"`
+}
+
+// GetInventory returns the NFTs owned by an address with live stats.
+// When verify is set the DB is cross-checked against the NFT contract; the
+// OwnershipReconciler corrects any drift.
+func (s *InventoryService) GetInventory(
+	ctx context.Context,
+	ownerAddress string,
+	filter InventoryFilter,
+	verify bool,
+) (*Inventory, error) {
+	ownerAddress = strings.ToLower(ownerAddress)
+	inventory := &Inventory{Address: ownerAddress}
+
+	if verify {
+		drift, err := s.verifyOwnership(ctx, ownerAddress)
+		if err != nil {
+			return nil, err
+		}
+		inventory.Drift = drift
+	}
+
+	nfts, err := s.nftRepo.WithContext(ctx).GetByOwner(ownerAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenIDs := make([]uint, len(nfts))
+	for i := range nfts {
+		tokenIDs[i] = nfts[i].TokenID
+	}
+	listings, err := s.listingRepo.WithContext(ctx).GetActiveByTokenIDs(tokenIDs)
+	if err != nil {
+		return nil, err
+	}
+	listingByToken := make(map[uint]*models.MarketListing, len(listings))
+	for i := range listings {
+		listingByToken[listings[i].TokenID] = &listings[i]
+	}
+
+	now := time.Now()
+	items := make([]InventoryItem, 0, len(nfts))
+	for _, nft := range nfts {
+		applyTimeDecay(&nft, now, s.game)
+		listing := listingByToken[nft.TokenID]
+		items = append(items, InventoryItem{
+			NFT:          nft,
+			IsAlive:      nft.IsAlive(),
+			NeedsFeeding: nft.NeedsFeeding(),
+			IsListed:     listing != nil,
+			Listing:      listing,
+		})
+	}
+
+	inventory.Summary = summarizeInventory(items)
+	inventory.NFTs = filterInventory(items, filter)
+	sortInventory(inventory.NFTs, filter.SortBy, filter.SortDesc)
+	inventory.Count = len(inventory.NFTs)
+
+	return inventory, nil
+}
+
+// verifyOwnership diffs DB ownership against tokensOfOwner for one address
+func (s *InventoryService) verifyOwnership(ctx context.Context, ownerAddress string) (*InventoryDrift, error) {
+	if s.blockchain == nil {
+		return nil, ErrChainDisabled
+	}
+
+	onChain, err := s.blockchain.TokensOfOwner(ctx, ownerAddress)
+	if err != nil {
+		return nil, chainError(err)
+	}
+
+	nftRepo := s.nftRepo.WithContext(ctx)
+	dbOwned, err := nftRepo.GetByOwner(ownerAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	drift := &InventoryDrift{
+		OnChainCount:  len(onChain),
+		MissingFromDB: []uint{},
+		StaleInDB:     []uint{},
+		UnknownTokens: []uint{},
+	}
+
+	onChainSet := make(map[uint]bool, len(onChain))
+	for _, tokenID := range onChain {
+		onChainSet[tokenID] = true
+	}
+	dbSet := make(map[uint]bool, len(dbOwned))
+	for _, nft := range dbOwned {
+		dbSet[nft.TokenID] = true
+		if !onChainSet[nft.TokenID] {
+			drift.StaleInDB = append(drift.StaleInDB, nft.TokenID)
+		}
+	}
+	for _, tokenID := range onChain {
+		if dbSet[tokenID] {
+			continue
+		}
+		if _, err := nftRepo.GetByTokenID(tokenID); err != nil {
+			drift.UnknownTokens = append(drift.UnknownTokens, tokenID)
+		} else {
+			drift.MissingFromDB = append(drift.MissingFromDB, tokenID)
+		}
+	}
+
+	drift.InSync = len(drift.MissingFromDB) == 0 && len(drift.StaleInDB) == 0 && len(drift.UnknownTokens) == 0
+	return drift, nil
+}
+
+func summarizeInventory(items []InventoryItem) InventorySummary {
+	summary := InventorySummary{
+		Total:      len(items),
+		ByRarity:   make(map[string]int),
+		ByMemeType: make(map[string]int),
+	}
+	for _, item := range items {
+		if item.IsAlive {
+			summary.Alive++
+		} else {
+			summary.Dead++
+		}
+		if item.IsListed {
+			summary.Listed++
+		}
+		summary.ByRarity[item.Rarity]++
+		summary.ByMemeType[item.MemeType]++
+	}
+	return summary
+}
+
+func filterInventory(items []InventoryItem, filter InventoryFilter) []InventoryItem {
+	filtered := make([]InventoryItem, 0, len(items))
+	for _, item := range items {
+		if filter.Rarity != "" && !strings.EqualFold(item.Rarity, filter.Rarity) {
+			continue
+		}
+		if filter.MemeType != "" && !strings.EqualFold(item.MemeType, filter.MemeType) {
+			continue
+		}
+		if filter.Alive != nil && item.IsAlive != *filter.Alive {
+			continue
+		}
+		filtered = append(filtered, item)
+	}
+	return filtered
+}
+
+func sortInventory(items []InventoryItem, sortBy string, desc bool) {
+	less := func(a, b *InventoryItem) bool {
+		switch sortBy {
+		case "level":
+			return a.Level < b.Level
+		case "rarity":
+			return RarityRank[a.Rarity] < RarityRank[b.Rarity]
+		case "hunger":
+			return a.Hunger < b.Hunger
+		case "mood":
+			return a.Mood < b.Mood
+		case "energy":
+			return a.Energy < b.Energy
+		case "minted_at":
+			return a.MintedAt.Before(b.MintedAt)
+		default:
+			return a.TokenID < b.TokenID
+		}
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		if desc {
+			return less(&items[j], &items[i])
+		}
+		return less(&items[i], &items[j])
+	})
+}
@@ -0,0 +1,46 @@
+package services
+
+import (
+	"brainrot-tamagotchi/internal/models"
+	"brainrot-tamagotchi/internal/repository"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ReportStore keeps the last report of each job. Jobs run on the scheduler
+// leader, so reports live in the DB where every instance can read them.
+type ReportStore interface {
+	SaveReport(report *models.JobReport) error
+	GetReport(jobName string) (*models.JobReport, error)
+}
+
+var _ ReportStore = (*repository.JobRunRepository)(nil)
+
+// saveReport stores report as the last report of a job
+func saveReport(store ReportStore, jobName string, finishedAt time.Time, report interface{}) error {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	return store.SaveReport(&models.JobReport{
+		JobName:    jobName,
+		Report:     string(data),
+		FinishedAt: finishedAt,
+	})
+}
+
+// loadReport decodes the last report of a job into report, and reports
+// whether there was one
+func loadReport(store ReportStore, jobName string, report interface{}) (bool, error) {
+	row, err := store.GetReport(jobName)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, json.Unmarshal([]byte(row.Report), report)
+}
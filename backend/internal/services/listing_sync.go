@@ -0,0 +1,419 @@
+package services
+
+import (
+	"brainrot-tamagotchi/internal/blockchain"
+	"brainrot-tamagotchi/internal/events"
+	"brainrot-tamagotchi/internal/metrics"
+	"brainrot-tamagotchi/internal/models"
+	"brainrot-tamagotchi/internal/realtime"
+	"brainrot-tamagotchi/internal/repository"
+	"brainrot-tamagotchi/pkg/money"
+	"brainrot-tamagotchi/pkg/tracing"
+	"context"
+	"errors"
+	"math/big"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// marketplaceSyncCursor names the sync_cursors row for Marketplace.sol events
+const marketplaceSyncCursor = "marketplace_listings"
+
+// listingSyncBatchBlocks caps the block range of a single eth_getLogs call
+const listingSyncBatchBlocks = 2000
+
+// MarketplaceChain reads Marketplace.sol events; *blockchain.Client
+// implements it
+type MarketplaceChain interface {
+	BlockNumber(ctx context.Context) (uint64, error)
+	FilterMarketplaceEvents(ctx context.Context, fromBlock, toBlock uint64) ([]*blockchain.MarketplaceEvent, error)
+	MarketplaceEventsFromTx(ctx context.Context, txHash string) ([]*blockchain.MarketplaceEvent, error)
+}
+
+var _ MarketplaceChain = (*blockchain.Client)(nil)
+
+// ListingSync mirrors Marketplace.sol events into market_listings.
+// Every event is applied at most once, keyed by (tx hash, log index), and
+// only once it is confirmations blocks deep.
+type ListingSync struct {
+	stores        repository.Stores
+	chain         MarketplaceChain // nil without a blockchain client
+	startBlock    uint64
+	confirmations uint64
+	hub           *realtime.Hub
+}
+
+func NewListingSync(
+	stores repository.Stores,
+	blockchain *blockchain.Client,
+	startBlock uint64,
+	confirmations uint64,
+	hub *realtime.Hub,
+) *ListingSync {
+	s := &ListingSync{
+		stores:        stores,
+		startBlock:    startBlock,
+		confirmations: confirmations,
+		hub:           hub,
+	}
+	if blockchain != nil {
+		s.chain = blockchain
+	}
+	return s
+}
+
+// SyncOnce applies all events between the stored cursor and the confirmed
+// chain head, and returns how many new events were applied
+func (s *ListingSync) SyncOnce(ctx context.Context) (_ int, err error) {
+	ctx, span := tracer.Start(ctx, "ListingSync.SyncOnce")
+	defer func() { tracing.End(span, err) }()
+
+	if s.chain == nil {
+		return 0, ErrChainDisabled
+	}
+
+	cursors := s.stores.WithContext(ctx).Cursors()
+	fromBlock, err := cursors.GetCursor(marketplaceSyncCursor)
+	if err != nil {
+		return 0, err
+	}
+	if fromBlock < s.startBlock {
+		fromBlock = s.startBlock
+	} else if fromBlock > 0 {
+		fromBlock++
+	}
+
+	head, err := s.chain.BlockNumber(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if head < s.confirmations {
+		return 0, nil
+	}
+	head -= s.confirmations
+	// Lag is measured from the unconfirmed head to the last block synced,
+	// however far this pass gets
+	chainHead := head + s.confirmations
+	defer func() {
+		if fromBlock > 0 {
+			metrics.IndexerBlockLag.WithLabelValues("marketplace").Set(float64(chainHead - (fromBlock - 1)))
+		}
+	}()
+
+	applied := 0
+	for fromBlock <= head {
+		toBlock := min64(fromBlock+listingSyncBatchBlocks-1, head)
+
+		events, err := s.chain.FilterMarketplaceEvents(ctx, fromBlock, toBlock)
+		if err != nil {
+			return applied, err
+		}
+
+		for _, event := range events {
+			isNew, err := s.apply(ctx, event)
+			if err != nil {
+				return applied, err
+			}
+			if isNew {
+				applied++
+			}
+		}
+
+		if err := cursors.SetCursor(marketplaceSyncCursor, toBlock); err != nil {
+			return applied, err
+		}
+		fromBlock = toBlock + 1
+	}
+
+	if applied > 0 {
+		logger.InfoContext(ctx, "applied marketplace events", "count", applied)
+	}
+	return applied, nil
+}
+
+// Lag returns how many blocks the last synced block trails the chain head
+func (s *ListingSync) Lag(ctx context.Context) (uint64, error) {
+	if s.chain == nil {
+		return 0, ErrChainDisabled
+	}
+
+	synced, err := s.stores.WithContext(ctx).Cursors().GetCursor(marketplaceSyncCursor)
+	if err != nil {
+		return 0, err
+	}
+	if synced < s.startBlock {
+		synced = s.startBlock
+	}
+	head, err := s.chain.BlockNumber(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if head < synced {
+		return 0, nil
+	}
+	return head - synced, nil
+}
+
+// ConfirmTx applies the marketplace events of a submitted transaction
+// without waiting for the next sync tick. Like SyncOnce it waits for the
+// confirmation depth, so a reorg can't leave a sale or owner change behind:
+// it returns blockchain.ErrTxPending until the transaction is mined and
+// confirmations blocks deep.
+func (s *ListingSync) ConfirmTx(ctx context.Context, txHash string) (_ []*blockchain.MarketplaceEvent, err error) {
+	ctx, span := tracer.Start(ctx, "ListingSync.ConfirmTx", trace.WithAttributes(attribute.String("tx", txHash)))
+	defer func() { tracing.End(span, err, blockchain.ErrTxPending) }()
+
+	if s.chain == nil {
+		return nil, ErrChainDisabled
+	}
+
+	events, err := s.chain.MarketplaceEventsFromTx(ctx, txHash)
+	if err != nil {
+		return nil, chainError(err)
+	}
+
+	if len(events) > 0 {
+		head, err := s.chain.BlockNumber(ctx)
+		if err != nil {
+			return nil, chainError(err)
+		}
+		if mined := events[0].BlockNumber; head < mined || head-mined < s.confirmations {
+			return nil, blockchain.ErrTxPending
+		}
+	}
+
+	for _, event := range events {
+		if _, err := s.apply(ctx, event); err != nil {
+			return nil, err
+		}
+	}
+	return events, nil
+}
+
+// apply mirrors one event into the DB and reports whether it was new
+func (s *ListingSync) apply(ctx context.Context, event *blockchain.MarketplaceEvent) (bool, error) {
+	isNew := false
+	err := s.stores.WithContext(ctx).Transaction(func(tx repository.Stores) error {
+		listings := tx.Listings()
+
+		var err error
+		isNew, err = tx.ChainEvents().MarkProcessed(&models.ProcessedChainEvent{
+			TxHash:      event.TxHash,
+			LogIndex:    event.LogIndex,
+			BlockNumber: event.BlockNumber,
+			Contract:    "marketplace",
+			EventName:   event.Name,
+			TokenID:     event.TokenID,
+		})
+		if err != nil || !isNew {
+			return err
+		}
+
+		if err := events.RecordTo(tx.Outbox(), domainEvent(event)); err != nil {
+			return err
+		}
+
+		// Every sale is booked, even if a later event already moved the listing on
+		if event.Name == blockchain.EventNFTSold {
+			if err := tx.Sales().Create(saleFromEvent(event)); err != nil {
+				return err
+			}
+		}
+
+		listing, err := listings.GetLatestByTokenID(event.TokenID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			listing = &models.MarketListing{TokenID: event.TokenID}
+		} else if err != nil {
+			return err
+		} else if !event.After(listing.LastEventBlock, listing.LastEventLogIndex) {
+			// A newer event for this token was already applied
+			return nil
+		}
+
+		switch event.Name {
+		case blockchain.EventNFTListed:
+			listing.SellerAddress = event.Seller
+			listing.Price = money.NewWei(event.Price)
+			listing.IsActive = true
+			listing.ListedAt = event.BlockTime
+			listing.TxHash = event.TxHash
+			listing.SoldAt = nil
+			listing.BuyerAddress = nil
+			listing.SaleTxHash = nil
+			listing.CancelledAt = nil
+			listing.CancelTxHash = nil
+
+		case blockchain.EventNFTSold:
+			listing.SellerAddress = event.Seller
+			listing.Price = money.NewWei(event.Price)
+			listing.IsActive = false
+			listing.SoldAt = &event.BlockTime
+			listing.BuyerAddress = &event.Buyer
+			listing.SaleTxHash = &event.TxHash
+			if err := tx.NFTs().UpdateOwner(event.TokenID, event.Buyer); err != nil {
+				return err
+			}
+
+		case blockchain.EventListingCancelled:
+			if listing.SellerAddress == "" {
+				listing.SellerAddress = event.Seller
+			}
+			listing.IsActive = false
+			listing.CancelledAt = &event.BlockTime
+			listing.CancelTxHash = &event.TxHash
+			if event.Emergency {
+				logger.WarnContext(ctx, "listing cancelled by contract owner", "token_id", event.TokenID, "tx", event.TxHash)
+			}
+
+		case blockchain.EventPriceUpdated:
+			if listing.ID == 0 {
+				// Listed before the sync start block; the seller is unknown
+				logger.WarnContext(ctx, "skipping price update for unknown listing", "token_id", event.TokenID, "tx", event.TxHash)
+				return nil
+			}
+			listing.Price = money.NewWei(event.Price)
+		}
+
+		listing.LastEventBlock = event.BlockNumber
+		listing.LastEventLogIndex = event.LogIndex
+		active := listing.IsActive
+		if err := listings.Upsert(listing); err != nil {
+			return err
+		}
+		if !active && listing.IsActive {
+			// A new row took the is_active column default
+			listing.IsActive = false
+			return listings.Update(listing)
+		}
+		return nil
+	})
+	if err == nil && isNew {
+		s.publish(event)
+		if event.Name == blockchain.EventNFTSold {
+			metrics.MarketplaceSales.Inc()
+			metrics.MarketplaceVolume.Add(money.NewWei(event.Price).EtherFloat())
+		}
+	}
+	return isNew, err
+}
+
+// domainEvent converts a contract event to its domain event
+func domainEvent(event *blockchain.MarketplaceEvent) events.Event {
+	switch event.Name {
+	case blockchain.EventNFTSold:
+		return events.ListingSold{
+			TokenID:     event.TokenID,
+			Seller:      event.Seller,
+			Buyer:       event.Buyer,
+			Price:       money.NewWei(event.Price),
+			PlatformFee: money.NewWei(event.PlatformFee),
+			TxHash:      event.TxHash,
+			LogIndex:    event.LogIndex,
+			SoldAt:      event.BlockTime,
+		}
+	case blockchain.EventListingCancelled:
+		return events.ListingCancelled{
+			TokenID:   event.TokenID,
+			Seller:    event.Seller,
+			Emergency: event.Emergency,
+			TxHash:    event.TxHash,
+		}
+	case blockchain.EventPriceUpdated:
+		return events.ListingRepriced{
+			TokenID: event.TokenID,
+			Price:   money.NewWei(event.Price),
+			TxHash:  event.TxHash,
+		}
+	default:
+		return events.ListingCreated{
+			TokenID: event.TokenID,
+			Seller:  event.Seller,
+			Price:   money.NewWei(event.Price),
+			TxHash:  event.TxHash,
+		}
+	}
+}
+
+// listingUpdate is the realtime payload for a marketplace event
+type listingUpdate struct {
+	TokenID   uint      `json:"token_id"`
+	Seller    string    `json:"seller"`
+	Buyer     string    `json:"buyer,omitempty"`
+	Price     money.Wei `json:"price"`
+	Emergency bool      `json:"emergency,omitempty"`
+	TxHash    string    `json:"tx_hash"`
+}
+
+// listingEventTypes maps contract events to realtime event types
+var listingEventTypes = map[string]string{
+	blockchain.EventNFTListed:        realtime.EventListingCreated,
+	blockchain.EventNFTSold:          realtime.EventListingSold,
+	blockchain.EventListingCancelled: realtime.EventListingCancelled,
+	blockchain.EventPriceUpdated:     realtime.EventListingUpdated,
+}
+
+// publish pushes an applied event to the marketplace feed and to the
+// wallets involved
+func (s *ListingSync) publish(event *blockchain.MarketplaceEvent) {
+	update := listingUpdate{
+		TokenID:   event.TokenID,
+		Seller:    event.Seller,
+		Buyer:     event.Buyer,
+		Price:     money.NewWei(event.Price),
+		Emergency: event.Emergency,
+		TxHash:    event.TxHash,
+	}
+
+	eventType := listingEventTypes[event.Name]
+	s.hub.Publish(realtime.MarketplaceTopic(), eventType, update)
+	if event.Seller != "" {
+		s.hub.Publish(realtime.WalletTopic(event.Seller), eventType, update)
+	}
+	if event.Buyer != "" {
+		s.hub.Publish(realtime.WalletTopic(event.Buyer), eventType, update)
+	}
+}
+
+// saleFromEvent builds the ledger entry for an NFTSold event
+func saleFromEvent(event *blockchain.MarketplaceEvent) *models.MarketSale {
+	gross := money.NewWei(event.Price)
+	fee := money.NewWei(event.PlatformFee)
+	return &models.MarketSale{
+		TokenID:        event.TokenID,
+		SellerAddress:  event.Seller,
+		BuyerAddress:   event.Buyer,
+		GrossPrice:     gross,
+		FeeBps:         feeBpsForSale(gross, fee),
+		PlatformFee:    fee,
+		SellerProceeds: gross.Sub(fee),
+		TxHash:         event.TxHash,
+		LogIndex:       event.LogIndex,
+		SoldAt:         event.BlockTime,
+	}
+}
+
+// feeBpsForSale recovers platformFeeBps from a sale. The contract computes
+// fee = price * bps / 10000 rounding down, so the floor of the inverse can
+// land one below the real rate; the candidate that reproduces fee wins.
+func feeBpsForSale(price, fee money.Wei) int {
+	if price.Sign() == 0 {
+		return 0
+	}
+
+	bps := new(big.Int).Mul(fee.BigInt(), big.NewInt(10000))
+	bps.Quo(bps, price.BigInt())
+	next := bps.Int64() + 1
+	if price.MulBps(next).Cmp(fee) == 0 {
+		return int(next)
+	}
+	return int(bps.Int64())
+}
+
+func min64(a, b uint64) uint64 {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,263 @@
+package services
+
+import (
+	"brainrot-tamagotchi/internal/blockchain"
+	"brainrot-tamagotchi/internal/events"
+	"brainrot-tamagotchi/internal/models"
+	"brainrot-tamagotchi/internal/repository"
+	"brainrot-tamagotchi/internal/repository/memstore"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+)
+
+// fakeMarketplaceChain serves Marketplace.sol events from memory
+type fakeMarketplaceChain struct {
+	head     uint64
+	logs     []*blockchain.MarketplaceEvent
+	reverted map[string]bool
+}
+
+func (c *fakeMarketplaceChain) BlockNumber(ctx context.Context) (uint64, error) {
+	return c.head, nil
+}
+
+func (c *fakeMarketplaceChain) FilterMarketplaceEvents(ctx context.Context, fromBlock, toBlock uint64) ([]*blockchain.MarketplaceEvent, error) {
+	var found []*blockchain.MarketplaceEvent
+	for _, event := range c.logs {
+		if event.BlockNumber >= fromBlock && event.BlockNumber <= toBlock {
+			found = append(found, event)
+		}
+	}
+	return found, nil
+}
+
+func (c *fakeMarketplaceChain) MarketplaceEventsFromTx(ctx context.Context, txHash string) ([]*blockchain.MarketplaceEvent, error) {
+	if c.reverted[txHash] {
+		return nil, fmt.Errorf("%w: %s", blockchain.ErrTxReverted, txHash)
+	}
+	var found []*blockchain.MarketplaceEvent
+	for _, event := range c.logs {
+		if event.TxHash == txHash {
+			found = append(found, event)
+		}
+	}
+	if found == nil {
+		return nil, blockchain.ErrTxPending
+	}
+	return found, nil
+}
+
+// newMemListingSync returns a listing sync over in-memory stores holding
+// pet 1, owned by 0xa, that waits for 2 confirmations
+func newMemListingSync(t *testing.T, chain *fakeMarketplaceChain) (*ListingSync, *memstore.Stores) {
+	t.Helper()
+	nfts := memstore.NewNFTStore()
+	if err := nfts.Create(&models.NFT{TokenID: 1, OwnerAddress: "0xa"}); err != nil {
+		t.Fatal(err)
+	}
+	stores := memstore.NewStores(nfts)
+	s := NewListingSync(stores, nil, 0, 2, nil)
+	s.chain = chain
+	return s, stores
+}
+
+func listedEvent(block uint64, logIndex uint, tx string) *blockchain.MarketplaceEvent {
+	return &blockchain.MarketplaceEvent{
+		Name:        blockchain.EventNFTListed,
+		TokenID:     1,
+		Seller:      "0xa",
+		Price:       big.NewInt(1000),
+		TxHash:      tx,
+		LogIndex:    logIndex,
+		BlockNumber: block,
+		BlockTime:   time.Unix(int64(block), 0),
+	}
+}
+
+func soldEvent(block uint64, logIndex uint, tx string) *blockchain.MarketplaceEvent {
+	return &blockchain.MarketplaceEvent{
+		Name:        blockchain.EventNFTSold,
+		TokenID:     1,
+		Seller:      "0xa",
+		Buyer:       "0xb",
+		Price:       big.NewInt(1000),
+		PlatformFee: big.NewInt(25),
+		TxHash:      tx,
+		LogIndex:    logIndex,
+		BlockNumber: block,
+		BlockTime:   time.Unix(int64(block), 0),
+	}
+}
+
+func cancelledEvent(block uint64, logIndex uint, tx string) *blockchain.MarketplaceEvent {
+	return &blockchain.MarketplaceEvent{
+		Name:        blockchain.EventListingCancelled,
+		TokenID:     1,
+		Seller:      "0xa",
+		TxHash:      tx,
+		LogIndex:    logIndex,
+		BlockNumber: block,
+		BlockTime:   time.Unix(int64(block), 0),
+	}
+}
+
+// saleCount returns how many sales the ledger holds
+func saleCount(t *testing.T, stores *memstore.Stores) int64 {
+	t.Helper()
+	totals, err := stores.Sales().GetTotals("", repository.DateRange{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return totals.SalesCount
+}
+
+func TestConfirmTxWaitsForConfirmations(t *testing.T) {
+	chain := &fakeMarketplaceChain{head: 11, logs: []*blockchain.MarketplaceEvent{soldEvent(10, 0, "0xsale")}}
+	s, stores := newMemListingSync(t, chain)
+	ctx := context.Background()
+
+	if _, err := s.ConfirmTx(ctx, "0xunknown"); !errors.Is(err, blockchain.ErrTxPending) {
+		t.Fatalf("unmined tx: %v, want ErrTxPending", err)
+	}
+
+	// One block deep: a reorg could still drop it
+	if _, err := s.ConfirmTx(ctx, "0xsale"); !errors.Is(err, blockchain.ErrTxPending) {
+		t.Fatalf("1 confirmation: %v, want ErrTxPending", err)
+	}
+	if saleCount(t, stores) != 0 || len(stores.OutboxEvents()) != 0 {
+		t.Fatal("an unconfirmed sale was applied")
+	}
+	if nft, _ := stores.NFTs().GetByTokenID(1); nft.OwnerAddress != "0xa" {
+		t.Fatalf("owner = %s before confirmation, want 0xa", nft.OwnerAddress)
+	}
+
+	chain.head = 12
+	found, err := s.ConfirmTx(ctx, "0xsale")
+	if err != nil || len(found) != 1 {
+		t.Fatalf("2 confirmations: %v, %v", found, err)
+	}
+	if saleCount(t, stores) != 1 {
+		t.Fatal("the confirmed sale was not booked")
+	}
+	if nft, _ := stores.NFTs().GetByTokenID(1); nft.OwnerAddress != "0xb" {
+		t.Fatalf("owner = %s, want the buyer", nft.OwnerAddress)
+	}
+}
+
+func TestConfirmTxReverted(t *testing.T) {
+	chain := &fakeMarketplaceChain{head: 100, reverted: map[string]bool{"0xfailed": true}}
+	s, stores := newMemListingSync(t, chain)
+
+	_, err := s.ConfirmTx(context.Background(), "0xfailed")
+	if !errors.Is(err, ErrTxReverted) {
+		t.Fatalf("reverted tx: %v, want ErrTxReverted", err)
+	}
+	if _, err := stores.Listings().GetLatestByTokenID(1); err == nil || len(stores.OutboxEvents()) != 0 {
+		t.Fatal("a reverted transaction left state behind")
+	}
+}
+
+func TestListingSyncAppliesEventsOnce(t *testing.T) {
+	chain := &fakeMarketplaceChain{head: 20, logs: []*blockchain.MarketplaceEvent{
+		listedEvent(5, 0, "0xlist"),
+		soldEvent(10, 3, "0xsale"),
+	}}
+	s, stores := newMemListingSync(t, chain)
+	ctx := context.Background()
+
+	// The buyer confirms first, then the sync tick sees the same logs
+	if _, err := s.ConfirmTx(ctx, "0xsale"); err != nil {
+		t.Fatal(err)
+	}
+	applied, err := s.SyncOnce(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if applied != 1 {
+		t.Fatalf("SyncOnce applied %d events, want only the listing", applied)
+	}
+	// Delivered a third time, e.g. by a retried request
+	if _, err := s.ConfirmTx(ctx, "0xsale"); err != nil {
+		t.Fatal(err)
+	}
+	// A second pass from an older cursor replays nothing
+	if err := stores.Cursors().SetCursor(marketplaceSyncCursor, 0); err != nil {
+		t.Fatal(err)
+	}
+	if applied, err := s.SyncOnce(ctx); err != nil || applied != 0 {
+		t.Fatalf("replayed pass applied %d, %v", applied, err)
+	}
+
+	if n := saleCount(t, stores); n != 1 {
+		t.Fatalf("booked %d sales, want 1", n)
+	}
+	if types := outboxTypes(stores); len(types) != 2 || types[0] != events.TypeListingSold || types[1] != events.TypeListingCreated {
+		t.Fatalf("outbox = %v, want one sold and one created event", types)
+	}
+
+	// The listing ends sold even though its NFTListed was applied last
+	listing, err := stores.Listings().GetLatestByTokenID(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if listing.IsActive || listing.SaleTxHash == nil || listing.LastEventBlock != 10 || listing.LastEventLogIndex != 3 {
+		t.Fatalf("listing = %+v, want sold at block 10 log 3", listing)
+	}
+	if cursor, _ := stores.Cursors().GetCursor(marketplaceSyncCursor); cursor != 18 {
+		t.Fatalf("cursor = %d, want head minus confirmations", cursor)
+	}
+}
+
+func TestListingSyncOutOfOrder(t *testing.T) {
+	tests := []struct {
+		name       string
+		order      []*blockchain.MarketplaceEvent
+		wantActive bool
+		wantBlock  uint64
+		wantLog    uint
+	}{
+		{
+			"relist after cancel, same block",
+			[]*blockchain.MarketplaceEvent{listedEvent(7, 2, "0xrelist"), cancelledEvent(7, 1, "0xcancel"), listedEvent(5, 0, "0xlist")},
+			true, 7, 2,
+		},
+		{
+			"cancel seen before its listing",
+			[]*blockchain.MarketplaceEvent{cancelledEvent(9, 0, "0xcancel"), listedEvent(5, 0, "0xlist")},
+			false, 9, 0,
+		},
+		{
+			"sale seen before its listing",
+			[]*blockchain.MarketplaceEvent{soldEvent(9, 4, "0xsale"), listedEvent(5, 0, "0xlist")},
+			false, 9, 4,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain := &fakeMarketplaceChain{head: 100, logs: tt.order}
+			s, stores := newMemListingSync(t, chain)
+			for _, event := range tt.order {
+				if _, err := s.ConfirmTx(context.Background(), event.TxHash); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			listing, err := stores.Listings().GetLatestByTokenID(1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if listing.IsActive != tt.wantActive || listing.LastEventBlock != tt.wantBlock || listing.LastEventLogIndex != tt.wantLog {
+				t.Fatalf("listing active=%v at %d/%d, want active=%v at %d/%d",
+					listing.IsActive, listing.LastEventBlock, listing.LastEventLogIndex, tt.wantActive, tt.wantBlock, tt.wantLog)
+			}
+			// Stale events are still recorded once, so they are not retried
+			if got := len(stores.OutboxEvents()); got != len(tt.order) {
+				t.Fatalf("outbox has %d events, want %d", got, len(tt.order))
+			}
+		})
+	}
+}
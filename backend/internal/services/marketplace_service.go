@@ -4,115 +4,131 @@ import (
 	"brainrot-tamagotchi/internal/blockchain"
 	"brainrot-tamagotchi/internal/models"
 	"brainrot-tamagotchi/internal/repository"
+	"brainrot-tamagotchi/pkg/apperr"
+	"brainrot-tamagotchi/pkg/tracing"
+	"context"
 	"fmt"
-	"time"
+	"regexp"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var txHashPattern = regexp.MustCompile(`^0x[0-9a-fA-F]{64}$`)
+
+var (
+	ErrNotBuyer       = apperr.Forbidden("not_buyer", "transaction was not sent by this buyer")
+	ErrNotSeller      = apperr.Forbidden("not_seller", "not the seller")
+	ErrInvalidTxHash  = apperr.Validation("invalid_tx_hash", "invalid transaction hash")
+	ErrTxEventMissing = apperr.Validation("tx_event_missing", "transaction has no matching marketplace event")
 )
 
+func isTxHash(txHash string) bool {
+	return txHashPattern.MatchString(txHash)
+}
+
 type MarketplaceService struct {
-	listingRepo *repository.MarketListingRepository
-	nftRepo     *repository.NFTRepository
+	listingRepo repository.ListingStore
+	nftRepo     repository.NFTStore
 	blockchain  *blockchain.Client
+	listingSync *ListingSync
 }
 
 func NewMarketplaceService(
-	listingRepo *repository.MarketListingRepository,
-	nftRepo *repository.NFTRepository,
+	listingRepo repository.ListingStore,
+	nftRepo repository.NFTStore,
 	blockchain *blockchain.Client,
+	listingSync *ListingSync,
 ) *MarketplaceService {
 	return &MarketplaceService{
 		listingRepo: listingRepo,
 		nftRepo:     nftRepo,
 		blockchain:  blockchain,
+		listingSync: listingSync,
 	}
 }
 
-// ListNFT creates a new marketplace listing
-func (s *MarketplaceService) ListNFT(tokenID uint, sellerAddress string, price float64) error {
-	// Verify ownership
-	nft, err := s.nftRepo.GetByTokenID(tokenID)
+// ListNFT confirms a submitted Marketplace.listNFT transaction
+func (s *MarketplaceService) ListNFT(ctx context.Context, tokenID uint, sellerAddress string, txHash string) (*models.MarketListing, error) {
+	event, err := s.confirmEvent(ctx, txHash, blockchain.EventNFTListed, tokenID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if nft.OwnerAddress != sellerAddress {
-		return fmt.Errorf("not the owner of this NFT")
+	if event.Seller != strings.ToLower(sellerAddress) {
+		return nil, ErrNotSeller
 	}
 
-	// Check if already listed
-	existing, _ := s.listingRepo.GetByTokenID(tokenID)
-	if existing != nil && existing.IsActive {
-		return fmt.Errorf("NFT already listed")
-	}
-
-	// Create listing
-	listing := &models.MarketListing{
-		TokenID:       tokenID,
-		SellerAddress: sellerAddress,
-		Price:         price,
-		IsActive:      true,
-		ListedAt:      time.Now(),
-	}
-
-	// In production: Call smart contract's listNFT function
-	// TODO: Implement blockchain integration
-
-	return s.listingRepo.Create(listing)
+	return s.latestListing(ctx, tokenID)
 }
 
-// BuyNFT processes an NFT purchase
-func (s *MarketplaceService) BuyNFT(tokenID uint, buyerAddress string) error {
-	// Get listing
-	listing, err := s.listingRepo.GetByTokenID(tokenID)
+// BuyNFT confirms a submitted Marketplace.buyNFT transaction
+func (s *MarketplaceService) BuyNFT(ctx context.Context, tokenID uint, buyerAddress string, txHash string) (*models.MarketListing, error) {
+	event, err := s.confirmEvent(ctx, txHash, blockchain.EventNFTSold, tokenID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if !listing.IsActive {
-		return fmt.Errorf("listing not active")
+	if event.Buyer != strings.ToLower(buyerAddress) {
+		return nil, ErrNotBuyer
 	}
 
-	if listing.SellerAddress == buyerAddress {
-		return fmt.Errorf("cannot buy your own NFT")
-	}
+	return s.latestListing(ctx, tokenID)
+}
 
-	// Verify NFT still owned by seller
-	nft, err := s.nftRepo.GetByTokenID(tokenID)
+// CancelListing confirms a submitted Marketplace.cancelListing transaction
+func (s *MarketplaceService) CancelListing(ctx context.Context, tokenID uint, sellerAddress string, txHash string) (*models.MarketListing, error) {
+	event, err := s.confirmEvent(ctx, txHash, blockchain.EventListingCancelled, tokenID)
 	if err != nil {
-		return err
-	}
-
-	if nft.OwnerAddress != listing.SellerAddress {
-		return fmt.Errorf("seller no longer owns the NFT")
+		return nil, err
 	}
 
-	// In production: Call smart contract's buyNFT function
-	// TODO: Implement blockchain integration
-
-	// Update NFT owner
-	nft.OwnerAddress = buyerAddress
-	if err := s.nftRepo.Update(nft); err != nil {
-		return err
+	if event.Seller != strings.ToLower(sellerAddress) {
+		return nil, ErrNotSeller
 	}
 
-	// Mark listing as sold
-	return s.listingRepo.MarkAsSold(tokenID, buyerAddress)
+	return s.latestListing(ctx, tokenID)
 }
 
-// CancelListing cancels an active listing
-func (s *MarketplaceService) CancelListing(tokenID uint, sellerAddress string) error {
-	listing, err := s.listingRepo.GetByTokenID(tokenID)
+// confirmEvent applies a transaction's marketplace events and returns the
+// one matching the expected event name and token
+func (s *MarketplaceService) confirmEvent(
+	ctx context.Context,
+	txHash string,
+	eventName string,
+	tokenID uint,
+) (_ *blockchain.MarketplaceEvent, err error) {
+	ctx, span := tracer.Start(ctx, "MarketplaceService.confirm "+eventName, trace.WithAttributes(
+		attribute.Int64("token_id", int64(tokenID)),
+		attribute.String("tx", txHash),
+	))
+	defer func() { tracing.End(span, err, blockchain.ErrTxPending) }()
+
+	if !isTxHash(txHash) {
+		return nil, ErrInvalidTxHash
+	}
+
+	events, err := s.listingSync.ConfirmTx(ctx, txHash)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if listing.SellerAddress != sellerAddress {
-		return fmt.Errorf("not the seller")
+	for _, event := range events {
+		if event.Name == eventName && event.TokenID == tokenID {
+			return event, nil
+		}
 	}
+	return nil, ErrTxEventMissing.WithMessage(fmt.Sprintf("transaction has no %s event for token %d", eventName, tokenID))
+}
 
-	// In production: Call smart contract's cancelListing function
-	// TODO: Implement blockchain integration
-
-	return s.listingRepo.Deactivate(tokenID)
+// latestListing returns the token's most recent listing
+func (s *MarketplaceService) latestListing(ctx context.Context, tokenID uint) (*models.MarketListing, error) {
+	listing, err := s.listingRepo.WithContext(ctx).GetLatestByTokenID(tokenID)
+	if err != nil {
+		return nil, notFound(err, ErrListingNotFound)
+	}
+	return listing, nil
 }
 
 // GetActiveListings retrieves active listings with filters
@@ -128,23 +144,22 @@ func (s *MarketplaceService) GetUserListings(userAddress string, activeOnly bool
 	return s.listingRepo.GetBySeller(userAddress, activeOnly)
 }
 
-// UpdatePrice updates the price of a listing
-func (s *MarketplaceService) UpdatePrice(tokenID uint, sellerAddress string, newPrice float64) error {
-	listing, err := s.listingRepo.GetByTokenID(tokenID)
-	if err != nil {
-		return err
+// UpdatePrice confirms a submitted Marketplace.updatePrice transaction
+func (s *MarketplaceService) UpdatePrice(ctx context.Context, tokenID uint, sellerAddress string, txHash string) (*models.MarketListing, error) {
+	if _, err := s.confirmEvent(ctx, txHash, blockchain.EventPriceUpdated, tokenID); err != nil {
+		return nil, err
 	}
 
-	if listing.SellerAddress != sellerAddress {
-		return fmt.Errorf("not the seller")
+	listing, err := s.latestListing(ctx, tokenID)
+	if err != nil {
+		return nil, err
 	}
 
-	listing.Price = newPrice
-
-	// In production: Call smart contract's updatePrice function
-	// TODO: Implement blockchain integration
+	if listing.SellerAddress != strings.ToLower(sellerAddress) {
+		return nil, ErrNotSeller
+	}
 
-	return s.listingRepo.Update(listing)
+	return listing, nil
 }
 
 // GetMarketplaceStats returns marketplace statistics
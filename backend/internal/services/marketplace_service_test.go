@@ -0,0 +1,165 @@
+package services
+
+import (
+	"brainrot-tamagotchi/internal/models"
+	"brainrot-tamagotchi/internal/repository/memstore"
+	"brainrot-tamagotchi/pkg/money"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// newMemMarketplaceService returns a marketplace service over in-memory
+// stores holding three listings: two active and one sold
+func newMemMarketplaceService(t *testing.T) *MarketplaceService {
+	t.Helper()
+	nfts := memstore.NewNFTStore()
+	listings := memstore.NewListingStore(nfts)
+
+	listedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for i, l := range []struct {
+		seller, rarity, price string
+	}{
+		{"0xa", "common", "1"},
+		{"0xa", "rare", "4"},
+		{"0xb", "rare", "2"},
+	} {
+		tokenID := uint(i + 1)
+		if err := nfts.Create(&models.NFT{TokenID: tokenID, OwnerAddress: l.seller, Rarity: l.rarity}); err != nil {
+			t.Fatal(err)
+		}
+		err := listings.Create(&models.MarketListing{
+			TokenID:       tokenID,
+			SellerAddress: l.seller,
+			Price:         money.MustParseEther(l.price),
+			IsActive:      true,
+			ListedAt:      listedAt.Add(time.Duration(i) * time.Minute),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := listings.MarkAsSold(1, "0xc"); err != nil {
+		t.Fatal(err)
+	}
+
+	return NewMarketplaceService(listings, nfts, nil, nil)
+}
+
+func listingTokenIDs(listings []models.MarketListing) []uint {
+	ids := make([]uint, len(listings))
+	for i := range listings {
+		ids[i] = listings[i].TokenID
+	}
+	return ids
+}
+
+func sameIDs(got, want []uint) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestMarketplaceRejectsInvalidTxHash(t *testing.T) {
+	s := newMemMarketplaceService(t)
+	ctx := context.Background()
+
+	confirms := map[string]func(txHash string) error{
+		"ListNFT": func(txHash string) error {
+			_, err := s.ListNFT(ctx, 2, "0xa", txHash)
+			return err
+		},
+		"BuyNFT": func(txHash string) error {
+			_, err := s.BuyNFT(ctx, 2, "0xc", txHash)
+			return err
+		},
+		"CancelListing": func(txHash string) error {
+			_, err := s.CancelListing(ctx, 2, "0xa", txHash)
+			return err
+		},
+		"UpdatePrice": func(txHash string) error {
+			_, err := s.UpdatePrice(ctx, 2, "0xa", txHash)
+			return err
+		},
+	}
+	for name, confirm := range confirms {
+		for _, txHash := range []string{"", "0x1234", "abc"} {
+			if err := confirm(txHash); !errors.Is(err, ErrInvalidTxHash) {
+				t.Errorf("%s(%q) = %v, want %v", name, txHash, err, ErrInvalidTxHash)
+			}
+		}
+	}
+}
+
+func TestGetActiveListings(t *testing.T) {
+	s := newMemMarketplaceService(t)
+
+	tests := []struct {
+		name    string
+		filters map[string]interface{}
+		want    []uint
+	}{
+		{"all", map[string]interface{}{}, []uint{3, 2}},
+		{"rarity", map[string]interface{}{"rarity": "rare"}, []uint{3, 2}},
+		{"max price", map[string]interface{}{"max_price": money.MustParseEther("3")}, []uint{3}},
+		{"sold rarity", map[string]interface{}{"rarity": "common"}, []uint{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			listings, err := s.GetActiveListings(20, 0, tt.filters)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := listingTokenIDs(listings); !sameIDs(got, tt.want) {
+				t.Errorf("got tokens %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetUserListings(t *testing.T) {
+	s := newMemMarketplaceService(t)
+
+	tests := []struct {
+		seller     string
+		activeOnly bool
+		want       []uint
+	}{
+		{"0xA", false, []uint{2, 1}},
+		{"0xa", true, []uint{2}},
+		{"0xc", false, []uint{}},
+	}
+	for _, tt := range tests {
+		listings, err := s.GetUserListings(tt.seller, tt.activeOnly)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := listingTokenIDs(listings); !sameIDs(got, tt.want) {
+			t.Errorf("GetUserListings(%s, %v) = %v, want %v", tt.seller, tt.activeOnly, got, tt.want)
+		}
+	}
+}
+
+func TestLatestListing(t *testing.T) {
+	s := newMemMarketplaceService(t)
+	ctx := context.Background()
+
+	listing, err := s.latestListing(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if listing.IsActive || listing.BuyerAddress == nil || *listing.BuyerAddress != "0xc" {
+		t.Errorf("latest listing of token 1 = %+v, want sold to 0xc", listing)
+	}
+
+	if _, err := s.latestListing(ctx, 99); !errors.Is(err, ErrListingNotFound) {
+		t.Errorf("latestListing(99) = %v, want %v", err, ErrListingNotFound)
+	}
+}
@@ -0,0 +1,458 @@
+package services
+
+import (
+	"brainrot-tamagotchi/internal/events"
+	"brainrot-tamagotchi/internal/models"
+	"brainrot-tamagotchi/internal/notify"
+	"brainrot-tamagotchi/internal/repository"
+	"brainrot-tamagotchi/pkg/apperr"
+	"brainrot-tamagotchi/pkg/money"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// hungerLowThreshold is the hunger below which owners are warned
+const hungerLowThreshold = 30
+
+// notificationBatchSize caps how many notifications one delivery pass sends
+const notificationBatchSize = 100
+
+// notificationMaxAttempts is how many delivery passes a notification gets
+// before it is marked failed
+const notificationMaxAttempts = 5
+
+// notificationSendTimeout bounds a single channel send
+const notificationSendTimeout = 15 * time.Second
+
+// saleNotifyMaxAge is how old a sale can be and still notify the seller
+const saleNotifyMaxAge = 24 * time.Hour
+
+// notificationKinds lists the kinds a wallet can mute
+var notificationKinds = map[string]bool{
+	models.NotifyHungerLow:     true,
+	models.NotifyMoodZero:      true,
+	models.NotifyPetDied:       true,
+	models.NotifyListingSold:   true,
+	models.NotifyOfferReceived: true,
+}
+
+// ErrInvalidPreferences is returned for a preference update that can't be
+// applied
+var ErrInvalidPreferences = apperr.Validation("invalid_preferences", "invalid notification preferences")
+
+// PreferenceUpdate changes a wallet's notification preferences. Nil fields
+// are left unchanged; an empty channel destination removes the channel.
+type PreferenceUpdate struct {
+	Channels   map[string]string `json:"channels"`
+	Kinds      map[string]bool   `json:"kinds"`
+	QuietStart *string           `json:"quiet_start"`
+	QuietEnd   *string           `json:"quiet_end"`
+	Timezone   *string           `json:"timezone"`
+}
+
+// NotificationService turns game events into notifications and delivers
+// them over each wallet's configured channels. Notifications are stored
+// first and sent by a worker, so triggers never wait on delivery.
+type NotificationService struct {
+	repo     repository.NotificationStore
+	channels map[string]notify.Channel
+}
+
+func NewNotificationService(
+	repo repository.NotificationStore,
+	channels map[string]notify.Channel,
+) *NotificationService {
+	return &NotificationService{
+		repo:     repo,
+		channels: channels,
+	}
+}
+
+// CheckPet applies the pet care rules to a pet's current stats. Each
+// condition is sent once per occurrence: the dedup keys change when the pet
+// is next fed, played with or restored. A nil service sends nothing.
+func (s *NotificationService) CheckPet(nft *models.NFT) {
+	if s == nil {
+		return
+	}
+
+	data := map[string]interface{}{
+		"token_id": nft.TokenID,
+		"hunger":   nft.Hunger,
+		"mood":     nft.Mood,
+		"energy":   nft.Energy,
+	}
+
+	if !nft.IsAlive() {
+		s.notify(nft.OwnerAddress, models.NotifyPetDied,
+			fmt.Sprintf("%s:%d:%d", models.NotifyPetDied, nft.TokenID, nft.LastInteract.Unix()),
+			fmt.Sprintf("Your %s has died", nft.MemeType),
+			fmt.Sprintf("Pet #%d ran out of hunger, mood or energy. Restore it to bring it back.", nft.TokenID),
+			data)
+	} else if nft.Hunger < hungerLowThreshold {
+		s.notify(nft.OwnerAddress, models.NotifyHungerLow,
+			fmt.Sprintf("%s:%d:%d", models.NotifyHungerLow, nft.TokenID, nft.LastFed.Unix()),
+			fmt.Sprintf("Your %s is starving", nft.MemeType),
+			fmt.Sprintf("Pet #%d is down to %d hunger. Feed it before it dies.", nft.TokenID, nft.Hunger),
+			data)
+	}
+
+	if nft.Mood == 0 {
+		s.notify(nft.OwnerAddress, models.NotifyMoodZero,
+			fmt.Sprintf("%s:%d:%d", models.NotifyMoodZero, nft.TokenID, nft.LastPlayed.Unix()),
+			fmt.Sprintf("Your %s is miserable", nft.MemeType),
+			fmt.Sprintf("Pet #%d has no mood left. Play with it to cheer it up.", nft.TokenID),
+			data)
+	}
+}
+
+// HandleListingSold tells the seller their pet sold. It subscribes to
+// events.ListingSold; redeliveries are absorbed by the dedup key.
+func (s *NotificationService) HandleListingSold(ctx context.Context, env events.Envelope) error {
+	var sold events.ListingSold
+	if err := env.Decode(&sold); err != nil {
+		return err
+	}
+	// Skip old sales replayed by a first marketplace sync
+	if time.Since(sold.SoldAt) > saleNotifyMaxAge {
+		return nil
+	}
+
+	return s.Notify(sold.Seller, models.NotifyListingSold,
+		fmt.Sprintf("%s:%s:%d", models.NotifyListingSold, sold.TxHash, sold.LogIndex),
+		"Your pet sold",
+		fmt.Sprintf("Pet #%d sold for %s ETH.", sold.TokenID, sold.Price.Ether()),
+		map[string]interface{}{
+			"token_id": sold.TokenID,
+			"buyer":    sold.Buyer,
+			"price":    sold.Price,
+			"tx_hash":  sold.TxHash,
+		})
+}
+
+// OfferReceived tells an owner someone made an offer on their pet. The
+// marketplace has no offers yet; this is the hook for when it does.
+func (s *NotificationService) OfferReceived(owner string, tokenID uint, offerID string, bidder string, price money.Wei) {
+	s.notify(owner, models.NotifyOfferReceived,
+		fmt.Sprintf("%s:%s", models.NotifyOfferReceived, offerID),
+		"New offer on your pet",
+		fmt.Sprintf("Pet #%d received an offer of %s ETH.", tokenID, price.Ether()),
+		map[string]interface{}{
+			"token_id": tokenID,
+			"offer_id": offerID,
+			"bidder":   bidder,
+			"price":    price,
+		})
+}
+
+// notify stores a notification for delivery. Triggers run inside jobs that
+// must keep going, so errors are only logged.
+func (s *NotificationService) notify(wallet, kind, dedupKey, title, body string, data map[string]interface{}) {
+	if err := s.Notify(wallet, kind, dedupKey, title, body, data); err != nil {
+		logger.Error("failed to queue notification", "kind", kind, "wallet", wallet, "error", err)
+	}
+}
+
+// Notify stores a notification unless one with the same dedup key exists.
+// It is scheduled after the wallet's quiet hours, and kept only for the
+// in-app list when the kind is muted or no channel is configured.
+func (s *NotificationService) Notify(wallet, kind, dedupKey, title, body string, data map[string]interface{}) error {
+	now := time.Now()
+	notification := &models.Notification{
+		WalletAddress: wallet,
+		Kind:          kind,
+		DedupKey:      dedupKey,
+		Title:         title,
+		Body:          body,
+		Data:          data,
+		Status:        models.NotificationPending,
+		DeliverAfter:  now,
+	}
+
+	pref, err := s.repo.GetPreference(wallet)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		notification.Status = models.NotificationSkipped
+	case err != nil:
+		return err
+	case len(pref.Channels) == 0 || !pref.KindEnabled(kind):
+		notification.Status = models.NotificationSkipped
+	default:
+		notification.DeliverAfter = quietHoursEnd(pref, now)
+	}
+
+	_, err = s.repo.Create(notification)
+	return err
+}
+
+// DeliverDue sends pending notifications whose time has come and returns
+// how many were fully sent. Channels that already received a notification
+// are not sent it again on retry.
+func (s *NotificationService) DeliverDue(ctx context.Context) (int, error) {
+	now := time.Now()
+	due, err := s.repo.GetDue(now, notificationBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for i := range due {
+		if err := ctx.Err(); err != nil {
+			return sent, err
+		}
+		notification := &due[i]
+
+		pref, err := s.repo.GetPreference(notification.WalletAddress)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return sent, err
+		}
+		if pref == nil || len(pref.Channels) == 0 || !pref.KindEnabled(notification.Kind) {
+			// Preferences changed since it was queued
+			notification.Status = models.NotificationSkipped
+			if err := s.repo.Update(notification); err != nil {
+				return sent, err
+			}
+			continue
+		}
+		if until := quietHoursEnd(pref, now); until.After(now) {
+			notification.DeliverAfter = until
+			if err := s.repo.Update(notification); err != nil {
+				return sent, err
+			}
+			continue
+		}
+
+		if s.deliver(ctx, notification, pref) {
+			sent++
+		}
+		if err := s.repo.Update(notification); err != nil {
+			return sent, err
+		}
+	}
+
+	if sent > 0 {
+		logger.InfoContext(ctx, "delivered notifications", "count", sent)
+	}
+	return sent, nil
+}
+
+// deliver sends a notification to every channel it hasn't reached yet and
+// updates its status. It reports whether the notification is fully sent.
+func (s *NotificationService) deliver(ctx context.Context, notification *models.Notification, pref *models.NotificationPreference) bool {
+	msg := notify.Message{
+		ID:     notification.ID,
+		Wallet: notification.WalletAddress,
+		Kind:   notification.Kind,
+		Title:  notification.Title,
+		Body:   notification.Body,
+		Data:   notification.Data,
+	}
+
+	delivered := make(map[string]bool, len(notification.Delivered))
+	for _, name := range notification.Delivered {
+		delivered[name] = true
+	}
+
+	var failures []string
+	prefChanged := false
+	for _, name := range sortedKeys(pref.Channels) {
+		channel, ok := s.channels[name]
+		if !ok || delivered[name] {
+			continue
+		}
+
+		sendCtx, cancel := context.WithTimeout(ctx, notificationSendTimeout)
+		err := channel.Send(sendCtx, pref.Channels[name], msg)
+		cancel()
+
+		switch {
+		case err == nil:
+			notification.Delivered = append(notification.Delivered, name)
+		case errors.Is(err, notify.ErrGone):
+			logger.InfoContext(ctx, "removing dead notification destination", "channel", name, "wallet", pref.WalletAddress, "error", err)
+			delete(pref.Channels, name)
+			prefChanged = true
+		default:
+			failures = append(failures, fmt.Sprintf("%s: %v", name, err))
+		}
+	}
+
+	if prefChanged {
+		if err := s.repo.SavePreference(pref); err != nil {
+			logger.ErrorContext(ctx, "failed to remove dead notification channels", "wallet", pref.WalletAddress, "error", err)
+		}
+	}
+
+	notification.Attempts++
+	if len(failures) == 0 {
+		now := time.Now()
+		notification.Status = models.NotificationSent
+		notification.SentAt = &now
+		notification.LastError = ""
+		return true
+	}
+
+	notification.LastError = strings.Join(failures, "; ")
+	if notification.Attempts >= notificationMaxAttempts {
+		notification.Status = models.NotificationFailed
+	} else {
+		// 2, 4, 8, 16 minutes
+		notification.DeliverAfter = time.Now().Add(time.Minute << notification.Attempts)
+	}
+	return false
+}
+
+// GetNotifications returns a wallet's latest notifications
+func (s *NotificationService) GetNotifications(wallet string, limit int) ([]models.Notification, error) {
+	return s.repo.GetByWallet(wallet, limit)
+}
+
+// GetPreferences returns a wallet's preferences, or the defaults (no
+// channels, every kind on, no quiet hours) if it has none
+func (s *NotificationService) GetPreferences(wallet string) (*models.NotificationPreference, error) {
+	pref, err := s.repo.GetPreference(wallet)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &models.NotificationPreference{
+			WalletAddress: strings.ToLower(wallet),
+			Channels:      map[string]string{},
+			Kinds:         map[string]bool{},
+			Timezone:      "UTC",
+		}, nil
+	}
+	return pref, err
+}
+
+// UpdatePreferences validates and applies a preference update
+func (s *NotificationService) UpdatePreferences(wallet string, update PreferenceUpdate) (*models.NotificationPreference, error) {
+	pref, err := s.GetPreferences(wallet)
+	if err != nil {
+		return nil, err
+	}
+	if pref.Channels == nil {
+		pref.Channels = map[string]string{}
+	}
+	if pref.Kinds == nil {
+		pref.Kinds = map[string]bool{}
+	}
+
+	for name, destination := range update.Channels {
+		channel, ok := s.channels[name]
+		if !ok {
+			return nil, invalidPreferences("unknown channel %q", name)
+		}
+		if destination == "" {
+			delete(pref.Channels, name)
+			continue
+		}
+		if err := channel.Validate(destination); err != nil {
+			return nil, invalidPreferences("%s: %v", name, err)
+		}
+		pref.Channels[name] = destination
+	}
+
+	for kind, enabled := range update.Kinds {
+		if !notificationKinds[kind] {
+			return nil, invalidPreferences("unknown notification kind %q", kind)
+		}
+		pref.Kinds[kind] = enabled
+	}
+
+	if update.QuietStart != nil {
+		pref.QuietStart = *update.QuietStart
+	}
+	if update.QuietEnd != nil {
+		pref.QuietEnd = *update.QuietEnd
+	}
+	if (pref.QuietStart == "") != (pref.QuietEnd == "") {
+		return nil, invalidPreferences("quiet hours need both a start and an end")
+	}
+	for _, t := range []string{pref.QuietStart, pref.QuietEnd} {
+		if _, err := parseClock(t); t != "" && err != nil {
+			return nil, invalidPreferences("quiet hours must be HH:MM")
+		}
+	}
+
+	if update.Timezone != nil {
+		if _, err := time.LoadLocation(*update.Timezone); err != nil || *update.Timezone == "" {
+			return nil, invalidPreferences("unknown timezone %q", *update.Timezone)
+		}
+		pref.Timezone = *update.Timezone
+	}
+
+	if err := s.repo.SavePreference(pref); err != nil {
+		return nil, err
+	}
+	return pref, nil
+}
+
+// invalidPreferences returns ErrInvalidPreferences saying what is wrong
+func invalidPreferences(format string, args ...any) error {
+	return ErrInvalidPreferences.WithMessage(fmt.Sprintf(format, args...))
+}
+
+// WebPushPublicKey returns the VAPID key browsers subscribe with, or "" when
+// web push is not configured
+func (s *NotificationService) WebPushPublicKey() string {
+	if push, ok := s.channels[notify.ChannelWebPush].(*notify.WebPush); ok {
+		return push.PublicKey()
+	}
+	return ""
+}
+
+// quietHoursEnd returns when the wallet's current quiet hours end, or now if
+// it is outside them
+func quietHoursEnd(pref *models.NotificationPreference, now time.Time) time.Time {
+	start, err1 := parseClock(pref.QuietStart)
+	end, err2 := parseClock(pref.QuietEnd)
+	if err1 != nil || err2 != nil || start == end {
+		return now
+	}
+
+	loc, err := time.LoadLocation(pref.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	local := now.In(loc)
+	minute := local.Hour()*60 + local.Minute()
+
+	var quiet bool
+	if start < end {
+		quiet = minute >= start && minute < end
+	} else {
+		// Window wraps past midnight
+		quiet = minute >= start || minute < end
+	}
+	if !quiet {
+		return now
+	}
+
+	until := time.Date(local.Year(), local.Month(), local.Day(), end/60, end%60, 0, 0, loc)
+	if !until.After(local) {
+		until = until.AddDate(0, 0, 1)
+	}
+	return until
+}
+
+// parseClock parses "HH:MM" into minutes after midnight
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, err
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
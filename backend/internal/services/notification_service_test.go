@@ -0,0 +1,315 @@
+package services
+
+import (
+	"brainrot-tamagotchi/internal/models"
+	"brainrot-tamagotchi/internal/notify"
+	"brainrot-tamagotchi/internal/repository/memstore"
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestQuietHoursEnd(t *testing.T) {
+	kyiv, err := time.LoadLocation("Europe/Kyiv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	utc := func(day, hour, minute int) time.Time {
+		return time.Date(2024, 5, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name                 string
+		start, end, timezone string
+		now                  time.Time
+		want                 time.Time
+	}{
+		{"no quiet hours", "", "", "UTC", utc(1, 23, 0), utc(1, 23, 0)},
+		{"empty window", "22:00", "22:00", "UTC", utc(1, 22, 30), utc(1, 22, 30)},
+		{"invalid clock", "22:00", "7am", "UTC", utc(1, 23, 0), utc(1, 23, 0)},
+		{"inside a daytime window", "09:00", "17:00", "UTC", utc(1, 10, 15), utc(1, 17, 0)},
+		{"at a window's end", "09:00", "17:00", "UTC", utc(1, 17, 0), utc(1, 17, 0)},
+		{"at a window's start", "09:00", "17:00", "UTC", utc(1, 9, 0), utc(1, 17, 0)},
+		{"before midnight in a wrapping window", "22:00", "07:00", "UTC", utc(1, 23, 30), utc(2, 7, 0)},
+		{"after midnight in a wrapping window", "22:00", "07:00", "UTC", utc(2, 3, 0), utc(2, 7, 0)},
+		{"outside a wrapping window", "22:00", "07:00", "UTC", utc(1, 12, 0), utc(1, 12, 0)},
+		// 20:30 UTC is 23:30 in Kyiv (UTC+3 in May)
+		{"wallet timezone", "22:00", "07:00", "Europe/Kyiv", utc(1, 20, 30), time.Date(2024, 5, 2, 7, 0, 0, 0, kyiv)},
+		// 23:30 UTC is already 02:30 the next day in Kyiv
+		{"wallet timezone past its midnight", "22:00", "07:00", "Europe/Kyiv", utc(1, 23, 30), time.Date(2024, 5, 2, 7, 0, 0, 0, kyiv)},
+		{"quiet in UTC but not locally", "22:00", "07:00", "Europe/Kyiv", utc(1, 4, 30), utc(1, 4, 30)},
+		{"unknown timezone falls back to UTC", "22:00", "07:00", "Mars/Olympus", utc(1, 23, 30), utc(2, 7, 0)},
+	}
+	for _, tt := range tests {
+		pref := &models.NotificationPreference{QuietStart: tt.start, QuietEnd: tt.end, Timezone: tt.timezone}
+		if got := quietHoursEnd(pref, tt.now); !got.Equal(tt.want) {
+			t.Errorf("%s: quietHoursEnd = %s, want %s", tt.name, got.UTC(), tt.want.UTC())
+		}
+	}
+}
+
+// failingChannel is a notify.Channel whose sends fail with err
+type failingChannel struct {
+	err   error
+	sends int
+}
+
+func (c *failingChannel) Send(ctx context.Context, destination string, msg notify.Message) error {
+	c.sends++
+	return c.err
+}
+
+func (c *failingChannel) Validate(destination string) error {
+	return nil
+}
+
+// newMemNotificationService returns a service over an in-memory store with
+// the given channels
+func newMemNotificationService(channels map[string]notify.Channel) (*NotificationService, *memstore.NotificationStore) {
+	store := memstore.NewNotificationStore()
+	return NewNotificationService(store, channels), store
+}
+
+func savePreference(t *testing.T, store *memstore.NotificationStore, wallet string, channels map[string]string) {
+	t.Helper()
+	err := store.SavePreference(&models.NotificationPreference{
+		WalletAddress: wallet,
+		Channels:      channels,
+		Kinds:         map[string]bool{models.NotifyMoodZero: false},
+		Timezone:      "UTC",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// stored returns a wallet's notifications in the order they were queued
+func stored(t *testing.T, store *memstore.NotificationStore, wallet string) []models.Notification {
+	t.Helper()
+	notifications, err := store.GetByWallet(wallet, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Slice(notifications, func(i, j int) bool { return notifications[i].ID < notifications[j].ID })
+	return notifications
+}
+
+func TestNotify(t *testing.T) {
+	s, store := newMemNotificationService(map[string]notify.Channel{notify.ChannelEmail: notify.NewLocal(notify.ChannelEmail)})
+	savePreference(t, store, "0xaaa", map[string]string{notify.ChannelEmail: "a@example.com"})
+
+	tests := []struct {
+		wallet, kind, dedupKey string
+		wantStatus             string
+	}{
+		{"0xAAA", models.NotifyHungerLow, "hunger_low:1:100", models.NotificationPending},
+		{"0xaaa", models.NotifyMoodZero, "mood_zero:1:100", models.NotificationSkipped},   // Muted
+		{"0xbbb", models.NotifyHungerLow, "hunger_low:2:100", models.NotificationSkipped}, // No preferences
+	}
+	for _, tt := range tests {
+		if err := s.Notify(tt.wallet, tt.kind, tt.dedupKey, "title", "body", nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The same condition again is absorbed by its dedup key
+	if err := s.Notify("0xaaa", models.NotifyHungerLow, "hunger_low:1:100", "again", "body", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	got := append(stored(t, store, "0xaaa"), stored(t, store, "0xbbb")...)
+	if len(got) != len(tests) {
+		t.Fatalf("stored %d notifications, want %d", len(got), len(tests))
+	}
+	for i, tt := range tests {
+		if got[i].DedupKey != tt.dedupKey || got[i].Status != tt.wantStatus || got[i].Title != "title" {
+			t.Errorf("notification %d = %s %s %q, want %s %s", i, got[i].DedupKey, got[i].Status, got[i].Title, tt.dedupKey, tt.wantStatus)
+		}
+	}
+}
+
+func TestCheckPetDedups(t *testing.T) {
+	s, store := newMemNotificationService(nil)
+	fed := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	pet := &models.NFT{TokenID: 7, OwnerAddress: "0xaaa", MemeType: "doge", Hunger: 10, Mood: 50, Energy: 50, LastFed: fed}
+
+	s.CheckPet(pet)
+	s.CheckPet(pet)
+	if got := stored(t, store, "0xaaa"); len(got) != 1 || got[0].Kind != models.NotifyHungerLow {
+		t.Fatalf("after two checks: %+v, want one hunger_low", got)
+	}
+
+	// Feeding changes the key, so the next time it starves is a new warning
+	pet.LastFed = fed.Add(time.Hour)
+	s.CheckPet(pet)
+	if got := stored(t, store, "0xaaa"); len(got) != 2 {
+		t.Fatalf("after feeding: %d notifications, want 2", len(got))
+	}
+
+	// A dead pet gets pet_died instead of hunger_low
+	pet.Hunger = 0
+	s.CheckPet(pet)
+	got := stored(t, store, "0xaaa")
+	if last := got[len(got)-1]; len(got) != 3 || last.Kind != models.NotifyPetDied {
+		t.Fatalf("after dying: %+v", got)
+	}
+}
+
+func TestDeliverDue(t *testing.T) {
+	email := notify.NewLocal(notify.ChannelEmail)
+	webhook := notify.NewLocal(notify.ChannelWebhook)
+	s, store := newMemNotificationService(map[string]notify.Channel{
+		notify.ChannelEmail:   email,
+		notify.ChannelWebhook: webhook,
+	})
+	savePreference(t, store, "0xaaa", map[string]string{
+		notify.ChannelEmail:   "a@example.com",
+		notify.ChannelWebhook: "https://example.com/hook",
+	})
+	if err := s.Notify("0xaaa", models.NotifyListingSold, "sold:1", "Your pet sold", "body", map[string]interface{}{"token_id": 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	sent, err := s.DeliverDue(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sent != 1 {
+		t.Fatalf("sent %d, want 1", sent)
+	}
+	for _, channel := range []*notify.Local{email, webhook} {
+		deliveries := channel.Sent()
+		if len(deliveries) != 1 || deliveries[0].Message.Title != "Your pet sold" || deliveries[0].Message.Wallet != "0xaaa" {
+			t.Errorf("deliveries = %+v", deliveries)
+		}
+	}
+	if got := email.Sent()[0].Destination; got != "a@example.com" {
+		t.Errorf("email sent to %q", got)
+	}
+	if got := stored(t, store, "0xaaa")[0]; got.Status != models.NotificationSent || got.SentAt == nil || got.Attempts != 1 {
+		t.Errorf("delivered notification = %+v", got)
+	}
+
+	// Nothing is sent twice
+	if sent, err := s.DeliverDue(context.Background()); err != nil || sent != 0 {
+		t.Errorf("second pass sent %d, %v", sent, err)
+	}
+	if len(email.Sent()) != 1 {
+		t.Errorf("email sent %d times", len(email.Sent()))
+	}
+}
+
+func TestDeliverDueRetriesFailedChannels(t *testing.T) {
+	email := notify.NewLocal(notify.ChannelEmail)
+	webhook := &failingChannel{err: errors.New("503")}
+	s, store := newMemNotificationService(map[string]notify.Channel{
+		notify.ChannelEmail:   email,
+		notify.ChannelWebhook: webhook,
+	})
+	savePreference(t, store, "0xaaa", map[string]string{
+		notify.ChannelEmail:   "a@example.com",
+		notify.ChannelWebhook: "https://example.com/hook",
+	})
+	if err := s.Notify("0xaaa", models.NotifyHungerLow, "hunger_low:1:1", "t", "b", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	before := time.Now()
+	if sent, err := s.DeliverDue(context.Background()); err != nil || sent != 0 {
+		t.Fatalf("sent %d, %v; want 0 with a failing channel", sent, err)
+	}
+	got := stored(t, store, "0xaaa")[0]
+	if got.Status != models.NotificationPending || got.Attempts != 1 || got.LastError == "" {
+		t.Fatalf("after a failure: %+v", got)
+	}
+	if len(got.Delivered) != 1 || got.Delivered[0] != notify.ChannelEmail {
+		t.Errorf("delivered = %v, want only email", got.Delivered)
+	}
+	if wait := got.DeliverAfter.Sub(before); wait < 2*time.Minute || wait > 3*time.Minute {
+		t.Errorf("retry in %s, want 2 minutes", wait)
+	}
+
+	// The retry skips the channel that already has it
+	got.DeliverAfter = time.Now()
+	webhook.err = nil
+	if err := store.Update(&got); err != nil {
+		t.Fatal(err)
+	}
+	if sent, err := s.DeliverDue(context.Background()); err != nil || sent != 1 {
+		t.Fatalf("retry sent %d, %v", sent, err)
+	}
+	if len(email.Sent()) != 1 || webhook.sends != 2 {
+		t.Errorf("email sent %d times, webhook tried %d times; want 1 and 2", len(email.Sent()), webhook.sends)
+	}
+}
+
+func TestDeliverDueRemovesGoneDestinations(t *testing.T) {
+	push := &failingChannel{err: notify.ErrGone}
+	s, store := newMemNotificationService(map[string]notify.Channel{
+		notify.ChannelEmail:   notify.NewLocal(notify.ChannelEmail),
+		notify.ChannelWebPush: push,
+	})
+	savePreference(t, store, "0xaaa", map[string]string{
+		notify.ChannelEmail:   "a@example.com",
+		notify.ChannelWebPush: `{"endpoint":"https://push.example.com/1"}`,
+	})
+	if err := s.Notify("0xaaa", models.NotifyPetDied, "pet_died:1:1", "t", "b", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if sent, err := s.DeliverDue(context.Background()); err != nil || sent != 1 {
+		t.Fatalf("sent %d, %v; a gone destination is not a failure", sent, err)
+	}
+	pref, err := store.GetPreference("0xaaa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := pref.Channels[notify.ChannelWebPush]; ok || len(pref.Channels) != 1 {
+		t.Errorf("channels after ErrGone = %v, want only email", pref.Channels)
+	}
+}
+
+func TestUpdatePreferences(t *testing.T) {
+	s, _ := newMemNotificationService(map[string]notify.Channel{notify.ChannelEmail: notify.NewLocal(notify.ChannelEmail)})
+	str := func(s string) *string { return &s }
+
+	pref, err := s.UpdatePreferences("0xAAA", PreferenceUpdate{
+		Channels:   map[string]string{notify.ChannelEmail: "a@example.com"},
+		Kinds:      map[string]bool{models.NotifyMoodZero: false},
+		QuietStart: str("22:00"),
+		QuietEnd:   str("07:00"),
+		Timezone:   str("Europe/Kyiv"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pref.WalletAddress != "0xaaa" || pref.Channels[notify.ChannelEmail] != "a@example.com" || pref.KindEnabled(models.NotifyMoodZero) {
+		t.Errorf("saved preferences = %+v", pref)
+	}
+
+	// An empty destination removes the channel; other fields are kept
+	pref, err = s.UpdatePreferences("0xaaa", PreferenceUpdate{Channels: map[string]string{notify.ChannelEmail: ""}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pref.Channels) != 0 || pref.QuietStart != "22:00" || pref.Timezone != "Europe/Kyiv" {
+		t.Errorf("after removing email: %+v", pref)
+	}
+
+	invalid := map[string]PreferenceUpdate{
+		"unknown channel":  {Channels: map[string]string{"pager": "123"}},
+		"bad destination":  {Channels: map[string]string{notify.ChannelEmail: "not an address"}},
+		"unknown kind":     {Kinds: map[string]bool{"weather": true}},
+		"half quiet hours": {QuietStart: str(""), QuietEnd: str("07:00")},
+		"bad clock":        {QuietStart: str("10pm")},
+		"unknown timezone": {Timezone: str("Mars/Olympus")},
+		"empty timezone":   {Timezone: str("")},
+	}
+	for name, update := range invalid {
+		if _, err := s.UpdatePreferences("0xaaa", update); !errors.Is(err, ErrInvalidPreferences) {
+			t.Errorf("%s: err = %v, want ErrInvalidPreferences", name, err)
+		}
+	}
+}
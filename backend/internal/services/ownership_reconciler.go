@@ -0,0 +1,260 @@
+package services
+
+import (
+	"brainrot-tamagotchi/internal/blockchain"
+	"brainrot-tamagotchi/internal/models"
+	"brainrot-tamagotchi/internal/repository"
+	"context"
+	"strconv"
+	"time"
+)
+
+// ownershipReportJob names the job_reports row of the last reconciliation
+const ownershipReportJob = "ownership_reconcile"
+
+// Drift kinds reported by the ownership reconciler
+const (
+	DriftOwner    = "owner"
+	DriftMetadata = "metadata"
+	DriftBurned   = "burned"
+)
+
+// TokenDrift is a single disagreement between the DB and BrainrotNFT
+type TokenDrift struct {
+	TokenID    uint   `json:"token_id"`
+	Kind       string `json:"kind"`
+	Field      string `json:"field"`
+	DBValue    string `json:"db_value"`
+	ChainValue string `json:"chain_value"`
+	Corrected  bool   `json:"corrected"`
+}
+
+// DriftReport summarizes one reconciliation pass
+type DriftReport struct {
+	StartedAt           time.Time    `json:"started_at"`
+	FinishedAt          time.Time    `json:"finished_at"`
+	AutoCorrect         bool         `json:"auto_correct"`
+	TokensChecked       int          `json:"tokens_checked"`
+	Drifts              []TokenDrift `json:"drifts"`
+	ListingsDeactivated []uint       `json:"listings_deactivated"`
+	Errors              int          `json:"errors"`
+}
+
+// OwnershipReconciler compares nfts rows with BrainrotNFT state
+type OwnershipReconciler struct {
+	nftRepo     *repository.NFTRepository
+	listingRepo *repository.MarketListingRepository
+	blockchain  *blockchain.Client
+	reports     ReportStore
+	autoCorrect bool
+	chunkSize   int
+}
+
+func NewOwnershipReconciler(
+	nftRepo *repository.NFTRepository,
+	listingRepo *repository.MarketListingRepository,
+	blockchain *blockchain.Client,
+	reports ReportStore,
+	autoCorrect bool,
+	chunkSize int,
+) *OwnershipReconciler {
+	if chunkSize <= 0 {
+		chunkSize = 200
+	}
+	return &OwnershipReconciler{
+		nftRepo:     nftRepo,
+		listingRepo: listingRepo,
+		blockchain:  blockchain,
+		reports:     reports,
+		autoCorrect: autoCorrect,
+		chunkSize:   chunkSize,
+	}
+}
+
+// LastReport returns the report of the most recent completed pass on any
+// instance, or nil if there was none
+func (r *OwnershipReconciler) LastReport() (*DriftReport, error) {
+	var report DriftReport
+	found, err := loadReport(r.reports, ownershipReportJob, &report)
+	if err != nil || !found {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// Reconcile walks every token in chunks, diffs owner and metadata against the
+// chain, corrects the DB when auto-correct is on, and always deactivates
+// listings whose seller no longer owns the token
+func (r *OwnershipReconciler) Reconcile(ctx context.Context) (*DriftReport, error) {
+	if r.blockchain == nil {
+		return nil, ErrChainDisabled
+	}
+
+	report := &DriftReport{
+		StartedAt:           time.Now(),
+		AutoCorrect:         r.autoCorrect,
+		Drifts:              []TokenDrift{},
+		ListingsDeactivated: []uint{},
+	}
+
+	var afterTokenID uint
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		nfts, err := r.nftRepo.WithContext(ctx).GetAfterTokenID(afterTokenID, r.chunkSize)
+		if err != nil {
+			return nil, err
+		}
+		if len(nfts) == 0 {
+			break
+		}
+
+		if err := r.reconcileChunk(ctx, nfts, report); err != nil {
+			return nil, err
+		}
+
+		afterTokenID = nfts[len(nfts)-1].TokenID
+		report.TokensChecked += len(nfts)
+	}
+
+	report.FinishedAt = time.Now()
+	r.emitReport(ctx, report)
+
+	if err := saveReport(r.reports, ownershipReportJob, report.FinishedAt, report); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func (r *OwnershipReconciler) reconcileChunk(ctx context.Context, nfts []models.NFT, report *DriftReport) error {
+	tokenIDs := make([]uint, len(nfts))
+	for i := range nfts {
+		tokenIDs[i] = nfts[i].TokenID
+	}
+	listings, err := r.listingRepo.WithContext(ctx).GetActiveByTokenIDs(tokenIDs)
+	if err != nil {
+		return err
+	}
+	sellerByToken := make(map[uint]string, len(listings))
+	for _, listing := range listings {
+		sellerByToken[listing.TokenID] = listing.SellerAddress
+	}
+
+	for i := range nfts {
+		nft := &nfts[i]
+
+		owner, err := r.blockchain.OwnerOf(ctx, nft.TokenID)
+		if blockchain.IsRevert(err) {
+			r.handleBurned(ctx, nft, sellerByToken, report)
+			continue
+		}
+		if err != nil {
+			logger.ErrorContext(ctx, "reading token owner failed", "token_id", nft.TokenID, "error", err)
+			report.Errors++
+			continue
+		}
+
+		metadata, err := r.blockchain.GetTokenMetadata(ctx, nft.TokenID)
+		if err != nil {
+			logger.ErrorContext(ctx, "reading token metadata failed", "token_id", nft.TokenID, "error", err)
+			report.Errors++
+			continue
+		}
+
+		drifts := diffToken(nft, owner, metadata)
+		if len(drifts) > 0 && r.autoCorrect {
+			nft.OwnerAddress = owner
+			nft.MemeType = metadata.MemeType
+			nft.Rarity = metadata.Rarity
+			nft.Level = metadata.Level
+			nft.ColorVariant = metadata.ColorVariant
+			if err := r.nftRepo.WithContext(ctx).UpdateChainFields(nft); err != nil {
+				logger.ErrorContext(ctx, "correcting token failed", "token_id", nft.TokenID, "error", err)
+				report.Errors++
+			} else {
+				for j := range drifts {
+					drifts[j].Corrected = true
+				}
+			}
+		}
+		report.Drifts = append(report.Drifts, drifts...)
+
+		if seller, listed := sellerByToken[nft.TokenID]; listed && seller != owner {
+			r.deactivateListing(ctx, nft.TokenID, report)
+		}
+	}
+
+	return nil
+}
+
+// handleBurned records a token whose ownerOf reverts, i.e. it no longer exists
+func (r *OwnershipReconciler) handleBurned(ctx context.Context, nft *models.NFT, sellerByToken map[uint]string, report *DriftReport) {
+	drift := TokenDrift{
+		TokenID:    nft.TokenID,
+		Kind:       DriftBurned,
+		Field:      "owner_address",
+		DBValue:    nft.OwnerAddress,
+		ChainValue: "",
+	}
+	if r.autoCorrect {
+		if err := r.nftRepo.WithContext(ctx).Delete(nft.TokenID); err != nil {
+			logger.ErrorContext(ctx, "removing burned token failed", "token_id", nft.TokenID, "error", err)
+			report.Errors++
+		} else {
+			drift.Corrected = true
+		}
+	}
+	report.Drifts = append(report.Drifts, drift)
+
+	if _, listed := sellerByToken[nft.TokenID]; listed {
+		r.deactivateListing(ctx, nft.TokenID, report)
+	}
+}
+
+func (r *OwnershipReconciler) deactivateListing(ctx context.Context, tokenID uint, report *DriftReport) {
+	if err := r.listingRepo.WithContext(ctx).Deactivate(tokenID); err != nil {
+		logger.ErrorContext(ctx, "deactivating listing failed", "token_id", tokenID, "error", err)
+		report.Errors++
+		return
+	}
+	report.ListingsDeactivated = append(report.ListingsDeactivated, tokenID)
+}
+
+func (r *OwnershipReconciler) emitReport(ctx context.Context, report *DriftReport) {
+	logger.InfoContext(ctx, "ownership reconciliation finished",
+		"tokens_checked", report.TokensChecked,
+		"drifts", len(report.Drifts),
+		"listings_deactivated", len(report.ListingsDeactivated),
+		"errors", report.Errors,
+	)
+
+	if len(report.Drifts) > 0 {
+		logger.WarnContext(ctx, "ownership drift", "drifts", report.Drifts)
+	}
+}
+
+// diffToken lists every field where the DB row disagrees with the chain
+func diffToken(nft *models.NFT, owner string, metadata *blockchain.TokenMetadata) []TokenDrift {
+	var drifts []TokenDrift
+	add := func(kind, field, dbValue, chainValue string) {
+		if dbValue != chainValue {
+			drifts = append(drifts, TokenDrift{
+				TokenID:    nft.TokenID,
+				Kind:       kind,
+				Field:      field,
+				DBValue:    dbValue,
+				ChainValue: chainValue,
+			})
+		}
+	}
+
+	add(DriftOwner, "owner_address", nft.OwnerAddress, owner)
+	add(DriftMetadata, "meme_type", nft.MemeType, metadata.MemeType)
+	add(DriftMetadata, "rarity", nft.Rarity, metadata.Rarity)
+	add(DriftMetadata, "level", strconv.Itoa(nft.Level), strconv.Itoa(metadata.Level))
+	add(DriftMetadata, "color_variant", strconv.Itoa(nft.ColorVariant), strconv.Itoa(metadata.ColorVariant))
+
+	return drifts
+}
@@ -0,0 +1,299 @@
+package services
+
+import (
+	"brainrot-tamagotchi/internal/models"
+	"brainrot-tamagotchi/internal/repository"
+	"brainrot-tamagotchi/pkg/apperr"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// casePitySyncCursor names the sync_cursors row holding the last case
+// opening ID checked for pity
+const casePitySyncCursor = "case_pity"
+
+// pityBatchSize caps how many openings one pass loads at a time
+const pityBatchSize = 500
+
+var (
+	ErrVoucherNotFound = apperr.NotFound("voucher_not_found", "voucher not found")
+	// ErrVoucherNotClaimable is returned when a voucher is not the caller's or
+	// was already claimed
+	ErrVoucherNotClaimable = apperr.Conflict("voucher_not_claimable", "voucher cannot be claimed")
+)
+
+// PityProgress is a wallet's pity counter for one case type
+type PityProgress struct {
+	CaseType   string `json:"case_type"`
+	PityRarity string `json:"pity_rarity"`
+	// Misses counts opens since the last pull of PityRarity or better, or the
+	// last pity voucher
+	Misses               int     `json:"misses"`
+	HardLimit            int     `json:"hard_limit"`
+	SoftStart            int     `json:"soft_start"`
+	OpensUntilGuaranteed int     `json:"opens_until_guaranteed"`
+	NextVoucherChance    float64 `json:"next_voucher_chance"` // If the next open misses
+}
+
+// PityService tracks per-wallet pity counters from case openings. Rarity is
+// rolled on-chain, so hitting pity issues a compensating reward voucher
+// instead of changing the roll.
+type PityService struct {
+	caseRepo    *repository.CaseOpeningRepository
+	catalogRepo *repository.CaseDefinitionRepository
+	voucherRepo *repository.VoucherRepository
+	cursorRepo  *repository.ChainEventRepository
+}
+
+func NewPityService(
+	caseRepo *repository.CaseOpeningRepository,
+	catalogRepo *repository.CaseDefinitionRepository,
+	voucherRepo *repository.VoucherRepository,
+	cursorRepo *repository.ChainEventRepository,
+) *PityService {
+	return &PityService{
+		caseRepo:    caseRepo,
+		catalogRepo: catalogRepo,
+		voucherRepo: voucherRepo,
+		cursorRepo:  cursorRepo,
+	}
+}
+
+// ProcessNewOpenings re-evaluates pity for every wallet and case type with
+// openings recorded since the last pass, and returns how many vouchers were
+// issued
+func (s *PityService) ProcessNewOpenings(ctx context.Context) (int, error) {
+	lastID, err := s.cursorRepo.GetCursor(casePitySyncCursor)
+	if err != nil {
+		return 0, err
+	}
+
+	issued := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return issued, err
+		}
+
+		openings, err := s.caseRepo.GetAfterID(uint(lastID), pityBatchSize)
+		if err != nil {
+			return issued, err
+		}
+		if len(openings) == 0 {
+			break
+		}
+
+		type walletCase struct{ wallet, caseType string }
+		seen := make(map[walletCase]bool)
+		for _, o := range openings {
+			key := walletCase{o.UserAddress, o.CaseType}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			_, vouchers, err := s.evaluate(o.UserAddress, o.CaseType, true)
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				// Case no longer in the catalog
+				continue
+			}
+			if err != nil {
+				return issued, err
+			}
+			issued += len(vouchers)
+		}
+
+		lastID = uint64(openings[len(openings)-1].ID)
+		if err := s.cursorRepo.SetCursor(casePitySyncCursor, lastID); err != nil {
+			return issued, err
+		}
+		if len(openings) < pityBatchSize {
+			break
+		}
+	}
+
+	if issued > 0 {
+		logger.InfoContext(ctx, "issued pity vouchers", "count", issued)
+	}
+	return issued, nil
+}
+
+// GetProgress returns a wallet's pity counters for every case with pity.
+// Openings the tracker has not processed yet are already counted.
+func (s *PityService) GetProgress(walletAddress string) ([]PityProgress, error) {
+	defs, err := s.catalogRepo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	progress := make([]PityProgress, 0, len(defs))
+	for _, def := range defs {
+		if def.PityHardLimit == 0 {
+			continue
+		}
+		p, _, err := s.evaluate(walletAddress, def.Slug, false)
+		if err != nil {
+			return nil, err
+		}
+		progress = append(progress, *p)
+	}
+	return progress, nil
+}
+
+// GetVouchers returns a wallet's vouchers, optionally filtered by status
+func (s *PityService) GetVouchers(walletAddress, status string) ([]models.RewardVoucher, error) {
+	return s.voucherRepo.GetByWallet(walletAddress, status)
+}
+
+// ClaimVoucher marks an issued voucher as claimed by its owner
+func (s *PityService) ClaimVoucher(code, walletAddress string) (*models.RewardVoucher, error) {
+	voucher, err := s.voucherRepo.GetByCode(code)
+	if err != nil {
+		return nil, notFound(err, ErrVoucherNotFound)
+	}
+	if voucher.WalletAddress != strings.ToLower(walletAddress) || voucher.Status != models.VoucherIssued {
+		return nil, ErrVoucherNotClaimable
+	}
+
+	now := time.Now()
+	voucher.Status = models.VoucherClaimed
+	voucher.ClaimedAt = &now
+	if err := s.voucherRepo.Update(voucher); err != nil {
+		return nil, err
+	}
+	return voucher, nil
+}
+
+// evaluate replays a wallet's openings of one case type with replayPity.
+// With issue set, vouchers that are due get stored.
+func (s *PityService) evaluate(walletAddress, caseType string, issue bool) (*PityProgress, []models.RewardVoucher, error) {
+	def, err := s.catalogRepo.GetBySlug(caseType)
+	if err != nil {
+		return nil, nil, err
+	}
+	if def.PityHardLimit == 0 {
+		progress, _ := replayPity(def, nil, nil)
+		return progress, nil, nil
+	}
+
+	openings, err := s.caseRepo.GetByUserAndCaseType(walletAddress, caseType)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	vouchered, err := s.voucherRepo.GetSourceOpeningIDs(walletAddress, caseType)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	progress, due := replayPity(def, openings, vouchered)
+	if !issue {
+		return progress, nil, nil
+	}
+
+	var issued []models.RewardVoucher
+	for i := range due {
+		voucher, isNew, err := s.issueVoucher(def, &due[i])
+		if err != nil {
+			return nil, nil, err
+		}
+		if isNew {
+			issued = append(issued, *voucher)
+		}
+	}
+	return progress, issued, nil
+}
+
+// replayPity walks a wallet's openings of one case type in order. Pulling
+// the pity rarity or better, or an opening that already has a voucher,
+// resets the misses. Each miss past the soft pity start rolls for a voucher
+// with a chance ramping linearly to 1 at the hard limit; the roll is derived
+// from the opening itself, so replays always agree. It returns the progress
+// after the last opening and the openings that earned a voucher.
+func replayPity(def *models.CaseDefinition, openings []models.CaseOpening, vouchered map[uint]bool) (*PityProgress, []models.CaseOpening) {
+	progress := &PityProgress{
+		CaseType:   def.Slug,
+		PityRarity: def.PityRarity,
+		HardLimit:  def.PityHardLimit,
+		SoftStart:  def.PitySoftStart,
+	}
+	if def.PityHardLimit == 0 {
+		return progress, nil
+	}
+
+	var due []models.CaseOpening
+	misses := 0
+	for _, o := range openings {
+		if vouchered[o.ID] || RarityRank[o.Rarity] >= RarityRank[def.PityRarity] {
+			misses = 0
+			continue
+		}
+
+		misses++
+		if pityRoll(&o) >= pityChance(def, misses) {
+			continue
+		}
+		misses = 0
+		due = append(due, o)
+	}
+
+	progress.Misses = misses
+	progress.OpensUntilGuaranteed = def.PityHardLimit - misses
+	progress.NextVoucherChance = math.Round(pityChance(def, misses+1)*10000) / 10000
+	return progress, due
+}
+
+func (s *PityService) issueVoucher(def *models.CaseDefinition, opening *models.CaseOpening) (*models.RewardVoucher, bool, error) {
+	code, err := newVoucherCode()
+	if err != nil {
+		return nil, false, err
+	}
+
+	openingID := opening.ID
+	voucher := &models.RewardVoucher{
+		Code:            code,
+		WalletAddress:   opening.UserAddress,
+		Kind:            "pity",
+		CaseType:        def.Slug,
+		Rarity:          def.PityRarity,
+		SourceOpeningID: &openingID,
+		Status:          models.VoucherIssued,
+		IssuedAt:        time.Now(),
+	}
+	isNew, err := s.voucherRepo.Create(voucher)
+	return voucher, isNew, err
+}
+
+// pityChance is the chance that the misses-th miss in a row earns a voucher
+func pityChance(def *models.CaseDefinition, misses int) float64 {
+	if misses >= def.PityHardLimit {
+		return 1
+	}
+	if misses <= def.PitySoftStart {
+		return 0
+	}
+	return float64(misses-def.PitySoftStart) / float64(def.PityHardLimit-def.PitySoftStart)
+}
+
+// pityRoll maps an opening to a fixed number in [0, 1)
+func pityRoll(opening *models.CaseOpening) float64 {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s:%d", opening.TxHash, opening.ID)))
+	return float64(binary.BigEndian.Uint64(sum[:8])>>11) / (1 << 53)
+}
+
+func newVoucherCode() (string, error) {
+	b := make([]byte, 10)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "PITY-" + base32.StdEncoding.EncodeToString(b), nil
+}
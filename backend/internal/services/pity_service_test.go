@@ -0,0 +1,179 @@
+package services
+
+import (
+	"brainrot-tamagotchi/internal/models"
+	"fmt"
+	"testing"
+)
+
+func pityCase(softStart, hardLimit int) *models.CaseDefinition {
+	return &models.CaseDefinition{
+		Slug:          "gold",
+		PityRarity:    "epic",
+		PitySoftStart: softStart,
+		PityHardLimit: hardLimit,
+	}
+}
+
+// pityOpenings builds consecutive gold openings with the given rarities
+func pityOpenings(firstID uint, rarities ...string) []models.CaseOpening {
+	openings := make([]models.CaseOpening, len(rarities))
+	for i, rarity := range rarities {
+		id := firstID + uint(i)
+		openings[i] = models.CaseOpening{
+			ID:          id,
+			UserAddress: "0xa",
+			CaseType:    "gold",
+			Rarity:      rarity,
+			TxHash:      fmt.Sprintf("0x%064x", id),
+		}
+	}
+	return openings
+}
+
+func repeat(rarity string, n int) []string {
+	rarities := make([]string, n)
+	for i := range rarities {
+		rarities[i] = rarity
+	}
+	return rarities
+}
+
+func TestPityChance(t *testing.T) {
+	def := pityCase(5, 10)
+	tests := []struct {
+		misses int
+		want   float64
+	}{
+		{0, 0},
+		{5, 0},
+		{6, 0.2},
+		{8, 0.6},
+		{9, 0.8},
+		{10, 1},
+		{15, 1},
+	}
+	for _, tt := range tests {
+		if got := pityChance(def, tt.misses); got != tt.want {
+			t.Errorf("pityChance(%d misses) = %v, want %v", tt.misses, got, tt.want)
+		}
+	}
+
+	// With the soft start at the hard limit there is no ramp
+	def = pityCase(10, 10)
+	if got := pityChance(def, 9); got != 0 {
+		t.Errorf("pityChance(9) with no ramp = %v, want 0", got)
+	}
+	if got := pityChance(def, 10); got != 1 {
+		t.Errorf("pityChance(10) with no ramp = %v, want 1", got)
+	}
+}
+
+func TestPityRoll(t *testing.T) {
+	openings := pityOpenings(1, repeat("common", 2000)...)
+
+	var sum float64
+	for i := range openings {
+		roll := pityRoll(&openings[i])
+		if roll < 0 || roll >= 1 {
+			t.Fatalf("roll of opening %d = %v, outside [0, 1)", openings[i].ID, roll)
+		}
+		sum += roll
+	}
+	if mean := sum / float64(len(openings)); mean < 0.45 || mean > 0.55 {
+		t.Errorf("mean roll = %v, want about 0.5", mean)
+	}
+
+	// The roll depends only on the opening, so replays agree
+	again := openings[7]
+	if pityRoll(&again) != pityRoll(&openings[7]) {
+		t.Error("the same opening rolled twice gave different results")
+	}
+	again.TxHash = openings[8].TxHash
+	if pityRoll(&again) == pityRoll(&openings[7]) {
+		t.Error("a different transaction gave the same roll")
+	}
+}
+
+func TestReplayPity(t *testing.T) {
+	tests := []struct {
+		name       string
+		rarities   []string
+		vouchered  []uint
+		wantMisses int
+		wantDue    []uint
+	}{
+		{"no openings", nil, nil, 0, nil},
+		{"misses below the limit", repeat("common", 4), nil, 4, nil},
+		{"hard limit issues a voucher", repeat("common", 6), nil, 0, []uint{6}},
+		{"counting restarts after a voucher", repeat("common", 8), nil, 2, []uint{6}},
+		{"pity rarity resets", append(repeat("rare", 5), "epic", "common"), nil, 1, nil},
+		{"better than pity rarity resets", append(repeat("common", 5), "legendary"), nil, 0, nil},
+		{"existing voucher resets", repeat("common", 8), []uint{3}, 5, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vouchered := make(map[uint]bool)
+			for _, id := range tt.vouchered {
+				vouchered[id] = true
+			}
+
+			// No soft pity, so only the hard limit issues vouchers
+			progress, due := replayPity(pityCase(6, 6), pityOpenings(1, tt.rarities...), vouchered)
+			if progress.Misses != tt.wantMisses {
+				t.Errorf("misses = %d, want %d", progress.Misses, tt.wantMisses)
+			}
+			if progress.OpensUntilGuaranteed != 6-tt.wantMisses {
+				t.Errorf("opens until guaranteed = %d, want %d", progress.OpensUntilGuaranteed, 6-tt.wantMisses)
+			}
+			if got := openingIDs(due); !sameIDs(got, tt.wantDue) {
+				t.Errorf("vouchers for openings %v, want %v", got, tt.wantDue)
+			}
+		})
+	}
+}
+
+func TestReplayPitySoftRamp(t *testing.T) {
+	def := pityCase(5, 10)
+
+	// Many wallets' worth of misses in a row: every voucher lands past the
+	// soft start and by the hard limit, and the ramp issues some early
+	openings := pityOpenings(1, repeat("common", 5000)...)
+	_, due := replayPity(def, openings, nil)
+	if len(due) == 0 {
+		t.Fatal("no vouchers issued")
+	}
+
+	early := 0
+	last := uint(0)
+	for _, o := range due {
+		misses := int(o.ID - last)
+		last = o.ID
+		if misses <= def.PitySoftStart || misses > def.PityHardLimit {
+			t.Fatalf("voucher for opening %d after %d misses, want 6 to 10", o.ID, misses)
+		}
+		if misses < def.PityHardLimit {
+			early++
+		}
+	}
+	if early == 0 {
+		t.Error("soft pity never issued a voucher before the hard limit")
+	}
+
+	progress, _ := replayPity(def, pityOpenings(1, repeat("common", 3)...), nil)
+	if progress.Misses != 3 || progress.NextVoucherChance != 0 {
+		t.Errorf("progress after 3 misses = %+v", progress)
+	}
+	// Misses up to the soft start never roll, so the count is certain
+	progress, _ = replayPity(def, pityOpenings(1, repeat("common", 5)...), nil)
+	if progress.Misses != 5 || progress.NextVoucherChance != 0.2 {
+		t.Errorf("progress after 5 misses = %+v, want a 0.2 next voucher chance", progress)
+	}
+}
+
+func TestReplayPityDisabled(t *testing.T) {
+	progress, due := replayPity(pityCase(0, 0), pityOpenings(1, repeat("common", 50)...), nil)
+	if progress.Misses != 0 || progress.HardLimit != 0 || len(due) != 0 {
+		t.Errorf("case without pity: progress %+v, %d vouchers", progress, len(due))
+	}
+}
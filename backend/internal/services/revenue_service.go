@@ -0,0 +1,165 @@
+package services
+
+import (
+	"brainrot-tamagotchi/internal/blockchain"
+	"brainrot-tamagotchi/internal/repository"
+	"brainrot-tamagotchi/pkg/money"
+	"context"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// BalanceReader reads ETH balances; *blockchain.Client implements it
+type BalanceReader interface {
+	BalanceOf(ctx context.Context, address common.Address) (*big.Int, error)
+}
+
+var _ BalanceReader = (*blockchain.Client)(nil)
+
+type RevenueService struct {
+	saleRepo   repository.SaleStore
+	caseRepo   repository.CaseOpeningStore
+	blockchain BalanceReader // nil without a blockchain client
+
+	marketplaceAddress common.Address
+	caseAddress        common.Address
+}
+
+func NewRevenueService(
+	saleRepo repository.SaleStore,
+	caseRepo repository.CaseOpeningStore,
+	blockchain *blockchain.Client,
+) *RevenueService {
+	s := &RevenueService{
+		saleRepo: saleRepo,
+		caseRepo: caseRepo,
+	}
+	if blockchain != nil {
+		s.blockchain = blockchain
+		s.marketplaceAddress = blockchain.MarketplaceAddress
+		s.caseAddress = blockchain.CaseAddress
+	}
+	return s
+}
+
+// GetSellerEarnings returns a seller's sale totals and recent sales
+func (s *RevenueService) GetSellerEarnings(
+	sellerAddress string,
+	dateRange repository.DateRange,
+	limit, offset int,
+) (map[string]interface{}, error) {
+	totals, err := s.saleRepo.GetTotals(sellerAddress, dateRange)
+	if err != nil {
+		return nil, err
+	}
+
+	sales, total, err := s.saleRepo.GetBySeller(sellerAddress, dateRange, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"seller_address": strings.ToLower(sellerAddress),
+		"totals":         totals,
+		"sales":          sales,
+		"count":          len(sales),
+		"total":          total,
+	}, nil
+}
+
+// GetPlatformRevenue combines marketplace fees and case sales in a date range
+func (s *RevenueService) GetPlatformRevenue(dateRange repository.DateRange) (map[string]interface{}, error) {
+	sales, err := s.saleRepo.GetTotals("", dateRange)
+	if err != nil {
+		return nil, err
+	}
+
+	caseTotals, err := s.caseRepo.GetTotalsByCaseType("", dateRange)
+	if err != nil {
+		return nil, err
+	}
+
+	var casesOpened int64
+	var caseRevenue money.Wei
+	for _, t := range caseTotals {
+		casesOpened += t.Count
+		caseRevenue = caseRevenue.Add(t.Revenue)
+	}
+
+	return map[string]interface{}{
+		"marketplace": sales,
+		"cases": map[string]interface{}{
+			"cases_opened": casesOpened,
+			"revenue":      caseRevenue,
+			"by_type":      caseTotals,
+		},
+		"total_revenue": sales.PlatformFees.Add(caseRevenue),
+	}, nil
+}
+
+// ReconcileBalances compares all-time recorded revenue with what the
+// Marketplace and CaseOpening contracts currently hold. Neither contract
+// emits an event on withdrawal, so a balance below the recorded revenue is
+// attributed to withdrawals; a balance above it means income we never booked.
+func (s *RevenueService) ReconcileBalances(ctx context.Context) (map[string]interface{}, error) {
+	if s.blockchain == nil {
+		return nil, ErrChainDisabled
+	}
+
+	sales, err := s.saleRepo.GetTotals("", repository.DateRange{})
+	if err != nil {
+		return nil, err
+	}
+
+	caseTotals, err := s.caseRepo.GetTotalsByCaseType("", repository.DateRange{})
+	if err != nil {
+		return nil, err
+	}
+	var caseRevenue money.Wei
+	for _, t := range caseTotals {
+		caseRevenue = caseRevenue.Add(t.Revenue)
+	}
+
+	marketplace, err := s.reconcileContract(ctx, s.marketplaceAddress, sales.PlatformFees)
+	if err != nil {
+		return nil, err
+	}
+
+	cases, err := s.reconcileContract(ctx, s.caseAddress, caseRevenue)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"marketplace": marketplace,
+		"cases":       cases,
+	}, nil
+}
+
+func (s *RevenueService) reconcileContract(ctx context.Context, address common.Address, recorded money.Wei) (map[string]interface{}, error) {
+	balanceWei, err := s.blockchain.BalanceOf(ctx, address)
+	if err != nil {
+		return nil, chainError(err)
+	}
+	balance := money.NewWei(balanceWei)
+
+	status := "ok"
+	unbooked := money.Wei{}
+	withdrawn := recorded.Sub(balance)
+	if balance.Cmp(recorded) > 0 {
+		status = "drift"
+		unbooked = balance.Sub(recorded)
+		withdrawn = money.Wei{}
+	}
+
+	return map[string]interface{}{
+		"contract":           strings.ToLower(address.Hex()),
+		"recorded_revenue":   recorded,
+		"withdrawable":       balance,
+		"inferred_withdrawn": withdrawn,
+		"unbooked":           unbooked,
+		"status":             status,
+	}, nil
+}
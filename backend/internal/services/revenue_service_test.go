@@ -0,0 +1,243 @@
+package services
+
+import (
+	"brainrot-tamagotchi/internal/blockchain"
+	"brainrot-tamagotchi/internal/models"
+	"brainrot-tamagotchi/internal/repository"
+	"brainrot-tamagotchi/internal/repository/memstore"
+	"brainrot-tamagotchi/pkg/money"
+	"context"
+	"errors"
+	"math/big"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestSaleFromEventFeeSplit(t *testing.T) {
+	tests := []struct {
+		name     string
+		price    string
+		fee      string // As the contract computed it: price * bps / 10000, rounded down
+		bps      int
+		proceeds string
+	}{
+		{"2.5% of 1 ETH", "1000000000000000000", "25000000000000000", 250, "975000000000000000"},
+		{"10% max", "1000000000000000000", "100000000000000000", 1000, "900000000000000000"},
+		{"10% max rounded down", "1000000000000000007", "100000000000000000", 1000, "900000000000000007"},
+		{"10% max of an odd price", "123456789012345678", "12345678901234567", 1000, "111111110111111111"},
+		{"2.5% of an odd price", "123456789012345678", "3086419725308641", 250, "120370369287037037"},
+		{"no fee", "1000000000000000000", "0", 0, "1000000000000000000"},
+		{"zero price", "0", "0", 0, "0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			price, _ := new(big.Int).SetString(tt.price, 10)
+			fee, _ := new(big.Int).SetString(tt.fee, 10)
+			if got := money.NewWei(price).MulBps(int64(tt.bps)); got.Cmp(money.NewWei(fee)) != 0 {
+				t.Fatalf("fixture fee %s is not %d bps of %s (%s)", tt.fee, tt.bps, tt.price, got)
+			}
+
+			sale := saleFromEvent(&blockchain.MarketplaceEvent{
+				Name:        blockchain.EventNFTSold,
+				TokenID:     1,
+				Seller:      "0xa",
+				Buyer:       "0xb",
+				Price:       price,
+				PlatformFee: fee,
+				TxHash:      "0xsale",
+			})
+			if sale.GrossPrice.String() != tt.price {
+				t.Errorf("gross = %s, want %s", sale.GrossPrice, tt.price)
+			}
+			if sale.PlatformFee.String() != tt.fee {
+				t.Errorf("fee = %s, want %s", sale.PlatformFee, tt.fee)
+			}
+			if sale.FeeBps != tt.bps {
+				t.Errorf("fee bps = %d, want %d", sale.FeeBps, tt.bps)
+			}
+			if sale.SellerProceeds.String() != tt.proceeds {
+				t.Errorf("proceeds = %s, want %s", sale.SellerProceeds, tt.proceeds)
+			}
+			if sum := sale.PlatformFee.Add(sale.SellerProceeds); sum.Cmp(sale.GrossPrice) != 0 {
+				t.Errorf("fee + proceeds = %s, want gross %s", sum, sale.GrossPrice)
+			}
+		})
+	}
+}
+
+// newMemRevenueService returns a revenue service over in-memory stores
+// holding sales by 0xa (two, on days 1 and 3) and 0xb (one, on day 2)
+func newMemRevenueService(t *testing.T) (*RevenueService, *memstore.CaseOpeningStore) {
+	t.Helper()
+	sales := memstore.NewSaleStore()
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 12, 0, 0, 0, time.UTC) }
+	for _, sale := range []*models.MarketSale{
+		{TokenID: 1, SellerAddress: "0xA", BuyerAddress: "0xc", GrossPrice: money.WeiFromInt64(1000), FeeBps: 250, PlatformFee: money.WeiFromInt64(25), SellerProceeds: money.WeiFromInt64(975), TxHash: "0x1", SoldAt: day(1)},
+		{TokenID: 2, SellerAddress: "0xb", BuyerAddress: "0xc", GrossPrice: money.WeiFromInt64(2000), FeeBps: 250, PlatformFee: money.WeiFromInt64(50), SellerProceeds: money.WeiFromInt64(1950), TxHash: "0x2", SoldAt: day(2)},
+		{TokenID: 3, SellerAddress: "0xa", BuyerAddress: "0xc", GrossPrice: money.WeiFromInt64(4000), FeeBps: 1000, PlatformFee: money.WeiFromInt64(400), SellerProceeds: money.WeiFromInt64(3600), TxHash: "0x3", SoldAt: day(3)},
+		// Re-recording a sale is a no-op
+		{TokenID: 1, SellerAddress: "0xa", BuyerAddress: "0xc", GrossPrice: money.WeiFromInt64(1000), FeeBps: 250, PlatformFee: money.WeiFromInt64(25), SellerProceeds: money.WeiFromInt64(975), TxHash: "0x1", SoldAt: day(1)},
+	} {
+		if err := sales.Create(sale); err != nil {
+			t.Fatal(err)
+		}
+	}
+	openings := memstore.NewCaseOpeningStore()
+	return NewRevenueService(sales, openings, nil), openings
+}
+
+func TestGetSellerEarnings(t *testing.T) {
+	s, _ := newMemRevenueService(t)
+	day := func(d int) *time.Time {
+		at := time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC)
+		return &at
+	}
+
+	tests := []struct {
+		name          string
+		seller        string
+		dateRange     repository.DateRange
+		limit, offset int
+		wantTokens    []uint // Newest first
+		wantTotal     int64
+		wantCount     int64
+		wantGross     int64
+		wantFees      int64
+		wantProceeds  int64
+	}{
+		{"all sales", "0xa", repository.DateRange{}, 20, 0, []uint{3, 1}, 2, 2, 5000, 425, 4575},
+		{"address case does not matter", "0xA", repository.DateRange{}, 20, 0, []uint{3, 1}, 2, 2, 5000, 425, 4575},
+		{"other seller", "0xb", repository.DateRange{}, 20, 0, []uint{2}, 1, 1, 2000, 50, 1950},
+		{"first page", "0xa", repository.DateRange{}, 1, 0, []uint{3}, 2, 2, 5000, 425, 4575},
+		{"second page", "0xa", repository.DateRange{}, 1, 1, []uint{1}, 2, 2, 5000, 425, 4575},
+		{"from day 2", "0xa", repository.DateRange{From: day(2)}, 20, 0, []uint{3}, 1, 1, 4000, 400, 3600},
+		{"before day 2", "0xa", repository.DateRange{To: day(2)}, 20, 0, []uint{1}, 1, 1, 1000, 25, 975},
+		{"no sales", "0xd", repository.DateRange{}, 20, 0, []uint{}, 0, 0, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			earnings, err := s.GetSellerEarnings(tt.seller, tt.dateRange, tt.limit, tt.offset)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if want := strings.ToLower(tt.seller); earnings["seller_address"] != want {
+				t.Errorf("seller_address = %v, want %s", earnings["seller_address"], want)
+			}
+			sales := earnings["sales"].([]models.MarketSale)
+			tokens := make([]uint, len(sales))
+			for i, sale := range sales {
+				tokens[i] = sale.TokenID
+			}
+			if !slices.Equal(tokens, tt.wantTokens) {
+				t.Errorf("sales = %v, want %v", tokens, tt.wantTokens)
+			}
+			if earnings["count"] != len(tt.wantTokens) || earnings["total"] != tt.wantTotal {
+				t.Errorf("count = %v, total = %v, want %d, %d", earnings["count"], earnings["total"], len(tt.wantTokens), tt.wantTotal)
+			}
+
+			totals := earnings["totals"].(*repository.SaleTotals)
+			if totals.SalesCount != tt.wantCount {
+				t.Errorf("sales_count = %d, want %d", totals.SalesCount, tt.wantCount)
+			}
+			for field, got := range map[string]struct {
+				got  money.Wei
+				want int64
+			}{
+				"gross_volume":    {totals.GrossVolume, tt.wantGross},
+				"platform_fees":   {totals.PlatformFees, tt.wantFees},
+				"seller_proceeds": {totals.SellerProceeds, tt.wantProceeds},
+			} {
+				if got.got.Cmp(money.WeiFromInt64(got.want)) != 0 {
+					t.Errorf("%s = %s, want %d", field, got.got, got.want)
+				}
+			}
+		})
+	}
+}
+
+// fakeBalances serves contract balances from memory
+type fakeBalances map[common.Address]int64
+
+func (b fakeBalances) BalanceOf(ctx context.Context, address common.Address) (*big.Int, error) {
+	return big.NewInt(b[address]), nil
+}
+
+func TestReconcileBalances(t *testing.T) {
+	marketplace := common.HexToAddress("0x1000000000000000000000000000000000000001")
+	cases := common.HexToAddress("0x2000000000000000000000000000000000000002")
+
+	// Recorded: 475 wei of marketplace fees and 300 wei of case sales
+	tests := []struct {
+		name                string
+		marketplaceBalance  int64
+		caseBalance         int64
+		wantMarketplace     string
+		wantMarketWithdrawn int64
+		wantMarketUnbooked  int64
+		wantCases           string
+		wantCasesWithdrawn  int64
+		wantCasesUnbooked   int64
+	}{
+		{"nothing withdrawn", 475, 300, "ok", 0, 0, "ok", 0, 0},
+		{"partly withdrawn", 400, 100, "ok", 75, 0, "ok", 200, 0},
+		{"all withdrawn", 0, 0, "ok", 475, 0, "ok", 300, 0},
+		{"unbooked marketplace income", 500, 300, "drift", 0, 25, "ok", 0, 0},
+		{"unbooked case income", 475, 301, "ok", 0, 0, "drift", 0, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, openings := newMemRevenueService(t)
+			for i, price := range []int64{100, 200} {
+				if err := openings.Create(&models.CaseOpening{
+					UserAddress: "0xc",
+					CaseType:    "basic",
+					TokenID:     uint(10 + i),
+					Price:       money.WeiFromInt64(price),
+					OpenedAt:    time.Now(),
+				}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			s.blockchain = fakeBalances{marketplace: tt.marketplaceBalance, cases: tt.caseBalance}
+			s.marketplaceAddress = marketplace
+			s.caseAddress = cases
+
+			report, err := s.ReconcileBalances(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			check := func(key string, recorded, balance int64, status string, withdrawn, unbooked int64) {
+				t.Helper()
+				got := report[key].(map[string]interface{})
+				if got["status"] != status {
+					t.Errorf("%s status = %v, want %s", key, got["status"], status)
+				}
+				for field, want := range map[string]int64{
+					"recorded_revenue":   recorded,
+					"withdrawable":       balance,
+					"inferred_withdrawn": withdrawn,
+					"unbooked":           unbooked,
+				} {
+					if v := got[field].(money.Wei); v.Cmp(money.WeiFromInt64(want)) != 0 {
+						t.Errorf("%s %s = %s, want %d", key, field, v, want)
+					}
+				}
+			}
+			check("marketplace", 475, tt.marketplaceBalance, tt.wantMarketplace, tt.wantMarketWithdrawn, tt.wantMarketUnbooked)
+			check("cases", 300, tt.caseBalance, tt.wantCases, tt.wantCasesWithdrawn, tt.wantCasesUnbooked)
+		})
+	}
+
+	t.Run("chain disabled", func(t *testing.T) {
+		s, _ := newMemRevenueService(t)
+		if _, err := s.ReconcileBalances(context.Background()); !errors.Is(err, ErrChainDisabled) {
+			t.Fatalf("err = %v, want ErrChainDisabled", err)
+		}
+	})
+}
@@ -0,0 +1,192 @@
+package services
+
+import (
+	"brainrot-tamagotchi/internal/config"
+	"brainrot-tamagotchi/internal/events"
+	"brainrot-tamagotchi/internal/models"
+	"brainrot-tamagotchi/internal/repository"
+	"brainrot-tamagotchi/pkg/database"
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// The decay tests and benchmarks need Postgres. TEST_DATABASE_URL must point at a
+// disposable database: the nfts, outbox and cursor tables are truncated.
+//
+//	TEST_DATABASE_URL=postgres://... go test ./internal/services -run '^$' -bench DecayStats
+func decayTestDB(tb testing.TB) *gorm.DB {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		tb.Skip("TEST_DATABASE_URL not set")
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: gormlogger.Default.LogMode(gormlogger.Silent)})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	if _, err := database.MigrateUp(db); err != nil {
+		tb.Fatal(err)
+	}
+	return db
+}
+
+// seedPets replaces every pet with n pets due for one hour of decay
+func seedPets(tb testing.TB, db *gorm.DB, n int) {
+	if err := db.Exec("TRUNCATE nfts, outbox_events, notifications, sync_cursors").Error; err != nil {
+		tb.Fatal(err)
+	}
+
+	now := time.Now()
+	pets := make([]models.NFT, n)
+	for i := range pets {
+		pets[i] = models.NFT{
+			TokenID:      uint(i + 1),
+			OwnerAddress: "0x000000000000000000000000000000000000beef",
+			MemeType:     "pepe",
+			Rarity:       "common",
+			Hunger:       100,
+			Mood:         100,
+			Energy:       90,
+			LastFed:      now.Add(-7 * time.Hour),
+			LastPlayed:   now.Add(-13 * time.Hour),
+			LastInteract: now.Add(-7 * time.Hour),
+		}
+	}
+	if err := db.CreateInBatches(pets, 1000).Error; err != nil {
+		tb.Fatal(err)
+	}
+}
+
+func newDecayService(db *gorm.DB) *TamagotchiService {
+	notifier := NewNotificationService(repository.NewNotificationRepository(db), nil)
+	return NewTamagotchiService(repository.NewDBStores(db), nil, nil, nil, notifier, config.Defaults().Game)
+}
+
+// decayPerRow is the decay loop DecayStats replaced: every alive pet is
+// loaded, then saved with its own UPDATE
+func decayPerRow(s *TamagotchiService) error {
+	nfts, err := s.stores.NFTs().GetAliveNFTs()
+	if err != nil {
+		return err
+	}
+
+	for _, nft := range nfts {
+		if hours := time.Since(nft.LastFed).Hours(); hours >= 6 {
+			nft.Hunger = max(0, nft.Hunger-25*int(hours/6))
+		}
+		if hours := time.Since(nft.LastPlayed).Hours(); hours >= 12 {
+			nft.Mood = max(0, nft.Mood-20*int(hours/12))
+		}
+		if nft.Energy < 100 {
+			nft.Energy = min(100, nft.Energy+10)
+		}
+
+		var died []events.Event
+		if !nft.IsAlive() {
+			died = append(died, events.PetDied{TokenID: nft.TokenID, Owner: nft.OwnerAddress})
+		}
+		if err := s.saveStats(context.Background(), &nft, died...); err != nil {
+			return err
+		}
+		s.notifier.CheckPet(&nft)
+	}
+	return nil
+}
+
+func TestDecayStatsResumes(t *testing.T) {
+	db := decayTestDB(t)
+	seedPets(t, db, 250)
+	s := newDecayService(db)
+
+	// Decay the first 100 pets and stop, as a pass cut short would
+	lastID, _, _, err := repository.NewNFTRepository(db).DecayBatch(0, 100, time.Now(), decayRules(config.Defaults().Game))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repository.NewChainEventRepository(db).SetCursor(statDecayCursor, uint64(lastID)); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := s.decayStats(context.Background(), 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.Complete || report.ResumedFrom != lastID || report.Scanned != 150 {
+		t.Fatalf("resumed pass = %+v; want the remaining 150 pets", report)
+	}
+
+	if cursor, _ := repository.NewChainEventRepository(db).GetCursor(statDecayCursor); cursor != 0 {
+		t.Fatalf("cursor = %d after a complete pass, want 0", cursor)
+	}
+
+	// Every pet was decayed exactly once
+	var wrong int64
+	db.Model(&models.NFT{}).Where("hunger <> 75 OR mood <> 80 OR energy <> 100").Count(&wrong)
+	if wrong != 0 {
+		t.Fatalf("%d pets decayed more or less than once", wrong)
+	}
+}
+
+func TestDecayStatsMatchesPerRow(t *testing.T) {
+	db := decayTestDB(t)
+	s := newDecayService(db)
+
+	seedPets(t, db, 300)
+	if err := decayPerRow(s); err != nil {
+		t.Fatal(err)
+	}
+	var want []models.NFT
+	db.Order("id").Find(&want)
+
+	seedPets(t, db, 300)
+	if _, err := s.DecayStats(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	var got []models.NFT
+	db.Order("id").Find(&got)
+
+	for i := range want {
+		if got[i].Hunger != want[i].Hunger || got[i].Mood != want[i].Mood || got[i].Energy != want[i].Energy {
+			t.Fatalf("pet %d: batched %d/%d/%d, per row %d/%d/%d", want[i].TokenID,
+				got[i].Hunger, got[i].Mood, got[i].Energy, want[i].Hunger, want[i].Mood, want[i].Energy)
+		}
+	}
+}
+
+func BenchmarkDecayStats(b *testing.B) {
+	db := decayTestDB(b)
+	s := newDecayService(db)
+
+	for _, pets := range []int{1000, 10000} {
+		b.Run(fmt.Sprintf("per_row/%d", pets), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				seedPets(b, db, pets)
+				b.StartTimer()
+				if err := decayPerRow(s); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(pets*b.N)/b.Elapsed().Seconds(), "pets/s")
+		})
+
+		b.Run(fmt.Sprintf("batched/%d", pets), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				seedPets(b, db, pets)
+				b.StartTimer()
+				if _, err := s.DecayStats(context.Background()); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(pets*b.N)/b.Elapsed().Seconds(), "pets/s")
+		})
+	}
+}
@@ -2,38 +2,65 @@ package services
 
 import (
 	"brainrot-tamagotchi/internal/blockchain"
+	"brainrot-tamagotchi/internal/config"
+	"brainrot-tamagotchi/internal/events"
+	"brainrot-tamagotchi/internal/metrics"
 	"brainrot-tamagotchi/internal/models"
+	"brainrot-tamagotchi/internal/realtime"
 	"brainrot-tamagotchi/internal/repository"
+	"brainrot-tamagotchi/pkg/apperr"
+	"brainrot-tamagotchi/pkg/logging"
+	"brainrot-tamagotchi/pkg/money"
+	"context"
 	"fmt"
-	"log"
 	"time"
 
 	"github.com/go-redis/redis/v8"
+	"go.opentelemetry.io/otel"
+)
+
+var logger = logging.For("services")
+
+var tracer = otel.Tracer("brainrot-tamagotchi/internal/services")
+
+var (
+	ErrFeedCooldown    = apperr.Conflict("feed_cooldown", "free feeding not available yet")
+	ErrNotEnoughEnergy = apperr.Conflict("not_enough_energy", "not enough energy to play")
+	ErrInvalidUpgrade  = apperr.Validation("invalid_upgrade_level", "level must increase")
 )
 
 type TamagotchiService struct {
-	nftRepo    *repository.NFTRepository
+	stores     repository.Stores
 	redis      *redis.Client
 	blockchain *blockchain.Client
+	hub        *realtime.Hub
+	notifier   *NotificationService
+	game       config.Game
 }
 
 func NewTamagotchiService(
-	nftRepo *repository.NFTRepository,
+	stores repository.Stores,
 	redis *redis.Client,
 	blockchain *blockchain.Client,
+	hub *realtime.Hub,
+	notifier *NotificationService,
+	game config.Game,
 ) *TamagotchiService {
 	return &TamagotchiService{
-		nftRepo:    nftRepo,
+		stores:     stores,
 		redis:      redis,
 		blockchain: blockchain,
+		hub:        hub,
+		notifier:   notifier,
+		game:       game,
 	}
 }
 
 // GetPetState retrieves the current state of a pet
-func (s *TamagotchiService) GetPetState(tokenID uint) (*models.NFT, error) {
-	nft, err := s.nftRepo.GetByTokenID(tokenID)
+func (s *TamagotchiService) GetPetState(ctx context.Context, tokenID uint) (*models.NFT, error) {
+	nft, err := s.stores.WithContext(ctx).NFTs().GetByTokenID(tokenID)
 	if err != nil {
-		return nil, err
+		return nil, notFound(err, ErrPetNotFound)
 	}
 
 	// Update stats based on time passed
@@ -42,156 +69,374 @@ func (s *TamagotchiService) GetPetState(tokenID uint) (*models.NFT, error) {
 	return nft, nil
 }
 
-// FeedPet feeds the pet (free once per day or paid)
-func (s *TamagotchiService) FeedPet(tokenID uint, ownerAddress string, isPaid bool) error {
-	nft, err := s.nftRepo.GetByTokenID(tokenID)
+// FeedPet feeds the pet (free once per cooldown or paid)
+func (s *TamagotchiService) FeedPet(ctx context.Context, tokenID uint, ownerAddress string, isPaid bool) error {
+	nft, err := s.stores.WithContext(ctx).NFTs().GetByTokenID(tokenID)
 	if err != nil {
-		return err
+		return notFound(err, ErrPetNotFound)
 	}
 
 	// Verify ownership
 	if nft.OwnerAddress != ownerAddress {
-		return fmt.Errorf("not the owner of this NFT")
+		return ErrNotOwner
 	}
 
 	// Check if can feed for free
-	if !isPaid && !nft.CanFeedFree() {
-		return fmt.Errorf("free feeding not available yet")
+	if !isPaid && !nft.CanFeedFree(s.game.FreeFeedCooldown) {
+		return ErrFeedCooldown
 	}
 
 	// Feed the pet
-	nft.Hunger = min(100, nft.Hunger+50)
+	nft.Hunger = min(100, nft.Hunger+s.game.FeedHunger)
 	nft.LastFed = time.Now()
 	nft.LastInteract = time.Now()
 
-	return s.nftRepo.UpdateStats(nft)
+	return s.saveStats(ctx, nft, events.PetFed{
+		TokenID: nft.TokenID,
+		Owner:   nft.OwnerAddress,
+		Hunger:  nft.Hunger,
+		Paid:    isPaid,
+	})
 }
 
 // PlayWithPet plays with the pet to improve mood
-func (s *TamagotchiService) PlayWithPet(tokenID uint, ownerAddress string) error {
-	nft, err := s.nftRepo.GetByTokenID(tokenID)
+func (s *TamagotchiService) PlayWithPet(ctx context.Context, tokenID uint, ownerAddress string) error {
+	nft, err := s.stores.WithContext(ctx).NFTs().GetByTokenID(tokenID)
 	if err != nil {
-		return err
+		return notFound(err, ErrPetNotFound)
 	}
 
 	// Verify ownership
 	if nft.OwnerAddress != ownerAddress {
-		return fmt.Errorf("not the owner of this NFT")
+		return ErrNotOwner
 	}
 
 	// Check energy
-	if nft.Energy < 10 {
-		return fmt.Errorf("not enough energy to play")
+	if nft.Energy < s.game.PlayEnergyCost {
+		return ErrNotEnoughEnergy
 	}
 
 	// Play with pet
-	nft.Mood = min(100, nft.Mood+30)
-	nft.Energy = max(0, nft.Energy-10)
+	nft.Mood = min(100, nft.Mood+s.game.PlayMood)
+	nft.Energy = max(0, nft.Energy-s.game.PlayEnergyCost)
 	nft.LastPlayed = time.Now()
 	nft.LastInteract = time.Now()
 
-	return s.nftRepo.UpdateStats(nft)
+	return s.saveStats(ctx, nft, events.PetPlayed{
+		TokenID: nft.TokenID,
+		Owner:   nft.OwnerAddress,
+		Mood:    nft.Mood,
+		Energy:  nft.Energy,
+	})
 }
 
 // RestorePet restores a dead pet (paid revival)
-func (s *TamagotchiService) RestorePet(tokenID uint, ownerAddress string) error {
-	nft, err := s.nftRepo.GetByTokenID(tokenID)
+func (s *TamagotchiService) RestorePet(ctx context.Context, tokenID uint, ownerAddress string) error {
+	nft, err := s.stores.WithContext(ctx).NFTs().GetByTokenID(tokenID)
 	if err != nil {
-		return err
+		return notFound(err, ErrPetNotFound)
 	}
 
 	// Verify ownership
 	if nft.OwnerAddress != ownerAddress {
-		return fmt.Errorf("not the owner of this NFT")
+		return ErrNotOwner
 	}
 
-	// Restore to 50% stats
-	nft.Hunger = 50
-	nft.Mood = 50
-	nft.Energy = 50
+	// Restore to the configured stats
+	nft.Hunger = s.game.RestoreStats
+	nft.Mood = s.game.RestoreStats
+	nft.Energy = s.game.RestoreStats
 	nft.LastInteract = time.Now()
 
-	return s.nftRepo.UpdateStats(nft)
+	return s.saveStats(ctx, nft, events.PetRestored{TokenID: nft.TokenID, Owner: nft.OwnerAddress})
 }
 
-// StartHungerDecayJob starts a background job to decay hunger/mood/energy
-func (s *TamagotchiService) StartHungerDecayJob() {
-	ticker := time.NewTicker(1 * time.Hour) // Check every hour
-	defer ticker.Stop()
+// AdjustPetStats sets a pet's stats on behalf of an admin and returns the
+// previous values. Like player actions it goes through saveStats, so the
+// change is recorded as an event and pushed to subscribers.
+func (s *TamagotchiService) AdjustPetStats(ctx context.Context, tokenID uint, adjustment PetStatsAdjustment, adjustedBy, reason string) (*models.NFT, map[string]int, error) {
+	nft, err := s.stores.WithContext(ctx).NFTs().GetByTokenID(tokenID)
+	if err != nil {
+		return nil, nil, notFound(err, ErrPetNotFound)
+	}
 
-	log.Println("🔄 Hunger decay job started")
+	before := map[string]int{"hunger": nft.Hunger, "mood": nft.Mood, "energy": nft.Energy}
 
-	for {
-		<-ticker.C
-		s.decreaseAllStats()
+	stats := []struct {
+		name  string
+		value *int
+		field *int
+	}{
+		{"hunger", adjustment.Hunger, &nft.Hunger},
+		{"mood", adjustment.Mood, &nft.Mood},
+		{"energy", adjustment.Energy, &nft.Energy},
+	}
+
+	changed := false
+	for _, stat := range stats {
+		if stat.value == nil {
+			continue
+		}
+		if *stat.value < 0 || *stat.value > 100 {
+			return nil, nil, ErrInvalidStats.WithMessage(fmt.Sprintf("%s must be between 0 and 100", stat.name))
+		}
+		*stat.field = *stat.value
+		changed = true
+	}
+	if !changed {
+		return nil, nil, ErrInvalidStats.WithMessage("no stats to adjust")
 	}
-}
 
-// decreaseAllStats decreases stats for all alive pets
-func (s *TamagotchiService) decreaseAllStats() {
-	nfts, err := s.nftRepo.GetAliveNFTs()
+	// Restart decay from now so the new values are not decayed retroactively
+	now := time.Now()
+	if adjustment.Hunger != nil {
+		nft.LastFed = now
+	}
+	if adjustment.Mood != nil {
+		nft.LastPlayed = now
+	}
+	nft.LastInteract = now
+
+	err = s.saveStats(ctx, nft, events.PetStatsAdjusted{
+		TokenID:    nft.TokenID,
+		Owner:      nft.OwnerAddress,
+		Hunger:     nft.Hunger,
+		Mood:       nft.Mood,
+		Energy:     nft.Energy,
+		AdjustedBy: adjustedBy,
+		Reason:     reason,
+	})
 	if err != nil {
-		log.Printf("Error fetching alive NFTs: %v", err)
-		return
+		return nil, nil, err
 	}
+	return nft, before, nil
+}
 
-	for _, nft := range nfts {
-		updated := false
+// MaxLevel is the highest level BrainrotNFT.upgradeLevel allows
+const MaxLevel = 30
+
+// upgradePriceTiers mirrors BrainrotNFT._getUpgradePrice: the price of an
+// upgrade depends only on the target level
+var upgradePriceTiers = []struct {
+	maxLevel int
+	price    money.Wei
+}{
+	{5, money.MustParseEther("0.001")},        // ~$1
+	{10, money.MustParseEther("0.002")},       // ~$2
+	{15, money.MustParseEther("0.003")},       // ~$3
+	{20, money.MustParseEther("0.005")},       // ~$5
+	{25, money.MustParseEther("0.008")},       // ~$8
+	{MaxLevel, money.MustParseEther("0.015")}, // ~$15
+}
 
-		// Decrease hunger every 6 hours
-		hoursSinceLastFed := time.Since(nft.LastFed).Hours()
-		if hoursSinceLastFed >= 6 {
-			periodsElapsed := int(hoursSinceLastFed / 6)
-			nft.Hunger = max(0, nft.Hunger-(25*periodsElapsed))
-			updated = true
+// UpgradePrice returns what BrainrotNFT charges to upgrade to a level
+func UpgradePrice(toLevel int) money.Wei {
+	for _, tier := range upgradePriceTiers {
+		if toLevel <= tier.maxLevel {
+			return tier.price
 		}
+	}
+	return upgradePriceTiers[len(upgradePriceTiers)-1].price
+}
+
+// GetUpgradePrice validates an upgrade of a pet to a level and returns its price
+func (s *TamagotchiService) GetUpgradePrice(ctx context.Context, tokenID uint, toLevel int) (*models.NFT, money.Wei, error) {
+	nft, err := s.stores.WithContext(ctx).NFTs().GetByTokenID(tokenID)
+	if err != nil {
+		return nil, money.Wei{}, notFound(err, ErrPetNotFound)
+	}
+
+	if toLevel <= nft.Level {
+		return nil, money.Wei{}, ErrInvalidUpgrade
+	}
+	if toLevel > MaxLevel {
+		return nil, money.Wei{}, ErrInvalidUpgrade.WithMessage(fmt.Sprintf("max level is %d", MaxLevel))
+	}
+
+	return nft, UpgradePrice(toLevel), nil
+}
 
-		// Decrease mood every 12 hours
-		hoursSinceLastPlayed := time.Since(nft.LastPlayed).Hours()
-		if hoursSinceLastPlayed >= 12 {
-			periodsElapsed := int(hoursSinceLastPlayed / 12)
-			nft.Mood = max(0, nft.Mood-(20*periodsElapsed))
-			updated = true
+// statDecayCursor names the sync_cursors row holding the last pet id an
+// interrupted decay pass got to; it is 0 between passes
+const statDecayCursor = "stat_decay"
+
+// statDecayBatchSize is how many pets one decay statement covers
+const statDecayBatchSize = 1000
+
+// statDecayLogEvery is how many batches pass between progress logs
+const statDecayLogEvery = 50
+
+// DecayReport summarizes one stat decay pass
+type DecayReport struct {
+	StartedAt   time.Time `json:"started_at"`
+	FinishedAt  time.Time `json:"finished_at"`
+	ResumedFrom uint      `json:"resumed_from,omitempty"` // Pet id an interrupted pass stopped at
+	Batches     int       `json:"batches"`
+	Scanned     int       `json:"scanned"` // Alive pets covered
+	Updated     int       `json:"updated"`
+	Died        int       `json:"died"`
+	Complete    bool      `json:"complete"`
+}
+
+// PetsPerSecond is the pass throughput
+func (r *DecayReport) PetsPerSecond() float64 {
+	elapsed := r.FinishedAt.Sub(r.StartedAt).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(r.Scanned) / elapsed
+}
+
+// DecayStats decays hunger and mood and regenerates energy for all alive
+// pets. It runs hourly, one UPDATE per batch of pets in id order. Each batch
+// commits with its death events and the cursor, so a pass cut short resumes
+// where it stopped instead of decaying the same pets twice.
+func (s *TamagotchiService) DecayStats(ctx context.Context) (*DecayReport, error) {
+	return s.decayStats(ctx, statDecayBatchSize)
+}
+
+func (s *TamagotchiService) decayStats(ctx context.Context, batchSize int) (*DecayReport, error) {
+	stores := s.stores.WithContext(ctx)
+	after, err := stores.Cursors().GetCursor(statDecayCursor)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &DecayReport{StartedAt: time.Now(), ResumedFrom: uint(after)}
+	if after > 0 {
+		logger.InfoContext(ctx, "resuming stat decay", "after_pet", after)
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			report.FinishedAt = time.Now()
+			return report, err
 		}
 
-		// Regenerate energy slowly (10 per hour)
-		if nft.Energy < 100 {
-			nft.Energy = min(100, nft.Energy+10)
-			updated = true
+		var lastID uint
+		var scanned int
+		var decayed []models.NFT
+		err := stores.Transaction(func(tx repository.Stores) error {
+			var err error
+			lastID, scanned, decayed, err = tx.NFTs().DecayBatch(uint(after), batchSize, report.StartedAt, decayRules(s.game))
+			if err != nil {
+				return err
+			}
+
+			// Only alive pets are decayed, so a dead one just died
+			var died []events.Event
+			for _, nft := range decayed {
+				if !nft.IsAlive() {
+					died = append(died, events.PetDied{
+						TokenID: nft.TokenID,
+						Owner:   nft.OwnerAddress,
+						Hunger:  nft.Hunger,
+						Mood:    nft.Mood,
+						Energy:  nft.Energy,
+					})
+				}
+			}
+			if err := events.RecordTo(tx.Outbox(), died...); err != nil {
+				return err
+			}
+			report.Died += len(died)
+
+			return tx.Cursors().SetCursor(statDecayCursor, uint64(lastID))
+		})
+		if err != nil {
+			report.FinishedAt = time.Now()
+			return report, fmt.Errorf("decaying pets after %d: %w", after, err)
+		}
+		if lastID == 0 {
+			break
 		}
 
-		if updated {
-			if err := s.nftRepo.UpdateStats(&nft); err != nil {
-				log.Printf("Error updating stats for NFT %d: %v", nft.TokenID, err)
+		died := 0
+		for i := range decayed {
+			s.hub.Publish(realtime.PetTopic(decayed[i].TokenID), realtime.EventPetUpdated, &decayed[i])
+			s.notifier.CheckPet(&decayed[i])
+			if !decayed[i].IsAlive() {
+				died++
 			}
 		}
+		metrics.DecayPets.WithLabelValues("scanned").Add(float64(scanned))
+		metrics.DecayPets.WithLabelValues("updated").Add(float64(len(decayed)))
+		metrics.DecayPets.WithLabelValues("died").Add(float64(died))
+
+		after = uint64(lastID)
+		report.Batches++
+		report.Scanned += scanned
+		report.Updated += len(decayed)
+		if report.Batches%statDecayLogEvery == 0 {
+			logger.InfoContext(ctx, "stat decay progress", "scanned", report.Scanned, "batches", report.Batches, "last_pet", lastID)
+		}
 	}
 
-	log.Printf("✅ Updated stats for %d pets", len(nfts))
+	report.FinishedAt = time.Now()
+	report.Complete = true
+	metrics.DecayDuration.Observe(report.FinishedAt.Sub(report.StartedAt).Seconds())
+	logger.InfoContext(ctx, "stat decay finished",
+		"updated", report.Updated,
+		"scanned", report.Scanned,
+		"died", report.Died,
+		"duration_ms", report.FinishedAt.Sub(report.StartedAt).Milliseconds(),
+		"pets_per_second", int(report.PetsPerSecond()),
+	)
+	return report, nil
+}
+
+// saveStats persists a pet's stats together with the events describing the
+// change, then pushes the stats to realtime subscribers
+func (s *TamagotchiService) saveStats(ctx context.Context, nft *models.NFT, evts ...events.Event) error {
+	err := s.stores.WithContext(ctx).Transaction(func(tx repository.Stores) error {
+		if err := tx.NFTs().UpdateStats(nft); err != nil {
+			return err
+		}
+		return events.RecordTo(tx.Outbox(), evts...)
+	})
+	if err != nil {
+		return err
+	}
+	s.hub.Publish(realtime.PetTopic(nft.TokenID), realtime.EventPetUpdated, nft)
+	return nil
 }
 
 // updateStatsBasedOnTime updates stats in real-time based on time passed
 func (s *TamagotchiService) updateStatsBasedOnTime(nft *models.NFT) {
-	now := time.Now()
+	applyTimeDecay(nft, time.Now(), s.game)
+}
 
+// applyTimeDecay projects a pet's stats forward to now without persisting them
+func applyTimeDecay(nft *models.NFT, now time.Time, game config.Game) {
 	// Calculate hunger decay
-	hoursSinceLastFed := now.Sub(nft.LastFed).Hours()
-	if hoursSinceLastFed >= 6 {
-		periodsElapsed := int(hoursSinceLastFed / 6)
-		nft.Hunger = max(0, nft.Hunger-(25*periodsElapsed))
+	sinceLastFed := now.Sub(nft.LastFed)
+	if sinceLastFed >= game.HungerDecayPeriod {
+		periodsElapsed := int(sinceLastFed / game.HungerDecayPeriod)
+		nft.Hunger = max(0, nft.Hunger-(game.HungerDecay*periodsElapsed))
 	}
 
 	// Calculate mood decay
-	hoursSinceLastPlayed := now.Sub(nft.LastPlayed).Hours()
-	if hoursSinceLastPlayed >= 12 {
-		periodsElapsed := int(hoursSinceLastPlayed / 12)
-		nft.Mood = max(0, nft.Mood-(20*periodsElapsed))
+	sinceLastPlayed := now.Sub(nft.LastPlayed)
+	if sinceLastPlayed >= game.MoodDecayPeriod {
+		periodsElapsed := int(sinceLastPlayed / game.MoodDecayPeriod)
+		nft.Mood = max(0, nft.Mood-(game.MoodDecay*periodsElapsed))
 	}
 
 	// Energy regeneration
 	hoursSinceLastInteract := now.Sub(nft.LastInteract).Hours()
 	if nft.Energy < 100 && hoursSinceLastInteract > 0 {
-		nft.Energy = min(100, nft.Energy+int(hoursSinceLastInteract*10))
+		nft.Energy = min(100, nft.Energy+int(hoursSinceLastInteract*float64(game.EnergyRegen)))
+	}
+}
+
+// decayRules are the game's decay rates for the hourly decay job
+func decayRules(game config.Game) repository.DecayRules {
+	return repository.DecayRules{
+		HungerDecay:       game.HungerDecay,
+		HungerDecayPeriod: game.HungerDecayPeriod,
+		MoodDecay:         game.MoodDecay,
+		MoodDecayPeriod:   game.MoodDecayPeriod,
+		EnergyRegen:       game.EnergyRegen,
 	}
 }
 
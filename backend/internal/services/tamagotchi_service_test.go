@@ -0,0 +1,307 @@
+package services
+
+import (
+	"brainrot-tamagotchi/internal/config"
+	"brainrot-tamagotchi/internal/events"
+	"brainrot-tamagotchi/internal/models"
+	"brainrot-tamagotchi/internal/repository/memstore"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// newMemTamagotchiService returns a service over in-memory stores holding pets
+func newMemTamagotchiService(t *testing.T, pets ...models.NFT) (*TamagotchiService, *memstore.Stores) {
+	t.Helper()
+	nfts := memstore.NewNFTStore()
+	for i := range pets {
+		if err := nfts.Create(&pets[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stores := memstore.NewStores(nfts)
+	return NewTamagotchiService(stores, nil, nil, nil, nil, config.Defaults().Game), stores
+}
+
+// outboxTypes lists the types of the events in the outbox, in order
+func outboxTypes(stores *memstore.Stores) []string {
+	var types []string
+	for _, event := range stores.OutboxEvents() {
+		types = append(types, event.Type)
+	}
+	return types
+}
+
+func TestGetPetStateProjectsDecay(t *testing.T) {
+	now := time.Now()
+	s, stores := newMemTamagotchiService(t, models.NFT{
+		TokenID:      1,
+		OwnerAddress: "0xa",
+		Hunger:       100,
+		Mood:         100,
+		Energy:       50,
+		LastFed:      now.Add(-13 * time.Hour),
+		LastPlayed:   now.Add(-25 * time.Hour),
+		LastInteract: now.Add(-2 * time.Hour),
+	})
+
+	pet, err := s.GetPetState(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Two hunger periods, two mood periods, two hours of regeneration
+	if pet.Hunger != 50 || pet.Mood != 60 || pet.Energy != 70 {
+		t.Fatalf("projected stats = %d/%d/%d, want 50/60/70", pet.Hunger, pet.Mood, pet.Energy)
+	}
+
+	stored, err := stores.NFTs().GetByTokenID(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Hunger != 100 || stored.Mood != 100 || stored.Energy != 50 {
+		t.Fatalf("GetPetState persisted %d/%d/%d", stored.Hunger, stored.Mood, stored.Energy)
+	}
+}
+
+func TestFeedPetRejects(t *testing.T) {
+	now := time.Now()
+	s, _ := newMemTamagotchiService(t, models.NFT{
+		TokenID:      1,
+		OwnerAddress: "0xa",
+		Hunger:       40,
+		LastFed:      now.Add(-time.Hour),
+		LastPlayed:   now,
+		LastInteract: now,
+	})
+
+	if err := s.FeedPet(context.Background(), 1, "0xb", true); !errors.Is(err, ErrNotOwner) {
+		t.Fatalf("feeding someone else's pet = %v", err)
+	}
+	if err := s.FeedPet(context.Background(), 1, "0xa", false); !errors.Is(err, ErrFeedCooldown) {
+		t.Fatalf("free feed on cooldown = %v", err)
+	}
+	if err := s.FeedPet(context.Background(), 2, "0xa", true); !errors.Is(err, ErrPetNotFound) {
+		t.Fatalf("feeding a missing pet = %v", err)
+	}
+}
+
+func TestFeedPetSavesStatsWithEvent(t *testing.T) {
+	now := time.Now()
+	s, stores := newMemTamagotchiService(t, models.NFT{
+		TokenID:      1,
+		OwnerAddress: "0xa",
+		Hunger:       40,
+		LastFed:      now.Add(-time.Hour),
+		LastPlayed:   now,
+		LastInteract: now,
+	})
+
+	if err := s.FeedPet(context.Background(), 1, "0xa", true); err != nil {
+		t.Fatal(err)
+	}
+
+	stored, err := stores.NFTs().GetByTokenID(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 40 + config.Defaults().Game.FeedHunger; stored.Hunger != min(100, want) {
+		t.Fatalf("hunger = %d, want %d", stored.Hunger, min(100, want))
+	}
+	if !stored.LastFed.After(now) {
+		t.Fatal("LastFed was not moved forward")
+	}
+	if types := outboxTypes(stores); len(types) != 1 || types[0] != events.TypePetFed {
+		t.Fatalf("outbox = %v, want one %s", types, events.TypePetFed)
+	}
+}
+
+func TestSavedStatsEventIsDispatchedOnce(t *testing.T) {
+	now := time.Now()
+	s, stores := newMemTamagotchiService(t, models.NFT{
+		TokenID:      1,
+		OwnerAddress: "0xa",
+		Hunger:       40,
+		LastFed:      now.Add(-time.Hour),
+		LastPlayed:   now,
+		LastInteract: now,
+	})
+	ctx := context.Background()
+
+	dispatcher := events.NewDispatcher(stores, nil)
+	var fed []events.PetFed
+	dispatcher.Subscribe(events.TypePetFed, "test", func(ctx context.Context, env events.Envelope) error {
+		var evt events.PetFed
+		if err := env.Decode(&evt); err != nil {
+			return err
+		}
+		fed = append(fed, evt)
+		return nil
+	})
+
+	if err := s.FeedPet(ctx, 1, "0xa", true); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := dispatcher.DispatchPending(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stored, err := stores.NFTs().GetByTokenID(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fed) != 1 || fed[0].TokenID != 1 || fed[0].Hunger != stored.Hunger {
+		t.Fatalf("delivered %+v, want one pet.fed with the saved hunger %d", fed, stored.Hunger)
+	}
+}
+
+func TestPlayWithPetSavesStatsWithEvent(t *testing.T) {
+	game := config.Defaults().Game
+	now := time.Now()
+	s, stores := newMemTamagotchiService(t, models.NFT{
+		TokenID:      1,
+		OwnerAddress: "0xa",
+		Mood:         50,
+		Energy:       game.PlayEnergyCost,
+		LastFed:      now,
+		LastPlayed:   now,
+		LastInteract: now,
+	})
+
+	if err := s.PlayWithPet(context.Background(), 1, "0xa"); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := stores.NFTs().GetByTokenID(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Mood != min(100, 50+game.PlayMood) || stored.Energy != 0 {
+		t.Fatalf("mood/energy = %d/%d, want %d/0", stored.Mood, stored.Energy, min(100, 50+game.PlayMood))
+	}
+
+	// Energy is now spent, so the second play is refused and nothing is recorded
+	if err := s.PlayWithPet(context.Background(), 1, "0xa"); !errors.Is(err, ErrNotEnoughEnergy) {
+		t.Fatalf("playing without energy = %v", err)
+	}
+	if types := outboxTypes(stores); len(types) != 1 || types[0] != events.TypePetPlayed {
+		t.Fatalf("outbox = %v, want one %s", types, events.TypePetPlayed)
+	}
+}
+
+func TestAdjustPetStatsSavesStatsWithEvent(t *testing.T) {
+	old := time.Now().Add(-48 * time.Hour)
+	s, stores := newMemTamagotchiService(t, models.NFT{
+		TokenID:      1,
+		OwnerAddress: "0xa",
+		Hunger:       10,
+		Mood:         20,
+		Energy:       30,
+		LastFed:      old,
+		LastPlayed:   old,
+		LastInteract: old,
+	})
+	ctx := context.Background()
+	intp := func(v int) *int { return &v }
+
+	invalid := []PetStatsAdjustment{
+		{},
+		{Hunger: intp(101)},
+		{Mood: intp(50), Energy: intp(-1)},
+	}
+	for _, adjustment := range invalid {
+		if _, _, err := s.AdjustPetStats(ctx, 1, adjustment, "0xadmin", "support ticket"); !errors.Is(err, ErrInvalidStats) {
+			t.Fatalf("AdjustPetStats(%+v) = %v, want ErrInvalidStats", adjustment, err)
+		}
+	}
+	if _, _, err := s.AdjustPetStats(ctx, 2, PetStatsAdjustment{Hunger: intp(50)}, "0xadmin", "r"); !errors.Is(err, ErrPetNotFound) {
+		t.Fatalf("adjusting a missing pet = %v", err)
+	}
+
+	nft, before, err := s.AdjustPetStats(ctx, 1, PetStatsAdjustment{Hunger: intp(80)}, "0xadmin", "support ticket")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if before["hunger"] != 10 || before["mood"] != 20 || nft.Hunger != 80 || nft.Mood != 20 {
+		t.Fatalf("before = %v, after = %d/%d", before, nft.Hunger, nft.Mood)
+	}
+
+	stored, err := stores.NFTs().GetByTokenID(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Only the adjusted stat restarts its decay
+	if stored.Hunger != 80 || !stored.LastFed.After(old) || !stored.LastPlayed.Equal(old) {
+		t.Fatalf("stored = %+v", stored)
+	}
+
+	evts := stores.OutboxEvents()
+	if len(evts) != 1 || evts[0].Type != events.TypePetStatsAdjusted {
+		t.Fatalf("outbox = %v, want one %s", outboxTypes(stores), events.TypePetStatsAdjusted)
+	}
+	var payload events.PetStatsAdjusted
+	if err := (events.Envelope{Payload: evts[0].Payload}).Decode(&payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.Hunger != 80 || payload.AdjustedBy != "0xadmin" || payload.Reason != "support ticket" {
+		t.Fatalf("event = %+v", payload)
+	}
+}
+
+func TestDecayStats(t *testing.T) {
+	game := config.Defaults().Game
+	now := time.Now()
+	fresh := models.NFT{Hunger: 100, Mood: 100, Energy: 100, LastFed: now, LastPlayed: now, LastInteract: now}
+
+	pets := []models.NFT{fresh, fresh, fresh, fresh, fresh}
+	for i := range pets {
+		pets[i].TokenID = uint(i + 1)
+		pets[i].OwnerAddress = "0xa"
+	}
+	// Due for one hunger period
+	pets[1].LastFed = now.Add(-game.HungerDecayPeriod)
+	// Starved: one period takes it to 0
+	pets[2].Hunger = game.HungerDecay
+	pets[2].LastFed = now.Add(-game.HungerDecayPeriod)
+	// Only regenerates energy
+	pets[3].Energy = 50
+	s, stores := newMemTamagotchiService(t, pets...)
+	// Already dead: never touched
+	if err := stores.NFTs().UpdateStats(&models.NFT{ID: 5, Hunger: 0, Mood: 100, Energy: 100, LastFed: now.Add(-48 * time.Hour), LastPlayed: now, LastInteract: now}); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := s.decayStats(context.Background(), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.Complete || report.Batches != 2 || report.Scanned != 4 || report.Updated != 3 || report.Died != 1 {
+		t.Fatalf("report = %+v, want 2 batches, 4 scanned, 3 updated, 1 died", report)
+	}
+
+	want := map[uint][3]int{
+		1: {100, 100, 100},
+		2: {100 - game.HungerDecay, 100, min(100, 100+game.EnergyRegen)},
+		3: {0, 100, 100},
+		4: {100, 100, min(100, 50+game.EnergyRegen)},
+		5: {0, 100, 100},
+	}
+	for tokenID, stats := range want {
+		pet, err := stores.NFTs().GetByTokenID(tokenID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := [3]int{pet.Hunger, pet.Mood, pet.Energy}; got != stats {
+			t.Errorf("pet %d stats = %v, want %v", tokenID, got, stats)
+		}
+	}
+
+	if types := outboxTypes(stores); len(types) != 1 || types[0] != events.TypePetDied {
+		t.Fatalf("outbox = %v, want one %s", types, events.TypePetDied)
+	}
+	// A finished pass leaves the cursor at 0 so the next one starts over
+	if cursor, _ := stores.Cursors().GetCursor(statDecayCursor); cursor != 0 {
+		t.Fatalf("cursor = %d after a complete pass", cursor)
+	}
+}
@@ -0,0 +1,139 @@
+// Package apperr classifies errors by what went wrong, so the API can answer
+// with the right status and a stable code whichever layer failed.
+//
+// Services declare the errors they can return as sentinels and wrap causes
+// into them:
+//
+//	var ErrPetNotFound = apperr.NotFound("pet_not_found", "pet not found")
+//
+//	if errors.Is(err, gorm.ErrRecordNotFound) {
+//		return nil, ErrPetNotFound.Wrap(err)
+//	}
+//
+// errors.Is matches by code, so callers can still test for the sentinel
+// after Wrap or With. Errors without a Kind, such as a DB outage, are
+// internal and their message is never shown to clients.
+package apperr
+
+import "errors"
+
+// Kind is the class of an error
+type Kind int
+
+const (
+	KindInternal     Kind = iota // A bug or an unclassified failure
+	KindValidation               // The request is malformed or breaks a rule
+	KindUnauthorized             // The caller is not authenticated
+	KindForbidden                // The caller may not do this
+	KindNotFound                 // The target does not exist
+	KindConflict                 // The target's state does not allow it
+	KindRateLimited              // The caller is over a rate limit
+	KindUpstream                 // A dependency, such as the chain RPC, failed
+	KindUnavailable              // A feature is not configured on this deployment
+)
+
+var kindNames = map[Kind]string{
+	KindInternal:     "internal",
+	KindValidation:   "validation",
+	KindUnauthorized: "unauthorized",
+	KindForbidden:    "forbidden",
+	KindNotFound:     "not_found",
+	KindConflict:     "conflict",
+	KindRateLimited:  "rate_limited",
+	KindUpstream:     "upstream",
+	KindUnavailable:  "unavailable",
+}
+
+func (k Kind) String() string {
+	if name, ok := kindNames[k]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// Error is a classified error
+type Error struct {
+	Kind    Kind
+	Code    string         // Stable and machine-readable, e.g. "pet_not_found"
+	Message string         // Shown to clients
+	Fields  map[string]any // Extra details shown to clients, e.g. retry_after
+	Err     error          // Cause; logged, never shown to clients
+}
+
+// New returns an error of a kind
+func New(kind Kind, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+// Validation, Unauthorized and the rest return a new error of their kind
+func Validation(code, message string) *Error   { return New(KindValidation, code, message) }
+func Unauthorized(code, message string) *Error { return New(KindUnauthorized, code, message) }
+func Forbidden(code, message string) *Error    { return New(KindForbidden, code, message) }
+func NotFound(code, message string) *Error     { return New(KindNotFound, code, message) }
+func Conflict(code, message string) *Error     { return New(KindConflict, code, message) }
+func RateLimited(code, message string) *Error  { return New(KindRateLimited, code, message) }
+func Upstream(code, message string) *Error     { return New(KindUpstream, code, message) }
+func Unavailable(code, message string) *Error  { return New(KindUnavailable, code, message) }
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is an *Error with the same code
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// Wrap returns a copy of e caused by err
+func (e *Error) Wrap(err error) *Error {
+	c := e.clone()
+	c.Err = err
+	return c
+}
+
+// WithMessage returns a copy of e with a more specific message
+func (e *Error) WithMessage(message string) *Error {
+	c := e.clone()
+	c.Message = message
+	return c
+}
+
+// With returns a copy of e with an extra field for clients
+func (e *Error) With(key string, value any) *Error {
+	c := e.clone()
+	c.Fields = make(map[string]any, len(e.Fields)+1)
+	for k, v := range e.Fields {
+		c.Fields[k] = v
+	}
+	c.Fields[key] = value
+	return c
+}
+
+func (e *Error) clone() *Error {
+	c := *e
+	return &c
+}
+
+// As returns the first *Error in err's chain
+func As(err error) (*Error, bool) {
+	var e *Error
+	ok := errors.As(err, &e)
+	return e, ok
+}
+
+// KindOf returns the kind of the first *Error in err's chain, or
+// KindInternal if there is none
+func KindOf(err error) Kind {
+	if e, ok := As(err); ok {
+		return e.Kind
+	}
+	return KindInternal
+}
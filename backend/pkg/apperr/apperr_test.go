@@ -0,0 +1,34 @@
+package apperr
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestError(t *testing.T) {
+	errMissing := NotFound("pet_not_found", "pet not found")
+	cause := errors.New("record not found")
+
+	err := fmt.Errorf("loading pet: %w", errMissing.Wrap(cause).With("token_id", 7))
+	if !errors.Is(err, errMissing) || !errors.Is(err, cause) {
+		t.Errorf("errors.Is lost the sentinel or the cause of %v", err)
+	}
+	if errors.Is(err, NotFound("listing_not_found", "listing not found")) {
+		t.Error("errors.Is matched a different code")
+	}
+	if KindOf(err) != KindNotFound || KindOf(cause) != KindInternal {
+		t.Errorf("KindOf = %v, %v", KindOf(err), KindOf(cause))
+	}
+
+	e, ok := As(err)
+	if !ok || e.Message != "pet not found" || e.Fields["token_id"] != 7 {
+		t.Fatalf("As = %+v, %v", e, ok)
+	}
+	if e.Error() != "pet not found: record not found" {
+		t.Errorf("Error() = %q", e.Error())
+	}
+	if errMissing.Err != nil || errMissing.Fields != nil {
+		t.Error("Wrap or With changed the sentinel")
+	}
+}
@@ -0,0 +1,67 @@
+// Package buildinfo reports which build of the backend is running.
+//
+// Release builds set the variables with ldflags:
+//
+//	go build -ldflags "-X brainrot-tamagotchi/pkg/buildinfo.Version=1.4.0 \
+//	  -X brainrot-tamagotchi/pkg/buildinfo.Commit=$(git rev-parse HEAD) \
+//	  -X brainrot-tamagotchi/pkg/buildinfo.BuildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)" ./cmd
+//
+// Unset values fall back to the VCS stamp the go tool embeds when building
+// inside a git checkout; BuildTime then shows the commit time.
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// Set with -ldflags "-X"
+var (
+	Version   = "dev"
+	Commit    = ""
+	BuildTime = ""
+)
+
+// Info describes the running build
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"build_time"`
+	Modified  bool   `json:"modified,omitempty"` // Built from a dirty checkout
+	GoVersion string `json:"go_version"`
+}
+
+// Get returns the running build
+func Get() Info {
+	info := Info{
+		Version:   Version,
+		Commit:    Commit,
+		BuildTime: BuildTime,
+		GoVersion: runtime.Version(),
+	}
+
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range bi.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				if info.Commit == "" {
+					info.Commit = s.Value
+				}
+			case "vcs.time":
+				if info.BuildTime == "" {
+					info.BuildTime = s.Value
+				}
+			case "vcs.modified":
+				info.Modified = s.Value == "true"
+			}
+		}
+	}
+
+	if info.Commit == "" {
+		info.Commit = "unknown"
+	}
+	if info.BuildTime == "" {
+		info.BuildTime = "unknown"
+	}
+	return info
+}
@@ -0,0 +1,18 @@
+package cache
+
+import (
+	"brainrot-tamagotchi/pkg/logging"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// NewRedisClient creates a Redis client from a redis:// URL
+func NewRedisClient(redisURL string) *redis.Client {
+	opts, err := redis.ParseURL(redisURL)
+	if err != nil {
+		logging.For("cache").Warn("invalid REDIS_URL, using localhost", "error", err)
+		opts = &redis.Options{Addr: "localhost:6379"}
+	}
+
+	return redis.NewClient(opts)
+}
@@ -0,0 +1,72 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// slogLogger adapts GORM's logger to slog. Failed queries log as errors and
+// queries slower than slow as warnings; the rest log at debug, so SQL only
+// shows up when the gorm component is set to debug. A missing record is not
+// an error here, since callers check for it.
+type slogLogger struct {
+	log  *slog.Logger
+	slow time.Duration
+}
+
+// NewLogger returns a GORM logger writing to log
+func NewLogger(log *slog.Logger, slow time.Duration) logger.Interface {
+	return &slogLogger{log: log, slow: slow}
+}
+
+// LogMode is a no-op; the slog level decides what is logged
+func (l *slogLogger) LogMode(logger.LogLevel) logger.Interface {
+	return l
+}
+
+func (l *slogLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	l.log.InfoContext(ctx, fmt.Sprintf(msg, args...))
+}
+
+func (l *slogLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	l.log.WarnContext(ctx, fmt.Sprintf(msg, args...))
+}
+
+func (l *slogLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	l.log.ErrorContext(ctx, fmt.Sprintf(msg, args...))
+}
+
+func (l *slogLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	elapsed := time.Since(begin)
+
+	var level slog.Level
+	var msg string
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound):
+		level, msg = slog.LevelError, "query failed"
+	case l.slow > 0 && elapsed > l.slow:
+		level, msg = slog.LevelWarn, "slow query"
+	default:
+		level, msg = slog.LevelDebug, "query"
+	}
+	if !l.log.Enabled(ctx, level) {
+		return
+	}
+
+	sql, rows := fc()
+	attrs := []slog.Attr{
+		slog.String("sql", sql),
+		slog.Int64("rows", rows),
+		slog.Float64("elapsed_ms", float64(elapsed.Microseconds())/1000),
+	}
+	if level == slog.LevelError {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	l.log.LogAttrs(ctx, level, msg, attrs...)
+}
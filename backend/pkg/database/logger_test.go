@@ -0,0 +1,41 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func TestLoggerLevels(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLogger(slog.New(slog.NewJSONHandler(&buf, nil)), 100*time.Millisecond)
+	sql := func() (string, int64) { return "SELECT 1", 1 }
+	ctx := context.Background()
+
+	l.Trace(ctx, time.Now(), sql, nil)                                   // Fast: debug, dropped
+	l.Trace(ctx, time.Now(), sql, gorm.ErrRecordNotFound)                // Not an error
+	l.Trace(ctx, time.Now().Add(-time.Second), sql, nil)                 // Slow
+	l.Trace(ctx, time.Now(), sql, errors.New("relation does not exist")) // Failed
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d records, want 2:\n%s", len(lines), buf.String())
+	}
+	want := []struct{ level, msg string }{{"WARN", "slow query"}, {"ERROR", "query failed"}}
+	for i, line := range lines {
+		var r map[string]any
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			t.Fatal(err)
+		}
+		if r["level"] != want[i].level || r["msg"] != want[i].msg || r["sql"] != "SELECT 1" {
+			t.Errorf("record %d = %v, want %s %q", i, r, want[i].level, want[i].msg)
+		}
+	}
+}
@@ -1,18 +1,317 @@
 package database
 
 import (
-	"brainrot-tamagotchi/internal/models"
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"gorm.io/gorm"
 )
 
-// AutoMigrate runs all database migrations
-func AutoMigrate(db *gorm.DB) error {
-	return db.AutoMigrate(
-		&models.User{},
-		&models.NFT{},
-		&models.MarketListing{},
-		&models.CaseOpening{},
-	)
+// MigrationsDir is where `migrate create` writes new migrations, relative to
+// the backend root. Files there are embedded into the binary at build time.
+const MigrationsDir = "pkg/database/migrations"
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationLockKey is the advisory lock held while migrating, so concurrent
+// boots apply each migration once. Any fixed bigint works; this one spells
+// "brainrot".
+const migrationLockKey int64 = 0x627261696e726f74
+
+// schemaMigrationsSQL creates the table recording applied versions
+const schemaMigrationsSQL = `CREATE TABLE IF NOT EXISTS schema_migrations (
+	version    bigint PRIMARY KEY,
+	name       text NOT NULL,
+	applied_at timestamptz NOT NULL DEFAULT now()
+)`
+
+// migrationFileName matches "0001_initial_schema.up.sql"
+var migrationFileName = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+var migrationName = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// Migration is one versioned schema change with its rollback
+type Migration struct {
+	Version uint
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus reports whether a migration has been applied
+type MigrationStatus struct {
+	Version   uint
+	Name      string
+	AppliedAt *time.Time
+	Missing   bool // Applied to the database but unknown to this binary
+}
+
+// LoadMigrations returns the embedded migrations in version order
+func LoadMigrations() ([]Migration, error) {
+	return loadMigrations(migrationFiles, "migrations")
 }
 
+func loadMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[uint]*Migration)
+	for _, entry := range entries {
+		m := migrationFileName.FindStringSubmatch(entry.Name())
+		if m == nil {
+			return nil, fmt.Errorf("migration %s: name must look like 0001_name.up.sql", entry.Name())
+		}
+		version, err := strconv.ParseUint(m[1], 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: %w", entry.Name(), err)
+		}
+		body, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[uint(version)]
+		if !ok {
+			migration = &Migration{Version: uint(version), Name: m[2]}
+			byVersion[uint(version)] = migration
+		}
+		if migration.Name != m[2] {
+			return nil, fmt.Errorf("migration %d is named both %s and %s", version, migration.Name, m[2])
+		}
+		if m[3] == "up" {
+			migration.Up = string(body)
+		} else {
+			migration.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %04d_%s needs both an up and a down file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// MigrateUp applies every pending migration in version order, each in its own
+// transaction, and returns the ones it applied
+func MigrateUp(db *gorm.DB) ([]Migration, error) {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []Migration
+	err = withMigrationLock(db, func(conn *sql.Conn) error {
+		done, err := appliedVersions(conn)
+		if err != nil {
+			return err
+		}
+		for _, migration := range migrations {
+			if _, ok := done[migration.Version]; ok {
+				continue
+			}
+			err := inTx(conn, func(tx *sql.Tx) error {
+				if _, err := tx.Exec(migration.Up); err != nil {
+					return err
+				}
+				_, err := tx.Exec(`INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`, migration.Version, migration.Name)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("migration %04d_%s failed: %w", migration.Version, migration.Name, err)
+			}
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// MigrateDown rolls back the latest steps applied migrations and returns them
+// in the order they were rolled back
+func MigrateDown(db *gorm.DB, steps int) ([]Migration, error) {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[uint]Migration, len(migrations))
+	for _, migration := range migrations {
+		known[migration.Version] = migration
+	}
+
+	var reverted []Migration
+	err = withMigrationLock(db, func(conn *sql.Conn) error {
+		done, err := appliedVersions(conn)
+		if err != nil {
+			return err
+		}
+		versions := make([]uint, 0, len(done))
+		for version := range done {
+			versions = append(versions, version)
+		}
+		sort.Slice(versions, func(i, j int) bool { return versions[i] > versions[j] })
+
+		for _, version := range versions {
+			if len(reverted) == steps {
+				break
+			}
+			migration, ok := known[version]
+			if !ok {
+				return fmt.Errorf("migration %d is applied but not in this build, so it cannot be rolled back", version)
+			}
+			err := inTx(conn, func(tx *sql.Tx) error {
+				if _, err := tx.Exec(migration.Down); err != nil {
+					return err
+				}
+				_, err := tx.Exec(`DELETE FROM schema_migrations WHERE version = $1`, migration.Version)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("rollback of %04d_%s failed: %w", migration.Version, migration.Name, err)
+			}
+			reverted = append(reverted, migration)
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// MigrationStatuses lists every migration known to this build or applied to
+// the database, in version order
+func MigrationStatuses(db *gorm.DB) ([]MigrationStatus, error) {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []MigrationStatus
+	err = withMigrationLock(db, func(conn *sql.Conn) error {
+		done, err := appliedVersions(conn)
+		if err != nil {
+			return err
+		}
+		for _, migration := range migrations {
+			status := MigrationStatus{Version: migration.Version, Name: migration.Name}
+			if row, ok := done[migration.Version]; ok {
+				status.AppliedAt = &row.appliedAt
+				delete(done, migration.Version)
+			}
+			statuses = append(statuses, status)
+		}
+		for version, row := range done {
+			appliedAt := row.appliedAt
+			statuses = append(statuses, MigrationStatus{Version: version, Name: row.name, AppliedAt: &appliedAt, Missing: true})
+		}
+		return nil
+	})
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, err
+}
+
+// CreateMigration writes an empty up/down pair to dir, numbered after the
+// highest version already there, and returns the two paths
+func CreateMigration(dir, name string) (string, string, error) {
+	name = strings.ToLower(strings.Join(strings.Fields(name), "_"))
+	if !migrationName.MatchString(name) {
+		return "", "", fmt.Errorf("migration name %q may only use letters, digits and underscores", name)
+	}
+
+	migrations, err := loadMigrations(os.DirFS(dir), ".")
+	if err != nil {
+		return "", "", err
+	}
+	var version uint = 1
+	if len(migrations) > 0 {
+		version = migrations[len(migrations)-1].Version + 1
+	}
+
+	base := filepath.Join(dir, fmt.Sprintf("%04d_%s", version, name))
+	up, down := base+".up.sql", base+".down.sql"
+	if err := os.WriteFile(up, []byte("-- Write the migration here\n"), 0o644); err != nil {
+		return "", "", err
+	}
+	if err := os.WriteFile(down, []byte("-- Undo the up migration here\n"), 0o644); err != nil {
+		os.Remove(up)
+		return "", "", err
+	}
+	return up, down, nil
+}
+
+// withMigrationLock runs fn on a dedicated connection holding the migration
+// advisory lock, waiting for any other migrator to finish first
+func withMigrationLock(db *gorm.DB, fn func(conn *sql.Conn) error) error {
+	ctx := context.Background()
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockKey); err != nil {
+		return fmt.Errorf("failed to take the migration lock: %w", err)
+	}
+	defer conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", migrationLockKey)
+
+	if _, err := conn.ExecContext(ctx, schemaMigrationsSQL); err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+type appliedMigration struct {
+	name      string
+	appliedAt time.Time
+}
+
+func appliedVersions(conn *sql.Conn) (map[uint]appliedMigration, error) {
+	rows, err := conn.QueryContext(context.Background(), `SELECT version, name, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	done := make(map[uint]appliedMigration)
+	for rows.Next() {
+		var version uint
+		var row appliedMigration
+		if err := rows.Scan(&version, &row.name, &row.appliedAt); err != nil {
+			return nil, err
+		}
+		done[version] = row
+	}
+	return done, rows.Err()
+}
+
+func inTx(conn *sql.Conn, fn func(tx *sql.Tx) error) error {
+	tx, err := conn.BeginTx(context.Background(), nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
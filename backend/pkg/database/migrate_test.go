@@ -0,0 +1,213 @@
+package database
+
+import (
+	"brainrot-tamagotchi/internal/models"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) == 0 || migrations[0].Version != 1 || migrations[0].Name != "initial_schema" {
+		t.Fatalf("first migration = %+v, want 0001_initial_schema", migrations)
+	}
+	for _, index := range []string{"idx_nfts_owner_address", "idx_market_listings_seller_address", "idx_market_listings_is_active"} {
+		if !strings.Contains(migrations[0].Up, index) {
+			t.Errorf("initial schema is missing %s", index)
+		}
+	}
+}
+
+func TestLoadMigrationsRejects(t *testing.T) {
+	cases := map[string]fstest.MapFS{
+		"missing down": {"m/0001_a.up.sql": {Data: []byte("SELECT 1")}},
+		"bad name":     {"m/1-a.up.sql": {Data: []byte("SELECT 1")}},
+		"renamed": {
+			"m/0001_a.up.sql":   {Data: []byte("SELECT 1")},
+			"m/0001_b.down.sql": {Data: []byte("SELECT 1")},
+		},
+	}
+	for name, fsys := range cases {
+		if _, err := loadMigrations(fsys, "m"); err == nil {
+			t.Errorf("%s: loaded without error", name)
+		}
+	}
+}
+
+func TestCreateMigration(t *testing.T) {
+	dir := t.TempDir()
+	up, down, err := CreateMigration(dir, "Add pet names")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(up) != "0001_add_pet_names.up.sql" || filepath.Base(down) != "0001_add_pet_names.down.sql" {
+		t.Fatalf("created %s and %s", up, down)
+	}
+
+	up, _, err = CreateMigration(dir, "drop_levels")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(up) != "0002_drop_levels.up.sql" {
+		t.Fatalf("second migration = %s, want version 0002", up)
+	}
+
+	if _, _, err := CreateMigration(dir, "no-dashes"); err == nil {
+		t.Fatal("accepted a name with dashes")
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 4 {
+		t.Fatalf("dir has %d files, want 4", len(entries))
+	}
+}
+
+// The baseline models, as AutoMigrate created their tables before versioned
+// migrations
+type baselineUser struct {
+	ID            uint   `gorm:"primarykey"`
+	WalletAddress string `gorm:"uniqueIndex;not null"`
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	DeletedAt     gorm.DeletedAt `gorm:"index"`
+}
+
+func (baselineUser) TableName() string { return "users" }
+
+type baselineNFT struct {
+	ID           uint   `gorm:"primarykey"`
+	TokenID      uint   `gorm:"uniqueIndex;not null"`
+	OwnerAddress string `gorm:"index;not null"`
+	MemeType     string
+	Rarity       string
+	Level        int `gorm:"default:1"`
+	ColorVariant int
+	TokenURI     string
+	Hunger       int `gorm:"default:100"`
+	Mood         int `gorm:"default:100"`
+	Energy       int `gorm:"default:100"`
+	LastFed      time.Time
+	LastPlayed   time.Time
+	LastInteract time.Time
+	TxHash       string
+	MintedAt     time.Time
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	DeletedAt    gorm.DeletedAt `gorm:"index"`
+}
+
+func (baselineNFT) TableName() string { return "nfts" }
+
+type baselineMarketListing struct {
+	ID            uint   `gorm:"primarykey"`
+	TokenID       uint   `gorm:"uniqueIndex;not null"`
+	SellerAddress string `gorm:"index;not null"`
+	Price         float64
+	IsActive      bool `gorm:"default:true;index"`
+	ListedAt      time.Time
+	SoldAt        *time.Time
+	BuyerAddress  *string
+	TxHash        string
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	DeletedAt     gorm.DeletedAt `gorm:"index"`
+}
+
+func (baselineMarketListing) TableName() string { return "market_listings" }
+
+type baselineCaseOpening struct {
+	ID          uint   `gorm:"primarykey"`
+	UserAddress string `gorm:"index;not null"`
+	CaseType    string
+	TokenID     uint
+	Rarity      string
+	MemeType    string
+	Price       float64
+	TxHash      string
+	OpenedAt    time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	DeletedAt   gorm.DeletedAt `gorm:"index"`
+}
+
+func (baselineCaseOpening) TableName() string { return "case_openings" }
+
+// baselineDB returns a connection to a fresh schema of the database at
+// TEST_DATABASE_URL, dropped when the test ends
+func baselineDB(t *testing.T) *gorm.DB {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	config := &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)}
+
+	admin, err := gorm.Open(postgres.Open(dsn), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const schema = "migrate_baseline_test"
+	if err := admin.Exec("DROP SCHEMA IF EXISTS " + schema + " CASCADE; CREATE SCHEMA " + schema).Error; err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { admin.Exec("DROP SCHEMA IF EXISTS " + schema + " CASCADE") })
+
+	switch {
+	case !strings.Contains(dsn, "://"):
+		dsn += " search_path=" + schema
+	case strings.Contains(dsn, "?"):
+		dsn += "&search_path=" + schema
+	default:
+		dsn += "?search_path=" + schema
+	}
+	db, err := gorm.Open(postgres.Open(dsn), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestMigrateUpFromBaseline(t *testing.T) {
+	db := baselineDB(t)
+	if err := db.AutoMigrate(&baselineUser{}, &baselineNFT{}, &baselineMarketListing{}, &baselineCaseOpening{}); err != nil {
+		t.Fatal(err)
+	}
+	listing := baselineMarketListing{TokenID: 7, SellerAddress: "0xa", Price: 0.25, IsActive: true}
+	if err := db.Create(&listing).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := MigrateUp(db); err != nil {
+		t.Fatalf("migrating a baseline database: %v", err)
+	}
+
+	// Every column of the current models exists on the baseline tables
+	for _, model := range []interface{}{&models.User{}, &models.NFT{}, &models.MarketListing{}, &models.CaseOpening{}} {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			t.Fatal(err)
+		}
+		for _, field := range stmt.Schema.Fields {
+			if field.DBName != "" && !db.Migrator().HasColumn(model, field.DBName) {
+				t.Errorf("%s.%s is missing", stmt.Schema.Table, field.DBName)
+			}
+		}
+	}
+
+	var migrated models.MarketListing
+	if err := db.First(&migrated, listing.ID).Error; err != nil {
+		t.Fatalf("reading a baseline listing: %v", err)
+	}
+	if migrated.Price.String() != "250000000000000000" || migrated.SaleTxHash != nil || migrated.LastEventBlock != 0 {
+		t.Fatalf("baseline listing = %+v, want its price in wei and no events", migrated)
+	}
+}
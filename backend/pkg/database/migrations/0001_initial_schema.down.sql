@@ -0,0 +1,17 @@
+DROP TABLE IF EXISTS sync_cursors;
+DROP TABLE IF EXISTS processed_chain_events;
+DROP TABLE IF EXISTS job_runs;
+DROP TABLE IF EXISTS outbox_events;
+DROP TABLE IF EXISTS notifications;
+DROP TABLE IF EXISTS notification_preferences;
+DROP TABLE IF EXISTS contract_call_jobs;
+DROP TABLE IF EXISTS wallet_bans;
+DROP TABLE IF EXISTS admin_audit_logs;
+DROP TABLE IF EXISTS admin_roles;
+DROP TABLE IF EXISTS reward_vouchers;
+DROP TABLE IF EXISTS case_definitions;
+DROP TABLE IF EXISTS case_openings;
+DROP TABLE IF EXISTS market_sales;
+DROP TABLE IF EXISTS market_listings;
+DROP TABLE IF EXISTS nfts;
+DROP TABLE IF EXISTS users;
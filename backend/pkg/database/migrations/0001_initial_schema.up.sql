@@ -0,0 +1,329 @@
+-- Schema as last produced by gorm AutoMigrate. Every statement is guarded so
+-- databases that were auto-migrated before versioned migrations adopt it as
+-- their baseline. Those databases only have users, nfts, market_listings and
+-- case_openings, in their original shape, so columns added to those tables
+-- since are added here too.
+
+CREATE TABLE IF NOT EXISTS users (
+    id             bigserial PRIMARY KEY,
+    wallet_address text NOT NULL,
+    created_at     timestamptz,
+    updated_at     timestamptz,
+    deleted_at     timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_wallet_address ON users (wallet_address);
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at);
+
+CREATE TABLE IF NOT EXISTS nfts (
+    id            bigserial PRIMARY KEY,
+    token_id      bigint NOT NULL,
+    owner_address text NOT NULL,
+    meme_type     text,
+    rarity        text,
+    level         bigint DEFAULT 1,
+    color_variant bigint,
+    token_uri     text,
+    hunger        bigint DEFAULT 100,
+    mood          bigint DEFAULT 100,
+    energy        bigint DEFAULT 100,
+    last_fed      timestamptz,
+    last_played   timestamptz,
+    last_interact timestamptz,
+    tx_hash       text,
+    minted_at     timestamptz,
+    created_at    timestamptz,
+    updated_at    timestamptz,
+    deleted_at    timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_nfts_token_id ON nfts (token_id);
+CREATE INDEX IF NOT EXISTS idx_nfts_owner_address ON nfts (owner_address);
+CREATE INDEX IF NOT EXISTS idx_nfts_deleted_at ON nfts (deleted_at);
+
+CREATE TABLE IF NOT EXISTS market_listings (
+    id                   bigserial PRIMARY KEY,
+    token_id             bigint NOT NULL,
+    seller_address       text NOT NULL,
+    price                numeric(78,0) NOT NULL DEFAULT 0,
+    is_active            boolean DEFAULT true,
+    listed_at            timestamptz,
+    sold_at              timestamptz,
+    buyer_address        text,
+    tx_hash              text,
+    sale_tx_hash         text,
+    cancel_tx_hash       text,
+    cancelled_at         timestamptz,
+    last_event_block     bigint,
+    last_event_log_index bigint,
+    created_at           timestamptz,
+    updated_at           timestamptz,
+    deleted_at           timestamptz
+);
+-- Existing rows have seen no events; the defaults keep them scannable
+ALTER TABLE market_listings
+    ADD COLUMN IF NOT EXISTS sale_tx_hash         text,
+    ADD COLUMN IF NOT EXISTS cancel_tx_hash       text,
+    ADD COLUMN IF NOT EXISTS cancelled_at         timestamptz,
+    ADD COLUMN IF NOT EXISTS last_event_block     bigint NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS last_event_log_index bigint NOT NULL DEFAULT 0;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_market_listings_token_id ON market_listings (token_id);
+CREATE INDEX IF NOT EXISTS idx_market_listings_seller_address ON market_listings (seller_address);
+CREATE INDEX IF NOT EXISTS idx_market_listings_is_active ON market_listings (is_active);
+CREATE INDEX IF NOT EXISTS idx_market_listings_deleted_at ON market_listings (deleted_at);
+
+CREATE TABLE IF NOT EXISTS market_sales (
+    id              bigserial PRIMARY KEY,
+    token_id        bigint NOT NULL,
+    seller_address  text NOT NULL,
+    buyer_address   text NOT NULL,
+    gross_price     numeric(78,0) NOT NULL,
+    fee_bps         bigint,
+    platform_fee    numeric(78,0) NOT NULL,
+    seller_proceeds numeric(78,0) NOT NULL,
+    tx_hash         text NOT NULL,
+    log_index       bigint NOT NULL,
+    sold_at         timestamptz,
+    created_at      timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_market_sale_tx_log ON market_sales (tx_hash, log_index);
+CREATE INDEX IF NOT EXISTS idx_market_sales_token_id ON market_sales (token_id);
+CREATE INDEX IF NOT EXISTS idx_market_sales_seller_address ON market_sales (seller_address);
+CREATE INDEX IF NOT EXISTS idx_market_sales_buyer_address ON market_sales (buyer_address);
+CREATE INDEX IF NOT EXISTS idx_market_sales_sold_at ON market_sales (sold_at);
+
+CREATE TABLE IF NOT EXISTS case_openings (
+    id           bigserial PRIMARY KEY,
+    user_address text NOT NULL,
+    case_type    text,
+    token_id     bigint,
+    rarity       text,
+    meme_type    text,
+    price        numeric(78,0) NOT NULL DEFAULT 0,
+    tx_hash      text,
+    opened_at    timestamptz,
+    created_at   timestamptz,
+    updated_at   timestamptz,
+    deleted_at   timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_case_openings_user_address ON case_openings (user_address);
+CREATE INDEX IF NOT EXISTS idx_case_openings_deleted_at ON case_openings (deleted_at);
+
+CREATE TABLE IF NOT EXISTS case_definitions (
+    id                 bigserial PRIMARY KEY,
+    slug               text NOT NULL,
+    name               text NOT NULL,
+    description        text,
+    artwork_url        text,
+    price              numeric(78,0) NOT NULL DEFAULT 0,
+    usd_target         decimal,
+    contract_case_type text,
+    is_active          boolean NOT NULL,
+    starts_at          timestamptz,
+    ends_at            timestamptz,
+    supply_cap         bigint,
+    rarity_weights     jsonb,
+    meme_weights       jsonb,
+    pity_rarity        text,
+    pity_hard_limit    bigint NOT NULL DEFAULT 0,
+    pity_soft_start    bigint NOT NULL DEFAULT 0,
+    sort_order         bigint NOT NULL DEFAULT 0,
+    created_at         timestamptz,
+    updated_at         timestamptz,
+    deleted_at         timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_case_definitions_slug ON case_definitions (slug);
+CREATE INDEX IF NOT EXISTS idx_case_definitions_contract_case_type ON case_definitions (contract_case_type);
+CREATE INDEX IF NOT EXISTS idx_case_definitions_deleted_at ON case_definitions (deleted_at);
+
+CREATE TABLE IF NOT EXISTS reward_vouchers (
+    id                bigserial PRIMARY KEY,
+    code              text NOT NULL,
+    wallet_address    text NOT NULL,
+    kind              text NOT NULL,
+    case_type         text,
+    rarity            text,
+    source_opening_id bigint,
+    status            text NOT NULL,
+    issued_at         timestamptz,
+    claimed_at        timestamptz,
+    fulfilled_at      timestamptz,
+    created_at        timestamptz,
+    updated_at        timestamptz,
+    deleted_at        timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_reward_vouchers_code ON reward_vouchers (code);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_reward_vouchers_source_opening_id ON reward_vouchers (source_opening_id);
+CREATE INDEX IF NOT EXISTS idx_reward_vouchers_wallet_address ON reward_vouchers (wallet_address);
+CREATE INDEX IF NOT EXISTS idx_reward_vouchers_case_type ON reward_vouchers (case_type);
+CREATE INDEX IF NOT EXISTS idx_reward_vouchers_status ON reward_vouchers (status);
+CREATE INDEX IF NOT EXISTS idx_reward_vouchers_deleted_at ON reward_vouchers (deleted_at);
+
+CREATE TABLE IF NOT EXISTS admin_roles (
+    id             bigserial PRIMARY KEY,
+    wallet_address text NOT NULL,
+    role           text NOT NULL,
+    granted_by     text,
+    created_at     timestamptz,
+    updated_at     timestamptz,
+    deleted_at     timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_admin_roles_wallet_address ON admin_roles (wallet_address);
+CREATE INDEX IF NOT EXISTS idx_admin_roles_deleted_at ON admin_roles (deleted_at);
+
+CREATE TABLE IF NOT EXISTS admin_audit_logs (
+    id            bigserial PRIMARY KEY,
+    actor_address text NOT NULL,
+    actor_role    text,
+    action        text NOT NULL,
+    target_type   text,
+    target_id     text,
+    reason        text,
+    details       jsonb,
+    status_code   bigint,
+    ip_address    text,
+    created_at    timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_admin_audit_logs_actor_address ON admin_audit_logs (actor_address);
+CREATE INDEX IF NOT EXISTS idx_admin_audit_logs_action ON admin_audit_logs (action);
+CREATE INDEX IF NOT EXISTS idx_audit_target ON admin_audit_logs (target_type, target_id);
+CREATE INDEX IF NOT EXISTS idx_admin_audit_logs_created_at ON admin_audit_logs (created_at);
+
+CREATE TABLE IF NOT EXISTS wallet_bans (
+    id             bigserial PRIMARY KEY,
+    wallet_address text NOT NULL,
+    reason         text NOT NULL,
+    banned_by      text NOT NULL,
+    expires_at     timestamptz,
+    created_at     timestamptz,
+    updated_at     timestamptz,
+    deleted_at     timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_wallet_bans_wallet_address ON wallet_bans (wallet_address);
+CREATE INDEX IF NOT EXISTS idx_wallet_bans_deleted_at ON wallet_bans (deleted_at);
+
+CREATE TABLE IF NOT EXISTS contract_call_jobs (
+    id           bigserial PRIMARY KEY,
+    contract     text NOT NULL,
+    method       text NOT NULL,
+    args         jsonb,
+    status       text NOT NULL,
+    tx_hash      text,
+    error        text,
+    attempts     bigint,
+    requested_by text NOT NULL,
+    reason       text NOT NULL,
+    sent_at      timestamptz,
+    finished_at  timestamptz,
+    created_at   timestamptz,
+    updated_at   timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_contract_call_jobs_status ON contract_call_jobs (status);
+
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    id             bigserial PRIMARY KEY,
+    wallet_address text NOT NULL,
+    channels       jsonb,
+    kinds          jsonb,
+    quiet_start    text,
+    quiet_end      text,
+    timezone       text NOT NULL DEFAULT 'UTC',
+    created_at     timestamptz,
+    updated_at     timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_notification_preferences_wallet_address ON notification_preferences (wallet_address);
+
+CREATE TABLE IF NOT EXISTS notifications (
+    id             bigserial PRIMARY KEY,
+    wallet_address text NOT NULL,
+    kind           text NOT NULL,
+    dedup_key      text NOT NULL,
+    title          text NOT NULL,
+    body           text NOT NULL,
+    data           jsonb,
+    status         text NOT NULL,
+    delivered      jsonb,
+    attempts       bigint,
+    last_error     text,
+    deliver_after  timestamptz,
+    sent_at        timestamptz,
+    created_at     timestamptz,
+    updated_at     timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_notifications_dedup_key ON notifications (dedup_key);
+CREATE INDEX IF NOT EXISTS idx_notifications_wallet_address ON notifications (wallet_address);
+CREATE INDEX IF NOT EXISTS idx_notifications_status ON notifications (status);
+CREATE INDEX IF NOT EXISTS idx_notifications_deliver_after ON notifications (deliver_after);
+
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id            bigserial PRIMARY KEY,
+    type          text NOT NULL,
+    payload       jsonb NOT NULL,
+    status        text NOT NULL,
+    attempts      bigint,
+    last_error    text,
+    available_at  timestamptz NOT NULL,
+    dispatched_at timestamptz,
+    created_at    timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_outbox_events_type ON outbox_events (type);
+CREATE INDEX IF NOT EXISTS idx_outbox_due ON outbox_events (status, available_at);
+
+CREATE TABLE IF NOT EXISTS job_runs (
+    id          bigserial PRIMARY KEY,
+    job_name    text NOT NULL,
+    trigger     text NOT NULL,
+    instance    text NOT NULL,
+    status      text NOT NULL,
+    error       text,
+    started_at  timestamptz NOT NULL,
+    finished_at timestamptz,
+    duration_ms bigint
+);
+CREATE INDEX IF NOT EXISTS idx_job_runs_job_name ON job_runs (job_name);
+CREATE INDEX IF NOT EXISTS idx_job_runs_started_at ON job_runs (started_at);
+
+CREATE TABLE IF NOT EXISTS processed_chain_events (
+    id           bigserial PRIMARY KEY,
+    tx_hash      text NOT NULL,
+    log_index    bigint NOT NULL,
+    block_number bigint,
+    contract     text,
+    event_name   text,
+    token_id     bigint,
+    created_at   timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_chain_event_tx_log ON processed_chain_events (tx_hash, log_index);
+CREATE INDEX IF NOT EXISTS idx_processed_chain_events_block_number ON processed_chain_events (block_number);
+CREATE INDEX IF NOT EXISTS idx_processed_chain_events_token_id ON processed_chain_events (token_id);
+
+CREATE TABLE IF NOT EXISTS sync_cursors (
+    name         text PRIMARY KEY,
+    block_number bigint,
+    updated_at   timestamptz
+);
+
+-- Amounts used to be float64 ETH, stored by gorm as unconstrained numeric or
+-- float columns. Scale any that survive to NUMERIC(78,0) wei.
+DO $$
+DECLARE
+    c record;
+BEGIN
+    FOR c IN
+        SELECT table_name, column_name FROM information_schema.columns
+        WHERE table_schema = current_schema()
+          AND (table_name, column_name) IN (
+              ('market_listings', 'price'),
+              ('case_openings', 'price'),
+              ('market_sales', 'gross_price'),
+              ('market_sales', 'platform_fee'),
+              ('market_sales', 'seller_proceeds'))
+          AND (data_type IN ('double precision', 'real')
+               OR (data_type = 'numeric' AND numeric_precision IS NULL))
+    LOOP
+        EXECUTE format(
+            'ALTER TABLE %I ALTER COLUMN %I TYPE NUMERIC(78,0) USING ROUND(%I::numeric * 1000000000000000000)',
+            c.table_name, c.column_name, c.column_name);
+    END LOOP;
+END
+$$;
+ALTER TABLE market_listings ALTER COLUMN price SET DEFAULT 0, ALTER COLUMN price SET NOT NULL;
+ALTER TABLE case_openings ALTER COLUMN price SET DEFAULT 0, ALTER COLUMN price SET NOT NULL;
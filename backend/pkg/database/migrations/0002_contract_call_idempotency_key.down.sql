@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_contract_call_jobs_pending_key;
+ALTER TABLE contract_call_jobs DROP COLUMN IF EXISTS idempotency_key;
@@ -0,0 +1,5 @@
+-- Owner calls queued by background jobs carry a key so a job that runs again
+-- before its call is mined does not queue a second one.
+ALTER TABLE contract_call_jobs ADD COLUMN IF NOT EXISTS idempotency_key text;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_contract_call_jobs_pending_key ON contract_call_jobs (idempotency_key)
+    WHERE status IN ('queued', 'sent');
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_case_opening_tx_log;
+ALTER TABLE case_openings DROP COLUMN IF EXISTS log_index;
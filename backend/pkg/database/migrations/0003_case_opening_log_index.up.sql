@@ -0,0 +1,5 @@
+-- Case openings are recorded from CaseOpened logs, once per (tx hash, log
+-- index). Rows written before that have no tx hash and are left alone.
+ALTER TABLE case_openings ADD COLUMN IF NOT EXISTS log_index bigint NOT NULL DEFAULT 0;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_case_opening_tx_log ON case_openings (tx_hash, log_index)
+    WHERE tx_hash <> '';
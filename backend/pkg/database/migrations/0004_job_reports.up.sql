@@ -0,0 +1,7 @@
+-- The last report of each reporting job, so any instance can serve it and
+-- not only the leader that ran the job.
+CREATE TABLE IF NOT EXISTS job_reports (
+    job_name    text PRIMARY KEY,
+    report      jsonb NOT NULL,
+    finished_at timestamptz NOT NULL
+);
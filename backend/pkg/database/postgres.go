@@ -1,48 +1,31 @@
 package database
 
 import (
+	"brainrot-tamagotchi/pkg/logging"
 	"fmt"
-	"os"
+	"time"
 
+	"github.com/uptrace/opentelemetry-go-extra/otelgorm"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
 )
 
-// NewPostgresDB creates a new PostgreSQL database connection
-func NewPostgresDB() (*gorm.DB, error) {
-	dsn := os.Getenv("DATABASE_URL")
-	if dsn == "" {
-		// Build DSN from individual env vars
-		host := getEnv("DB_HOST", "localhost")
-		port := getEnv("DB_PORT", "5432")
-		user := getEnv("DB_USER", "postgres")
-		password := getEnv("DB_PASSWORD", "")
-		dbname := getEnv("DB_NAME", "brainrot")
-		sslmode := getEnv("DB_SSLMODE", "disable")
-
-		dsn = fmt.Sprintf(
-			"host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
-			host, port, user, password, dbname, sslmode,
-		)
-	}
-
+// NewPostgresDB creates a new PostgreSQL database connection. Queries
+// slower than slowQuery are logged as warnings. Every query gets a span,
+// nested under the span of its context when it has one.
+func NewPostgresDB(dsn string, slowQuery time.Duration) (*gorm.DB, error) {
 	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info),
+		Logger: NewLogger(logging.For("gorm"), slowQuery),
 	})
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
-	return db, nil
-}
-
-func getEnv(key, defaultValue string) string {
-	value := os.Getenv(key)
-	if value == "" {
-		return defaultValue
+	// Query arguments stay out of spans; they can hold wallet addresses
+	if err := db.Use(otelgorm.NewPlugin(otelgorm.WithoutQueryVariables(), otelgorm.WithoutMetrics())); err != nil {
+		return nil, fmt.Errorf("failed to install tracing: %w", err)
 	}
-	return value
-}
 
+	return db, nil
+}
@@ -0,0 +1,216 @@
+// Package logging configures structured logging on top of log/slog.
+//
+// Setup installs the process-wide handler. Each package logs through its own
+// component logger from For, whose level can be set separately, and values
+// attached to a context with With (the request ID, a job's name and run ID)
+// are added to every record logged with that context, as are the trace and
+// span IDs of the OpenTelemetry span it carries.
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Options configures Setup
+type Options struct {
+	Level  string            // Default minimum level: debug, info, warn or error
+	Format string            // json or text
+	Levels map[string]string // Minimum level per component, overriding Level
+}
+
+// current is what Setup installed; component loggers read it when they log,
+// so loggers created before Setup, such as package variables, follow it
+var current atomic.Pointer[setup]
+
+type setup struct {
+	root   slog.Handler
+	level  slog.Level
+	levels map[string]slog.Level
+}
+
+func init() {
+	current.Store(&setup{root: slog.NewTextHandler(os.Stderr, nil), level: slog.LevelInfo})
+}
+
+// Setup makes w the destination of all logs, including the standard log
+// package's. Component loggers must be created after it is called.
+func Setup(w io.Writer, opts Options) error {
+	lvl, err := ParseLevel(opts.Level)
+	if err != nil {
+		return err
+	}
+	perComponent := make(map[string]slog.Level, len(opts.Levels))
+	for name, value := range opts.Levels {
+		l, err := ParseLevel(value)
+		if err != nil {
+			return fmt.Errorf("component %s: %w", name, err)
+		}
+		perComponent[name] = l
+	}
+
+	// The inner handler lets everything through; levelHandler filters
+	handlerOpts := &slog.HandlerOptions{Level: slog.LevelDebug - 4}
+	var h slog.Handler
+	switch strings.ToLower(opts.Format) {
+	case "", "json":
+		h = slog.NewJSONHandler(w, handlerOpts)
+	case "text":
+		h = slog.NewTextHandler(w, handlerOpts)
+	default:
+		return fmt.Errorf("unknown log format %q (want json or text)", opts.Format)
+	}
+
+	current.Store(&setup{root: h, level: lvl, levels: perComponent})
+
+	// This also routes the standard log package, used by dependencies, to h
+	slog.SetDefault(For(""))
+	return nil
+}
+
+// ParseLevel parses debug, info, warn or error. An empty string is info.
+func ParseLevel(s string) (slog.Level, error) {
+	var l slog.Level
+	if s == "" {
+		return slog.LevelInfo, nil
+	}
+	if err := l.UnmarshalText([]byte(s)); err != nil {
+		return 0, fmt.Errorf("unknown log level %q (want debug, info, warn or error)", s)
+	}
+	return l, nil
+}
+
+// For returns the logger for a component, usually the package name. Its
+// records carry component=name and use the component's level if one is set.
+// It may be called before Setup.
+func For(component string) *slog.Logger {
+	l := slog.New(&levelHandler{component: component})
+	if component != "" {
+		l = l.With("component", component)
+	}
+	return l
+}
+
+type ctxKey struct{}
+
+// With returns a copy of ctx whose log records also carry args, given as
+// alternating keys and values like slog.Logger.With
+func With(ctx context.Context, args ...any) context.Context {
+	attrs := argsToAttrs(args)
+	if prev, ok := ctx.Value(ctxKey{}).([]slog.Attr); ok {
+		attrs = append(append([]slog.Attr(nil), prev...), attrs...)
+	}
+	return context.WithValue(ctx, ctxKey{}, attrs)
+}
+
+// Attrs returns the attributes attached to ctx by With
+func Attrs(ctx context.Context) []slog.Attr {
+	attrs, _ := ctx.Value(ctxKey{}).([]slog.Attr)
+	return attrs
+}
+
+type requestIDKey struct{}
+
+// WithRequestID attaches a request ID to ctx, both for logs and for
+// RequestID
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return With(context.WithValue(ctx, requestIDKey{}, id), "request_id", id)
+}
+
+// RequestID returns the request ID attached to ctx, or ""
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// NewID returns a random 16 character hex ID for requests and job runs
+func NewID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func argsToAttrs(args []any) []slog.Attr {
+	var r slog.Record
+	r.Add(args...)
+	attrs := make([]slog.Attr, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+	return attrs
+}
+
+// levelHandler applies its component's level and adds the context's
+// attributes. It rebuilds its inner handler, replaying With and WithGroup
+// calls in ops, whenever Setup has installed a new root.
+type levelHandler struct {
+	component string
+	ops       []func(slog.Handler) slog.Handler
+	built     atomic.Pointer[built]
+}
+
+type built struct {
+	from  *setup
+	inner slog.Handler
+	level slog.Level
+}
+
+func (h *levelHandler) resolve() *built {
+	cur := current.Load()
+	if b := h.built.Load(); b != nil && b.from == cur {
+		return b
+	}
+	b := &built{from: cur, inner: cur.root, level: cur.level}
+	if l, ok := cur.levels[h.component]; ok {
+		b.level = l
+	}
+	for _, op := range h.ops {
+		b.inner = op(b.inner)
+	}
+	h.built.Store(b)
+	return b
+}
+
+func (h *levelHandler) Enabled(_ context.Context, l slog.Level) bool {
+	return l >= h.resolve().level
+}
+
+func (h *levelHandler) Handle(ctx context.Context, r slog.Record) error {
+	if ctx != nil {
+		attrs := Attrs(ctx)
+		if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+			attrs = append(attrs[:len(attrs):len(attrs)],
+				slog.String("trace_id", sc.TraceID().String()),
+				slog.String("span_id", sc.SpanID().String()),
+			)
+		}
+		if len(attrs) > 0 {
+			r = r.Clone()
+			r.AddAttrs(attrs...)
+		}
+	}
+	return h.resolve().inner.Handle(ctx, r)
+}
+
+func (h *levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h.with(func(inner slog.Handler) slog.Handler { return inner.WithAttrs(attrs) })
+}
+
+func (h *levelHandler) WithGroup(name string) slog.Handler {
+	return h.with(func(inner slog.Handler) slog.Handler { return inner.WithGroup(name) })
+}
+
+func (h *levelHandler) with(op func(slog.Handler) slog.Handler) slog.Handler {
+	ops := append(append([]func(slog.Handler) slog.Handler(nil), h.ops...), op)
+	return &levelHandler{component: h.component, ops: ops}
+}
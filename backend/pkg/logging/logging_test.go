@@ -0,0 +1,95 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+func decodeLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var records []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var m map[string]any
+		if err := json.Unmarshal([]byte(line), &m); err != nil {
+			t.Fatalf("not JSON: %q", line)
+		}
+		records = append(records, m)
+	}
+	return records
+}
+
+func TestComponentLevelsAndContext(t *testing.T) {
+	// Created before Setup, like a package variable
+	early := For("scheduler").With("instance", "a")
+
+	var buf bytes.Buffer
+	err := Setup(&buf, Options{Level: "info", Format: "json", Levels: map[string]string{"gorm": "warn", "scheduler": "debug"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := WithRequestID(context.Background(), "req1")
+	ctx = With(ctx, "job", "stat_decay")
+
+	For("gorm").InfoContext(ctx, "dropped")
+	For("gorm").WarnContext(ctx, "slow query")
+	For("api").Debug("dropped")
+	early.DebugContext(ctx, "election")
+
+	records := decodeLines(t, &buf)
+	if len(records) != 2 {
+		t.Fatalf("got %d records, want 2: %s", len(records), buf.String())
+	}
+	if r := records[0]; r["msg"] != "slow query" || r["component"] != "gorm" || r["request_id"] != "req1" || r["job"] != "stat_decay" {
+		t.Errorf("gorm record = %v", r)
+	}
+	if r := records[1]; r["msg"] != "election" || r["component"] != "scheduler" || r["instance"] != "a" {
+		t.Errorf("scheduler record = %v", r)
+	}
+	if RequestID(ctx) != "req1" {
+		t.Errorf("RequestID = %q", RequestID(ctx))
+	}
+}
+
+func TestSetupRejects(t *testing.T) {
+	for _, opts := range []Options{
+		{Level: "loud"},
+		{Format: "xml"},
+		{Levels: map[string]string{"gorm": "verbose"}},
+	} {
+		if err := Setup(&bytes.Buffer{}, opts); err == nil {
+			t.Errorf("Setup(%+v) succeeded", opts)
+		}
+	}
+}
+
+func TestTraceIDs(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Setup(&buf, Options{Level: "info", Format: "json"}); err != nil {
+		t.Fatal(err)
+	}
+
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+		SpanID:  trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+	})
+	ctx := trace.ContextWithSpanContext(WithRequestID(context.Background(), "req1"), sc)
+	For("api").InfoContext(ctx, "traced")
+	For("api").Info("untraced")
+
+	records := decodeLines(t, &buf)
+	if r := records[0]; r["trace_id"] != "4bf92f3577b34da6a3ce929d0e0e4736" || r["span_id"] != "00f067aa0ba902b7" || r["request_id"] != "req1" {
+		t.Errorf("traced record = %v", r)
+	}
+	if _, ok := records[1]["trace_id"]; ok {
+		t.Errorf("untraced record = %v", records[1])
+	}
+}
@@ -0,0 +1,242 @@
+// Package money provides an exact, wei-denominated amount type for ETH prices.
+package money
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+)
+
+// WeiPerEther is 10^18
+var WeiPerEther = new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil)
+
+// etherPattern is a plain decimal number; big.Rat would also take fractions,
+// exponents and hex
+var etherPattern = regexp.MustCompile(`^-?(\d+(\.\d*)?|\.\d+)$`)
+
+// Wei is an exact amount of wei. The zero value is 0 wei.
+//
+// It is stored as NUMERIC(78,0), which holds any uint256, and marshals to
+// JSON as a decimal string so no precision is lost in JavaScript clients.
+type Wei struct {
+	v *big.Int
+}
+
+// NewWei copies a big.Int into a Wei. A nil value is 0 wei.
+func NewWei(v *big.Int) Wei {
+	if v == nil {
+		return Wei{}
+	}
+	return Wei{v: new(big.Int).Set(v)}
+}
+
+// WeiFromInt64 builds a Wei from an int64 amount of wei
+func WeiFromInt64(v int64) Wei {
+	return Wei{v: big.NewInt(v)}
+}
+
+// ParseWei parses a base-10 integer amount of wei
+func ParseWei(s string) (Wei, error) {
+	v, ok := new(big.Int).SetString(strings.TrimSpace(s), 10)
+	if !ok {
+		return Wei{}, fmt.Errorf("invalid wei amount: %q", s)
+	}
+	return Wei{v: v}, nil
+}
+
+// ParseEther parses a decimal ETH amount such as "0.0005" exactly.
+// Amounts with more than 18 decimals are rejected rather than rounded.
+func ParseEther(s string) (Wei, error) {
+	trimmed := strings.TrimSpace(s)
+	if !etherPattern.MatchString(trimmed) {
+		return Wei{}, fmt.Errorf("invalid ether amount: %q", s)
+	}
+	r, ok := new(big.Rat).SetString(trimmed)
+	if !ok {
+		return Wei{}, fmt.Errorf("invalid ether amount: %q", s)
+	}
+	r.Mul(r, new(big.Rat).SetInt(WeiPerEther))
+	if !r.IsInt() {
+		return Wei{}, fmt.Errorf("ether amount has more than 18 decimals: %q", s)
+	}
+	return Wei{v: new(big.Int).Set(r.Num())}, nil
+}
+
+// MustParseEther is ParseEther for constants; it panics on invalid input
+func MustParseEther(s string) Wei {
+	w, err := ParseEther(s)
+	if err != nil {
+		panic(err)
+	}
+	return w
+}
+
+// BigInt returns a copy of the amount
+func (w Wei) BigInt() *big.Int {
+	if w.v == nil {
+		return new(big.Int)
+	}
+	return new(big.Int).Set(w.v)
+}
+
+func (w Wei) int() *big.Int {
+	if w.v == nil {
+		return new(big.Int)
+	}
+	return w.v
+}
+
+// String returns the amount in wei as a base-10 integer
+func (w Wei) String() string {
+	return w.int().String()
+}
+
+// Ether formats the amount in ETH without trailing zeros, e.g. "0.0005"
+func (w Wei) Ether() string {
+	quo, rem := new(big.Int).QuoRem(w.int(), WeiPerEther, new(big.Int))
+	if rem.Sign() == 0 {
+		return quo.String()
+	}
+
+	sign := ""
+	if w.Sign() < 0 {
+		sign = "-"
+		quo.Abs(quo)
+		rem.Abs(rem)
+	}
+	frac := strings.TrimRight(fmt.Sprintf("%018s", rem.String()), "0")
+	return fmt.Sprintf("%s%s.%s", sign, quo.String(), frac)
+}
+
+// EtherFloat approximates the amount in ETH, for display and price feeds only
+func (w Wei) EtherFloat() float64 {
+	f, _ := new(big.Rat).SetFrac(w.int(), WeiPerEther).Float64()
+	return f
+}
+
+// Add returns w + o
+func (w Wei) Add(o Wei) Wei {
+	return Wei{v: new(big.Int).Add(w.int(), o.int())}
+}
+
+// Sub returns w - o
+func (w Wei) Sub(o Wei) Wei {
+	return Wei{v: new(big.Int).Sub(w.int(), o.int())}
+}
+
+// MulInt64 returns w * n
+func (w Wei) MulInt64(n int64) Wei {
+	return Wei{v: new(big.Int).Mul(w.int(), big.NewInt(n))}
+}
+
+// MulBps returns w * bps / 10000 rounded down, the way the contracts compute fees
+func (w Wei) MulBps(bps int64) Wei {
+	v := new(big.Int).Mul(w.int(), big.NewInt(bps))
+	return Wei{v: v.Quo(v, big.NewInt(10000))}
+}
+
+// MulFloat scales w by a ratio such as a probability, rounding down
+func (w Wei) MulFloat(f float64) Wei {
+	r := new(big.Rat).SetFloat64(f)
+	if r == nil {
+		return Wei{}
+	}
+	r.Mul(r, new(big.Rat).SetInt(w.int()))
+	return Wei{v: new(big.Int).Quo(r.Num(), r.Denom())}
+}
+
+// DivInt64 returns w / n rounded down
+func (w Wei) DivInt64(n int64) Wei {
+	if n == 0 {
+		return Wei{}
+	}
+	return Wei{v: new(big.Int).Quo(w.int(), big.NewInt(n))}
+}
+
+// Cmp compares w and o and returns -1, 0 or +1
+func (w Wei) Cmp(o Wei) int {
+	return w.int().Cmp(o.int())
+}
+
+// Sign returns -1, 0 or +1
+func (w Wei) Sign() int {
+	return w.int().Sign()
+}
+
+// IsZero reports whether the amount is 0 wei
+func (w Wei) IsZero() bool {
+	return w.Sign() == 0
+}
+
+// MarshalJSON encodes the amount as a decimal string of wei
+func (w Wei) MarshalJSON() ([]byte, error) {
+	return json.Marshal(w.String())
+}
+
+// UnmarshalJSON accepts a decimal string or an integer JSON number of wei
+func (w *Wei) UnmarshalJSON(data []byte) error {
+	s := strings.TrimSpace(string(data))
+	if s == "null" {
+		*w = Wei{}
+		return nil
+	}
+	if strings.HasPrefix(s, `"`) {
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+	}
+	parsed, err := ParseWei(s)
+	if err != nil {
+		return err
+	}
+	*w = parsed
+	return nil
+}
+
+// GormDataType sizes the column for any uint256
+func (Wei) GormDataType() string {
+	return "numeric(78,0)"
+}
+
+// Value implements driver.Valuer
+func (w Wei) Value() (driver.Value, error) {
+	return w.String(), nil
+}
+
+// Scan implements sql.Scanner. Aggregates such as AVG can return fractional
+// numerics; the fraction is truncated toward zero.
+func (w *Wei) Scan(src interface{}) error {
+	var s string
+	switch v := src.(type) {
+	case nil:
+		*w = Wei{}
+		return nil
+	case []byte:
+		s = string(v)
+	case string:
+		s = v
+	case int64:
+		*w = WeiFromInt64(v)
+		return nil
+	case float64:
+		return fmt.Errorf("cannot scan float %v into Wei without losing precision", v)
+	default:
+		return fmt.Errorf("cannot scan %T into Wei", src)
+	}
+
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		s = s[:i]
+	}
+	if s == "" || s == "-" {
+		s = "0"
+	}
+	parsed, err := ParseWei(s)
+	if err != nil {
+		return err
+	}
+	*w = parsed
+	return nil
+}
@@ -0,0 +1,249 @@
+package money
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+)
+
+// maxUint256 is the largest amount a contract can hold
+const maxUint256 = "115792089237316195423570985008687907853269984665640564039457584007913129639935"
+
+func TestParseEther(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string // wei
+		wantErr bool
+	}{
+		{"1", "1000000000000000000", false},
+		{"0.0005", "500000000000000", false},
+		{" 2.5 ", "2500000000000000000", false},
+		{"0.000000000000000001", "1", false},
+		{"-0.25", "-250000000000000000", false},
+		{"0", "0", false},
+		{"1000000000000", "1000000000000000000000000000000", false},
+		{"0.0000000000000000001", "", true}, // 19 decimals
+		{"1.0000000000000000005", "", true},
+		{"", "", true},
+		{"abc", "", true},
+		{"0x10", "", true},
+		{"1/2", "", true},
+		{"1e-3", "", true},
+		{".5", "500000000000000000", false},
+	}
+	for _, tt := range tests {
+		got, err := ParseEther(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseEther(%q) = %s, want an error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseEther(%q): %v", tt.in, err)
+			continue
+		}
+		if got.String() != tt.want {
+			t.Errorf("ParseEther(%q) = %s wei, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseWei(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"0", "0", false},
+		{"42", "42", false},
+		{"-42", "-42", false},
+		{maxUint256, maxUint256, false},
+		{"1" + maxUint256, "1" + maxUint256, false},
+		{"1.5", "", true},
+		{"1e18", "", true},
+		{"", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ParseWei(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseWei(%q) = %s, want an error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseWei(%q): %v", tt.in, err)
+			continue
+		}
+		if got.String() != tt.want {
+			t.Errorf("ParseWei(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestEther(t *testing.T) {
+	tests := []struct {
+		wei  string
+		want string
+	}{
+		{"0", "0"},
+		{"1000000000000000000", "1"},
+		{"1500000000000000000", "1.5"},
+		{"500000000000000", "0.0005"},
+		{"1", "0.000000000000000001"},
+		{"-250000000000000000", "-0.25"},
+		{"-1000000000000000000", "-1"},
+		{"-1500000000000000000", "-1.5"},
+		{maxUint256, "115792089237316195423570985008687907853269984665640564039457.584007913129639935"},
+	}
+	for _, tt := range tests {
+		w, err := ParseWei(tt.wei)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := w.Ether(); got != tt.want {
+			t.Errorf("Ether(%s wei) = %s, want %s", tt.wei, got, tt.want)
+		}
+	}
+
+	if got := (Wei{}).Ether(); got != "0" {
+		t.Errorf("zero value Ether() = %s, want 0", got)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	type listing struct {
+		Price Wei `json:"price"`
+	}
+
+	for _, wei := range []string{"0", "500000000000000", "-7", maxUint256} {
+		in := listing{Price: mustParseWei(t, wei)}
+		data, err := json.Marshal(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := `{"price":"` + wei + `"}`; string(data) != want {
+			t.Errorf("Marshal = %s, want %s", data, want)
+		}
+
+		var out listing
+		if err := json.Unmarshal(data, &out); err != nil {
+			t.Fatalf("Unmarshal(%s): %v", data, err)
+		}
+		if out.Price.Cmp(in.Price) != 0 {
+			t.Errorf("round trip of %s wei = %s", wei, out.Price)
+		}
+	}
+
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{`{"price":12}`, "12", false},
+		{`{"price":null}`, "0", false},
+		{`{"price":"1.5"}`, "", true},
+		{`{"price":1.5}`, "", true},
+		{`{"price":"abc"}`, "", true},
+	}
+	for _, tt := range tests {
+		var out listing
+		err := json.Unmarshal([]byte(tt.in), &out)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Unmarshal(%s) = %s, want an error", tt.in, out.Price)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Unmarshal(%s): %v", tt.in, err)
+			continue
+		}
+		if out.Price.String() != tt.want {
+			t.Errorf("Unmarshal(%s) = %s, want %s", tt.in, out.Price, tt.want)
+		}
+	}
+}
+
+func TestScan(t *testing.T) {
+	tests := []struct {
+		src     interface{}
+		want    string
+		wantErr bool
+	}{
+		{nil, "0", false},
+		{int64(42), "42", false},
+		{[]byte(maxUint256), maxUint256, false},
+		{"1500", "1500", false},
+		// AVG returns fractional numerics, which truncate toward zero
+		{"12.99", "12", false},
+		{[]byte("-12.99"), "-12", false},
+		{"-0.5", "0", false},
+		{".5", "0", false},
+		{float64(1.5), "", true},
+		{true, "", true},
+		{"abc", "", true},
+	}
+	for _, tt := range tests {
+		w := WeiFromInt64(99)
+		err := w.Scan(tt.src)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Scan(%#v) = %s, want an error", tt.src, w)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Scan(%#v): %v", tt.src, err)
+			continue
+		}
+		if w.String() != tt.want {
+			t.Errorf("Scan(%#v) = %s, want %s", tt.src, w, tt.want)
+		}
+	}
+
+	// Value and Scan round trip through the database's text form
+	v, err := mustParseWei(t, maxUint256).Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var w Wei
+	if err := w.Scan(v); err != nil || w.String() != maxUint256 {
+		t.Errorf("Scan(Value()) = %s, %v", w, err)
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	price := MustParseEther("1.5")
+	if got := price.MulBps(250); got.Ether() != "0.0375" {
+		t.Errorf("MulBps(250) = %s ETH, want 0.0375", got.Ether())
+	}
+	if got := WeiFromInt64(10).DivInt64(3); got.String() != "3" {
+		t.Errorf("10 / 3 = %s wei, want 3", got)
+	}
+	if got := WeiFromInt64(10).DivInt64(0); !got.IsZero() {
+		t.Errorf("10 / 0 = %s wei, want 0", got)
+	}
+	if got := WeiFromInt64(7).MulFloat(0.5); got.String() != "3" {
+		t.Errorf("7 * 0.5 = %s wei, want 3", got)
+	}
+
+	// NewWei and BigInt copy, so callers cannot change an amount in place
+	src := big.NewInt(5)
+	w := NewWei(src)
+	src.SetInt64(6)
+	w.BigInt().SetInt64(7)
+	if w.String() != "5" {
+		t.Errorf("amount changed through an alias: %s", w)
+	}
+}
+
+func mustParseWei(t *testing.T, s string) Wei {
+	t.Helper()
+	w, err := ParseWei(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w
+}
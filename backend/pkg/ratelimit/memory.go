@@ -0,0 +1,80 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// memorySweepInterval is how often idle buckets are dropped
+const memorySweepInterval = time.Minute
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	window  time.Duration
+}
+
+// Memory is an in-process token-bucket limiter. Limits are per process, so
+// with several replicas each one allows the full rate.
+type Memory struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time // Replaced in tests
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		buckets:   make(map[string]*bucket),
+		lastSweep: time.Now(),
+		now:       time.Now,
+	}
+}
+
+// Allow implements Limiter
+func (m *Memory) Allow(ctx context.Context, key string, rule Rule) (Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	m.sweep(now)
+
+	capacity := float64(rule.Limit)
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: capacity, updated: now}
+		m.buckets[key] = b
+	}
+	b.window = rule.Window
+
+	elapsed := float64(now.Sub(b.updated).Milliseconds())
+	b.tokens = math.Min(capacity, b.tokens+elapsed*rule.refillPerMs())
+	b.updated = now
+
+	result := Result{Limit: rule.Limit}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		wait := math.Ceil((1 - b.tokens) / rule.refillPerMs())
+		result.RetryAfter = time.Duration(wait) * time.Millisecond
+	}
+	result.Remaining = int(b.tokens)
+	return result, nil
+}
+
+// sweep drops buckets that have been idle long enough to be full again
+func (m *Memory) sweep(now time.Time) {
+	if now.Sub(m.lastSweep) < memorySweepInterval {
+		return
+	}
+	m.lastSweep = now
+
+	for key, b := range m.buckets {
+		if now.Sub(b.updated) > b.window {
+			delete(m.buckets, key)
+		}
+	}
+}
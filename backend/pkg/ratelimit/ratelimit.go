@@ -0,0 +1,281 @@
+// Package ratelimit implements token-bucket rate limiting backed by Redis,
+// with an in-memory fallback for when Redis is unavailable.
+package ratelimit
+
+import (
+	"brainrot-tamagotchi/pkg/logging"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var logger = logging.For("ratelimit")
+
+// Rule allows Limit requests per Window for one identity kind, refilling
+// continuously, so short bursts of up to Limit are allowed
+type Rule struct {
+	Identity string // "wallet", "ip" or "api_key"
+	Limit    int
+	Window   time.Duration
+}
+
+// Result is the outcome of taking one token from a bucket
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration // Until the next token when not allowed
+}
+
+// Limiter takes one token from the bucket at key under rule
+type Limiter interface {
+	Allow(ctx context.Context, key string, rule Rule) (Result, error)
+}
+
+// Policies maps a route group name to the rules checked for it
+type Policies map[string][]Rule
+
+// ParsePolicies parses a policy spec such as
+//
+//	pet_actions:wallet=20/1m,ip=60/1m;marketplace_write:wallet=10/1m
+//
+// Groups are separated by ";", rules by ","; each rule is
+// identity=limit/window.
+func ParsePolicies(spec string) (Policies, error) {
+	policies := Policies{}
+	for _, group := range strings.Split(spec, ";") {
+		group = strings.TrimSpace(group)
+		if group == "" {
+			continue
+		}
+
+		name, rulesSpec, ok := strings.Cut(group, ":")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("invalid rate limit group %q", group)
+		}
+
+		var rules []Rule
+		for _, ruleSpec := range strings.Split(rulesSpec, ",") {
+			rule, err := parseRule(strings.TrimSpace(ruleSpec))
+			if err != nil {
+				return nil, fmt.Errorf("group %s: %w", name, err)
+			}
+			rules = append(rules, rule)
+		}
+		policies[strings.TrimSpace(name)] = rules
+	}
+	return policies, nil
+}
+
+func parseRule(spec string) (Rule, error) {
+	identity, rate, ok := strings.Cut(spec, "=")
+	if !ok {
+		return Rule{}, fmt.Errorf("invalid rule %q", spec)
+	}
+	switch identity {
+	case "wallet", "ip", "api_key":
+	default:
+		return Rule{}, fmt.Errorf("unknown identity %q", identity)
+	}
+
+	limitStr, windowStr, ok := strings.Cut(rate, "/")
+	if !ok {
+		return Rule{}, fmt.Errorf("invalid rate %q", rate)
+	}
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil || limit <= 0 {
+		return Rule{}, fmt.Errorf("invalid limit %q", limitStr)
+	}
+	window, err := time.ParseDuration(windowStr)
+	if err != nil || window <= 0 {
+		return Rule{}, fmt.Errorf("invalid window %q", windowStr)
+	}
+
+	return Rule{Identity: identity, Limit: limit, Window: window}, nil
+}
+
+// refillPerMs is how many tokens a rule adds per millisecond
+func (r Rule) refillPerMs() float64 {
+	return float64(r.Limit) / float64(r.Window.Milliseconds())
+}
+
+// Fallback uses primary and switches to fallback for any call primary fails
+type Fallback struct {
+	primary  Limiter
+	fallback Limiter
+
+	mu       sync.Mutex
+	lastWarn time.Time
+	degraded bool
+}
+
+func NewFallback(primary, fallback Limiter) *Fallback {
+	return &Fallback{primary: primary, fallback: fallback}
+}
+
+// Allow implements Limiter
+func (f *Fallback) Allow(ctx context.Context, key string, rule Rule) (Result, error) {
+	result, err := f.primary.Allow(ctx, key, rule)
+	if err == nil {
+		f.recovered()
+		return result, nil
+	}
+
+	f.warn(err)
+	return f.fallback.Allow(ctx, key, rule)
+}
+
+// warn logs primary failures at most once a minute
+func (f *Fallback) warn(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.degraded = true
+	if time.Since(f.lastWarn) < time.Minute {
+		return
+	}
+	f.lastWarn = time.Now()
+	logger.Warn("rate limiter falling back to in-memory buckets", "error", err)
+}
+
+func (f *Fallback) recovered() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.degraded {
+		f.degraded = false
+		logger.Info("rate limiter back on Redis")
+	}
+}
+
+// DefaultPolicies apply to any group the configured policies do not override
+var DefaultPolicies = Policies{
+	"pet_actions":       {{Identity: "wallet", Limit: 20, Window: time.Minute}, {Identity: "ip", Limit: 60, Window: time.Minute}},
+	"case_actions":      {{Identity: "wallet", Limit: 10, Window: time.Minute}, {Identity: "ip", Limit: 30, Window: time.Minute}},
+	"marketplace_write": {{Identity: "wallet", Limit: 10, Window: time.Minute}, {Identity: "ip", Limit: 30, Window: time.Minute}},
+	"chain_reads":       {{Identity: "ip", Limit: 10, Window: time.Minute}},
+}
+
+// Guard holds the limiter, per-group policies and bypass list
+type Guard struct {
+	limiter    Limiter
+	policies   Policies
+	bypassNets []*net.IPNet
+	bypassKeys map[string]bool
+}
+
+// NewGuard builds a Guard from policy overrides (see ParsePolicies), bypass
+// IPs or CIDRs and bypass X-API-Key values
+func NewGuard(limiter Limiter, overridePolicies string, bypassIPs, bypassKeys []string) (*Guard, error) {
+	policies := Policies{}
+	for name, rules := range DefaultPolicies {
+		policies[name] = rules
+	}
+	overrides, err := ParsePolicies(overridePolicies)
+	if err != nil {
+		return nil, fmt.Errorf("rate limit policies: %w", err)
+	}
+	for name, rules := range overrides {
+		policies[name] = rules
+	}
+
+	guard := &Guard{
+		limiter:    limiter,
+		policies:   policies,
+		bypassKeys: make(map[string]bool),
+	}
+
+	for _, entry := range bypassIPs {
+		if !strings.Contains(entry, "/") {
+			if strings.Contains(entry, ":") {
+				entry += "/128"
+			} else {
+				entry += "/32"
+			}
+		}
+		_, ipNet, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("rate limit bypass IPs: %w", err)
+		}
+		guard.bypassNets = append(guard.bypassNets, ipNet)
+	}
+	for _, key := range bypassKeys {
+		guard.bypassKeys[key] = true
+	}
+
+	return guard, nil
+}
+
+// Bypassed reports whether the caller is an internal service exempt from
+// limits
+func (g *Guard) Bypassed(ip, apiKey string) bool {
+	if apiKey != "" && g.bypassKeys[apiKey] {
+		return true
+	}
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, ipNet := range g.bypassNets {
+		if ipNet.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// Check takes a token for every rule of group whose identity is present in
+// identities, and returns the most restrictive result. A group with no
+// policy is unlimited.
+func (g *Guard) Check(ctx context.Context, group string, identities map[string]string) (Result, bool, error) {
+	rules, ok := g.policies[group]
+	if !ok {
+		return Result{Allowed: true}, false, nil
+	}
+
+	var (
+		worst   Result
+		checked bool
+	)
+	for _, rule := range rules {
+		value := identities[rule.Identity]
+		if value == "" {
+			continue
+		}
+		if rule.Identity == "api_key" {
+			sum := sha256.Sum256([]byte(value))
+			value = hex.EncodeToString(sum[:8])
+		}
+
+		result, err := g.limiter.Allow(ctx, group+":"+rule.Identity+":"+value, rule)
+		if err != nil {
+			return Result{}, false, err
+		}
+		if !checked || moreRestrictive(result, worst) {
+			worst = result
+		}
+		checked = true
+	}
+	if !checked {
+		return Result{Allowed: true}, false, nil
+	}
+	return worst, true, nil
+}
+
+// moreRestrictive orders denials first, then by longest wait, then by
+// fewest remaining tokens
+func moreRestrictive(a, b Result) bool {
+	if a.Allowed != b.Allowed {
+		return !a.Allowed
+	}
+	if a.RetryAfter != b.RetryAfter {
+		return a.RetryAfter > b.RetryAfter
+	}
+	return a.Remaining < b.Remaining
+}
@@ -0,0 +1,243 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParsePolicies(t *testing.T) {
+	got, err := ParsePolicies(" pet_actions:wallet=20/1m, ip=60/1m ;marketplace_write:api_key=5/10s;")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Policies{
+		"pet_actions": {
+			{Identity: "wallet", Limit: 20, Window: time.Minute},
+			{Identity: "ip", Limit: 60, Window: time.Minute},
+		},
+		"marketplace_write": {{Identity: "api_key", Limit: 5, Window: 10 * time.Second}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParsePolicies = %+v, want %+v", got, want)
+	}
+
+	if got, err := ParsePolicies(""); err != nil || len(got) != 0 {
+		t.Errorf("ParsePolicies(\"\") = %v, %v, want no policies", got, err)
+	}
+
+	invalid := []string{
+		"pet_actions",               // no rules
+		":wallet=1/1m",              // no group name
+		"pet_actions:user=1/1m",     // unknown identity
+		"pet_actions:wallet",        // no rate
+		"pet_actions:wallet=10",     // no window
+		"pet_actions:wallet=0/1m",   // zero limit
+		"pet_actions:wallet=ten/1m", // non-numeric limit
+		"pet_actions:wallet=10/1x",  // bad window
+		"pet_actions:wallet=10/-1m", // negative window
+		"pet_actions:wallet=10/1m,", // empty rule
+	}
+	for _, spec := range invalid {
+		if _, err := ParsePolicies(spec); err == nil {
+			t.Errorf("ParsePolicies(%q) succeeded, want an error", spec)
+		}
+	}
+}
+
+func TestMemoryRefill(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	m := NewMemory()
+	m.now = func() time.Time { return now }
+	m.lastSweep = now
+	rule := Rule{Identity: "wallet", Limit: 2, Window: time.Second}
+	ctx := context.Background()
+
+	steps := []struct {
+		advance       time.Duration
+		wantAllowed   bool
+		wantRemaining int
+		wantRetry     time.Duration
+	}{
+		{0, true, 1, 0},
+		{0, true, 0, 0},
+		{0, false, 0, 500 * time.Millisecond}, // One token per 500ms
+		{250 * time.Millisecond, false, 0, 250 * time.Millisecond}, // Half a token back
+		{250 * time.Millisecond, true, 0, 0},
+		{time.Hour, true, 1, 0}, // Refills no further than the limit
+	}
+	for i, step := range steps {
+		now = now.Add(step.advance)
+		result, err := m.Allow(ctx, "k", rule)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := Result{Allowed: step.wantAllowed, Limit: 2, Remaining: step.wantRemaining, RetryAfter: step.wantRetry}
+		if result != want {
+			t.Errorf("step %d: got %+v, want %+v", i, result, want)
+		}
+	}
+
+	// Buckets are independent
+	if result, _ := m.Allow(ctx, "other", rule); !result.Allowed || result.Remaining != 1 {
+		t.Errorf("other bucket: %+v", result)
+	}
+
+	// Idle buckets are dropped once they would be full again
+	now = now.Add(2 * memorySweepInterval)
+	if _, err := m.Allow(ctx, "k", rule); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := m.buckets["other"]; ok {
+		t.Error("idle bucket was not swept")
+	}
+}
+
+// stubLimiter returns a fixed result or error per key and records the keys
+type stubLimiter struct {
+	results map[string]Result
+	err     error
+	keys    []string
+}
+
+func (s *stubLimiter) Allow(ctx context.Context, key string, rule Rule) (Result, error) {
+	s.keys = append(s.keys, key)
+	if s.err != nil {
+		return Result{}, s.err
+	}
+	if result, ok := s.results[key]; ok {
+		return result, nil
+	}
+	return Result{Allowed: true, Limit: rule.Limit, Remaining: rule.Limit - 1}, nil
+}
+
+func TestFallback(t *testing.T) {
+	primary := &stubLimiter{}
+	fallback := &stubLimiter{}
+	f := NewFallback(primary, fallback)
+	ctx := context.Background()
+	rule := Rule{Identity: "ip", Limit: 5, Window: time.Minute}
+
+	if _, err := f.Allow(ctx, "a", rule); err != nil || len(fallback.keys) != 0 || f.degraded {
+		t.Fatalf("healthy primary: err %v, fallback calls %v, degraded %v", err, fallback.keys, f.degraded)
+	}
+
+	primary.err = errors.New("connection refused")
+	result, err := f.Allow(ctx, "b", rule)
+	if err != nil || !result.Allowed {
+		t.Fatalf("primary down: %+v, %v; want the fallback's result", result, err)
+	}
+	if len(fallback.keys) != 1 || fallback.keys[0] != "b" || !f.degraded {
+		t.Errorf("primary down: fallback calls %v, degraded %v", fallback.keys, f.degraded)
+	}
+
+	// Each call tries the primary first, so recovery is immediate
+	primary.err = nil
+	if _, err := f.Allow(ctx, "c", rule); err != nil || len(fallback.keys) != 1 || f.degraded {
+		t.Errorf("primary back: err %v, fallback calls %v, degraded %v", err, fallback.keys, f.degraded)
+	}
+
+	// Only the fallback's own failure reaches the caller
+	primary.err = errors.New("connection refused")
+	fallback.err = errors.New("broken")
+	if _, err := f.Allow(ctx, "d", rule); err == nil {
+		t.Error("both limiters failed but Allow succeeded")
+	}
+}
+
+func TestGuardCheck(t *testing.T) {
+	limiter := &stubLimiter{results: map[string]Result{
+		"writes:wallet:0xa": {Allowed: true, Limit: 10, Remaining: 2},
+		"writes:ip:1.2.3.4": {Allowed: true, Limit: 30, Remaining: 25},
+		"writes:ip:5.6.7.8": {Allowed: false, Limit: 30, RetryAfter: 2 * time.Second},
+		"slow:wallet:0xa":   {Allowed: false, Limit: 1, RetryAfter: 3 * time.Second},
+		"slow:ip:1.2.3.4":   {Allowed: false, Limit: 1, RetryAfter: 9 * time.Second},
+	}}
+	guard, err := NewGuard(limiter, "writes:wallet=10/1m,ip=30/1m,api_key=100/1m;slow:wallet=1/1h,ip=1/1h", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	tests := []struct {
+		name        string
+		group       string
+		identities  map[string]string
+		want        Result
+		wantChecked bool
+	}{
+		{"fewest remaining", "writes", map[string]string{"wallet": "0xa", "ip": "1.2.3.4"}, Result{Allowed: true, Limit: 10, Remaining: 2}, true},
+		{"denial wins", "writes", map[string]string{"wallet": "0xa", "ip": "5.6.7.8"}, Result{Allowed: false, Limit: 30, RetryAfter: 2 * time.Second}, true},
+		{"longest wait wins", "slow", map[string]string{"wallet": "0xa", "ip": "1.2.3.4"}, Result{Allowed: false, Limit: 1, RetryAfter: 9 * time.Second}, true},
+		{"absent identity skipped", "writes", map[string]string{"ip": "1.2.3.4"}, Result{Allowed: true, Limit: 30, Remaining: 25}, true},
+		{"no identities", "writes", map[string]string{}, Result{Allowed: true}, false},
+		{"no policy", "reads", map[string]string{"ip": "1.2.3.4"}, Result{Allowed: true}, false},
+	}
+	for _, tt := range tests {
+		got, checked, err := guard.Check(ctx, tt.group, tt.identities)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got != tt.want || checked != tt.wantChecked {
+			t.Errorf("%s: got %+v checked=%v, want %+v checked=%v", tt.name, got, checked, tt.want, tt.wantChecked)
+		}
+	}
+
+	// API keys are hashed before they become part of a Redis key
+	limiter.keys = nil
+	if _, _, err := guard.Check(ctx, "writes", map[string]string{"api_key": "secret"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(limiter.keys) != 1 || limiter.keys[0] == "writes:api_key:secret" {
+		t.Errorf("api key bucket keys = %v", limiter.keys)
+	}
+
+	// Overrides replace a default group; the other defaults stay
+	if rules := guard.policies["pet_actions"]; !reflect.DeepEqual(rules, DefaultPolicies["pet_actions"]) {
+		t.Errorf("pet_actions rules = %+v", rules)
+	}
+
+	limiter.err = errors.New("boom")
+	if _, _, err := guard.Check(ctx, "writes", map[string]string{"ip": "1.2.3.4"}); err == nil {
+		t.Error("limiter error was swallowed")
+	}
+}
+
+func TestGuardBypassed(t *testing.T) {
+	guard, err := NewGuard(NewMemory(), "", []string{"10.0.0.0/8", "192.168.1.5", "::1", "fd00::/8"}, []string{"internal-key"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		ip, apiKey string
+		want       bool
+	}{
+		{"10.1.2.3", "", true},
+		{"10.255.255.255", "", true},
+		{"11.0.0.1", "", false},
+		{"192.168.1.5", "", true},
+		{"192.168.1.6", "", false},
+		{"::1", "", true},
+		{"fd12:3456::1", "", true},
+		{"fe80::1", "", false},
+		{"not an ip", "", false},
+		{"", "internal-key", true},
+		{"8.8.8.8", "internal-key", true},
+		{"8.8.8.8", "other-key", false},
+	}
+	for _, tt := range tests {
+		if got := guard.Bypassed(tt.ip, tt.apiKey); got != tt.want {
+			t.Errorf("Bypassed(%q, %q) = %v, want %v", tt.ip, tt.apiKey, got, tt.want)
+		}
+	}
+
+	if _, err := NewGuard(NewMemory(), "", []string{"10.0.0.0/33"}, nil); err == nil {
+		t.Error("invalid bypass CIDR accepted")
+	}
+	if _, err := NewGuard(NewMemory(), "pet_actions:wallet=0/1m", nil, nil); err == nil {
+		t.Error("invalid policy override accepted")
+	}
+}
@@ -0,0 +1,83 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// tokenBucketScript refills and takes from a bucket atomically, using the
+// Redis clock so every replica agrees on time.
+// Returns {allowed, remaining, retry_after_ms}.
+var tokenBucketScript = redis.NewScript(`
+local capacity = tonumber(ARGV[1])
+local refill_per_ms = tonumber(ARGV[2])
+local ttl_ms = tonumber(ARGV[3])
+
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+
+local data = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(data[1]) or capacity
+local ts = tonumber(data[2]) or now
+tokens = math.min(capacity, tokens + math.max(0, now - ts) * refill_per_ms)
+
+local allowed = 0
+local retry = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	retry = math.ceil((1 - tokens) / refill_per_ms)
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', KEYS[1], ttl_ms)
+return {allowed, math.floor(tokens), retry}
+`)
+
+// Redis is a token-bucket limiter shared by every replica
+type Redis struct {
+	client    *redis.Client
+	keyPrefix string
+	timeout   time.Duration
+}
+
+func NewRedis(client *redis.Client) *Redis {
+	return &Redis{
+		client:    client,
+		keyPrefix: "ratelimit:",
+		timeout:   100 * time.Millisecond,
+	}
+}
+
+// Allow implements Limiter
+func (r *Redis) Allow(ctx context.Context, key string, rule Rule) (Result, error) {
+	if r.client == nil {
+		return Result{}, fmt.Errorf("redis client not configured")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	out, err := tokenBucketScript.Run(ctx, r.client, []string{r.keyPrefix + key},
+		rule.Limit,
+		rule.refillPerMs(),
+		rule.Window.Milliseconds(),
+	).Int64Slice()
+	if err != nil {
+		return Result{}, err
+	}
+	if len(out) != 3 {
+		return Result{}, fmt.Errorf("unexpected rate limit script result %v", out)
+	}
+
+	return Result{
+		Allowed:    out[0] == 1,
+		Limit:      rule.Limit,
+		Remaining:  int(out[1]),
+		RetryAfter: time.Duration(out[2]) * time.Millisecond,
+	}, nil
+}
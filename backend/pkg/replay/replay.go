@@ -0,0 +1,94 @@
+// Package replay remembers signed requests for as long as their signature is
+// valid, so each one is accepted once. Keys live in Redis, shared by every
+// replica, with an in-memory fallback for when Redis is unavailable.
+package replay
+
+import (
+	"brainrot-tamagotchi/pkg/logging"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+var logger = logging.For("replay")
+
+// memorySweepInterval is how often expired in-memory keys are dropped
+const memorySweepInterval = time.Minute
+
+// Guard records keys until they expire
+type Guard struct {
+	client    *redis.Client
+	keyPrefix string
+	timeout   time.Duration
+
+	mu        sync.Mutex
+	seen      map[string]time.Time
+	lastSweep time.Time
+	lastWarn  time.Time
+}
+
+// NewGuard returns a Guard on client; a nil client keeps keys in memory only
+func NewGuard(client *redis.Client) *Guard {
+	return &Guard{
+		client:    client,
+		keyPrefix: "replay:",
+		timeout:   100 * time.Millisecond,
+		seen:      make(map[string]time.Time),
+		lastSweep: time.Now(),
+	}
+}
+
+// Claim records key for ttl and reports whether it was unused. If Redis
+// fails the key is checked in this process only, which still stops a replay
+// against the same replica.
+func (g *Guard) Claim(ctx context.Context, key string, ttl time.Duration) bool {
+	if g.client != nil {
+		ctx, cancel := context.WithTimeout(ctx, g.timeout)
+		defer cancel()
+
+		fresh, err := g.client.SetNX(ctx, g.keyPrefix+key, 1, ttl).Result()
+		if err == nil {
+			// Remember it locally too, in case Redis drops out before it
+			// expires, and honour keys claimed while Redis was down
+			local := g.claimMemory(key, ttl)
+			return fresh && local
+		}
+		g.warn(err)
+	}
+	return g.claimMemory(key, ttl)
+}
+
+func (g *Guard) claimMemory(key string, ttl time.Duration) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(g.lastSweep) >= memorySweepInterval {
+		for k, expires := range g.seen {
+			if now.After(expires) {
+				delete(g.seen, k)
+			}
+		}
+		g.lastSweep = now
+	}
+
+	if expires, ok := g.seen[key]; ok && now.Before(expires) {
+		return false
+	}
+	g.seen[key] = now.Add(ttl)
+	return true
+}
+
+// warn logs Redis failures at most once a minute
+func (g *Guard) warn(err error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if time.Since(g.lastWarn) < time.Minute {
+		return
+	}
+	g.lastWarn = time.Now()
+	logger.Warn("replay guard falling back to in-memory keys", "error", err)
+}
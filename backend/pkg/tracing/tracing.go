@@ -0,0 +1,105 @@
+// Package tracing sets up OpenTelemetry tracing for the backend.
+//
+// Setup installs the global tracer provider and W3C trace context
+// propagation. Packages create their own tracer with otel.Tracer and start
+// spans from the request or job context, so spans nest however deep the
+// call goes. Before Setup, and with the none exporter, spans are no-ops.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Options configures Setup
+type Options struct {
+	Exporter    string // none, stdout or otlp
+	Endpoint    string // host:port of the OTLP/HTTP collector
+	Insecure    bool   // send OTLP over plain HTTP
+	SampleRatio float64
+	ServiceName string
+}
+
+// Setup installs the global tracer provider. The returned function flushes
+// buffered spans and must be called before the process exits.
+func Setup(ctx context.Context, opts Options) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{},
+	))
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch opts.Exporter {
+	case "", "none":
+		return func(context.Context) error { return nil }, nil
+	case "stdout":
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case "otlp":
+		httpOpts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(opts.Endpoint)}
+		if opts.Insecure {
+			httpOpts = append(httpOpts, otlptracehttp.WithInsecure())
+		}
+		exporter, err = otlptracehttp.New(ctx, httpOpts...)
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", opts.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("creating %s trace exporter: %w", opts.Exporter, err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName(opts.ServiceName),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("building trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		// Follow the caller's sampling decision so a trace is kept whole
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// TraceID returns the ID of the trace ctx is part of, or "" outside a trace
+func TraceID(ctx context.Context) string {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return ""
+	}
+	return sc.TraceID().String()
+}
+
+// End records err on span, unless it is nil or one of the expected errors,
+// and ends the span. It is meant for a deferred call with a named error
+// result:
+//
+//	ctx, span := tracer.Start(ctx, "ListingSync.ConfirmTx")
+//	defer func() { tracing.End(span, err, blockchain.ErrTxPending) }()
+func End(span trace.Span, err error, expected ...error) {
+	for _, e := range expected {
+		if errors.Is(err, e) {
+			err = nil
+		}
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,42 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestEnd(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+	errPending := errors.New("pending")
+
+	ctx, span := tracer.Start(context.Background(), "failed")
+	End(span, errors.New("boom"), errPending)
+	_, span = tracer.Start(context.Background(), "pending")
+	End(span, errPending, errPending)
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("got %d ended spans, want 2", len(spans))
+	}
+	if spans[0].Status().Code != codes.Error || len(spans[0].Events()) != 1 {
+		t.Errorf("failed span = %v, %d events; want an error status and the error event", spans[0].Status(), len(spans[0].Events()))
+	}
+	if spans[1].Status().Code == codes.Error {
+		t.Errorf("an expected error marked the span failed")
+	}
+	if TraceID(ctx) != spans[0].SpanContext().TraceID().String() || TraceID(context.Background()) != "" {
+		t.Errorf("TraceID = %q", TraceID(ctx))
+	}
+}
+
+func TestSetupRejects(t *testing.T) {
+	if _, err := Setup(context.Background(), Options{Exporter: "jaeger"}); err == nil {
+		t.Error("Setup accepted an unknown exporter")
+	}
+}
@@ -3,14 +3,27 @@
 import { ConnectButton } from '@rainbow-me/rainbowkit';
 import Link from 'next/link';
 import { useState, useEffect } from 'react';
-import { useAccount } from 'wagmi';
+import { useAccount, usePublicClient, useSignMessage, useWriteContract } from 'wagmi';
+import { formatEther } from 'viem';
 import { marketplaceAPI, setWalletAddress } from '@/lib/api';
 import { motion } from 'framer-motion';
 
+const MARKETPLACE_ADDRESS = process.env.NEXT_PUBLIC_MARKETPLACE_CONTRACT as `0x${string}`;
+
+const marketplaceAbi = [
+  {
+    type: 'function',
+    name: 'buyNFT',
+    stateMutability: 'payable',
+    inputs: [{ name: 'tokenId', type: 'uint256' }],
+    outputs: [],
+  },
+] as const;
+
 interface NFTListing {
   token_id: number;
   seller_address: string;
-  price: number;
+  price: string; // wei
   nft?: {
     meme_type: string;
     rarity: string;
@@ -23,6 +36,9 @@ export default function MarketplacePage() {
   const [listings, setListings] = useState<NFTListing[]>([]);
   const [loading, setLoading] = useState(false);
   const [filter, setFilter] = useState<string>('all');
+  const { writeContractAsync } = useWriteContract();
+  const { signMessageAsync } = useSignMessage();
+  const publicClient = usePublicClient();
 
   useEffect(() => {
     if (address) {
@@ -43,9 +59,25 @@ export default function MarketplacePage() {
     setLoading(false);
   };
 
-  const handleBuy = async (tokenId: number) => {
+  const handleBuy = async (listing: NFTListing) => {
+    if (!address || !publicClient) return;
     try {
-      await marketplaceAPI.buyNFT(tokenId);
+      const txHash = await writeContractAsync({
+        address: MARKETPLACE_ADDRESS,
+        abi: marketplaceAbi,
+        functionName: 'buyNFT',
+        args: [BigInt(listing.token_id)],
+        value: BigInt(listing.price),
+      });
+      // Each confirmation is a fresh signature, so wait for the transaction
+      // first rather than polling through 202s
+      await publicClient.waitForTransactionReceipt({ hash: txHash });
+      const wallet = { address, signMessage: signMessageAsync };
+      let response = await marketplaceAPI.buyNFT(listing.token_id, txHash, wallet);
+      while (response.status === 202) {
+        await new Promise((resolve) => setTimeout(resolve, 2000));
+        response = await marketplaceAPI.buyNFT(listing.token_id, txHash, wallet);
+      }
       loadListings();
     } catch (error) {
       console.error('Failed to buy NFT:', error);
@@ -166,10 +198,10 @@ export default function MarketplacePage() {
 
                     <div className="border-t border-gray-700 pt-4">
                       <div className="text-2xl font-bold text-purple-400 mb-4">
-                        {listing.price} BASE
+                        {formatEther(BigInt(listing.price))} BASE
                       </div>
                       <button
-                        onClick={() => handleBuy(listing.token_id)}
+                        onClick={() => handleBuy(listing)}
                         className="brainrot-button w-full text-sm"
                       >
                         Buy Now
@@ -3,8 +3,8 @@
 import { ConnectButton } from '@rainbow-me/rainbowkit';
 import Link from 'next/link';
 import { useState, useEffect } from 'react';
-import { useAccount } from 'wagmi';
-import { petAPI, setWalletAddress } from '@/lib/api';
+import { useAccount, useSignMessage } from 'wagmi';
+import { petAPI, realtimeAPI, setWalletAddress } from '@/lib/api';
 import { motion } from 'framer-motion';
 
 interface PetState {
@@ -19,6 +19,7 @@ interface PetState {
 
 export default function PetPage() {
   const { address, isConnected } = useAccount();
+  const { signMessageAsync } = useSignMessage();
   const [pet, setPet] = useState<PetState | null>(null);
   const [loading, setLoading] = useState(false);
 
@@ -29,11 +30,19 @@ export default function PetPage() {
     }
   }, [address]);
 
-  const handleFeed = async () => {
+  // Live stat changes from feeding, playing and decay
+  useEffect(() => {
     if (!pet) return;
+    return realtimeAPI.subscribe([`pet:${pet.token_id}`], (event) => {
+      if (event.type === 'pet.updated') setPet(event.data as PetState);
+    });
+  }, [pet?.token_id]);
+
+  const handleFeed = async () => {
+    if (!pet || !address) return;
     setLoading(true);
     try {
-      await petAPI.feedPet(pet.token_id);
+      await petAPI.feedPet(pet.token_id, { address, signMessage: signMessageAsync });
       // Refresh pet state
       const response = await petAPI.getPet(pet.token_id);
       setPet(response.data);
@@ -44,10 +53,10 @@ export default function PetPage() {
   };
 
   const handlePlay = async () => {
-    if (!pet) return;
+    if (!pet || !address) return;
     setLoading(true);
     try {
-      await petAPI.playWithPet(pet.token_id);
+      await petAPI.playWithPet(pet.token_id, { address, signMessage: signMessageAsync });
       // Refresh pet state
       const response = await petAPI.getPet(pet.token_id);
       setPet(response.data);
@@ -1,4 +1,5 @@
 import axios from 'axios';
+import { sha256, stringToBytes } from 'viem';
 
 const API_URL = process.env.NEXT_PUBLIC_API_URL || 'http://localhost:8080/api/v1';
 
@@ -14,31 +15,140 @@ export const setWalletAddress = (address: string) => {
   api.defaults.headers.common['X-Wallet-Address'] = address;
 };
 
+export interface SignedWalletHeaders {
+  address: string;
+  // personal_sign of `Brainrot Tamagotchi request\n<METHOD> <path>[?<query>]\n<body sha256 hex>\n<unix seconds>`;
+  // sign once per request, a signature can't be reused
+  signature: string;
+  timestamp: number;
+}
+
+// The connected wallet; signMessage is wagmi's signMessageAsync
+export interface WalletSigner {
+  address: string;
+  signMessage: (args: { message: string }) => Promise<string>;
+}
+
+const signedHeaders = ({ address, signature, timestamp }: SignedWalletHeaders) => ({
+  'X-Wallet-Address': address,
+  'X-Wallet-Signature': signature,
+  'X-Wallet-Timestamp': String(timestamp),
+});
+
+// Sends one request signed by wallet. Writes and private reads need this;
+// bans apply to the signing wallet.
+const signedRequest = async (wallet: WalletSigner, method: 'GET' | 'POST' | 'PUT' | 'DELETE', path: string, data?: unknown) => {
+  const body = data === undefined ? '' : JSON.stringify(data);
+  const url = new URL(API_URL + path, typeof window === 'undefined' ? 'http://localhost' : window.location.origin);
+  const timestamp = Math.floor(Date.now() / 1000);
+  const message = `Brainrot Tamagotchi request\n${method} ${url.pathname}${url.search}\n${sha256(stringToBytes(body)).slice(2)}\n${timestamp}`;
+  const signature = await wallet.signMessage({ message });
+  return api.request({
+    method,
+    url: path,
+    // Send exactly the bytes that were hashed
+    data: data === undefined ? undefined : body,
+    headers: signedHeaders({ address: wallet.address, signature, timestamp }),
+  });
+};
+
 // API functions
 export const petAPI = {
   getPet: (tokenId: number) => api.get(`/pets/${tokenId}`),
-  feedPet: (tokenId: number, isPaid: boolean = false) => 
-    api.post(`/pets/${tokenId}/feed`, { is_paid: isPaid }),
-  playWithPet: (tokenId: number) => api.post(`/pets/${tokenId}/play`),
+  feedPet: (tokenId: number, wallet: WalletSigner, isPaid: boolean = false) =>
+    signedRequest(wallet, 'POST', `/pets/${tokenId}/feed`, { is_paid: isPaid }),
+  playWithPet: (tokenId: number, wallet: WalletSigner) => signedRequest(wallet, 'POST', `/pets/${tokenId}/play`),
+  // Quote is { wei, eth, usd }; usd is null when no fresh ETH/USD rate is available
+  getUpgradeQuote: (tokenId: number, level: number) =>
+    api.get(`/pets/${tokenId}/upgrade-quote`, { params: { level } }),
 };
 
 export const casesAPI = {
+  // Catalog of cases on sale: { cases: [{ slug, name, price (wei), price_usd, remaining, ... }], eth_usd }
   getPrices: () => api.get('/cases/prices'),
-  buyCase: (caseType: string) => api.post('/cases/buy', { case_type: caseType }),
-  openCase: (caseId: string) => api.post(`/cases/${caseId}/open`),
+  buyCase: (caseType: string, wallet: WalletSigner) => signedRequest(wallet, 'POST', '/cases/buy', { case_type: caseType }),
+  openCase: (caseId: string, wallet: WalletSigner) => signedRequest(wallet, 'POST', `/cases/${caseId}/open`),
+  getHistory: (params?: { address?: string; from?: string; to?: string; limit?: number; offset?: number }) =>
+    api.get('/cases/history', { params }),
+  getStats: (params?: { address?: string; from?: string; to?: string }) =>
+    api.get('/cases/stats', { params }),
+  // Pity progress per case type plus issued pity vouchers
+  getPity: (address?: string) => api.get('/cases/pity', { params: { address } }),
+  // Codes are only shown to the signing wallet
+  getVouchers: (wallet: WalletSigner, status?: 'issued' | 'claimed' | 'fulfilled') =>
+    signedRequest(wallet, 'GET', status ? `/cases/vouchers?status=${status}` : '/cases/vouchers'),
+  claimVoucher: (code: string, wallet: WalletSigner) =>
+    signedRequest(wallet, 'POST', `/cases/vouchers/${code}/claim`),
 };
 
 export const marketplaceAPI = {
-  getListings: (params?: { rarity?: string; min_level?: number; limit?: number; offset?: number }) => 
+  // Prices are wei amounts encoded as decimal strings
+  getListings: (params?: { rarity?: string; min_level?: number; max_price?: string; limit?: number; offset?: number }) =>
     api.get('/marketplace', { params }),
-  listNFT: (tokenId: number, price: number) => 
-    api.post('/marketplace/list', { token_id: tokenId, price }),
-  buyNFT: (tokenId: number) => api.post(`/marketplace/${tokenId}/buy`),
-  cancelListing: (tokenId: number) => api.delete(`/marketplace/${tokenId}`),
+  // Write endpoints confirm a Marketplace.sol transaction the wallet already sent.
+  // They answer 202 while the transaction is pending; retry until 200.
+  listNFT: (tokenId: number, txHash: string, wallet: WalletSigner) =>
+    signedRequest(wallet, 'POST', '/marketplace/list', { token_id: tokenId, tx_hash: txHash }),
+  buyNFT: (tokenId: number, txHash: string, wallet: WalletSigner) =>
+    signedRequest(wallet, 'POST', `/marketplace/${tokenId}/buy`, { tx_hash: txHash }),
+  cancelListing: (tokenId: number, txHash: string, wallet: WalletSigner) =>
+    signedRequest(wallet, 'DELETE', `/marketplace/${tokenId}`, { tx_hash: txHash }),
+  updateListingPrice: (tokenId: number, txHash: string, wallet: WalletSigner) =>
+    signedRequest(wallet, 'PUT', `/marketplace/${tokenId}/price`, { tx_hash: txHash }),
 };
 
 export const userAPI = {
   getUser: (address: string) => api.get(`/users/${address}`),
-  getInventory: (address: string) => api.get(`/users/${address}/inventory`),
+  getInventory: (
+    address: string,
+    params?: { rarity?: string; meme?: string; alive?: boolean; sort?: string; order?: 'asc' | 'desc'; verify?: boolean }
+  ) => api.get(`/users/${address}/inventory`, { params }),
+};
+
+
+export interface NotificationPreferencesUpdate {
+  // webhook (https URL), email, telegram (chat ID), webpush (PushSubscription JSON); '' removes a channel
+  channels?: Partial<Record<'webhook' | 'email' | 'telegram' | 'webpush', string>>;
+  // hunger_low, mood_zero, pet_died, listing_sold, offer_received; false mutes
+  kinds?: Record<string, boolean>;
+  quiet_start?: string; // HH:MM
+  quiet_end?: string;
+  timezone?: string; // IANA name
+}
+
+export const notificationsAPI = {
+  getNotifications: (wallet: WalletSigner, limit?: number) =>
+    signedRequest(wallet, 'GET', limit === undefined ? '/notifications' : `/notifications?limit=${limit}`),
+  // Also returns webpush_public_key for PushManager.subscribe
+  getPreferences: (wallet: WalletSigner) => signedRequest(wallet, 'GET', '/notifications/preferences'),
+  updatePreferences: (update: NotificationPreferencesUpdate, wallet: WalletSigner) =>
+    signedRequest(wallet, 'PUT', '/notifications/preferences', update),
 };
 
+export type RealtimeTopic = `pet:${number}` | `wallet:${string}` | 'marketplace' | 'cases';
+
+export interface RealtimeEvent<T = unknown> {
+  topic: string;
+  type: string;
+  data: T;
+  at: string;
+}
+
+export const realtimeAPI = {
+  // Sign `Brainrot Tamagotchi realtime subscription\n<lowercase address>\n<unix seconds>` with personal_sign
+  getToken: (address: string, signature: string, timestamp: number) =>
+    api.post('/realtime/token', null, {
+      headers: { 'X-Wallet-Address': address, 'X-Wallet-Signature': signature, 'X-Wallet-Timestamp': String(timestamp) },
+    }),
+  // Opens a Server-Sent Events stream; wallet topics need a token. Returns a function that closes it.
+  subscribe: (topics: RealtimeTopic[], onEvent: (event: RealtimeEvent) => void, token?: string) => {
+    const params = new URLSearchParams({ topics: topics.join(',') });
+    if (token) params.set('token', token);
+    const source = new EventSource(`${API_URL}/realtime/stream?${params}`);
+    const handle = (e: MessageEvent) => onEvent(JSON.parse(e.data));
+    ['pet.updated', 'listing.created', 'listing.sold', 'listing.updated', 'listing.cancelled', 'case.revealed'].forEach((type) =>
+      source.addEventListener(type, handle as EventListener)
+    );
+    return () => source.close();
+  },
+};